@@ -0,0 +1,88 @@
+package controllers
+
+import (
+	"loan-tracker/domain"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+type AcceptanceController struct {
+	AcceptanceUsecase domain.AcceptanceUsecase
+	LoanUsecase       domain.LoanUsecase
+}
+
+func NewAcceptanceController(acceptanceUsecase domain.AcceptanceUsecase, loanUsecase domain.LoanUsecase) *AcceptanceController {
+	return &AcceptanceController{
+		AcceptanceUsecase: acceptanceUsecase,
+		LoanUsecase:       loanUsecase,
+	}
+}
+
+// RequestCode emails the borrower a one-time code for accepting the current agreement
+func (c *AcceptanceController) RequestCode(ctx *gin.Context) {
+	loanID, userID, ok := loanAndUserIDs(ctx)
+	if !ok {
+		return
+	}
+
+	pending, err := c.AcceptanceUsecase.RequestCode(ctx, loanID, userID)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	ctx.JSON(http.StatusOK, gin.H{
+		"message":            "a code has been sent to " + pending.Email,
+		"document_id":        pending.DocumentID,
+		"agreement_checksum": pending.AgreementChecksum,
+		"expires_at":         pending.CodeExpiresAt,
+	})
+}
+
+// AcceptAgreement records the acceptance along with the client's IP address and user agent
+func (c *AcceptanceController) AcceptAgreement(ctx *gin.Context) {
+	loanID, userID, ok := loanAndUserIDs(ctx)
+	if !ok {
+		return
+	}
+	var req domain.AcceptanceRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	acceptance, err := c.AcceptanceUsecase.AcceptAgreement(ctx, loanID, userID, req, ctx.ClientIP(), ctx.Request.UserAgent())
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	ctx.JSON(http.StatusOK, acceptance)
+}
+
+func (c *AcceptanceController) GetAcceptances(ctx *gin.Context) {
+	loanID, userID, ok := loanAndUserIDs(ctx)
+	if !ok {
+		return
+	}
+
+	acceptances, err := c.AcceptanceUsecase.GetAcceptances(ctx, loanID, userID, ctx.GetBool("isadmin"))
+	if err != nil {
+		ctx.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	ctx.JSON(http.StatusOK, acceptances)
+}
+
+func (c *AcceptanceController) DisburseLoan(ctx *gin.Context) {
+	loanID, adminID, ok := loanAndUserIDs(ctx)
+	if !ok {
+		return
+	}
+
+	if err := c.LoanUsecase.DisburseLoan(ctx, loanID, adminID); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"message": "loan disbursed"})
+}
@@ -0,0 +1,107 @@
+package controllers
+
+import (
+	"loan-tracker/domain"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type AssignmentController struct {
+	AssignmentUsecase domain.AssignmentUsecase
+}
+
+func NewAssignmentController(assignmentUsecase domain.AssignmentUsecase) *AssignmentController {
+	return &AssignmentController{
+		AssignmentUsecase: assignmentUsecase,
+	}
+}
+
+func (c *AssignmentController) AssignLoan(ctx *gin.Context) {
+	loanID, adminID, ok := loanAndUserIDs(ctx)
+	if !ok {
+		return
+	}
+	var req domain.AssignRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	officerID, err := primitive.ObjectIDFromHex(req.OfficerID)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid officer id"})
+		return
+	}
+
+	isSupervisor := ctx.GetString("role") == "supervisor"
+	loan, err := c.AssignmentUsecase.AssignLoan(ctx, loanID, officerID, adminID, isSupervisor)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, loan)
+}
+
+func (c *AssignmentController) AutoAssign(ctx *gin.Context) {
+	adminID, err := primitive.ObjectIDFromHex(ctx.GetString("userid"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid user id"})
+		return
+	}
+
+	assigned, err := c.AssignmentUsecase.AutoAssignPending(ctx, adminID, ctx.Query("strategy"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error(), "assigned": assigned})
+		return
+	}
+	ctx.JSON(http.StatusOK, gin.H{"assigned": assigned})
+}
+
+func (c *AssignmentController) MyQueue(ctx *gin.Context) {
+	officerID, err := primitive.ObjectIDFromHex(ctx.GetString("userid"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid user id"})
+		return
+	}
+
+	loans, err := c.AssignmentUsecase.GetQueue(ctx, officerID)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	ctx.JSON(http.StatusOK, loans)
+}
+
+func (c *AssignmentController) StartReview(ctx *gin.Context) {
+	loanID, officerID, ok := loanAndUserIDs(ctx)
+	if !ok {
+		return
+	}
+
+	loan, err := c.AssignmentUsecase.StartReview(ctx, loanID, officerID)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	ctx.JSON(http.StatusOK, loan)
+}
+
+func (c *AssignmentController) GetWorkloads(ctx *gin.Context) {
+	workloads, err := c.AssignmentUsecase.GetWorkloads(ctx)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	ctx.JSON(http.StatusOK, workloads)
+}
+
+func (c *AssignmentController) GetStageTimes(ctx *gin.Context) {
+	times, err := c.AssignmentUsecase.GetStageTimes(ctx, ctx.Query("by") == "officer")
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	ctx.JSON(http.StatusOK, times)
+}
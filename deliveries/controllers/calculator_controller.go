@@ -0,0 +1,33 @@
+package controllers
+
+import (
+	"loan-tracker/domain"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+type CalculatorController struct {
+	CalculatorUsecase domain.CalculatorUsecase
+}
+
+func NewCalculatorController(calculatorUsecase domain.CalculatorUsecase) *CalculatorController {
+	return &CalculatorController{
+		CalculatorUsecase: calculatorUsecase,
+	}
+}
+
+func (c *CalculatorController) Quote(ctx *gin.Context) {
+	var req domain.QuoteRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	quote, err := c.CalculatorUsecase.Quote(ctx, req)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	ctx.JSON(http.StatusOK, quote)
+}
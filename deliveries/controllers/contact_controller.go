@@ -0,0 +1,125 @@
+package controllers
+
+import (
+	"loan-tracker/domain"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type ContactController struct {
+	ContactUsecase domain.ContactUsecase
+}
+
+func NewContactController(contactUsecase domain.ContactUsecase) *ContactController {
+	return &ContactController{
+		ContactUsecase: contactUsecase,
+	}
+}
+
+// RequestPhoneVerification texts a code to the number, which becomes the user's phone once verified
+func (c *ContactController) RequestPhoneVerification(ctx *gin.Context) {
+	userID, ok := currentUserID(ctx)
+	if !ok {
+		return
+	}
+	var req domain.PhoneRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := c.ContactUsecase.RequestPhoneVerification(ctx, userID, req.Phone); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	ctx.JSON(http.StatusOK, gin.H{"message": "a verification code has been sent to your phone"})
+}
+
+func (c *ContactController) VerifyPhone(ctx *gin.Context) {
+	userID, ok := currentUserID(ctx)
+	if !ok {
+		return
+	}
+	var req domain.PhoneCodeRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	phone, err := c.ContactUsecase.VerifyPhone(ctx, userID, req.Code)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	ctx.JSON(http.StatusOK, gin.H{"message": "phone number verified", "phone": phone})
+}
+
+func (c *ContactController) RemovePhone(ctx *gin.Context) {
+	userID, ok := currentUserID(ctx)
+	if !ok {
+		return
+	}
+
+	if err := c.ContactUsecase.RemovePhone(ctx, userID); err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	ctx.JSON(http.StatusOK, gin.H{"message": "phone number removed"})
+}
+
+func (c *ContactController) GetPushDevices(ctx *gin.Context) {
+	userID, ok := currentUserID(ctx)
+	if !ok {
+		return
+	}
+
+	devices, err := c.ContactUsecase.GetPushDevices(ctx, userID)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	ctx.JSON(http.StatusOK, devices)
+}
+
+// RegisterPushDevice adds an app installation's push token; registering a token again refreshes it
+func (c *ContactController) RegisterPushDevice(ctx *gin.Context) {
+	userID, ok := currentUserID(ctx)
+	if !ok {
+		return
+	}
+	var device domain.PushDevice
+	if err := ctx.ShouldBindJSON(&device); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := c.ContactUsecase.RegisterPushDevice(ctx, userID, device); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	ctx.JSON(http.StatusCreated, gin.H{"message": "device registered"})
+}
+
+func (c *ContactController) RemovePushDevice(ctx *gin.Context) {
+	userID, ok := currentUserID(ctx)
+	if !ok {
+		return
+	}
+
+	if err := c.ContactUsecase.RemovePushDevice(ctx, userID, ctx.Param("token")); err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	ctx.JSON(http.StatusOK, gin.H{"message": "device removed"})
+}
+
+func currentUserID(ctx *gin.Context) (primitive.ObjectID, bool) {
+	userID, err := primitive.ObjectIDFromHex(ctx.GetString("userid"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid user id"})
+		return primitive.NilObjectID, false
+	}
+	return userID, true
+}
@@ -0,0 +1,171 @@
+package controllers
+
+import (
+	"loan-tracker/domain"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type CreditLineController struct {
+	CreditLineUsecase domain.CreditLineUsecase
+}
+
+func NewCreditLineController(creditLineUsecase domain.CreditLineUsecase) *CreditLineController {
+	return &CreditLineController{
+		CreditLineUsecase: creditLineUsecase,
+	}
+}
+
+func (c *CreditLineController) GetMyCreditLines(ctx *gin.Context) {
+	userID, err := primitive.ObjectIDFromHex(ctx.GetString("userid"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid user id"})
+		return
+	}
+
+	lines, err := c.CreditLineUsecase.GetMyCreditLines(ctx, userID)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	ctx.JSON(http.StatusOK, lines)
+}
+
+func (c *CreditLineController) GetCreditLine(ctx *gin.Context) {
+	id, userID, ok := creditLineIDs(ctx)
+	if !ok {
+		return
+	}
+
+	line, err := c.CreditLineUsecase.GetCreditLine(ctx, id, userID)
+	if err != nil {
+		ctx.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	ctx.JSON(http.StatusOK, line)
+}
+
+func (c *CreditLineController) GetTransactions(ctx *gin.Context) {
+	id, userID, ok := creditLineIDs(ctx)
+	if !ok {
+		return
+	}
+
+	txs, err := c.CreditLineUsecase.GetTransactions(ctx, id, userID)
+	if err != nil {
+		ctx.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	ctx.JSON(http.StatusOK, txs)
+}
+
+func (c *CreditLineController) Draw(ctx *gin.Context) {
+	id, userID, ok := creditLineIDs(ctx)
+	if !ok {
+		return
+	}
+	var req domain.CreditLineAmount
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	line, err := c.CreditLineUsecase.Draw(ctx, id, userID, req.Amount)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, line)
+}
+
+func (c *CreditLineController) Repay(ctx *gin.Context) {
+	id, userID, ok := creditLineIDs(ctx)
+	if !ok {
+		return
+	}
+	var req domain.CreditLineAmount
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	line, err := c.CreditLineUsecase.Repay(ctx, id, userID, req.Amount)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, line)
+}
+
+func (c *CreditLineController) GetAllCreditLines(ctx *gin.Context) {
+	lines, err := c.CreditLineUsecase.GetAllCreditLines(ctx)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	ctx.JSON(http.StatusOK, lines)
+}
+
+func (c *CreditLineController) ChangeLimit(ctx *gin.Context) {
+	id, adminID, ok := creditLineIDs(ctx)
+	if !ok {
+		return
+	}
+	var req struct {
+		Limit float64 `json:"limit"`
+	}
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	line, err := c.CreditLineUsecase.ChangeLimit(ctx, id, adminID, req.Limit)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, line)
+}
+
+func (c *CreditLineController) FreezeCreditLine(ctx *gin.Context) {
+	c.setFrozen(ctx, true)
+}
+
+func (c *CreditLineController) UnfreezeCreditLine(ctx *gin.Context) {
+	c.setFrozen(ctx, false)
+}
+
+func (c *CreditLineController) setFrozen(ctx *gin.Context, frozen bool) {
+	id, adminID, ok := creditLineIDs(ctx)
+	if !ok {
+		return
+	}
+
+	line, err := c.CreditLineUsecase.SetFrozen(ctx, id, adminID, frozen)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, line)
+}
+
+// creditLineIDs parses the credit line id from the path and the caller's id from the token
+func creditLineIDs(ctx *gin.Context) (primitive.ObjectID, primitive.ObjectID, bool) {
+	id, err := primitive.ObjectIDFromHex(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		return primitive.NilObjectID, primitive.NilObjectID, false
+	}
+	userID, err := primitive.ObjectIDFromHex(ctx.GetString("userid"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid user id"})
+		return primitive.NilObjectID, primitive.NilObjectID, false
+	}
+	return id, userID, true
+}
@@ -0,0 +1,99 @@
+package controllers
+
+import (
+	"loan-tracker/domain"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type DocumentController struct {
+	DocumentUsecase domain.DocumentUsecase
+}
+
+func NewDocumentController(documentUsecase domain.DocumentUsecase) *DocumentController {
+	return &DocumentController{
+		DocumentUsecase: documentUsecase,
+	}
+}
+
+func (c *DocumentController) GetDocuments(ctx *gin.Context) {
+	loanID, userID, ok := loanAndUserIDs(ctx)
+	if !ok {
+		return
+	}
+
+	docs, err := c.DocumentUsecase.GetDocuments(ctx, loanID, userID, ctx.GetBool("isadmin"))
+	if err != nil {
+		ctx.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	ctx.JSON(http.StatusOK, docs)
+}
+
+// DownloadDocument sends the PDF with its recorded SHA-256 checksum in the X-Checksum-SHA256 header
+func (c *DocumentController) DownloadDocument(ctx *gin.Context) {
+	loanID, userID, ok := loanAndUserIDs(ctx)
+	if !ok {
+		return
+	}
+	docID, err := primitive.ObjectIDFromHex(ctx.Param("docid"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid document id"})
+		return
+	}
+
+	doc, err := c.DocumentUsecase.GetDocument(ctx, loanID, docID, userID, ctx.GetBool("isadmin"))
+	if err != nil {
+		ctx.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	ctx.Header("Content-Disposition", `attachment; filename="`+doc.FileName+`"`)
+	ctx.Header("X-Checksum-SHA256", doc.Checksum)
+	ctx.Data(http.StatusOK, "application/pdf", doc.Content)
+}
+
+// GenerateStatement issues a statement for from..to (YYYY-MM-DD, inclusive), defaulting to last month
+func (c *DocumentController) GenerateStatement(ctx *gin.Context) {
+	loanID, userID, ok := loanAndUserIDs(ctx)
+	if !ok {
+		return
+	}
+	from, to, err := queryDateRange(ctx)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if from.IsZero() && to.IsZero() {
+		now := time.Now()
+		to = time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, now.Location())
+		from = to.AddDate(0, -1, 0)
+	}
+	if from.IsZero() || to.IsZero() {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "please provide both from and to"})
+		return
+	}
+
+	doc, err := c.DocumentUsecase.GenerateStatement(ctx, loanID, userID, ctx.GetBool("isadmin"), from, to)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	ctx.JSON(http.StatusOK, doc)
+}
+
+func (c *DocumentController) RegenerateAgreement(ctx *gin.Context) {
+	loanID, adminID, ok := loanAndUserIDs(ctx)
+	if !ok {
+		return
+	}
+
+	doc, err := c.DocumentUsecase.RegenerateAgreement(ctx, loanID, adminID)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	ctx.JSON(http.StatusOK, doc)
+}
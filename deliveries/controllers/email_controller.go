@@ -0,0 +1,76 @@
+package controllers
+
+import (
+	"loan-tracker/domain"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+type EmailController struct {
+	EmailUsecase domain.EmailUsecase
+}
+
+func NewEmailController(emailUsecase domain.EmailUsecase) *EmailController {
+	return &EmailController{
+		EmailUsecase: emailUsecase,
+	}
+}
+
+// GetEmails lists the email outbox, filtered by ?to=, ?kind= and ?status= (e.g. "failed")
+func (c *EmailController) GetEmails(ctx *gin.Context) {
+	filter := domain.EmailFilter{
+		To:     ctx.Query("to"),
+		Kind:   ctx.Query("kind"),
+		Status: ctx.Query("status"),
+	}
+
+	emails, err := c.EmailUsecase.GetEmails(ctx, filter)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	ctx.JSON(http.StatusOK, emails)
+}
+
+// ResendEmail queues a failed email to be sent again
+func (c *EmailController) ResendEmail(ctx *gin.Context) {
+	id, adminID, ok := loanAndUserIDs(ctx)
+	if !ok {
+		return
+	}
+
+	email, err := c.EmailUsecase.ResendEmail(ctx, id, adminID)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, email)
+}
+
+// GetTemplates lists the email kinds and the languages each is available in
+func (c *EmailController) GetTemplates(ctx *gin.Context) {
+	ctx.JSON(http.StatusOK, c.EmailUsecase.GetTemplates(ctx))
+}
+
+// PreviewTemplate renders an email kind with sample data in ?locale= (the default language when
+// absent). ?format=html or ?format=text returns just that part, for viewing in a browser.
+func (c *EmailController) PreviewTemplate(ctx *gin.Context) {
+	preview, err := c.EmailUsecase.PreviewTemplate(ctx, ctx.Param("kind"), ctx.Query("locale"))
+	if err != nil {
+		ctx.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	switch ctx.Query("format") {
+	case "html":
+		ctx.Data(http.StatusOK, "text/html; charset=utf-8", []byte(preview.HTML))
+	case "text":
+		ctx.Data(http.StatusOK, "text/plain; charset=utf-8", []byte(preview.Text))
+	case "", "json":
+		ctx.JSON(http.StatusOK, preview)
+	default:
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid format, use json, html or text"})
+	}
+}
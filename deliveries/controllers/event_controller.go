@@ -0,0 +1,45 @@
+package controllers
+
+import (
+	"loan-tracker/domain"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type EventController struct {
+	EventBus domain.EventBus
+}
+
+func NewEventController(eventBus domain.EventBus) *EventController {
+	return &EventController{
+		EventBus: eventBus,
+	}
+}
+
+// GetEvents lists outbox events, filtered by ?type= and ?status= (e.g. "failed")
+func (c *EventController) GetEvents(ctx *gin.Context) {
+	events, err := c.EventBus.GetEvents(ctx, domain.EventFilter{Type: ctx.Query("type"), Status: ctx.Query("status")})
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	ctx.JSON(http.StatusOK, events)
+}
+
+// RetryEvent queues a failed event for another round of delivery
+func (c *EventController) RetryEvent(ctx *gin.Context) {
+	id, err := primitive.ObjectIDFromHex(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid event id"})
+		return
+	}
+
+	event, err := c.EventBus.RetryEvent(ctx, id)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	ctx.JSON(http.StatusOK, event)
+}
@@ -0,0 +1,117 @@
+package controllers
+
+import (
+	"loan-tracker/domain"
+	"loan-tracker/infrastructure"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type ExportController struct {
+	ExportUsecase domain.ExportUsecase
+}
+
+func NewExportController(exportUsecase domain.ExportUsecase) *ExportController {
+	return &ExportController{
+		ExportUsecase: exportUsecase,
+	}
+}
+
+func (c *ExportController) ExportLoans(ctx *gin.Context) {
+	filter, ok := loanFilter(ctx)
+	if !ok {
+		return
+	}
+	c.stream(ctx, "loans", func(adminID primitive.ObjectID, w domain.RowWriter) error {
+		return c.ExportUsecase.ExportLoans(ctx, adminID, filter, w)
+	})
+}
+
+func (c *ExportController) ExportRepayments(ctx *gin.Context) {
+	filter, ok := repaymentFilter(ctx)
+	if !ok {
+		return
+	}
+	c.stream(ctx, "repayments", func(adminID primitive.ObjectID, w domain.RowWriter) error {
+		return c.ExportUsecase.ExportRepayments(ctx, adminID, filter, w)
+	})
+}
+
+func (c *ExportController) ExportUsers(ctx *gin.Context) {
+	filter, ok := userFilter(ctx)
+	if !ok {
+		return
+	}
+	c.stream(ctx, "users", func(adminID primitive.ObjectID, w domain.RowWriter) error {
+		return c.ExportUsecase.ExportUsers(ctx, adminID, filter, w)
+	})
+}
+
+func (c *ExportController) ExportLogs(ctx *gin.Context) {
+	filter, ok := logFilter(ctx)
+	if !ok {
+		return
+	}
+	c.stream(ctx, "logs", func(adminID primitive.ObjectID, w domain.RowWriter) error {
+		return c.ExportUsecase.ExportLogs(ctx, adminID, filter, w)
+	})
+}
+
+func (c *ExportController) ExportAcceptances(ctx *gin.Context) {
+	filter := domain.AcceptanceFilter{}
+	var err error
+	if filter.LoanID, err = queryObjectID(ctx, "loan_id"); err == nil {
+		filter.From, filter.To, err = queryDateRange(ctx)
+	}
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.stream(ctx, "acceptances", func(adminID primitive.ObjectID, w domain.RowWriter) error {
+		return c.ExportUsecase.ExportAcceptances(ctx, adminID, filter, w)
+	})
+}
+
+// stream writes the export straight into the response in the requested format ("csv" or "xlsx").
+// Once rows have been sent the status can no longer change, so a failure part way is only logged
+// and the client receives a truncated file.
+func (c *ExportController) stream(ctx *gin.Context, dataset string, export func(primitive.ObjectID, domain.RowWriter) error) {
+	adminID, ok := currentUserID(ctx)
+	if !ok {
+		return
+	}
+	format := ctx.DefaultQuery("format", "csv")
+	var contentType string
+	switch format {
+	case "csv":
+		contentType = "text/csv; charset=utf-8"
+	case "xlsx":
+		contentType = "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
+	default:
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid format, you can only enter csv or xlsx"})
+		return
+	}
+
+	filename := dataset + "-" + time.Now().Format("20060102") + "." + format
+	ctx.Header("Content-Type", contentType)
+	ctx.Header("Content-Disposition", `attachment; filename="`+filename+`"`)
+	ctx.Status(http.StatusOK)
+
+	var w domain.RowWriter
+	if format == "xlsx" {
+		var err error
+		if w, err = infrastructure.NewXLSXRowWriter(ctx.Writer, dataset); err != nil {
+			log.Println("Error starting export of", dataset+":", err)
+			return
+		}
+	} else {
+		w = infrastructure.NewCSVRowWriter(ctx.Writer)
+	}
+	if err := export(adminID, w); err != nil {
+		log.Println("Error exporting", dataset+":", err)
+	}
+}
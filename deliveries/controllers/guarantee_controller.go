@@ -0,0 +1,114 @@
+package controllers
+
+import (
+	"loan-tracker/domain"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type GuaranteeController struct {
+	GuaranteeUsecase domain.GuaranteeUsecase
+}
+
+func NewGuaranteeController(guaranteeUsecase domain.GuaranteeUsecase) *GuaranteeController {
+	return &GuaranteeController{
+		GuaranteeUsecase: guaranteeUsecase,
+	}
+}
+
+func (c *GuaranteeController) GetMyGuarantees(ctx *gin.Context) {
+	userID, err := primitive.ObjectIDFromHex(ctx.GetString("userid"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid user id"})
+		return
+	}
+
+	guarantees, err := c.GuaranteeUsecase.GetMyGuarantees(ctx, userID)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	ctx.JSON(http.StatusOK, guarantees)
+}
+
+func (c *GuaranteeController) GetLoanGuarantees(ctx *gin.Context) {
+	loanID, err := primitive.ObjectIDFromHex(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		return
+	}
+	userID, err := primitive.ObjectIDFromHex(ctx.GetString("userid"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid user id"})
+		return
+	}
+
+	guarantees, err := c.GuaranteeUsecase.GetLoanGuarantees(ctx, loanID, userID, ctx.GetBool("isadmin"))
+	if err != nil {
+		ctx.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	ctx.JSON(http.StatusOK, guarantees)
+}
+
+func (c *GuaranteeController) InviteGuarantor(ctx *gin.Context) {
+	loanID, err := primitive.ObjectIDFromHex(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		return
+	}
+	userID, err := primitive.ObjectIDFromHex(ctx.GetString("userid"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid user id"})
+		return
+	}
+
+	var invite domain.GuarantorInvite
+	if err := ctx.ShouldBindJSON(&invite); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	id, err := c.GuaranteeUsecase.InviteGuarantor(ctx, loanID, userID, invite)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"guarantee_id": id})
+}
+
+func (c *GuaranteeController) AcceptGuarantee(ctx *gin.Context) {
+	c.respond(ctx, "accepted")
+}
+
+func (c *GuaranteeController) DeclineGuarantee(ctx *gin.Context) {
+	c.respond(ctx, "declined")
+}
+
+func (c *GuaranteeController) respond(ctx *gin.Context, status string) {
+	id, err := primitive.ObjectIDFromHex(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		return
+	}
+	userID, err := primitive.ObjectIDFromHex(ctx.GetString("userid"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid user id"})
+		return
+	}
+
+	if status == "accepted" {
+		err = c.GuaranteeUsecase.AcceptGuarantee(ctx, id, userID)
+	} else {
+		err = c.GuaranteeUsecase.DeclineGuarantee(ctx, id, userID)
+	}
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"message": "guarantee " + status})
+}
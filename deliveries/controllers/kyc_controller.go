@@ -0,0 +1,90 @@
+package controllers
+
+import (
+	"loan-tracker/domain"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type KYCController struct {
+	KYCUsecase domain.KYCUsecase
+}
+
+func NewKYCController(kycUsecase domain.KYCUsecase) *KYCController {
+	return &KYCController{
+		KYCUsecase: kycUsecase,
+	}
+}
+
+func (c *KYCController) GetKYC(ctx *gin.Context) {
+	userID, err := primitive.ObjectIDFromHex(ctx.GetString("userid"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid user id"})
+		return
+	}
+
+	profile, err := c.KYCUsecase.GetKYC(ctx, userID)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	ctx.JSON(http.StatusOK, profile)
+}
+
+func (c *KYCController) SubmitKYC(ctx *gin.Context) {
+	userID, err := primitive.ObjectIDFromHex(ctx.GetString("userid"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid user id"})
+		return
+	}
+
+	var profile domain.KYCProfile
+	if err := ctx.ShouldBindJSON(&profile); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := c.KYCUsecase.SubmitKYC(ctx, userID, profile); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"message": "KYC profile submitted for review"})
+}
+
+func (c *KYCController) GetReviewQueue(ctx *gin.Context) {
+	users, err := c.KYCUsecase.GetReviewQueue(ctx, ctx.Query("status"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	ctx.JSON(http.StatusOK, gin.H{"users": users})
+}
+
+func (c *KYCController) ReviewKYC(ctx *gin.Context) {
+	userID, err := primitive.ObjectIDFromHex(ctx.Param("userid"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid user id"})
+		return
+	}
+	reviewerID, err := primitive.ObjectIDFromHex(ctx.GetString("userid"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid reviewer id"})
+		return
+	}
+
+	var review domain.KYCReview
+	if err := ctx.ShouldBindJSON(&review); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := c.KYCUsecase.ReviewKYC(ctx, userID, reviewerID, review); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"message": "KYC profile " + review.Status})
+}
@@ -4,7 +4,6 @@ import (
 	"loan-tracker/domain"
 	"log"
 	"net/http"
-	"time"
 
 	"github.com/gin-gonic/gin"
 	"go.mongodb.org/mongo-driver/bson/primitive"
@@ -12,13 +11,11 @@ import (
 
 type LoanController struct {
 	LoanUsecase domain.LoanUsecase
-	LogUsecase  domain.LogUsecase
 }
 
-func NewLoanController(LoanUsecase domain.LoanUsecase, logUsecase domain.LogUsecase) *LoanController {
+func NewLoanController(LoanUsecase domain.LoanUsecase) *LoanController {
 	return &LoanController{
 		LoanUsecase: LoanUsecase,
-		LogUsecase:  logUsecase,
 	}
 }
 func (c *LoanController) ApplyForLoan(ctx *gin.Context) {
@@ -48,16 +45,6 @@ func (c *LoanController) ApplyForLoan(ctx *gin.Context) {
 		return
 	}
 
-	// Log loan application
-	logEntry := domain.Log{
-		Timestamp: time.Now(),
-		Type:      "loan_application",
-		Details:   "Loan applied with ID: " + loanID.Hex(),
-	}
-	if logErr := c.LogUsecase.LogEvent(ctx, logEntry); logErr != nil {
-		log.Println("Error logging loan application:", logErr)
-	}
-
 	ctx.JSON(http.StatusOK, gin.H{"loan_id": loanID})
 }
 
@@ -68,42 +55,35 @@ func (c *LoanController) ViewLoanStatus(ctx *gin.Context) {
 		ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
 		return
 	}
-	loan, err := c.LoanUsecase.ViewLoanStatus(ctx, objID)
+	viewerID, ok := currentUserID(ctx)
+	if !ok {
+		return
+	}
+	loan, err := c.LoanUsecase.ViewLoanStatus(ctx, objID, viewerID)
 	if err != nil {
 		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
 
-	// Log view loan status
-	logEntry := domain.Log{
-		Timestamp: time.Now(),
-		Type:      "view_loan_status",
-		Details:   "Loan status retrieved for ID: " + id,
-	}
-	if logErr := c.LogUsecase.LogEvent(ctx, logEntry); logErr != nil {
-		log.Println("Error logging view loan status:", logErr)
-	}
 	ctx.JSON(http.StatusOK, loan)
 }
 
 func (c *LoanController) ViewAllLoans(ctx *gin.Context) {
+	adminID, ok := currentUserID(ctx)
+	if !ok {
+		return
+	}
+	filter, ok := loanFilter(ctx)
+	if !ok {
+		return
+	}
 
-	loans, err := c.LoanUsecase.ViewAllLoans(ctx)
+	loans, err := c.LoanUsecase.ViewAllLoans(ctx, adminID, filter)
 	if err != nil {
 		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
 
-	// Log view all loans
-	logEntry := domain.Log{
-		Timestamp: time.Now(),
-		Type:      "view_all_loans",
-		Details:   "All loans retrieved",
-	}
-	if logErr := c.LogUsecase.LogEvent(ctx, logEntry); logErr != nil {
-		log.Println("Error logging view all loans:", logErr)
-	}
-
 	ctx.JSON(http.StatusOK, loans)
 }
 
@@ -115,50 +95,162 @@ func (c *LoanController) ApproveOrRejectLoan(ctx *gin.Context) {
 		return
 	}
 
+	adminID, err := primitive.ObjectIDFromHex(ctx.GetString("userid"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid user id"})
+		return
+	}
+
 	var status domain.Loan
 	if err := ctx.ShouldBindJSON(&status); err != nil {
 		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
-	if err := c.LoanUsecase.ApproveOrRejectLoan(ctx, objID, status.Status); err != nil {
+	if err := c.LoanUsecase.ApproveOrRejectLoan(ctx, objID, adminID, status.Status); err != nil {
 		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
 
-	// Log loan approval/rejection
-	logEntry := domain.Log{
-		Timestamp: time.Now(),
-		Type:      "loan_approval_rejection",
-		Details:   "Loan status updated for ID: " + id,
+	ctx.JSON(http.StatusOK, gin.H{"message": "loan status updated"})
+}
+
+func (c *LoanController) DeleteLoan(ctx *gin.Context) {
+	id, adminID, ok := loanAndUserIDs(ctx)
+	if !ok {
+		return
 	}
-	if logErr := c.LogUsecase.LogEvent(ctx, logEntry); logErr != nil {
-		log.Println("Error logging loan approval/rejection:", logErr)
+	if err := c.LoanUsecase.DeleteLoan(ctx, id, adminID); err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
 	}
 
-	ctx.JSON(http.StatusOK, gin.H{"message": "loan status updated"})
+	ctx.JSON(http.StatusOK, gin.H{"message": "loan deleted"})
 }
 
-func (c *LoanController) DeleteLoan(ctx *gin.Context) {
+func (c *LoanController) RefinanceLoan(ctx *gin.Context) {
 	id := ctx.Param("id")
 	objID, err := primitive.ObjectIDFromHex(id)
 	if err != nil {
 		ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
 		return
 	}
-	if err := c.LoanUsecase.DeleteLoan(ctx, objID); err != nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	userID, err := primitive.ObjectIDFromHex(ctx.GetString("userid"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid user id"})
 		return
 	}
 
-	// Log loan deletion
-	logEntry := domain.Log{
-		Timestamp: time.Now(),
-		Type:      "loan_deletion",
-		Details:   "Loan deleted with ID: " + id,
+	var loan domain.Loan
+	if err := ctx.ShouldBindJSON(&loan); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
 	}
-	if logErr := c.LogUsecase.LogEvent(ctx, logEntry); logErr != nil {
-		log.Println("Error logging loan deletion:", logErr)
+	loan.UserID = userID
+
+	loanID, err := c.LoanUsecase.RefinanceLoan(ctx, objID, loan)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
 	}
 
-	ctx.JSON(http.StatusOK, gin.H{"message": "loan deleted"})
+	ctx.JSON(http.StatusOK, gin.H{"loan_id": loanID})
+}
+
+func (c *LoanController) EditLoan(ctx *gin.Context) {
+	id := ctx.Param("id")
+	objID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		return
+	}
+	userID, err := primitive.ObjectIDFromHex(ctx.GetString("userid"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid user id"})
+		return
+	}
+
+	var edit domain.LoanEdit
+	if err := ctx.ShouldBindJSON(&edit); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	loan, err := c.LoanUsecase.EditLoan(ctx, objID, userID, edit)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, loan)
+}
+
+func (c *LoanController) CancelLoan(ctx *gin.Context) {
+	id := ctx.Param("id")
+	objID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		return
+	}
+	userID, err := primitive.ObjectIDFromHex(ctx.GetString("userid"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid user id"})
+		return
+	}
+
+	// The reason is optional, so an empty body is fine
+	var req struct {
+		Reason string `json:"reason"`
+	}
+	_ = ctx.ShouldBindJSON(&req)
+
+	if err := c.LoanUsecase.CancelLoan(ctx, objID, userID, req.Reason); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"message": "loan application cancelled"})
+}
+
+func (c *LoanController) GetLoanHistory(ctx *gin.Context) {
+	objID, err := primitive.ObjectIDFromHex(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		return
+	}
+	userID, err := primitive.ObjectIDFromHex(ctx.GetString("userid"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid user id"})
+		return
+	}
+
+	events, err := c.LoanUsecase.GetLoanHistory(ctx, objID, userID, ctx.GetBool("isadmin"))
+	if err != nil {
+		ctx.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	ctx.JSON(http.StatusOK, events)
+}
+
+// loanFilter reads the admin loan listing filters: status, product, user_id, officer_id,
+// from and to (application date, YYYY-MM-DD, to is inclusive) and order ("asc" or "desc")
+func loanFilter(ctx *gin.Context) (domain.LoanFilter, bool) {
+	filter := domain.LoanFilter{
+		Status:      ctx.Query("status"),
+		ProductCode: ctx.Query("product"),
+		Order:       ctx.Query("order"),
+	}
+	var err error
+	if filter.UserID, err = queryObjectID(ctx, "user_id"); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return filter, false
+	}
+	if filter.AssignedOfficerID, err = queryObjectID(ctx, "officer_id"); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return filter, false
+	}
+	if filter.From, filter.To, err = queryDateRange(ctx); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return filter, false
+	}
+	return filter, true
 }
@@ -0,0 +1,133 @@
+package controllers
+
+import (
+	"loan-tracker/domain"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type LoanNoteController struct {
+	LoanNoteUsecase domain.LoanNoteUsecase
+}
+
+func NewLoanNoteController(loanNoteUsecase domain.LoanNoteUsecase) *LoanNoteController {
+	return &LoanNoteController{
+		LoanNoteUsecase: loanNoteUsecase,
+	}
+}
+
+func (c *LoanNoteController) AddNote(ctx *gin.Context) {
+	loanID, userID, ok := loanAndUserIDs(ctx)
+	if !ok {
+		return
+	}
+	var req domain.NoteRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	id, err := c.LoanNoteUsecase.AddNote(ctx, loanID, userID, req)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	ctx.JSON(http.StatusOK, gin.H{"note_id": id})
+}
+
+func (c *LoanNoteController) GetNotes(ctx *gin.Context) {
+	loanID, err := primitive.ObjectIDFromHex(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		return
+	}
+
+	notes, err := c.LoanNoteUsecase.GetNotes(ctx, loanID)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	ctx.JSON(http.StatusOK, notes)
+}
+
+func (c *LoanNoteController) EditNote(ctx *gin.Context) {
+	c.edit(ctx, "internal")
+}
+
+func (c *LoanNoteController) AddComment(ctx *gin.Context) {
+	loanID, userID, ok := loanAndUserIDs(ctx)
+	if !ok {
+		return
+	}
+	var req domain.NoteRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	id, err := c.LoanNoteUsecase.AddComment(ctx, loanID, userID, ctx.GetBool("isadmin"), req)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	ctx.JSON(http.StatusOK, gin.H{"comment_id": id})
+}
+
+func (c *LoanNoteController) GetComments(ctx *gin.Context) {
+	loanID, userID, ok := loanAndUserIDs(ctx)
+	if !ok {
+		return
+	}
+
+	comments, err := c.LoanNoteUsecase.GetComments(ctx, loanID, userID, ctx.GetBool("isadmin"))
+	if err != nil {
+		ctx.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	ctx.JSON(http.StatusOK, comments)
+}
+
+func (c *LoanNoteController) EditComment(ctx *gin.Context) {
+	c.edit(ctx, "borrower")
+}
+
+func (c *LoanNoteController) edit(ctx *gin.Context, visibility string) {
+	loanID, userID, ok := loanAndUserIDs(ctx)
+	if !ok {
+		return
+	}
+	noteID, err := primitive.ObjectIDFromHex(ctx.Param("noteid"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid note id"})
+		return
+	}
+	var req domain.NoteRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	note, err := c.LoanNoteUsecase.EditNote(ctx, loanID, noteID, userID, visibility, req.Body)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	ctx.JSON(http.StatusOK, note)
+}
+
+// loanAndUserIDs parses the loan id from the path and the caller's id from the token
+func loanAndUserIDs(ctx *gin.Context) (primitive.ObjectID, primitive.ObjectID, bool) {
+	loanID, err := primitive.ObjectIDFromHex(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		return primitive.NilObjectID, primitive.NilObjectID, false
+	}
+	userID, err := primitive.ObjectIDFromHex(ctx.GetString("userid"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid user id"})
+		return primitive.NilObjectID, primitive.NilObjectID, false
+	}
+	return loanID, userID, true
+}
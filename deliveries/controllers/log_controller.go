@@ -18,10 +18,26 @@ func NewLogController(logUsecase domain.LogUsecase) *LogController {
 }
 
 func (c *LogController) ViewSystemLogs(ctx *gin.Context) {
-	logs, err := c.logUsecase.GetSystemLogs(ctx)
+	filter, ok := logFilter(ctx)
+	if !ok {
+		return
+	}
+
+	logs, err := c.logUsecase.GetSystemLogs(ctx, filter)
 	if err != nil {
 		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
 	ctx.JSON(http.StatusOK, logs)
 }
+
+// logFilter reads type, from and to (YYYY-MM-DD, to is inclusive)
+func logFilter(ctx *gin.Context) (domain.LogFilter, bool) {
+	filter := domain.LogFilter{Type: ctx.Query("type")}
+	var err error
+	if filter.From, filter.To, err = queryDateRange(ctx); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return filter, false
+	}
+	return filter, true
+}
@@ -0,0 +1,105 @@
+package controllers
+
+import (
+	"loan-tracker/domain"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type MandateController struct {
+	MandateUsecase domain.MandateUsecase
+}
+
+func NewMandateController(mandateUsecase domain.MandateUsecase) *MandateController {
+	return &MandateController{
+		MandateUsecase: mandateUsecase,
+	}
+}
+
+// CreateMandate authorizes direct debit collection of the borrower's installments
+func (c *MandateController) CreateMandate(ctx *gin.Context) {
+	loanID, userID, ok := loanAndUserIDs(ctx)
+	if !ok {
+		return
+	}
+	var req domain.MandateRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	mandate, err := c.MandateUsecase.CreateMandate(ctx, loanID, userID, req)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	ctx.JSON(http.StatusCreated, mandate)
+}
+
+func (c *MandateController) GetMandates(ctx *gin.Context) {
+	loanID, userID, ok := loanAndUserIDs(ctx)
+	if !ok {
+		return
+	}
+
+	mandates, err := c.MandateUsecase.GetMandates(ctx, loanID, userID, ctx.GetBool("isadmin"))
+	if err != nil {
+		ctx.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	ctx.JSON(http.StatusOK, mandates)
+}
+
+func (c *MandateController) RevokeMandate(ctx *gin.Context) {
+	loanID, userID, ok := loanAndUserIDs(ctx)
+	if !ok {
+		return
+	}
+	mandateID, err := primitive.ObjectIDFromHex(ctx.Param("mandateid"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid mandate id"})
+		return
+	}
+	var req struct {
+		Reason string `json:"reason"`
+	}
+	if ctx.Request.ContentLength > 0 {
+		if err := ctx.ShouldBindJSON(&req); err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+
+	mandate, err := c.MandateUsecase.RevokeMandate(ctx, loanID, mandateID, userID, ctx.GetBool("isadmin"), req.Reason)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	ctx.JSON(http.StatusOK, mandate)
+}
+
+func (c *MandateController) GetCollections(ctx *gin.Context) {
+	loanID, userID, ok := loanAndUserIDs(ctx)
+	if !ok {
+		return
+	}
+
+	collections, err := c.MandateUsecase.GetCollections(ctx, loanID, userID, ctx.GetBool("isadmin"))
+	if err != nil {
+		ctx.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	ctx.JSON(http.StatusOK, collections)
+}
+
+// GetAllCollections lists direct debit collections across loans, filtered by ?status= (e.g. "failed")
+func (c *MandateController) GetAllCollections(ctx *gin.Context) {
+	collections, err := c.MandateUsecase.GetCollectionsByStatus(ctx, ctx.Query("status"))
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	ctx.JSON(http.StatusOK, collections)
+}
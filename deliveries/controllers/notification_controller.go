@@ -0,0 +1,100 @@
+package controllers
+
+import (
+	"loan-tracker/domain"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type NotificationController struct {
+	NotificationUsecase domain.NotificationUsecase
+}
+
+func NewNotificationController(notificationUsecase domain.NotificationUsecase) *NotificationController {
+	return &NotificationController{
+		NotificationUsecase: notificationUsecase,
+	}
+}
+
+// GetInbox lists the user's in-app notifications, newest first, with ?status=read or ?status=unread
+func (c *NotificationController) GetInbox(ctx *gin.Context) {
+	userID, err := primitive.ObjectIDFromHex(ctx.GetString("userid"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid user id"})
+		return
+	}
+
+	inbox, err := c.NotificationUsecase.GetInbox(ctx, userID, ctx.Query("status"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	ctx.JSON(http.StatusOK, inbox)
+}
+
+func (c *NotificationController) MarkRead(ctx *gin.Context) {
+	id, userID, ok := loanAndUserIDs(ctx)
+	if !ok {
+		return
+	}
+
+	if err := c.NotificationUsecase.MarkRead(ctx, id, userID); err != nil {
+		ctx.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	ctx.JSON(http.StatusOK, gin.H{"message": "notification marked as read"})
+}
+
+func (c *NotificationController) MarkAllRead(ctx *gin.Context) {
+	userID, err := primitive.ObjectIDFromHex(ctx.GetString("userid"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid user id"})
+		return
+	}
+
+	count, err := c.NotificationUsecase.MarkAllRead(ctx, userID)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	ctx.JSON(http.StatusOK, gin.H{"marked_read": count})
+}
+
+// GetPreferences returns the channels the user receives each kind of notification on
+func (c *NotificationController) GetPreferences(ctx *gin.Context) {
+	userID, err := primitive.ObjectIDFromHex(ctx.GetString("userid"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid user id"})
+		return
+	}
+
+	preferences, err := c.NotificationUsecase.GetPreferences(ctx, userID)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	ctx.JSON(http.StatusOK, preferences)
+}
+
+// UpdatePreferences sets the channels of the kinds given, e.g. {"channels": {"payment_reminder": ["email"]}}
+func (c *NotificationController) UpdatePreferences(ctx *gin.Context) {
+	userID, err := primitive.ObjectIDFromHex(ctx.GetString("userid"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid user id"})
+		return
+	}
+	var req domain.NotificationPreferencesRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	preferences, err := c.NotificationUsecase.UpdatePreferences(ctx, userID, req.Channels)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	ctx.JSON(http.StatusOK, preferences)
+}
@@ -0,0 +1,110 @@
+package controllers
+
+import (
+	"errors"
+	"loan-tracker/domain"
+	"loan-tracker/infrastructure"
+	"log"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+type PaymentController struct {
+	PaymentUsecase domain.PaymentUsecase
+}
+
+func NewPaymentController(paymentUsecase domain.PaymentUsecase) *PaymentController {
+	return &PaymentController{
+		PaymentUsecase: paymentUsecase,
+	}
+}
+
+// CreateCheckout starts an online repayment and returns the provider's checkout URL
+func (c *PaymentController) CreateCheckout(ctx *gin.Context) {
+	loanID, userID, ok := loanAndUserIDs(ctx)
+	if !ok {
+		return
+	}
+	var req domain.PaymentRequest
+	if ctx.Request.ContentLength > 0 {
+		if err := ctx.ShouldBindJSON(&req); err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+
+	payment, err := c.PaymentUsecase.CreateCheckout(ctx, loanID, userID, req)
+	if errors.Is(err, infrastructure.ErrPaymentsDisabled) {
+		ctx.JSON(http.StatusServiceUnavailable, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	ctx.JSON(http.StatusCreated, payment)
+}
+
+// SimulatorEnabled reports whether the simulator's checkout route should be served
+func (c *PaymentController) SimulatorEnabled() bool {
+	return c.PaymentUsecase.SimulatorEnabled()
+}
+
+func (c *PaymentController) GetPayments(ctx *gin.Context) {
+	loanID, userID, ok := loanAndUserIDs(ctx)
+	if !ok {
+		return
+	}
+
+	payments, err := c.PaymentUsecase.GetPayments(ctx, loanID, userID, ctx.GetBool("isadmin"))
+	if err != nil {
+		ctx.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	ctx.JSON(http.StatusOK, payments)
+}
+
+// Webhook receives payment notifications from the provider named in the path. It is not behind
+// authentication; each notification is verified by its signature instead.
+func (c *PaymentController) Webhook(ctx *gin.Context) {
+	body, err := ctx.GetRawData()
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "could not read the request body"})
+		return
+	}
+
+	if err := c.PaymentUsecase.HandleWebhook(ctx, ctx.Param("provider"), ctx.Request.Header, body); err != nil {
+		if errors.Is(err, infrastructure.ErrInvalidSignature) {
+			ctx.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+			return
+		}
+		if errors.Is(err, infrastructure.ErrPaymentsDisabled) {
+			ctx.JSON(http.StatusServiceUnavailable, gin.H{"error": err.Error()})
+			return
+		}
+		log.Println("Error handling payment webhook:", err)
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	ctx.JSON(http.StatusOK, gin.H{"message": "received"})
+}
+
+// SimulatePayment stands in for the provider's hosted checkout page while the simulator is in use.
+// It settles payments without collecting money, so only admins may call it.
+func (c *PaymentController) SimulatePayment(ctx *gin.Context) {
+	var req struct {
+		Outcome string `json:"outcome"`
+	}
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	payment, err := c.PaymentUsecase.SimulatePayment(ctx, ctx.Param("ref"), req.Outcome)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	ctx.JSON(http.StatusOK, payment)
+}
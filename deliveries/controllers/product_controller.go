@@ -0,0 +1,76 @@
+package controllers
+
+import (
+	"loan-tracker/domain"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+type ProductController struct {
+	ProductUsecase domain.ProductUsecase
+}
+
+func NewProductController(productUsecase domain.ProductUsecase) *ProductController {
+	return &ProductController{
+		ProductUsecase: productUsecase,
+	}
+}
+
+func (c *ProductController) CreateProduct(ctx *gin.Context) {
+	adminID, ok := currentUserID(ctx)
+	if !ok {
+		return
+	}
+	var product domain.LoanProduct
+	if err := ctx.ShouldBindJSON(&product); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	id, err := c.ProductUsecase.CreateProduct(ctx, adminID, product)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"product_id": id})
+}
+
+func (c *ProductController) GetAllProducts(ctx *gin.Context) {
+	products, err := c.ProductUsecase.GetAllProducts(ctx)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	ctx.JSON(http.StatusOK, products)
+}
+
+func (c *ProductController) GetProduct(ctx *gin.Context) {
+	product, err := c.ProductUsecase.GetProduct(ctx, ctx.Param("code"))
+	if err != nil {
+		ctx.JSON(http.StatusNotFound, gin.H{"error": "product not found"})
+		return
+	}
+	ctx.JSON(http.StatusOK, product)
+}
+
+func (c *ProductController) UpdateProduct(ctx *gin.Context) {
+	adminID, ok := currentUserID(ctx)
+	if !ok {
+		return
+	}
+	code := ctx.Param("code")
+	var product domain.LoanProduct
+	if err := ctx.ShouldBindJSON(&product); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := c.ProductUsecase.UpdateProduct(ctx, adminID, code, product); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"message": "product updated"})
+}
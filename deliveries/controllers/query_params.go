@@ -0,0 +1,63 @@
+package controllers
+
+import (
+	"errors"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// queryDateRange reads from and to; to is inclusive, so the returned end is the start of the next day
+func queryDateRange(ctx *gin.Context) (time.Time, time.Time, error) {
+	from, err := queryDate(ctx, "from")
+	if err != nil {
+		return time.Time{}, time.Time{}, err
+	}
+	to, err := queryDate(ctx, "to")
+	if err != nil {
+		return time.Time{}, time.Time{}, err
+	}
+	if !to.IsZero() {
+		to = to.AddDate(0, 0, 1)
+	}
+	return from, to, nil
+}
+
+func queryDate(ctx *gin.Context, name string) (time.Time, error) {
+	value := ctx.Query(name)
+	if value == "" {
+		return time.Time{}, nil
+	}
+	date, err := time.Parse("2006-01-02", value)
+	if err != nil {
+		return time.Time{}, errors.New("invalid " + name + " date, use YYYY-MM-DD")
+	}
+	return date, nil
+}
+
+func queryObjectID(ctx *gin.Context, name string) (primitive.ObjectID, error) {
+	value := ctx.Query(name)
+	if value == "" {
+		return primitive.NilObjectID, nil
+	}
+	id, err := primitive.ObjectIDFromHex(value)
+	if err != nil {
+		return primitive.NilObjectID, errors.New("invalid " + name)
+	}
+	return id, nil
+}
+
+// queryBool returns nil when the parameter is absent
+func queryBool(ctx *gin.Context, name string) (*bool, error) {
+	value := ctx.Query(name)
+	if value == "" {
+		return nil, nil
+	}
+	b, err := strconv.ParseBool(value)
+	if err != nil {
+		return nil, errors.New("invalid " + name + ", use true or false")
+	}
+	return &b, nil
+}
@@ -0,0 +1,121 @@
+package controllers
+
+import (
+	"context"
+	"io"
+	"loan-tracker/domain"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// maxStatementSize bounds uploaded bank statement files
+const maxStatementSize = 10 << 20
+
+type ReconciliationController struct {
+	ReconciliationUsecase domain.ReconciliationUsecase
+}
+
+func NewReconciliationController(reconciliationUsecase domain.ReconciliationUsecase) *ReconciliationController {
+	return &ReconciliationController{
+		ReconciliationUsecase: reconciliationUsecase,
+	}
+}
+
+// ImportStatement takes a multipart "file" upload. The format ("csv", "mt940" or "camt053") is
+// detected from the file unless given in the "format" field.
+func (c *ReconciliationController) ImportStatement(ctx *gin.Context) {
+	adminID, err := primitive.ObjectIDFromHex(ctx.GetString("userid"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid user id"})
+		return
+	}
+
+	ctx.Request.Body = http.MaxBytesReader(ctx.Writer, ctx.Request.Body, maxStatementSize)
+	header, err := ctx.FormFile("file")
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "a statement file of at most 10MB is required"})
+		return
+	}
+	file, err := header.Open()
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "could not read the statement file"})
+		return
+	}
+	defer file.Close()
+	data, err := io.ReadAll(file)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "could not read the statement file"})
+		return
+	}
+
+	statement, err := c.ReconciliationUsecase.ImportStatement(ctx, header.Filename, ctx.PostForm("format"), data, adminID)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, statement)
+}
+
+func (c *ReconciliationController) GetStatements(ctx *gin.Context) {
+	statements, err := c.ReconciliationUsecase.GetStatements(ctx)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	ctx.JSON(http.StatusOK, statements)
+}
+
+// GetTransactions lists the lines of a statement, or the unmatched queue when no filter is given
+func (c *ReconciliationController) GetTransactions(ctx *gin.Context) {
+	statementID, err := queryObjectID(ctx, "statement_id")
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	filter := domain.BankTransactionFilter{
+		StatementID: statementID,
+		Status:      ctx.Query("status"),
+	}
+
+	transactions, err := c.ReconciliationUsecase.GetTransactions(ctx, filter)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	ctx.JSON(http.StatusOK, transactions)
+}
+
+// MatchTransaction posts a queued line as a repayment of the loan given in the body
+func (c *ReconciliationController) MatchTransaction(ctx *gin.Context) {
+	c.resolve(ctx, c.ReconciliationUsecase.MatchTransaction)
+}
+
+func (c *ReconciliationController) IgnoreTransaction(ctx *gin.Context) {
+	c.resolve(ctx, c.ReconciliationUsecase.IgnoreTransaction)
+}
+
+func (c *ReconciliationController) resolve(ctx *gin.Context,
+	action func(ctx context.Context, id, adminID primitive.ObjectID, req domain.ReconcileRequest) (domain.BankTransaction, error)) {
+	id, adminID, ok := loanAndUserIDs(ctx)
+	if !ok {
+		return
+	}
+	var req domain.ReconcileRequest
+	if ctx.Request.ContentLength > 0 {
+		if err := ctx.ShouldBindJSON(&req); err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+
+	transaction, err := action(ctx, id, adminID, req)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, transaction)
+}
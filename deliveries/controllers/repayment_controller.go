@@ -0,0 +1,100 @@
+package controllers
+
+import (
+	"loan-tracker/domain"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type RepaymentController struct {
+	RepaymentUsecase domain.RepaymentUsecase
+}
+
+func NewRepaymentController(repaymentUsecase domain.RepaymentUsecase) *RepaymentController {
+	return &RepaymentController{
+		RepaymentUsecase: repaymentUsecase,
+	}
+}
+
+func (c *RepaymentController) RecordRepayment(ctx *gin.Context) {
+	id := ctx.Param("id")
+	loanID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		return
+	}
+	adminID, err := primitive.ObjectIDFromHex(ctx.GetString("userid"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid user id"})
+		return
+	}
+
+	var repayment domain.Repayment
+	if err := ctx.ShouldBindJSON(&repayment); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	repaymentID, err := c.RepaymentUsecase.RecordRepayment(ctx, loanID, adminID, repayment)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"repayment_id": repaymentID})
+}
+
+func (c *RepaymentController) GetLoanRepayments(ctx *gin.Context) {
+	loanID, err := primitive.ObjectIDFromHex(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		return
+	}
+	userID, err := primitive.ObjectIDFromHex(ctx.GetString("userid"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid user id"})
+		return
+	}
+
+	repayments, err := c.RepaymentUsecase.GetLoanRepayments(ctx, loanID, userID, ctx.GetBool("isadmin"))
+	if err != nil {
+		ctx.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	ctx.JSON(http.StatusOK, repayments)
+}
+
+func (c *RepaymentController) GetRepayments(ctx *gin.Context) {
+	filter, ok := repaymentFilter(ctx)
+	if !ok {
+		return
+	}
+
+	repayments, err := c.RepaymentUsecase.GetRepayments(ctx, filter)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	ctx.JSON(http.StatusOK, repayments)
+}
+
+// repaymentFilter reads loan_id, user_id, method, from and to (payment date, YYYY-MM-DD, to is inclusive)
+func repaymentFilter(ctx *gin.Context) (domain.RepaymentFilter, bool) {
+	filter := domain.RepaymentFilter{Method: ctx.Query("method")}
+	var err error
+	if filter.LoanID, err = queryObjectID(ctx, "loan_id"); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return filter, false
+	}
+	if filter.UserID, err = queryObjectID(ctx, "user_id"); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return filter, false
+	}
+	if filter.From, filter.To, err = queryDateRange(ctx); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return filter, false
+	}
+	return filter, true
+}
@@ -0,0 +1,102 @@
+package controllers
+
+import (
+	"loan-tracker/domain"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+type ReportController struct {
+	ReportUsecase domain.ReportUsecase
+}
+
+func NewReportController(reportUsecase domain.ReportUsecase) *ReportController {
+	return &ReportController{
+		ReportUsecase: reportUsecase,
+	}
+}
+
+func (c *ReportController) Portfolio(ctx *gin.Context) {
+	filter, ok := reportFilter(ctx)
+	if !ok {
+		return
+	}
+	rows, err := c.ReportUsecase.Portfolio(ctx, filter)
+	respondReport(ctx, rows, err)
+}
+
+func (c *ReportController) Approvals(ctx *gin.Context) {
+	filter, ok := reportFilter(ctx)
+	if !ok {
+		return
+	}
+	rows, err := c.ReportUsecase.Approvals(ctx, filter)
+	respondReport(ctx, rows, err)
+}
+
+func (c *ReportController) PortfolioAtRisk(ctx *gin.Context) {
+	filter, ok := reportFilter(ctx)
+	if !ok {
+		return
+	}
+	rows, err := c.ReportUsecase.PortfolioAtRisk(ctx, filter)
+	respondReport(ctx, rows, err)
+}
+
+func (c *ReportController) Collections(ctx *gin.Context) {
+	filter, ok := reportFilter(ctx)
+	if !ok {
+		return
+	}
+	rows, err := c.ReportUsecase.Collections(ctx, filter)
+	respondReport(ctx, rows, err)
+}
+
+func (c *ReportController) Vintage(ctx *gin.Context) {
+	filter, ok := reportFilter(ctx)
+	if !ok {
+		return
+	}
+	rows, err := c.ReportUsecase.Vintage(ctx, filter)
+	respondReport(ctx, rows, err)
+}
+
+func respondReport(ctx *gin.Context, rows interface{}, err error) {
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	ctx.JSON(http.StatusOK, gin.H{"rows": rows})
+}
+
+// reportFilter reads from, to and as_of (YYYY-MM-DD, to is inclusive) and
+// group_by ("product", "month" or "product,month") from the query string
+func reportFilter(ctx *gin.Context) (domain.ReportFilter, bool) {
+	var filter domain.ReportFilter
+	var err error
+	if filter.From, filter.To, err = queryDateRange(ctx); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return filter, false
+	}
+	if filter.AsOf, err = queryDate(ctx, "as_of"); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return filter, false
+	}
+
+	if groupBy := ctx.Query("group_by"); groupBy != "" {
+		for _, field := range strings.Split(groupBy, ",") {
+			switch strings.TrimSpace(field) {
+			case "product":
+				filter.GroupByProduct = true
+			case "month":
+				filter.GroupByMonth = true
+			default:
+				ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid group_by, you can only enter product and/or month"})
+				return filter, false
+			}
+		}
+	}
+	return filter, true
+}
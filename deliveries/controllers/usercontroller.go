@@ -2,8 +2,7 @@ package controllers
 
 import (
 	"loan-tracker/domain"
-	"log"
-	"time"
+	"loan-tracker/infrastructure"
 
 	"github.com/gin-gonic/gin"
 	"go.mongodb.org/mongo-driver/bson/primitive"
@@ -11,14 +10,12 @@ import (
 
 type UserController struct {
 	Userusecase domain.UserUsecase
-	LogUsecase  domain.LogUsecase
 }
 
 // Blog-controller constructor
-func NewUserController(Usermgr domain.UserUsecase, logUsecase domain.LogUsecase) *UserController {
+func NewUserController(Usermgr domain.UserUsecase) *UserController {
 	return &UserController{
 		Userusecase: Usermgr,
-		LogUsecase:  logUsecase,
 	}
 }
 
@@ -45,23 +42,16 @@ func (uc *UserController) RegisterUser(c *gin.Context) {
 	}
 
 	user.IsAdmin = false
+	if user.Locale == "" {
+		user.Locale = c.GetHeader("Accept-Language")
+	}
 
-	err := uc.Userusecase.RegisterUser(c, &user)
+	err := uc.Userusecase.RegisterUser(c, &user, clientPlatform(c))
 	if err != nil {
 		c.JSON(400, gin.H{"error": err.Error()})
 		return
 	}
 
-	// Log user registration
-	logEntry := domain.Log{
-		Timestamp: time.Now(),
-		Type:      "user_registration",
-		Details:   "User registered with email: " + user.Email,
-	}
-	if logErr := uc.LogUsecase.LogEvent(c, logEntry); logErr != nil {
-		log.Println("Error logging user registration:", logErr)
-	}
-
 	c.JSON(200, gin.H{"message": "User registered successfully"})
 }
 
@@ -78,16 +68,6 @@ func (uc *UserController) VerifyUserEmail(c *gin.Context) {
 		return
 	}
 
-	// Log email verification
-	logEntry := domain.Log{
-		Timestamp: time.Now(),
-		Type:      "email_verification",
-		Details:   "Email verified with token: " + token,
-	}
-	if logErr := uc.LogUsecase.LogEvent(c, logEntry); logErr != nil {
-		log.Println("Error logging email verification:", logErr)
-	}
-
 	c.JSON(200, gin.H{"message": "Email verified successfully"})
 }
 
@@ -114,16 +94,6 @@ func (uc *UserController) LoginUser(c *gin.Context) {
 		return
 	}
 
-	// Log login attempt
-	logEntry := domain.Log{
-		Timestamp: time.Now(),
-		Type:      "login_attempt",
-		Details:   "User login attempt with email: " + user.Email,
-	}
-	if logErr := uc.LogUsecase.LogEvent(c, logEntry); logErr != nil {
-		log.Println("Error logging login attempt:", logErr)
-	}
-
 	c.JSON(200, gin.H{"message": "user successfully logged in", "token": token})
 }
 
@@ -143,16 +113,6 @@ func (uc *UserController) TokenRefresh(c *gin.Context) {
 		return
 	}
 
-	// Log token refresh
-	logEntry := domain.Log{
-		Timestamp: time.Now(),
-		Type:      "token_refresh",
-		Details:   "Token refreshed with refresh token: " + tokenRequest.RefreshToken,
-	}
-	if logErr := uc.LogUsecase.LogEvent(c, logEntry); logErr != nil {
-		log.Println("Error logging token refresh:", logErr)
-	}
-
 	c.JSON(200, gin.H{"message": "token refreshed successfully", "token": newToken})
 }
 
@@ -178,16 +138,6 @@ func (uc *UserController) UserProfile(c *gin.Context) {
 		return
 	}
 
-	// Log user profile retrieval
-	logEntry := domain.Log{
-		Timestamp: time.Now(),
-		Type:      "user_profile_retrieval",
-		Details:   "User profile retrieved for user ID: " + userID.Hex(),
-	}
-	if logErr := uc.LogUsecase.LogEvent(c, logEntry); logErr != nil {
-		log.Println("Error logging user profile retrieval:", logErr)
-	}
-
 	c.JSON(200, gin.H{"message": "user profile retrieved successfully", "user": fuser})
 }
 
@@ -203,22 +153,12 @@ func (uc *UserController) PasswordResetRequest(c *gin.Context) {
 		return
 	}
 
-	err := uc.Userusecase.PasswordResetRequest(c, rest.Email)
+	err := uc.Userusecase.PasswordResetRequest(c, rest.Email, clientPlatform(c))
 	if err != nil {
 		c.JSON(400, gin.H{"error": err.Error()})
 		return
 	}
 
-	// Log password reset request
-	logEntry := domain.Log{
-		Timestamp: time.Now(),
-		Type:      "password_reset_request",
-		Details:   "Password reset requested for email: " + rest.Email,
-	}
-	if logErr := uc.LogUsecase.LogEvent(c, logEntry); logErr != nil {
-		log.Println("Error logging password reset request:", logErr)
-	}
-
 	c.JSON(200, gin.H{"message": "password reset request successful"})
 }
 
@@ -246,35 +186,25 @@ func (uc *UserController) PasswordReset(c *gin.Context) {
 		return
 	}
 
-	logEntry := domain.Log{
-		Timestamp: time.Now(),
-		Type:      "password_reset_completion",
-		Details:   "Password reset completed with token: " + token,
-	}
-	if logErr := uc.LogUsecase.LogEvent(c, logEntry); logErr != nil {
-		log.Println("Error logging password reset completion:", logErr)
-	}
-
 	c.JSON(200, gin.H{"message": "password reset successful"})
 }
 
 func (uc *UserController) GetAllUsers(c *gin.Context) {
-	users, err := uc.Userusecase.GetAllUsers(c)
+	adminID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+	filter, ok := userFilter(c)
+	if !ok {
+		return
+	}
+	users, err := uc.Userusecase.GetAllUsers(c, adminID, filter)
 
 	if err != nil {
 		c.JSON(400, gin.H{"error": err.Error()})
 		return
 	}
 
-	logEntry := domain.Log{
-		Timestamp: time.Now(),
-		Type:      "get_all_users",
-		Details:   "All users retrieved",
-	}
-	if logErr := uc.LogUsecase.LogEvent(c, logEntry); logErr != nil {
-		log.Println("Error logging retrieval of all users:", logErr)
-	}
-
 	c.JSON(200, gin.H{"message": "users retrieved successfully", "users": users})
 }
 
@@ -290,22 +220,68 @@ func (uc *UserController) DeleteUser(c *gin.Context) {
 		c.JSON(400, gin.H{"error": err.Error()})
 		return
 	}
+	adminID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
 	var user domain.User
 	user.ID = userID
-	err = uc.Userusecase.DeleteUser(c, user)
+	err = uc.Userusecase.DeleteUser(c, adminID, user)
 	if err != nil {
 		c.JSON(400, gin.H{"error": err.Error()})
 		return
 	}
 
-	logEntry := domain.Log{
-		Timestamp: time.Now(),
-		Type:      "user_deletion",
-		Details:   "User deleted with ID: " + userID.Hex(),
+	c.JSON(200, gin.H{"message": "user deleted successfully"})
+}
+
+func (uc *UserController) SetRole(c *gin.Context) {
+	userID, err := primitive.ObjectIDFromHex(c.Param("userid"))
+	if err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+	var body struct {
+		Role string `json:"role"`
 	}
-	if logErr := uc.LogUsecase.LogEvent(c, logEntry); logErr != nil {
-		log.Println("Error logging user deletion:", logErr)
+	if err := c.ShouldBindJSON(&body); err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
 	}
 
-	c.JSON(200, gin.H{"message": "user deleted successfully"})
+	adminID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+	err = uc.Userusecase.SetRole(c, adminID, domain.User{ID: userID}, body.Role)
+	if err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(200, gin.H{"message": "role updated successfully"})
+}
+
+// userFilter reads isadmin, isverified, role and kyc_status
+func userFilter(c *gin.Context) (domain.UserFilter, bool) {
+	filter := domain.UserFilter{
+		Role:      c.Query("role"),
+		KYCStatus: c.Query("kyc_status"),
+	}
+	var err error
+	if filter.IsAdmin, err = queryBool(c, "isadmin"); err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return filter, false
+	}
+	if filter.IsVerified, err = queryBool(c, "isverified"); err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return filter, false
+	}
+	return filter, true
+}
+
+// clientPlatform reads the X-Client-Platform header ("web", "ios", "android" or "mobile") so
+// emailed links open where the user started; clients that send none get web links
+func clientPlatform(c *gin.Context) string {
+	return infrastructure.ClientPlatform(c.GetHeader("X-Client-Platform"))
 }
@@ -0,0 +1,130 @@
+package controllers
+
+import (
+	"loan-tracker/domain"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type WebhookController struct {
+	WebhookUsecase domain.WebhookUsecase
+}
+
+func NewWebhookController(webhookUsecase domain.WebhookUsecase) *WebhookController {
+	return &WebhookController{
+		WebhookUsecase: webhookUsecase,
+	}
+}
+
+// CreateSubscription responds with the signing secret, which is not shown again
+func (c *WebhookController) CreateSubscription(ctx *gin.Context) {
+	adminID, err := primitive.ObjectIDFromHex(ctx.GetString("userid"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid user id"})
+		return
+	}
+	var req domain.WebhookSubscriptionRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	subscription, secret, err := c.WebhookUsecase.CreateSubscription(ctx, adminID, req)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, gin.H{"subscription": subscription, "secret": secret})
+}
+
+func (c *WebhookController) GetSubscriptions(ctx *gin.Context) {
+	subscriptions, err := c.WebhookUsecase.GetSubscriptions(ctx)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	ctx.JSON(http.StatusOK, subscriptions)
+}
+
+func (c *WebhookController) UpdateSubscription(ctx *gin.Context) {
+	id, err := primitive.ObjectIDFromHex(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid subscription id"})
+		return
+	}
+	adminID, ok := currentUserID(ctx)
+	if !ok {
+		return
+	}
+	var req domain.WebhookSubscriptionRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	subscription, err := c.WebhookUsecase.UpdateSubscription(ctx, id, adminID, req)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, subscription)
+}
+
+func (c *WebhookController) DeleteSubscription(ctx *gin.Context) {
+	id, err := primitive.ObjectIDFromHex(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid subscription id"})
+		return
+	}
+	adminID, ok := currentUserID(ctx)
+	if !ok {
+		return
+	}
+
+	if err := c.WebhookUsecase.DeleteSubscription(ctx, id, adminID); err != nil {
+		ctx.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"message": "webhook subscription deleted"})
+}
+
+// GetDeliveries lists the delivery log, filtered by ?subscription_id=, ?event_type= and ?status= (e.g. "dead")
+func (c *WebhookController) GetDeliveries(ctx *gin.Context) {
+	subscriptionID, err := queryObjectID(ctx, "subscription_id")
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	filter := domain.WebhookDeliveryFilter{
+		SubscriptionID: subscriptionID,
+		EventType:      ctx.Query("event_type"),
+		Status:         ctx.Query("status"),
+	}
+
+	deliveries, err := c.WebhookUsecase.GetDeliveries(ctx, filter)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	ctx.JSON(http.StatusOK, deliveries)
+}
+
+func (c *WebhookController) ReplayDelivery(ctx *gin.Context) {
+	id, adminID, ok := loanAndUserIDs(ctx)
+	if !ok {
+		return
+	}
+
+	replay, err := c.WebhookUsecase.ReplayDelivery(ctx, id, adminID)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, replay)
+}
@@ -3,16 +3,30 @@ package router
 import (
 	"loan-tracker/deliveries/controllers"
 	"loan-tracker/infrastructure/middleware"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"go.mongodb.org/mongo-driver/mongo"
 )
 
-func SetRouter(router *gin.Engine, uc controllers.UserController, lc controllers.LoanController, loc controllers.LogController, client *mongo.Client) {
+func SetRouter(router *gin.Engine, uc controllers.UserController, lc controllers.LoanController, loc controllers.LogController,
+	pc controllers.ProductController, gc controllers.GuaranteeController, kc controllers.KYCController,
+	clc controllers.CreditLineController, rc controllers.RepaymentController,
+	cc controllers.CalculatorController, nc controllers.LoanNoteController, ac controllers.AssignmentController,
+	rpc controllers.ReportController, ec controllers.ExportController,
+	dc controllers.DocumentController, acc controllers.AcceptanceController, pyc controllers.PaymentController,
+	rcc controllers.ReconciliationController, mc controllers.MandateController, evc controllers.EventController,
+	wc controllers.WebhookController, emc controllers.EmailController, ntc controllers.NotificationController, ctc controllers.ContactController, client *mongo.Client) {
 	router.POST("/users/register", uc.RegisterUser)
 	router.GET("/users/verify-email", uc.VerifyUserEmail)
 	router.POST("/users/login", uc.LoginUser)
 
+	// The calculator is public, so it is rate-limited per client IP
+	router.POST("/calculator/quote", middleware.RateLimitMiddleware(30, time.Minute), cc.Quote)
+
+	// Called by payment providers, which authenticate by signing each request
+	router.POST("/payments/webhooks/:provider", pyc.Webhook)
+
 	authRoutes := router.Group("/")
 	authRoutes.Use(middleware.AuthMiddleware(client))
 
@@ -20,9 +34,58 @@ func SetRouter(router *gin.Engine, uc controllers.UserController, lc controllers
 	authRoutes.POST("/users/password-update", uc.PasswordResetRequest)
 	authRoutes.POST("/users/password-reset", uc.PasswordReset)
 	authRoutes.GET("/users/profile", middleware.AuthMiddleware(client), uc.UserProfile)
+	authRoutes.GET("/users/kyc", kc.GetKYC)
+	authRoutes.PUT("/users/kyc", kc.SubmitKYC)
+	authRoutes.PUT("/users/phone", ctc.RequestPhoneVerification)
+	authRoutes.POST("/users/phone/verify", ctc.VerifyPhone)
+	authRoutes.DELETE("/users/phone", ctc.RemovePhone)
+	authRoutes.GET("/users/devices", ctc.GetPushDevices)
+	authRoutes.POST("/users/devices", ctc.RegisterPushDevice)
+	authRoutes.DELETE("/users/devices/:token", ctc.RemovePushDevice)
 
 	authRoutes.POST("/loans", lc.ApplyForLoan)
 	authRoutes.GET("/loans/:id", lc.ViewLoanStatus)
+	authRoutes.PATCH("/loans/:id", lc.EditLoan)
+	authRoutes.POST("/loans/:id/cancel", lc.CancelLoan)
+	authRoutes.GET("/loans/:id/history", lc.GetLoanHistory)
+	authRoutes.GET("/loans/:id/comments", nc.GetComments)
+	authRoutes.POST("/loans/:id/comments", nc.AddComment)
+	authRoutes.PATCH("/loans/:id/comments/:noteid", nc.EditComment)
+	authRoutes.GET("/loans/:id/guarantees", gc.GetLoanGuarantees)
+	authRoutes.POST("/loans/:id/guarantors", gc.InviteGuarantor)
+	authRoutes.POST("/loans/:id/refinance", lc.RefinanceLoan)
+	authRoutes.GET("/loans/:id/repayments", rc.GetLoanRepayments)
+	authRoutes.GET("/loans/:id/documents", dc.GetDocuments)
+	authRoutes.GET("/loans/:id/documents/:docid", dc.DownloadDocument)
+	authRoutes.POST("/loans/:id/statements", dc.GenerateStatement)
+	authRoutes.GET("/loans/:id/acceptance", acc.GetAcceptances)
+	authRoutes.POST("/loans/:id/acceptance", acc.AcceptAgreement)
+	authRoutes.POST("/loans/:id/acceptance/code", acc.RequestCode)
+	authRoutes.GET("/loans/:id/payments", pyc.GetPayments)
+	authRoutes.POST("/loans/:id/payments", pyc.CreateCheckout)
+	authRoutes.GET("/loans/:id/mandates", mc.GetMandates)
+	authRoutes.POST("/loans/:id/mandates", mc.CreateMandate)
+	authRoutes.POST("/loans/:id/mandates/:mandateid/revoke", mc.RevokeMandate)
+	authRoutes.GET("/loans/:id/collections", mc.GetCollections)
+
+	authRoutes.GET("/products", pc.GetAllProducts)
+	authRoutes.GET("/products/:code", pc.GetProduct)
+
+	authRoutes.GET("/credit-lines", clc.GetMyCreditLines)
+	authRoutes.GET("/credit-lines/:id", clc.GetCreditLine)
+	authRoutes.GET("/credit-lines/:id/transactions", clc.GetTransactions)
+	authRoutes.POST("/credit-lines/:id/draw", clc.Draw)
+	authRoutes.POST("/credit-lines/:id/repay", clc.Repay)
+
+	authRoutes.GET("/guarantees", gc.GetMyGuarantees)
+	authRoutes.POST("/guarantees/:id/accept", gc.AcceptGuarantee)
+	authRoutes.POST("/guarantees/:id/decline", gc.DeclineGuarantee)
+
+	authRoutes.GET("/notifications", ntc.GetInbox)
+	authRoutes.POST("/notifications/read", ntc.MarkAllRead)
+	authRoutes.POST("/notifications/:id/read", ntc.MarkRead)
+	authRoutes.GET("/notifications/preferences", ntc.GetPreferences)
+	authRoutes.PUT("/notifications/preferences", ntc.UpdatePreferences)
 
 	// Admin routes
 	adminRoutes := authRoutes.Group("/admin")
@@ -30,10 +93,68 @@ func SetRouter(router *gin.Engine, uc controllers.UserController, lc controllers
 
 	adminRoutes.GET("/users", middleware.AuthMiddleware(client), uc.GetAllUsers)
 	adminRoutes.DELETE("/users/:userid", middleware.AuthMiddleware(client), uc.DeleteUser)
+	adminRoutes.PATCH("/users/:userid/role", middleware.SupervisorMiddleware(), uc.SetRole)
+	adminRoutes.GET("/kyc", kc.GetReviewQueue)
+	adminRoutes.PATCH("/kyc/:userid", kc.ReviewKYC)
 
 	adminRoutes.GET("/loans", lc.ViewAllLoans)
 	adminRoutes.PATCH("/loans/:id/status", lc.ApproveOrRejectLoan)
 	adminRoutes.DELETE("/loans/:id", lc.DeleteLoan)
+	adminRoutes.POST("/loans/:id/repayments", rc.RecordRepayment)
+	adminRoutes.GET("/repayments", rc.GetRepayments)
+	adminRoutes.POST("/loans/:id/agreement", dc.RegenerateAgreement)
+	adminRoutes.POST("/loans/:id/disburse", acc.DisburseLoan)
+	adminRoutes.GET("/loans/:id/notes", nc.GetNotes)
+	adminRoutes.POST("/loans/:id/notes", nc.AddNote)
+	adminRoutes.PATCH("/loans/:id/notes/:noteid", nc.EditNote)
+	adminRoutes.POST("/loans/:id/assign", ac.AssignLoan)
+	adminRoutes.POST("/loans/:id/review", ac.StartReview)
+	adminRoutes.GET("/queue", ac.MyQueue)
+	adminRoutes.POST("/queue/auto-assign", middleware.SupervisorMiddleware(), ac.AutoAssign)
+	adminRoutes.GET("/queue/workloads", middleware.SupervisorMiddleware(), ac.GetWorkloads)
+	adminRoutes.GET("/queue/stage-times", ac.GetStageTimes)
 	adminRoutes.GET("/logs", loc.ViewSystemLogs)
 
+	adminRoutes.GET("/reports/portfolio", rpc.Portfolio)
+	adminRoutes.GET("/reports/approvals", rpc.Approvals)
+	adminRoutes.GET("/reports/par", rpc.PortfolioAtRisk)
+	adminRoutes.GET("/reports/collections", rpc.Collections)
+	adminRoutes.GET("/reports/vintage", rpc.Vintage)
+
+	adminRoutes.GET("/exports/loans", ec.ExportLoans)
+	adminRoutes.GET("/exports/repayments", ec.ExportRepayments)
+	adminRoutes.GET("/exports/users", ec.ExportUsers)
+	adminRoutes.GET("/exports/logs", ec.ExportLogs)
+	adminRoutes.GET("/exports/acceptances", ec.ExportAcceptances)
+
+	adminRoutes.POST("/bank-statements", rcc.ImportStatement)
+	adminRoutes.GET("/bank-statements", rcc.GetStatements)
+	adminRoutes.GET("/reconciliation", rcc.GetTransactions)
+	adminRoutes.POST("/reconciliation/:id/match", rcc.MatchTransaction)
+	adminRoutes.POST("/reconciliation/:id/ignore", rcc.IgnoreTransaction)
+	adminRoutes.GET("/collections", mc.GetAllCollections)
+	if pyc.SimulatorEnabled() {
+		adminRoutes.POST("/payments/simulator/:ref", pyc.SimulatePayment)
+	}
+	adminRoutes.GET("/events", evc.GetEvents)
+	adminRoutes.POST("/events/:id/retry", evc.RetryEvent)
+	adminRoutes.POST("/webhooks", wc.CreateSubscription)
+	adminRoutes.GET("/webhooks", wc.GetSubscriptions)
+	adminRoutes.PUT("/webhooks/:id", wc.UpdateSubscription)
+	adminRoutes.DELETE("/webhooks/:id", wc.DeleteSubscription)
+	adminRoutes.GET("/webhook-deliveries", wc.GetDeliveries)
+	adminRoutes.POST("/webhook-deliveries/:id/replay", wc.ReplayDelivery)
+	adminRoutes.GET("/emails", emc.GetEmails)
+	adminRoutes.POST("/emails/:id/resend", emc.ResendEmail)
+	adminRoutes.GET("/email-templates", emc.GetTemplates)
+	adminRoutes.GET("/email-templates/:kind/preview", emc.PreviewTemplate)
+
+	adminRoutes.GET("/credit-lines", clc.GetAllCreditLines)
+	adminRoutes.PATCH("/credit-lines/:id/limit", clc.ChangeLimit)
+	adminRoutes.POST("/credit-lines/:id/freeze", clc.FreezeCreditLine)
+	adminRoutes.POST("/credit-lines/:id/unfreeze", clc.UnfreezeCreditLine)
+
+	adminRoutes.POST("/products", pc.CreateProduct)
+	adminRoutes.PUT("/products/:code", pc.UpdateProduct)
+
 }
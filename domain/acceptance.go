@@ -0,0 +1,63 @@
+package domain
+
+import (
+	"context"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// AgreementAcceptance is the borrower's electronic acceptance of a loan agreement. It is created
+// "pending" when a one-time code is emailed to the borrower and becomes "accepted" once the code
+// is confirmed; the accepted record is the evidence kept for the loan.
+type AgreementAcceptance struct {
+	ID         primitive.ObjectID `bson:"_id,omitempty" json:"id,omitempty"`
+	LoanID     primitive.ObjectID `bson:"loan_id" json:"loan_id"`
+	UserID     primitive.ObjectID `bson:"user_id" json:"user_id"`
+	DocumentID primitive.ObjectID `bson:"document_id" json:"document_id"`
+	// AgreementChecksum is the SHA-256 of the agreement PDF, identifying the exact version accepted.
+	AgreementChecksum string    `bson:"agreement_checksum" json:"agreement_checksum"`
+	TemplateVersion   string    `bson:"template_version" json:"template_version"`
+	Status            string    `bson:"status" json:"status"` // "pending" or "accepted"
+	Email             string    `bson:"email" json:"email"`   // where the code was sent
+	CodeHash          string    `bson:"code_hash" json:"-"`
+	CodeSentAt        time.Time `bson:"code_sent_at" json:"code_sent_at"`
+	CodeExpiresAt     time.Time `bson:"code_expires_at" json:"code_expires_at"`
+	Attempts          int       `bson:"attempts" json:"attempts"`
+	AcceptedAt        time.Time `bson:"accepted_at,omitempty" json:"accepted_at,omitempty"`
+	IPAddress         string    `bson:"ip_address,omitempty" json:"ip_address,omitempty"`
+	UserAgent         string    `bson:"user_agent,omitempty" json:"user_agent,omitempty"`
+}
+
+// AcceptanceRequest confirms the emailed code for the agreement version the borrower read.
+type AcceptanceRequest struct {
+	Code              string `json:"code"`
+	AgreementChecksum string `json:"agreement_checksum"`
+}
+
+// AcceptanceFilter narrows the accepted agreements included in an export.
+type AcceptanceFilter struct {
+	LoanID primitive.ObjectID
+	From   time.Time // accepted on or after
+	To     time.Time // accepted before
+}
+
+type AcceptanceRepository interface {
+	CreateAcceptance(ctx context.Context, acceptance AgreementAcceptance) (primitive.ObjectID, error)
+	// GetPendingAcceptance returns the loan's most recently sent code that has not been confirmed.
+	GetPendingAcceptance(ctx context.Context, loanID primitive.ObjectID) (AgreementAcceptance, error)
+	RecordFailedAttempt(ctx context.Context, id primitive.ObjectID) error
+	// ConfirmAcceptance marks a pending record accepted with its evidence.
+	ConfirmAcceptance(ctx context.Context, acceptance AgreementAcceptance) error
+	// GetAcceptedAgreement finds the loan's acceptance of the agreement with the given checksum.
+	GetAcceptedAgreement(ctx context.Context, loanID primitive.ObjectID, checksum string) (AgreementAcceptance, error)
+	GetAcceptancesByLoan(ctx context.Context, loanID primitive.ObjectID) ([]AgreementAcceptance, error)
+	StreamAcceptances(ctx context.Context, filter AcceptanceFilter, fn func(AgreementAcceptance) error) error
+}
+
+type AcceptanceUsecase interface {
+	// RequestCode emails the borrower a one-time code for accepting the loan's current agreement.
+	RequestCode(ctx context.Context, loanID, userID primitive.ObjectID) (AgreementAcceptance, error)
+	AcceptAgreement(ctx context.Context, loanID, userID primitive.ObjectID, req AcceptanceRequest, ipAddress, userAgent string) (AgreementAcceptance, error)
+	GetAcceptances(ctx context.Context, loanID, userID primitive.ObjectID, isAdmin bool) ([]AgreementAcceptance, error)
+}
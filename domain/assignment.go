@@ -0,0 +1,51 @@
+package domain
+
+import (
+	"context"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// StageInterval is one stretch of time a loan application spent in a review stage.
+type StageInterval struct {
+	Stage     string             `bson:"stage" json:"stage"`
+	OfficerID primitive.ObjectID `bson:"officer_id,omitempty" json:"officer_id,omitempty"`
+	EnteredAt time.Time          `bson:"entered_at" json:"entered_at"`
+	ExitedAt  time.Time          `bson:"exited_at,omitempty" json:"exited_at,omitempty"`
+	Seconds   int64              `bson:"seconds,omitempty" json:"seconds,omitempty"`
+}
+
+// OfficerWorkload counts the applications assigned to an officer.
+type OfficerWorkload struct {
+	OfficerID      primitive.ObjectID `bson:"_id" json:"officer_id"`
+	UserName       string             `bson:"-" json:"username,omitempty"`
+	OpenLoans      int                `bson:"open_loans" json:"open_loans"`
+	TotalLoans     int                `bson:"total_loans" json:"total_loans"`
+	LastAssignedAt time.Time          `bson:"last_assigned_at" json:"last_assigned_at"`
+}
+
+// StageTime summarises the completed intervals of one stage, optionally per officer.
+type StageTime struct {
+	Stage          string             `bson:"stage" json:"stage"`
+	OfficerID      primitive.ObjectID `bson:"officer_id,omitempty" json:"officer_id,omitempty"`
+	Count          int                `bson:"count" json:"count"`
+	AverageSeconds float64            `bson:"average_seconds" json:"average_seconds"`
+	MaxSeconds     int64              `bson:"max_seconds" json:"max_seconds"`
+}
+
+// AssignRequest names the officer a loan should be assigned to.
+type AssignRequest struct {
+	OfficerID string `json:"officer_id"`
+}
+
+type AssignmentUsecase interface {
+	// AssignLoan assigns a pending loan by hand; only supervisors may take it from another officer.
+	AssignLoan(ctx context.Context, id, officerID, actorID primitive.ObjectID, isSupervisor bool) (Loan, error)
+	// AutoAssignPending assigns every unassigned pending loan using the given strategy.
+	AutoAssignPending(ctx context.Context, actorID primitive.ObjectID, strategy string) (int, error)
+	GetQueue(ctx context.Context, officerID primitive.ObjectID) ([]Loan, error)
+	StartReview(ctx context.Context, id, officerID primitive.ObjectID) (Loan, error)
+	GetWorkloads(ctx context.Context) ([]OfficerWorkload, error)
+	GetStageTimes(ctx context.Context, byOfficer bool) ([]StageTime, error)
+}
@@ -0,0 +1,29 @@
+package domain
+
+import "context"
+
+// QuoteRequest describes a prospective loan. Either Product or InterestRate must be given.
+type QuoteRequest struct {
+	Amount       float64 `json:"amount"`
+	Product      string  `json:"product,omitempty"`
+	InterestRate float64 `json:"interest_rate,omitempty"`
+	Tenor        int     `json:"tenor"`
+	Frequency    string  `json:"frequency"`
+}
+
+type Quote struct {
+	Amount            float64       `json:"amount"`
+	InterestRate      float64       `json:"interest_rate"`
+	Tenor             int           `json:"tenor"`
+	Frequency         string        `json:"frequency"`
+	InstallmentAmount float64       `json:"installment_amount"`
+	TotalInterest     float64       `json:"total_interest"`
+	ProcessingFee     float64       `json:"processing_fee"`
+	TotalPayable      float64       `json:"total_payable"`
+	APR               float64       `json:"apr"`
+	Schedule          []Installment `json:"schedule"`
+}
+
+type CalculatorUsecase interface {
+	Quote(ctx context.Context, req QuoteRequest) (Quote, error)
+}
@@ -0,0 +1,64 @@
+package domain
+
+import (
+	"context"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// CreditLine is a revolving facility opened when a "credit_line" loan application is approved.
+// Interest accrues daily on the drawn balance only.
+type CreditLine struct {
+	ID               primitive.ObjectID `bson:"_id,omitempty" json:"id,omitempty"`
+	LoanID           primitive.ObjectID `bson:"loan_id" json:"loan_id"`
+	UserID           primitive.ObjectID `bson:"user_id" json:"user_id"`
+	Limit            float64            `bson:"limit" json:"limit"`
+	DrawnBalance     float64            `bson:"drawn_balance" json:"drawn_balance"`
+	AvailableBalance float64            `bson:"available_balance" json:"available_balance"`
+	AccruedInterest  float64            `bson:"accrued_interest" json:"accrued_interest"`
+	InterestRate     float64            `bson:"interest_rate" json:"interest_rate"` // annual percentage
+	Status           string             `bson:"status" json:"status"`               // "active" or "frozen"
+	LastAccrualAt    time.Time          `bson:"last_accrual_at" json:"last_accrual_at"`
+	CreatedAt        time.Time          `bson:"created_at" json:"created_at"`
+	UpdatedAt        time.Time          `bson:"updated_at" json:"updated_at"`
+}
+
+// CreditLineTransaction records every movement on a credit line.
+type CreditLineTransaction struct {
+	ID           primitive.ObjectID `bson:"_id,omitempty" json:"id,omitempty"`
+	CreditLineID primitive.ObjectID `bson:"credit_line_id" json:"credit_line_id"`
+	Type         string             `bson:"type" json:"type"` // "draw", "repayment", "limit_change", "freeze" or "unfreeze"
+	Amount       float64            `bson:"amount" json:"amount"`
+	Interest     float64            `bson:"interest,omitempty" json:"interest,omitempty"`   // part of a repayment that settled interest
+	Principal    float64            `bson:"principal,omitempty" json:"principal,omitempty"` // part of a repayment that reduced the drawn balance
+	DrawnAfter   float64            `bson:"drawn_after" json:"drawn_after"`
+	ActorID      primitive.ObjectID `bson:"actor_id" json:"actor_id"`
+	CreatedAt    time.Time          `bson:"created_at" json:"created_at"`
+}
+
+type CreditLineAmount struct {
+	Amount float64 `json:"amount"`
+}
+
+type CreditLineRepository interface {
+	CreateCreditLine(ctx context.Context, line CreditLine) (primitive.ObjectID, error)
+	GetCreditLineByID(ctx context.Context, id primitive.ObjectID) (CreditLine, error)
+	GetCreditLinesByUser(ctx context.Context, userID primitive.ObjectID) ([]CreditLine, error)
+	GetAllCreditLines(ctx context.Context) ([]CreditLine, error)
+	// UpdateCreditLine saves the line only if it was not modified since lastUpdated.
+	UpdateCreditLine(ctx context.Context, line CreditLine, lastUpdated time.Time) error
+	CreateTransaction(ctx context.Context, tx CreditLineTransaction) error
+	GetTransactions(ctx context.Context, creditLineID primitive.ObjectID) ([]CreditLineTransaction, error)
+}
+
+type CreditLineUsecase interface {
+	GetMyCreditLines(ctx context.Context, userID primitive.ObjectID) ([]CreditLine, error)
+	GetCreditLine(ctx context.Context, id, userID primitive.ObjectID) (CreditLine, error)
+	GetTransactions(ctx context.Context, id, userID primitive.ObjectID) ([]CreditLineTransaction, error)
+	Draw(ctx context.Context, id, userID primitive.ObjectID, amount float64) (CreditLine, error)
+	Repay(ctx context.Context, id, userID primitive.ObjectID, amount float64) (CreditLine, error)
+	GetAllCreditLines(ctx context.Context) ([]CreditLine, error)
+	ChangeLimit(ctx context.Context, id, adminID primitive.ObjectID, limit float64) (CreditLine, error)
+	SetFrozen(ctx context.Context, id, adminID primitive.ObjectID, frozen bool) (CreditLine, error)
+}
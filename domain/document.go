@@ -0,0 +1,46 @@
+package domain
+
+import (
+	"context"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// LoanDocument is a generated PDF kept with the loan. Checksum is the SHA-256 of Content,
+// recorded when the file was generated so later copies can be verified against it.
+type LoanDocument struct {
+	ID              primitive.ObjectID `bson:"_id,omitempty" json:"id,omitempty"`
+	LoanID          primitive.ObjectID `bson:"loan_id" json:"loan_id"`
+	UserID          primitive.ObjectID `bson:"user_id" json:"user_id"`
+	Kind            string             `bson:"kind" json:"kind"` // "agreement" or "statement"
+	TemplateVersion string             `bson:"template_version" json:"template_version"`
+	PeriodFrom      time.Time          `bson:"period_from,omitempty" json:"period_from,omitempty"` // statements only
+	PeriodTo        time.Time          `bson:"period_to,omitempty" json:"period_to,omitempty"`
+	FileName        string             `bson:"file_name" json:"file_name"`
+	Size            int                `bson:"size" json:"size"`
+	Checksum        string             `bson:"checksum" json:"checksum"`
+	Content         []byte             `bson:"content,omitempty" json:"-"`
+	GeneratedBy     primitive.ObjectID `bson:"generated_by,omitempty" json:"generated_by,omitempty"`
+	GeneratedAt     time.Time          `bson:"generated_at" json:"generated_at"`
+}
+
+type LoanDocumentRepository interface {
+	CreateDocument(ctx context.Context, doc LoanDocument) (primitive.ObjectID, error)
+	// GetDocumentsByLoan lists the loan's documents without their content.
+	GetDocumentsByLoan(ctx context.Context, loanID primitive.ObjectID) ([]LoanDocument, error)
+	GetDocumentByID(ctx context.Context, id primitive.ObjectID) (LoanDocument, error)
+	// GetLatestDocument returns the newest document of a kind for the loan, without its content.
+	GetLatestDocument(ctx context.Context, loanID primitive.ObjectID, kind string) (LoanDocument, error)
+	StatementExists(ctx context.Context, loanID primitive.ObjectID, periodFrom time.Time) (bool, error)
+}
+
+type DocumentUsecase interface {
+	GetDocuments(ctx context.Context, loanID, userID primitive.ObjectID, isAdmin bool) ([]LoanDocument, error)
+	// GetDocument returns the file after checking it still matches its recorded checksum.
+	GetDocument(ctx context.Context, loanID, docID, userID primitive.ObjectID, isAdmin bool) (LoanDocument, error)
+	GenerateStatement(ctx context.Context, loanID, userID primitive.ObjectID, isAdmin bool, from, to time.Time) (LoanDocument, error)
+	RegenerateAgreement(ctx context.Context, loanID, adminID primitive.ObjectID) (LoanDocument, error)
+	// GenerateMonthlyStatements issues last month's statement for every active term loan that lacks one.
+	GenerateMonthlyStatements(ctx context.Context) error
+}
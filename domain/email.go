@@ -0,0 +1,74 @@
+package domain
+
+import (
+	"context"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// EmailMessage is an email in the outbox. Requests only queue messages; a background worker sends
+// them and retries failures with backoff until the attempts run out and the message is "failed".
+type EmailMessage struct {
+	ID      primitive.ObjectID `bson:"_id,omitempty" json:"id,omitempty"`
+	To      string             `bson:"to" json:"to"`
+	Kind    string             `bson:"kind" json:"kind"` // e.g. "email_verification" or "password_reset"
+	Locale  string             `bson:"locale,omitempty" json:"locale,omitempty"`
+	Subject string             `bson:"subject" json:"subject"`
+	// The text and HTML parts hold tokens and codes, so they are never shown to admins
+	Body string `bson:"body" json:"-"`
+	HTML string `bson:"html,omitempty" json:"-"`
+
+	Status        string             `bson:"status" json:"status"` // "queued", "retrying", "sent" or "failed"
+	Attempts      int                `bson:"attempts" json:"attempts"`
+	NextAttemptAt time.Time          `bson:"next_attempt_at,omitempty" json:"next_attempt_at,omitempty"`
+	LastError     string             `bson:"last_error,omitempty" json:"last_error,omitempty"`
+	SentAt        time.Time          `bson:"sent_at,omitempty" json:"sent_at,omitempty"`
+	ResentBy      primitive.ObjectID `bson:"resent_by,omitempty" json:"resent_by,omitempty"`
+	CreatedAt     time.Time          `bson:"created_at" json:"created_at"`
+	UpdatedAt     time.Time          `bson:"updated_at" json:"updated_at"`
+}
+
+type EmailFilter struct {
+	To     string
+	Kind   string
+	Status string
+}
+
+// EmailTemplate is an email kind and the languages it is written in.
+type EmailTemplate struct {
+	Kind    string   `json:"kind"`
+	Locales []string `json:"locales"`
+}
+
+// EmailPreview is a template rendered with sample data.
+type EmailPreview struct {
+	Kind    string `json:"kind"`
+	Locale  string `json:"locale"`
+	Subject string `json:"subject"`
+	Text    string `json:"text"`
+	HTML    string `json:"html"`
+}
+
+type EmailRepository interface {
+	QueueEmail(ctx context.Context, message EmailMessage) error
+	GetEmailByID(ctx context.Context, id primitive.ObjectID) (EmailMessage, error)
+	FindEmails(ctx context.Context, filter EmailFilter) ([]EmailMessage, error)
+	// GetDueEmails returns queued and retrying messages whose next attempt is due, oldest first.
+	GetDueEmails(ctx context.Context, now time.Time, limit int) ([]EmailMessage, error)
+	// ClaimEmail leases a due message to this worker until the given time. It fails if another
+	// worker claimed it first.
+	ClaimEmail(ctx context.Context, message EmailMessage, until time.Time) error
+	UpdateEmail(ctx context.Context, message EmailMessage) error
+}
+
+type EmailUsecase interface {
+	// SendQueuedEmails sends the messages that are due.
+	SendQueuedEmails(ctx context.Context) error
+	GetEmails(ctx context.Context, filter EmailFilter) ([]EmailMessage, error)
+	// ResendEmail queues a failed message for another round of attempts.
+	ResendEmail(ctx context.Context, id, adminID primitive.ObjectID) (EmailMessage, error)
+	GetTemplates(ctx context.Context) []EmailTemplate
+	// PreviewTemplate renders an email kind in a language with sample data.
+	PreviewTemplate(ctx context.Context, kind, locale string) (EmailPreview, error)
+}
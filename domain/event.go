@@ -0,0 +1,124 @@
+package domain
+
+import (
+	"context"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Domain event types. They are written to the outbox in the same transaction as the change they
+// describe and delivered to subscribers on the event bus afterwards.
+const (
+	EventUserRegistered    = "user.registered"
+	EventLoanApplied       = "loan.applied"
+	EventLoanApproved      = "loan.approved"
+	EventLoanRejected      = "loan.rejected"
+	EventLoanEdited        = "loan.edited"
+	EventLoanCancelled     = "loan.cancelled"
+	EventLoanExpired       = "loan.expired"
+	EventLoanDisbursed     = "loan.disbursed"
+	EventLoanClosed        = "loan.closed"
+	EventRepaymentReceived = "repayment.received"
+)
+
+// Audit event types record who did what for the system log. They go through the outbox like the
+// domain events but are not offered to webhook subscriptions.
+const (
+	EventUserEmailVerified          = "user.email_verified"
+	EventUserLoggedIn               = "user.logged_in"
+	EventUserTokenRefreshed         = "user.token_refreshed"
+	EventUserProfileViewed          = "user.profile_viewed"
+	EventUserPasswordResetRequested = "user.password_reset_requested"
+	EventUserPasswordReset          = "user.password_reset"
+	EventUsersListed                = "users.listed"
+	EventUserDeleted                = "user.deleted"
+	EventUserRoleChanged            = "user.role_changed"
+	EventKYCSubmitted               = "kyc.submitted"
+	EventKYCReviewed                = "kyc.reviewed"
+	EventProductCreated             = "product.created"
+	EventProductUpdated             = "product.updated"
+	EventGuarantorInvited           = "guarantee.invited"
+	EventGuaranteeAnswered          = "guarantee.answered"
+	EventCreditLineDrawn            = "credit_line.drawn"
+	EventCreditLineRepaid           = "credit_line.repaid"
+	EventCreditLineLimitChanged     = "credit_line.limit_changed"
+	EventCreditLineStatusChanged    = "credit_line.status_changed"
+	EventLoanViewed                 = "loan.viewed"
+	EventLoansListed                = "loans.listed"
+	EventLoanDeleted                = "loan.deleted"
+	EventLoanAssigned               = "loan.assigned"
+	EventBankStatementImported      = "bank_statement.imported"
+	EventBankTransactionResolved    = "bank_transaction.resolved"
+	EventDataExported               = "data.exported"
+	EventWebhookSubscriptionCreated = "webhook_subscription.created"
+	EventWebhookSubscriptionUpdated = "webhook_subscription.updated"
+	EventWebhookSubscriptionDeleted = "webhook_subscription.deleted"
+	EventWebhookDeliveryReplayed    = "webhook_delivery.replayed"
+	EventEmailResent                = "email.resent"
+)
+
+// EventTypes lists every domain event type that is published; webhooks can subscribe to these.
+var EventTypes = []string{
+	EventUserRegistered, EventLoanApplied, EventLoanEdited, EventLoanApproved, EventLoanRejected, EventLoanCancelled,
+	EventLoanExpired, EventLoanDisbursed, EventLoanClosed, EventRepaymentReceived,
+}
+
+// Event is a domain event together with its delivery state in the outbox.
+type Event struct {
+	ID            primitive.ObjectID     `bson:"_id,omitempty" json:"id,omitempty"`
+	Type          string                 `bson:"type" json:"type"`
+	AggregateType string                 `bson:"aggregate_type" json:"aggregate_type"` // "loan" or "user"
+	AggregateID   primitive.ObjectID     `bson:"aggregate_id" json:"aggregate_id"`
+	ActorID       primitive.ObjectID     `bson:"actor_id,omitempty" json:"actor_id,omitempty"`
+	Data          map[string]interface{} `bson:"data,omitempty" json:"data,omitempty"`
+	OccurredAt    time.Time              `bson:"occurred_at" json:"occurred_at"`
+
+	Status        string    `bson:"status" json:"status"`                       // "pending", "dispatched" or "failed"
+	Handled       []string  `bson:"handled,omitempty" json:"handled,omitempty"` // subscribers that have processed the event
+	Attempts      int       `bson:"attempts" json:"attempts"`
+	NextAttemptAt time.Time `bson:"next_attempt_at,omitempty" json:"next_attempt_at,omitempty"`
+	LastError     string    `bson:"last_error,omitempty" json:"last_error,omitempty"`
+	DispatchedAt  time.Time `bson:"dispatched_at,omitempty" json:"dispatched_at,omitempty"`
+}
+
+type EventFilter struct {
+	Type   string
+	Status string
+}
+
+// EventHandler processes one event. Events are delivered at least once, so handlers must
+// tolerate seeing the same event again.
+type EventHandler func(ctx context.Context, event Event) error
+
+type OutboxRepository interface {
+	AddEvents(ctx context.Context, events ...Event) error
+	GetEventByID(ctx context.Context, id primitive.ObjectID) (Event, error)
+	FindEvents(ctx context.Context, filter EventFilter) ([]Event, error)
+	// GetDueEvents returns pending events whose next attempt is due, oldest first.
+	GetDueEvents(ctx context.Context, now time.Time, limit int) ([]Event, error)
+	// ClaimEvent leases a due event to this dispatcher until the given time. It fails if another
+	// dispatcher claimed the event first.
+	ClaimEvent(ctx context.Context, event Event, until time.Time) error
+	// MarkHandled records that a subscriber has processed the event.
+	MarkHandled(ctx context.Context, id primitive.ObjectID, subscriber string) error
+	UpdateEventStatus(ctx context.Context, event Event) error
+}
+
+// Transactor runs a group of writes as one unit.
+type Transactor interface {
+	// WithTransaction commits fn's writes together or not at all. Calls made inside fn with the
+	// context it receives join the same transaction.
+	WithTransaction(ctx context.Context, fn func(ctx context.Context) error) error
+}
+
+type EventBus interface {
+	// Subscribe registers a handler under a unique name for the given event types, or for every
+	// event when none are given. Subscribers are registered at startup.
+	Subscribe(name string, handler EventHandler, types ...string)
+	// Dispatch delivers due outbox events to their subscribers, retrying failures with backoff.
+	Dispatch(ctx context.Context) error
+	GetEvents(ctx context.Context, filter EventFilter) ([]Event, error)
+	// RetryEvent queues a failed event for delivery to the subscribers that have not processed it.
+	RetryEvent(ctx context.Context, id primitive.ObjectID) (Event, error)
+}
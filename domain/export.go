@@ -0,0 +1,22 @@
+package domain
+
+import (
+	"context"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// RowWriter receives an export one row at a time, so nothing has to hold the whole
+// dataset. Values are strings, numbers, bools or time.Time.
+type RowWriter interface {
+	WriteRow(values ...interface{}) error
+	Close() error
+}
+
+type ExportUsecase interface {
+	ExportLoans(ctx context.Context, adminID primitive.ObjectID, filter LoanFilter, w RowWriter) error
+	ExportRepayments(ctx context.Context, adminID primitive.ObjectID, filter RepaymentFilter, w RowWriter) error
+	ExportUsers(ctx context.Context, adminID primitive.ObjectID, filter UserFilter, w RowWriter) error
+	ExportLogs(ctx context.Context, adminID primitive.ObjectID, filter LogFilter, w RowWriter) error
+	ExportAcceptances(ctx context.Context, adminID primitive.ObjectID, filter AcceptanceFilter, w RowWriter) error
+}
@@ -0,0 +1,55 @@
+package domain
+
+import (
+	"context"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Guarantee is a guarantor's commitment to cover part of a borrower's loan.
+type Guarantee struct {
+	ID              primitive.ObjectID `bson:"_id,omitempty" json:"id,omitempty"`
+	LoanID          primitive.ObjectID `bson:"loan_id" json:"loan_id"`
+	BorrowerID      primitive.ObjectID `bson:"borrower_id" json:"borrower_id"`
+	GuarantorID     primitive.ObjectID `bson:"guarantor_id" json:"guarantor_id"`
+	GuarantorEmail  string             `bson:"guarantor_email" json:"guarantor_email"`
+	LiabilityShare  float64            `bson:"liability_share" json:"liability_share"` // percentage of the loan amount
+	LiabilityAmount float64            `bson:"liability_amount" json:"liability_amount"`
+	Status          string             `bson:"status" json:"status"` // "pending", "accepted", "declined" or "released"
+	InvitedAt       time.Time          `bson:"invited_at" json:"invited_at"`
+	RespondedAt     time.Time          `bson:"responded_at,omitempty" json:"responded_at,omitempty"`
+}
+
+// GuarantorInvite is supplied by the borrower when applying for a loan.
+type GuarantorInvite struct {
+	Email          string  `json:"email"`
+	LiabilityShare float64 `json:"liability_share"`
+}
+
+// GuarantorExposure summarises what a user currently stands guarantor for.
+type GuarantorExposure struct {
+	ActiveGuarantees int     `json:"active_guarantees"`
+	TotalLiability   float64 `json:"total_liability"`
+}
+
+type GuaranteeRepository interface {
+	CreateGuarantee(ctx context.Context, guarantee Guarantee) (primitive.ObjectID, error)
+	GetGuaranteeByID(ctx context.Context, id primitive.ObjectID) (Guarantee, error)
+	GetGuaranteesByLoan(ctx context.Context, loanID primitive.ObjectID) ([]Guarantee, error)
+	GetGuaranteesByGuarantor(ctx context.Context, guarantorID primitive.ObjectID) ([]Guarantee, error)
+	// UpdateGuaranteeStatus records the guarantor's answer, failing unless the guarantee is still pending.
+	UpdateGuaranteeStatus(ctx context.Context, id primitive.ObjectID, status string) error
+	ReleaseLoanGuarantees(ctx context.Context, loanID primitive.ObjectID) error
+	// ResetLoanGuarantees recomputes liability amounts for a new loan amount and asks guarantors to confirm again.
+	ResetLoanGuarantees(ctx context.Context, loanID primitive.ObjectID, amount float64) error
+}
+
+type GuaranteeUsecase interface {
+	GetMyGuarantees(ctx context.Context, guarantorID primitive.ObjectID) ([]Guarantee, error)
+	// GetLoanGuarantees lists a loan's guarantees to an admin, the borrower or one of its guarantors.
+	GetLoanGuarantees(ctx context.Context, loanID, userID primitive.ObjectID, isAdmin bool) ([]Guarantee, error)
+	InviteGuarantor(ctx context.Context, loanID, borrowerID primitive.ObjectID, invite GuarantorInvite) (primitive.ObjectID, error)
+	AcceptGuarantee(ctx context.Context, id, guarantorID primitive.ObjectID) error
+	DeclineGuarantee(ctx context.Context, id, guarantorID primitive.ObjectID) error
+}
@@ -0,0 +1,50 @@
+package domain
+
+import (
+	"context"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// KYCProfile holds the identity data collected from a borrower.
+// Status is one of "not_started", "submitted", "verified" or "rejected".
+type KYCProfile struct {
+	LegalName       string             `bson:"legal_name" json:"legal_name"`
+	DateOfBirth     string             `bson:"date_of_birth" json:"date_of_birth"` // YYYY-MM-DD
+	NationalID      string             `bson:"national_id" json:"national_id"`
+	Address         Address            `bson:"address" json:"address"`
+	Employment      Employment         `bson:"employment" json:"employment"`
+	MonthlyIncome   float64            `bson:"monthly_income" json:"monthly_income"`
+	Status          string             `bson:"status" json:"status"`
+	RejectionReason string             `bson:"rejection_reason,omitempty" json:"rejection_reason,omitempty"`
+	SubmittedAt     time.Time          `bson:"submitted_at,omitempty" json:"submitted_at,omitempty"`
+	ReviewedAt      time.Time          `bson:"reviewed_at,omitempty" json:"reviewed_at,omitempty"`
+	ReviewedBy      primitive.ObjectID `bson:"reviewed_by,omitempty" json:"reviewed_by,omitempty"`
+}
+
+type Address struct {
+	Street     string `bson:"street" json:"street"`
+	City       string `bson:"city" json:"city"`
+	Region     string `bson:"region,omitempty" json:"region,omitempty"`
+	PostalCode string `bson:"postal_code,omitempty" json:"postal_code,omitempty"`
+	Country    string `bson:"country" json:"country"`
+}
+
+type Employment struct {
+	Status   string `bson:"status" json:"status"` // e.g., "employed", "self_employed", "unemployed"
+	Employer string `bson:"employer,omitempty" json:"employer,omitempty"`
+	JobTitle string `bson:"job_title,omitempty" json:"job_title,omitempty"`
+}
+
+type KYCReview struct {
+	Status string `json:"status"` // "verified" or "rejected"
+	Reason string `json:"reason"`
+}
+
+type KYCUsecase interface {
+	GetKYC(ctx context.Context, userID primitive.ObjectID) (KYCProfile, error)
+	SubmitKYC(ctx context.Context, userID primitive.ObjectID, profile KYCProfile) error
+	GetReviewQueue(ctx context.Context, status string) ([]ResponseUser, error)
+	ReviewKYC(ctx context.Context, userID, reviewerID primitive.ObjectID, review KYCReview) error
+}
@@ -10,29 +10,134 @@ import (
 type Loan struct {
 	ID          primitive.ObjectID `bson:"_id,omitempty" json:"id,omitempty"`
 	UserID      primitive.ObjectID `bson:"user_id,omitempty" json:"user_id,omitempty"`
+	ProductCode string             `bson:"product_code,omitempty" json:"product,omitempty"`
+	Type        string             `bson:"type,omitempty" json:"type,omitempty"` // "term" or "credit_line"; for credit lines Amount is the requested limit
 	Description string             `json:"description"`
 	Amount      float64            `json:"amount"`
-	Status      string             `json:"status"`
+	Status      string             `json:"status"` // "pending", "approved", "rejected", "expired", "cancelled" or "closed"
 	CreatedAt   time.Time          `json:"created_at"`
 	UpdatedAt   time.Time          `json:"updated_at"`
+
+	// InterestRate is the annual percentage taken from the product; Tenor is the
+	// number of installments of the given Frequency.
+	InterestRate float64       `bson:"interest_rate,omitempty" json:"interest_rate,omitempty"`
+	Tenor        int           `bson:"tenor,omitempty" json:"tenor,omitempty"`
+	Frequency    string        `bson:"frequency,omitempty" json:"frequency,omitempty"` // "weekly", "biweekly" or "monthly"
+	Schedule     []Installment `bson:"schedule,omitempty" json:"schedule,omitempty"`
+
+	AmountRepaid       float64 `bson:"amount_repaid" json:"amount_repaid"`
+	OutstandingBalance float64 `bson:"outstanding_balance" json:"outstanding_balance"` // every installment still owed, future interest included
+	// OutstandingPrincipal is the part of the amount lent that has not been repaid yet.
+	OutstandingPrincipal float64 `bson:"outstanding_principal" json:"outstanding_principal"`
+	// NetDisbursement is what the borrower actually receives: the amount less the processing fee
+	// and, for a refinance, the payoff of the old loan.
+	NetDisbursement float64 `bson:"net_disbursement,omitempty" json:"net_disbursement,omitempty"`
+	// ProcessingFee is the product's fee on the amount, deducted at disbursement.
+	ProcessingFee float64 `bson:"processing_fee,omitempty" json:"processing_fee,omitempty"`
+	// RefinancePayoff is the part of the amount used to settle the loan being refinanced.
+	RefinancePayoff    float64            `bson:"refinance_payoff,omitempty" json:"refinance_payoff,omitempty"`
+	RefinancesLoanID   primitive.ObjectID `bson:"refinances_loan_id,omitempty" json:"refinances_loan_id,omitempty"`
+	RefinancedByLoanID primitive.ObjectID `bson:"refinanced_by_loan_id,omitempty" json:"refinanced_by_loan_id,omitempty"`
+	ClosedReason       string             `bson:"closed_reason,omitempty" json:"closed_reason,omitempty"` // "repaid" or "refinanced"
+	ApprovedAt         time.Time          `bson:"approved_at,omitempty" json:"approved_at,omitempty"`
+	// DisbursedAt is set once the borrower has accepted the agreement and the funds were released.
+	DisbursedAt time.Time `bson:"disbursed_at,omitempty" json:"disbursed_at,omitempty"`
+	ClosedAt    time.Time `bson:"closed_at,omitempty" json:"closed_at,omitempty"`
+
+	// AssignedOfficerID is the admin reviewing the application. Stages records
+	// how long the application spent in each review stage.
+	AssignedOfficerID primitive.ObjectID `bson:"assigned_officer_id,omitempty" json:"assigned_officer_id,omitempty"`
+	AssignedAt        time.Time          `bson:"assigned_at,omitempty" json:"assigned_at,omitempty"`
+	Stage             string             `bson:"stage,omitempty" json:"stage,omitempty"` // "unassigned", "assigned", "in_review" or "decided"
+	Stages            []StageInterval    `bson:"stages,omitempty" json:"stages,omitempty"`
+
+	// Guarantors is only read from the application request; the invitations
+	// themselves are stored as Guarantee documents.
+	Guarantors []GuarantorInvite `bson:"-" json:"guarantors,omitempty"`
+}
+
+// Installment is one entry of a loan's repayment schedule.
+type Installment struct {
+	Number     int       `bson:"number" json:"number"`
+	DueDate    time.Time `bson:"due_date" json:"due_date"`
+	Amount     float64   `bson:"amount" json:"amount"`
+	Principal  float64   `bson:"principal" json:"principal"`
+	Interest   float64   `bson:"interest" json:"interest"`
+	Balance    float64   `bson:"balance" json:"balance"` // principal left after this installment
+	PaidAmount float64   `bson:"paid_amount" json:"paid_amount"`
+	Status     string    `bson:"status" json:"status"` // "due", "partial" or "paid"
+}
+
+// LoanEdit carries the fields a borrower may change while the application is pending.
+type LoanEdit struct {
+	Amount      *float64 `json:"amount,omitempty"`
+	Description *string  `json:"description,omitempty"`
+	Tenor       *int     `json:"tenor,omitempty"`
+	Frequency   *string  `json:"frequency,omitempty"`
 }
+
 type LoanFilter struct {
-	Status string
-	Order  string
+	Status            string
+	Order             string
+	UserID            primitive.ObjectID
+	RefinancesLoanID  primitive.ObjectID
+	AssignedOfficerID primitive.ObjectID
+	ProductCode       string
+	From              time.Time // applications made on or after
+	To                time.Time // applications made before
+}
+
+// LoanStatusError is returned when a loan is no longer in the status a change was made from,
+// because someone else changed it in the meantime.
+type LoanStatusError struct {
+	Expected string
+}
+
+func (e LoanStatusError) Error() string {
+	return "loan is no longer " + e.Expected
 }
 
 type LoanRepository interface {
 	CreateLoan(ctx context.Context, loan Loan) (primitive.ObjectID, error)
 	GetLoanByID(ctx context.Context, id primitive.ObjectID) (Loan, error)
 	GetAllLoans(ctx context.Context) ([]Loan, error)
+	FindLoans(ctx context.Context, filter LoanFilter) ([]Loan, error)
+	// StreamLoans calls fn for each matching loan without loading them all into memory.
+	StreamLoans(ctx context.Context, filter LoanFilter, fn func(Loan) error) error
 	UpdateLoanStatus(ctx context.Context, id primitive.ObjectID, status string) error
+	// TransitionLoanStatus changes the status only if the loan is still in the from state.
+	TransitionLoanStatus(ctx context.Context, id primitive.ObjectID, from, to string) error
+	// EditPendingLoan saves the fields a borrower may edit, only if the loan is still pending.
+	EditPendingLoan(ctx context.Context, loan Loan) error
+	// UpdateLoan replaces the loan only if it is still in the from state, so a decision cannot
+	// undo a change of status made in the meantime.
+	UpdateLoan(ctx context.Context, loan Loan, from string) error
+	// ApplyRepayment reduces the outstanding balance of an approved, disbursed loan and returns the updated loan.
+	ApplyRepayment(ctx context.Context, id primitive.ObjectID, amount float64) (Loan, error)
+	CloseLoan(ctx context.Context, id primitive.ObjectID, reason string, refinancedBy primitive.ObjectID) error
+	// UpdateSchedule saves the principal left to repay after a repayment and the schedule, if the loan has one.
+	UpdateSchedule(ctx context.Context, id primitive.ObjectID, schedule []Installment, outstandingPrincipal float64) error
+	// MarkDisbursed records the disbursement of an approved loan, failing if it was already disbursed.
+	MarkDisbursed(ctx context.Context, id primitive.ObjectID, at time.Time, netDisbursement, refinancePayoff float64) error
+	// UpdateAssignment saves the loan's assigned officer and review stages.
+	UpdateAssignment(ctx context.Context, loan Loan) error
+	OfficerWorkloads(ctx context.Context) ([]OfficerWorkload, error)
+	StageTimes(ctx context.Context, byOfficer bool) ([]StageTime, error)
 	DeleteLoan(ctx context.Context, id primitive.ObjectID) error
 }
 
 type LoanUsecase interface {
 	ApplyForLoan(ctx context.Context, loan Loan) (primitive.ObjectID, error)
-	ViewLoanStatus(ctx context.Context, id primitive.ObjectID) (Loan, error)
-	ViewAllLoans(ctx context.Context) ([]Loan, error)
-	ApproveOrRejectLoan(ctx context.Context, id primitive.ObjectID, status string) error
-	DeleteLoan(ctx context.Context, id primitive.ObjectID) error
+	ViewLoanStatus(ctx context.Context, id, viewerID primitive.ObjectID) (Loan, error)
+	ViewAllLoans(ctx context.Context, adminID primitive.ObjectID, filter LoanFilter) ([]Loan, error)
+	ApproveOrRejectLoan(ctx context.Context, id, adminID primitive.ObjectID, status string) error
+	// DisburseLoan releases an approved loan once its current agreement has been accepted.
+	DisburseLoan(ctx context.Context, id, adminID primitive.ObjectID) error
+	DeleteLoan(ctx context.Context, id, adminID primitive.ObjectID) error
+	RefinanceLoan(ctx context.Context, id primitive.ObjectID, loan Loan) (primitive.ObjectID, error)
+	EditLoan(ctx context.Context, id, userID primitive.ObjectID, edit LoanEdit) (Loan, error)
+	CancelLoan(ctx context.Context, id, userID primitive.ObjectID, reason string) error
+	GetLoanHistory(ctx context.Context, id, userID primitive.ObjectID, isAdmin bool) ([]LoanEvent, error)
+	ExpireStaleApplications(ctx context.Context) error
+	EscalateApplicationsNearSLA(ctx context.Context) error
 }
@@ -0,0 +1,31 @@
+package domain
+
+import (
+	"context"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// LoanEvent is an append-only entry in a loan's history. ActorID is empty for
+// changes made by scheduled jobs.
+type LoanEvent struct {
+	ID        primitive.ObjectID `bson:"_id,omitempty" json:"id,omitempty"`
+	LoanID    primitive.ObjectID `bson:"loan_id" json:"loan_id"`
+	Type      string             `bson:"type" json:"type"` // e.g., "applied", "edited", "approved", "payment", "closed"
+	ActorID   primitive.ObjectID `bson:"actor_id,omitempty" json:"actor_id,omitempty"`
+	Changes   []FieldChange      `bson:"changes,omitempty" json:"changes,omitempty"`
+	Note      string             `bson:"note,omitempty" json:"note,omitempty"`
+	Timestamp time.Time          `bson:"timestamp" json:"timestamp"`
+}
+
+type FieldChange struct {
+	Field    string      `bson:"field" json:"field"`
+	OldValue interface{} `bson:"old_value" json:"old_value"`
+	NewValue interface{} `bson:"new_value" json:"new_value"`
+}
+
+type LoanHistoryRepository interface {
+	AppendEvent(ctx context.Context, event LoanEvent) error
+	GetEventsByLoan(ctx context.Context, loanID primitive.ObjectID) ([]LoanEvent, error)
+}
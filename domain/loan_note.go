@@ -0,0 +1,49 @@
+package domain
+
+import (
+	"context"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// LoanNote is a message attached to a loan. Internal notes are only ever shown to admins;
+// comments are shared with the borrower who owns the loan.
+type LoanNote struct {
+	ID         primitive.ObjectID   `bson:"_id,omitempty" json:"id,omitempty"`
+	LoanID     primitive.ObjectID   `bson:"loan_id" json:"loan_id"`
+	ParentID   primitive.ObjectID   `bson:"parent_id,omitempty" json:"parent_id,omitempty"`
+	AuthorID   primitive.ObjectID   `bson:"author_id" json:"author_id"`
+	Visibility string               `bson:"visibility" json:"visibility"` // "internal" or "borrower"
+	Body       string               `bson:"body" json:"body"`
+	Mentions   []primitive.ObjectID `bson:"mentions,omitempty" json:"mentions,omitempty"`
+	Edits      []NoteRevision       `bson:"edits,omitempty" json:"edits,omitempty"`
+	CreatedAt  time.Time            `bson:"created_at" json:"created_at"`
+	UpdatedAt  time.Time            `bson:"updated_at" json:"updated_at"`
+}
+
+// NoteRevision keeps the text a note had before an edit.
+type NoteRevision struct {
+	Body     string    `bson:"body" json:"body"`
+	EditedAt time.Time `bson:"edited_at" json:"edited_at"`
+}
+
+type NoteRequest struct {
+	Body     string `json:"body"`
+	ParentID string `json:"parent_id,omitempty"`
+}
+
+type LoanNoteRepository interface {
+	CreateNote(ctx context.Context, note LoanNote) (primitive.ObjectID, error)
+	GetNoteByID(ctx context.Context, id primitive.ObjectID) (LoanNote, error)
+	GetNotesByLoan(ctx context.Context, loanID primitive.ObjectID, visibility string) ([]LoanNote, error)
+	UpdateNoteBody(ctx context.Context, id primitive.ObjectID, body string, revision NoteRevision) error
+}
+
+type LoanNoteUsecase interface {
+	AddNote(ctx context.Context, loanID, authorID primitive.ObjectID, req NoteRequest) (primitive.ObjectID, error)
+	GetNotes(ctx context.Context, loanID primitive.ObjectID) ([]LoanNote, error)
+	AddComment(ctx context.Context, loanID, authorID primitive.ObjectID, isAdmin bool, req NoteRequest) (primitive.ObjectID, error)
+	GetComments(ctx context.Context, loanID, userID primitive.ObjectID, isAdmin bool) ([]LoanNote, error)
+	EditNote(ctx context.Context, loanID, noteID, authorID primitive.ObjectID, visibility, body string) (LoanNote, error)
+}
@@ -14,12 +14,21 @@ type Log struct {
 	Details   string             `json:"details"`
 }
 
+type LogFilter struct {
+	Type string
+	From time.Time
+	To   time.Time
+}
+
 type LogRepository interface {
 	CreateLog(ctx context.Context, log Log) error
-	GetAllLogs(ctx context.Context) ([]Log, error)
+	FindLogs(ctx context.Context, filter LogFilter) ([]Log, error)
+	StreamLogs(ctx context.Context, filter LogFilter, fn func(Log) error) error
 }
 
 type LogUsecase interface {
 	LogEvent(ctx context.Context, log Log) error
-	GetSystemLogs(ctx context.Context) ([]Log, error)
+	// RecordEvent logs a domain event; it is subscribed to the event bus.
+	RecordEvent(ctx context.Context, event Event) error
+	GetSystemLogs(ctx context.Context, filter LogFilter) ([]Log, error)
 }
@@ -0,0 +1,101 @@
+package domain
+
+import (
+	"context"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Mandate is a borrower's authorization to collect a loan's installments by direct debit. It is
+// "pending" until the bank confirms it, then "active" until revoked by the borrower, an admin or the bank.
+type Mandate struct {
+	ID                primitive.ObjectID `bson:"_id,omitempty" json:"id,omitempty"`
+	LoanID            primitive.ObjectID `bson:"loan_id" json:"loan_id"`
+	UserID            primitive.ObjectID `bson:"user_id" json:"user_id"`
+	AccountHolder     string             `bson:"account_holder" json:"account_holder"`
+	AccountMask       string             `bson:"account_mask" json:"account_mask"` // only the last digits of the account are kept
+	BankCode          string             `bson:"bank_code" json:"bank_code"`
+	Provider          string             `bson:"provider" json:"provider"`
+	ProviderReference string             `bson:"provider_reference" json:"provider_reference"`
+	Status            string             `bson:"status" json:"status"` // "pending", "active" or "revoked"
+	CreatedAt         time.Time          `bson:"created_at" json:"created_at"`
+	ActivatedAt       time.Time          `bson:"activated_at,omitempty" json:"activated_at,omitempty"`
+	RevokedAt         time.Time          `bson:"revoked_at,omitempty" json:"revoked_at,omitempty"`
+	RevokedBy         primitive.ObjectID `bson:"revoked_by,omitempty" json:"revoked_by,omitempty"` // empty when revoked by the bank or the scheduler
+	RevocationReason  string             `bson:"revocation_reason,omitempty" json:"revocation_reason,omitempty"`
+}
+
+// MandateRequest carries the account a borrower authorizes to be debited.
+type MandateRequest struct {
+	AccountHolder string `json:"account_holder" binding:"required"`
+	AccountNumber string `json:"account_number" binding:"required"`
+	BankCode      string `json:"bank_code" binding:"required"`
+}
+
+// DebitCollection is one installment being collected under a mandate. A failed attempt is
+// retried after the configured delays until it succeeds or the retries run out.
+type DebitCollection struct {
+	ID                primitive.ObjectID `bson:"_id,omitempty" json:"id,omitempty"`
+	MandateID         primitive.ObjectID `bson:"mandate_id" json:"mandate_id"`
+	LoanID            primitive.ObjectID `bson:"loan_id" json:"loan_id"`
+	UserID            primitive.ObjectID `bson:"user_id" json:"user_id"`
+	Installment       int                `bson:"installment" json:"installment"`
+	Amount            float64            `bson:"amount" json:"amount"`
+	Currency          string             `bson:"currency" json:"currency"`
+	Provider          string             `bson:"provider" json:"provider"`
+	ProviderReference string             `bson:"provider_reference,omitempty" json:"provider_reference,omitempty"` // of the attempt in flight
+	// Status is "scheduled", "submitted", "retrying", "succeeded", "failed" once retries are exhausted,
+	// "cancelled", or "unapplied" when the money arrived but could not be posted to the loan.
+	Status        string             `bson:"status" json:"status"`
+	Attempts      []DebitAttempt     `bson:"attempts" json:"attempts"`
+	NextAttemptAt time.Time          `bson:"next_attempt_at,omitempty" json:"next_attempt_at,omitempty"`
+	FailureCode   string             `bson:"failure_code,omitempty" json:"failure_code,omitempty"`
+	RepaymentID   primitive.ObjectID `bson:"repayment_id,omitempty" json:"repayment_id,omitempty"`
+	CreatedAt     time.Time          `bson:"created_at" json:"created_at"`
+	UpdatedAt     time.Time          `bson:"updated_at" json:"updated_at"`
+}
+
+type DebitAttempt struct {
+	Number            int       `bson:"number" json:"number"`
+	ProviderReference string    `bson:"provider_reference,omitempty" json:"provider_reference,omitempty"`
+	Amount            float64   `bson:"amount" json:"amount"`
+	SubmittedAt       time.Time `bson:"submitted_at" json:"submitted_at"`
+	Status            string    `bson:"status" json:"status"` // "submitted", "succeeded" or "failed"
+	FailureCode       string    `bson:"failure_code,omitempty" json:"failure_code,omitempty"`
+	CompletedAt       time.Time `bson:"completed_at,omitempty" json:"completed_at,omitempty"`
+}
+
+type MandateRepository interface {
+	CreateMandate(ctx context.Context, mandate Mandate) (primitive.ObjectID, error)
+	GetMandateByID(ctx context.Context, id primitive.ObjectID) (Mandate, error)
+	GetMandateByProviderReference(ctx context.Context, provider, reference string) (Mandate, error)
+	GetMandatesByLoan(ctx context.Context, loanID primitive.ObjectID) ([]Mandate, error)
+	GetActiveMandates(ctx context.Context) ([]Mandate, error)
+	// UpdateMandateStatus saves the mandate's status and outcome only if it is still in the from status.
+	UpdateMandateStatus(ctx context.Context, mandate Mandate, from string) error
+
+	CreateCollection(ctx context.Context, collection DebitCollection) (primitive.ObjectID, error)
+	GetCollectionByProviderReference(ctx context.Context, provider, reference string) (DebitCollection, error)
+	GetCollectionsByLoan(ctx context.Context, loanID primitive.ObjectID) ([]DebitCollection, error)
+	// GetCollectionsByStatus lists collections for admins, all of them when status is empty.
+	GetCollectionsByStatus(ctx context.Context, status string) ([]DebitCollection, error)
+	// GetDueCollections returns scheduled and retrying collections whose next attempt is due.
+	GetDueCollections(ctx context.Context, now time.Time) ([]DebitCollection, error)
+	// HasCollection reports whether the installment is already being, or has been, collected.
+	HasCollection(ctx context.Context, loanID primitive.ObjectID, installment int) (bool, error)
+	// UpdateCollection replaces the collection only if it is still in the from status.
+	UpdateCollection(ctx context.Context, collection DebitCollection, from string) error
+	// CancelCollections cancels a mandate's collections that have not been submitted yet.
+	CancelCollections(ctx context.Context, mandateID primitive.ObjectID, reason string) error
+}
+
+type MandateUsecase interface {
+	CreateMandate(ctx context.Context, loanID, userID primitive.ObjectID, req MandateRequest) (Mandate, error)
+	GetMandates(ctx context.Context, loanID, userID primitive.ObjectID, isAdmin bool) ([]Mandate, error)
+	RevokeMandate(ctx context.Context, loanID, mandateID, userID primitive.ObjectID, isAdmin bool, reason string) (Mandate, error)
+	GetCollections(ctx context.Context, loanID, userID primitive.ObjectID, isAdmin bool) ([]DebitCollection, error)
+	GetCollectionsByStatus(ctx context.Context, status string) ([]DebitCollection, error)
+	// RunCollections applies the bank's results, raises debits for installments falling due and retries failures.
+	RunCollections(ctx context.Context) error
+}
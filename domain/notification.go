@@ -0,0 +1,121 @@
+package domain
+
+import (
+	"context"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Notification kinds. Borrowers choose the channels of each kind separately.
+const (
+	NotificationPaymentReminder = "payment_reminder"
+	NotificationPaymentOverdue  = "payment_overdue"
+	NotificationPaymentReceipt  = "payment_receipt"
+	NotificationLoanStatus      = "loan_status"
+)
+
+var NotificationKinds = []string{
+	NotificationPaymentReminder, NotificationPaymentOverdue, NotificationPaymentReceipt, NotificationLoanStatus,
+}
+
+// Notification channels
+const (
+	ChannelInApp = "in_app"
+	ChannelEmail = "email"
+	ChannelSMS   = "sms"
+	ChannelPush  = "push"
+)
+
+var NotificationChannels = []string{ChannelInApp, ChannelEmail, ChannelSMS, ChannelPush}
+
+// DefaultNotificationChannels are used for the kinds a user has not chosen channels for. SMS and
+// push need a verified phone or a registered device, so they are opt-in.
+var DefaultNotificationChannels = []string{ChannelInApp, ChannelEmail}
+
+// Notification is a message to a borrower. Those sent in-app make up the borrower's inbox; all of
+// them are kept so the same reminder or alert is never sent twice.
+type Notification struct {
+	ID     primitive.ObjectID `bson:"_id,omitempty" json:"id,omitempty"`
+	UserID primitive.ObjectID `bson:"user_id" json:"user_id"`
+	Kind   string             `bson:"kind" json:"kind"`
+	LoanID primitive.ObjectID `bson:"loan_id,omitempty" json:"loan_id,omitempty"`
+	Title  string             `bson:"title" json:"title"`
+	Body   string             `bson:"body" json:"body"`
+	// Channels are the channels it was sent on: the borrower's preferences at the time and any fallbacks
+	Channels []string `bson:"channels" json:"channels"`
+	// Deliveries records how each channel other than in-app went, fallbacks included
+	Deliveries []NotificationDelivery `bson:"deliveries,omitempty" json:"deliveries,omitempty"`
+	// Key names what the notification is about, e.g. "reminder:<loan id>:3:24h" or "event:<event id>"
+	Key       string    `bson:"key" json:"-"`
+	Read      bool      `bson:"read" json:"read"`
+	ReadAt    time.Time `bson:"read_at,omitempty" json:"read_at,omitempty"`
+	CreatedAt time.Time `bson:"created_at" json:"created_at"`
+}
+
+// NotificationDelivery is the outcome of sending a notification on one channel. A channel that
+// failed names the channel that was tried instead.
+type NotificationDelivery struct {
+	Channel    string    `bson:"channel" json:"channel"`
+	Status     string    `bson:"status" json:"status"` // "queued" (email, sent by the outbox), "sent" or "failed"
+	Error      string    `bson:"error,omitempty" json:"error,omitempty"`
+	FallbackTo string    `bson:"fallback_to,omitempty" json:"fallback_to,omitempty"`
+	At         time.Time `bson:"at" json:"at"`
+}
+
+type NotificationFilter struct {
+	UserID  primitive.ObjectID
+	Channel string
+	Status  string // "read" or "unread"
+}
+
+// NotificationInbox is a page of a borrower's in-app notifications with their unread count.
+type NotificationInbox struct {
+	Unread        int64          `json:"unread"`
+	Notifications []Notification `json:"notifications"`
+}
+
+// NotificationPreferences are the channels a user receives each kind of notification on. Kinds
+// the user has not set use DefaultNotificationChannels.
+type NotificationPreferences struct {
+	UserID    primitive.ObjectID  `bson:"_id" json:"-"`
+	Channels  map[string][]string `bson:"channels" json:"channels"`
+	UpdatedAt time.Time           `bson:"updated_at,omitempty" json:"updated_at,omitempty"`
+}
+
+type NotificationPreferencesRequest struct {
+	Channels map[string][]string `json:"channels" binding:"required"`
+}
+
+type NotificationRepository interface {
+	// AddNotification stores a notification unless one with the same user and key exists, and
+	// reports whether it was added.
+	AddNotification(ctx context.Context, notification Notification) (bool, error)
+	FindNotifications(ctx context.Context, filter NotificationFilter) ([]Notification, error)
+	CountNotifications(ctx context.Context, filter NotificationFilter) (int64, error)
+	// SetDeliveries records the channels a notification ended up on and how each went.
+	SetDeliveries(ctx context.Context, id primitive.ObjectID, channels []string, deliveries []NotificationDelivery) error
+	// MarkRead marks one of the user's notifications as read.
+	MarkRead(ctx context.Context, id, userID primitive.ObjectID, at time.Time) error
+	// MarkAllRead marks all of the user's unread notifications as read and returns how many there were.
+	MarkAllRead(ctx context.Context, userID primitive.ObjectID, at time.Time) (int64, error)
+	// GetPreferences returns empty preferences for a user who has not set any.
+	GetPreferences(ctx context.Context, userID primitive.ObjectID) (NotificationPreferences, error)
+	SavePreferences(ctx context.Context, preferences NotificationPreferences) error
+}
+
+type NotificationUsecase interface {
+	// HandleEvent alerts borrowers to loan status changes and sends payment receipts; it is
+	// subscribed to the event bus.
+	HandleEvent(ctx context.Context, event Event) error
+	// SendPaymentReminders reminds borrowers of installments falling due and sends overdue notices.
+	SendPaymentReminders(ctx context.Context) error
+	// GetInbox lists the user's in-app notifications, optionally only the "read" or "unread" ones.
+	GetInbox(ctx context.Context, userID primitive.ObjectID, status string) (NotificationInbox, error)
+	MarkRead(ctx context.Context, id, userID primitive.ObjectID) error
+	MarkAllRead(ctx context.Context, userID primitive.ObjectID) (int64, error)
+	// GetPreferences returns the channels of every kind, defaults included.
+	GetPreferences(ctx context.Context, userID primitive.ObjectID) (NotificationPreferences, error)
+	// UpdatePreferences changes the channels of the given kinds and leaves the others as they are.
+	UpdatePreferences(ctx context.Context, userID primitive.ObjectID, channels map[string][]string) (NotificationPreferences, error)
+}
@@ -0,0 +1,58 @@
+package domain
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Payment is an online repayment started by the borrower through a payment provider. Once the
+// provider confirms it, a Repayment is posted against the loan and linked through RepaymentID.
+type Payment struct {
+	ID                primitive.ObjectID `bson:"_id,omitempty" json:"id,omitempty"`
+	LoanID            primitive.ObjectID `bson:"loan_id" json:"loan_id"`
+	UserID            primitive.ObjectID `bson:"user_id" json:"user_id"`
+	Installment       int                `bson:"installment,omitempty" json:"installment,omitempty"` // schedule number the payment is for
+	Amount            float64            `bson:"amount" json:"amount"`
+	Currency          string             `bson:"currency" json:"currency"`
+	Provider          string             `bson:"provider" json:"provider"`
+	ProviderReference string             `bson:"provider_reference" json:"provider_reference"`
+	CheckoutURL       string             `bson:"checkout_url" json:"checkout_url"`
+	// Status is "pending", "succeeded", "failed", or "unapplied" when the money arrived
+	// but could not be posted to the loan, e.g. because it was already repaid.
+	Status        string             `bson:"status" json:"status"`
+	FailureReason string             `bson:"failure_reason,omitempty" json:"failure_reason,omitempty"`
+	RepaymentID   primitive.ObjectID `bson:"repayment_id,omitempty" json:"repayment_id,omitempty"`
+	CreatedAt     time.Time          `bson:"created_at" json:"created_at"`
+	ExpiresAt     time.Time          `bson:"expires_at" json:"expires_at"`
+	PaidAt        time.Time          `bson:"paid_at,omitempty" json:"paid_at,omitempty"`
+	UpdatedAt     time.Time          `bson:"updated_at" json:"updated_at"`
+}
+
+// PaymentRequest starts a checkout; with no amount the remainder of the installment is charged.
+type PaymentRequest struct {
+	Amount      float64 `json:"amount"`
+	Installment int     `json:"installment"`
+}
+
+type PaymentRepository interface {
+	CreatePayment(ctx context.Context, payment Payment) (primitive.ObjectID, error)
+	GetPaymentByID(ctx context.Context, id primitive.ObjectID) (Payment, error)
+	GetPaymentByProviderReference(ctx context.Context, provider, reference string) (Payment, error)
+	GetPaymentsByLoan(ctx context.Context, loanID primitive.ObjectID) ([]Payment, error)
+	// UpdatePaymentStatus saves the payment's status and outcome only if it is still in the from status.
+	UpdatePaymentStatus(ctx context.Context, payment Payment, from string) error
+}
+
+type PaymentUsecase interface {
+	CreateCheckout(ctx context.Context, loanID, userID primitive.ObjectID, req PaymentRequest) (Payment, error)
+	GetPayments(ctx context.Context, loanID, userID primitive.ObjectID, isAdmin bool) ([]Payment, error)
+	// HandleWebhook verifies and applies a provider callback; repeated deliveries are ignored.
+	HandleWebhook(ctx context.Context, provider string, header http.Header, body []byte) error
+	// SimulatorEnabled reports whether payments go through the simulator provider.
+	SimulatorEnabled() bool
+	// SimulatePayment completes a checkout when the simulator provider is in use.
+	SimulatePayment(ctx context.Context, providerReference, outcome string) (Payment, error)
+}
@@ -0,0 +1,37 @@
+package domain
+
+import (
+	"context"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// LoanProduct describes a lending product a borrower can apply for.
+type LoanProduct struct {
+	ID                 primitive.ObjectID `bson:"_id,omitempty" json:"id,omitempty"`
+	Code               string             `bson:"code" json:"code"`
+	Name               string             `bson:"name" json:"name"`
+	Description        string             `bson:"description" json:"description"`
+	InterestRate       float64            `bson:"interest_rate" json:"interest_rate"`   // annual percentage
+	ProcessingFee      float64            `bson:"processing_fee" json:"processing_fee"` // percentage of the principal deducted upfront
+	RequiredGuarantors int                `bson:"required_guarantors" json:"required_guarantors"`
+	RequiresKYC        bool               `bson:"requires_kyc" json:"requires_kyc"`
+	PendingSLADays     int                `bson:"pending_sla_days" json:"pending_sla_days"` // 0 uses the default SLA
+	CreatedAt          time.Time          `bson:"created_at" json:"created_at"`
+	UpdatedAt          time.Time          `bson:"updated_at" json:"updated_at"`
+}
+
+type ProductRepository interface {
+	CreateProduct(ctx context.Context, product LoanProduct) (primitive.ObjectID, error)
+	GetProductByCode(ctx context.Context, code string) (LoanProduct, error)
+	GetAllProducts(ctx context.Context) ([]LoanProduct, error)
+	UpdateProduct(ctx context.Context, code string, product LoanProduct) error
+}
+
+type ProductUsecase interface {
+	CreateProduct(ctx context.Context, adminID primitive.ObjectID, product LoanProduct) (primitive.ObjectID, error)
+	GetProduct(ctx context.Context, code string) (LoanProduct, error)
+	GetAllProducts(ctx context.Context) ([]LoanProduct, error)
+	UpdateProduct(ctx context.Context, adminID primitive.ObjectID, code string, product LoanProduct) error
+}
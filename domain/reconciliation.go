@@ -0,0 +1,87 @@
+package domain
+
+import (
+	"context"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// BankStatement is an uploaded statement file; its lines are stored as BankTransactions.
+type BankStatement struct {
+	ID         primitive.ObjectID `bson:"_id,omitempty" json:"id,omitempty"`
+	FileName   string             `bson:"file_name" json:"file_name"`
+	Format     string             `bson:"format" json:"format"`     // "csv", "mt940" or "camt053"
+	Checksum   string             `bson:"checksum" json:"checksum"` // SHA-256 of the file, to refuse re-uploads
+	ImportedBy primitive.ObjectID `bson:"imported_by" json:"imported_by"`
+	ImportedAt time.Time          `bson:"imported_at" json:"imported_at"`
+	Lines      int                `bson:"lines" json:"lines"`
+	Matched    int                `bson:"matched" json:"matched"`
+	Unmatched  int                `bson:"unmatched" json:"unmatched"`
+	Ignored    int                `bson:"ignored" json:"ignored"`
+	Duplicates int                `bson:"duplicates" json:"duplicates"` // lines already imported from an earlier statement
+}
+
+// BankTransaction is one statement line. Incoming payments that could not be matched with
+// confidence wait in the reconciliation queue with status "unmatched".
+type BankTransaction struct {
+	ID                  primitive.ObjectID `bson:"_id,omitempty" json:"id,omitempty"`
+	StatementID         primitive.ObjectID `bson:"statement_id" json:"statement_id"`
+	LineNumber          int                `bson:"line_number" json:"line_number"`
+	BookingDate         time.Time          `bson:"booking_date" json:"booking_date"`
+	Amount              float64            `bson:"amount" json:"amount"` // negative for money paid out
+	Currency            string             `bson:"currency,omitempty" json:"currency,omitempty"`
+	Reference           string             `bson:"reference,omitempty" json:"reference,omitempty"`
+	CounterpartyName    string             `bson:"counterparty_name,omitempty" json:"counterparty_name,omitempty"`
+	CounterpartyAccount string             `bson:"counterparty_account,omitempty" json:"counterparty_account,omitempty"`
+	BankReference       string             `bson:"bank_reference,omitempty" json:"bank_reference,omitempty"`
+	Fingerprint         string             `bson:"fingerprint" json:"-"`
+	Status              string             `bson:"status" json:"status"` // "matched", "unmatched", "ignored", or "matching" while being posted
+	Note                string             `bson:"note,omitempty" json:"note,omitempty"`
+	Candidates          []MatchCandidate   `bson:"candidates,omitempty" json:"candidates,omitempty"`
+	LoanID              primitive.ObjectID `bson:"loan_id,omitempty" json:"loan_id,omitempty"`
+	RepaymentID         primitive.ObjectID `bson:"repayment_id,omitempty" json:"repayment_id,omitempty"`
+	ResolvedBy          primitive.ObjectID `bson:"resolved_by,omitempty" json:"resolved_by,omitempty"` // empty when matched automatically
+	ResolvedAt          time.Time          `bson:"resolved_at,omitempty" json:"resolved_at,omitempty"`
+}
+
+// MatchCandidate is a loan a statement line may be paying, scored out of 100.
+type MatchCandidate struct {
+	LoanID  primitive.ObjectID `bson:"loan_id" json:"loan_id"`
+	Score   int                `bson:"score" json:"score"`
+	Reasons []string           `bson:"reasons" json:"reasons"`
+}
+
+type BankTransactionFilter struct {
+	StatementID primitive.ObjectID
+	Status      string
+}
+
+// ReconcileRequest resolves a queued line by matching it to a loan or ignoring it.
+type ReconcileRequest struct {
+	LoanID primitive.ObjectID `json:"loan_id"`
+	Note   string             `json:"note"`
+}
+
+type ReconciliationRepository interface {
+	CreateStatement(ctx context.Context, statement BankStatement) (primitive.ObjectID, error)
+	UpdateStatement(ctx context.Context, statement BankStatement) error
+	StatementExists(ctx context.Context, checksum string) (bool, error)
+	GetStatements(ctx context.Context) ([]BankStatement, error)
+	CreateTransactions(ctx context.Context, transactions []BankTransaction) error
+	// TransactionExists reports whether a line with the fingerprint was imported before.
+	TransactionExists(ctx context.Context, fingerprint string) (bool, error)
+	GetTransactionByID(ctx context.Context, id primitive.ObjectID) (BankTransaction, error)
+	FindTransactions(ctx context.Context, filter BankTransactionFilter) ([]BankTransaction, error)
+	// UpdateTransactionStatus saves the line's status and outcome only if it is still in the from status.
+	UpdateTransactionStatus(ctx context.Context, transaction BankTransaction, from string) error
+}
+
+type ReconciliationUsecase interface {
+	// ImportStatement parses a statement file, posts confidently matched payments and queues the rest.
+	ImportStatement(ctx context.Context, fileName, format string, data []byte, adminID primitive.ObjectID) (BankStatement, error)
+	GetStatements(ctx context.Context) ([]BankStatement, error)
+	GetTransactions(ctx context.Context, filter BankTransactionFilter) ([]BankTransaction, error)
+	MatchTransaction(ctx context.Context, id, adminID primitive.ObjectID, req ReconcileRequest) (BankTransaction, error)
+	IgnoreTransaction(ctx context.Context, id, adminID primitive.ObjectID, req ReconcileRequest) (BankTransaction, error)
+}
@@ -0,0 +1,42 @@
+package domain
+
+import (
+	"context"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type Repayment struct {
+	ID          primitive.ObjectID `bson:"_id,omitempty" json:"id,omitempty"`
+	LoanID      primitive.ObjectID `bson:"loan_id" json:"loan_id"`
+	UserID      primitive.ObjectID `bson:"user_id" json:"user_id"`
+	Amount      float64            `bson:"amount" json:"amount"`
+	Method      string             `bson:"method" json:"method"` // e.g., "manual", "refinance", "online"
+	Reference   string             `bson:"reference,omitempty" json:"reference,omitempty"`
+	Installment int                `bson:"installment,omitempty" json:"installment,omitempty"` // schedule number an online payment was made for
+	RecordedBy  primitive.ObjectID `bson:"recorded_by,omitempty" json:"recorded_by,omitempty"`
+	PaidAt      time.Time          `bson:"paid_at" json:"paid_at"`
+	CreatedAt   time.Time          `bson:"created_at" json:"created_at"`
+}
+
+type RepaymentFilter struct {
+	LoanID primitive.ObjectID
+	UserID primitive.ObjectID
+	Method string
+	From   time.Time // paid on or after
+	To     time.Time // paid before
+}
+
+type RepaymentRepository interface {
+	CreateRepayment(ctx context.Context, repayment Repayment) (primitive.ObjectID, error)
+	GetRepaymentsByLoan(ctx context.Context, loanID primitive.ObjectID) ([]Repayment, error)
+	FindRepayments(ctx context.Context, filter RepaymentFilter) ([]Repayment, error)
+	StreamRepayments(ctx context.Context, filter RepaymentFilter, fn func(Repayment) error) error
+}
+
+type RepaymentUsecase interface {
+	RecordRepayment(ctx context.Context, loanID, recordedBy primitive.ObjectID, repayment Repayment) (primitive.ObjectID, error)
+	GetLoanRepayments(ctx context.Context, loanID, userID primitive.ObjectID, isAdmin bool) ([]Repayment, error)
+	GetRepayments(ctx context.Context, filter RepaymentFilter) ([]Repayment, error)
+}
@@ -0,0 +1,107 @@
+package domain
+
+import (
+	"context"
+	"time"
+)
+
+// ReportFilter limits a report to a date range and chooses how its rows are grouped.
+// Each report documents which date the range applies to.
+type ReportFilter struct {
+	From           time.Time
+	To             time.Time // exclusive
+	GroupByProduct bool
+	GroupByMonth   bool
+	AsOf           time.Time // PAR and collections only; defaults to now
+}
+
+// PortfolioRow covers disbursed term loans, grouped by disbursement date.
+type PortfolioRow struct {
+	Product              string  `bson:"product,omitempty" json:"product,omitempty"`
+	Month                string  `bson:"month,omitempty" json:"month,omitempty"`
+	LoansDisbursed       int     `bson:"loans_disbursed" json:"loans_disbursed"`
+	TotalDisbursed       float64 `bson:"total_disbursed" json:"total_disbursed"`
+	AverageTicketSize    float64 `bson:"average_ticket_size" json:"average_ticket_size"`
+	ActiveLoans          int     `bson:"active_loans" json:"active_loans"`
+	OutstandingPrincipal float64 `bson:"outstanding_principal" json:"outstanding_principal"`
+	OutstandingBalance   float64 `bson:"outstanding_balance" json:"outstanding_balance"`
+}
+
+// ApprovalRow covers applications, grouped by application date. Rates are over decided applications.
+type ApprovalRow struct {
+	Product       string  `bson:"product,omitempty" json:"product,omitempty"`
+	Month         string  `bson:"month,omitempty" json:"month,omitempty"`
+	Applications  int     `bson:"applications" json:"applications"`
+	Approved      int     `bson:"approved" json:"approved"`
+	Rejected      int     `bson:"rejected" json:"rejected"`
+	Expired       int     `bson:"expired" json:"expired"`
+	Cancelled     int     `bson:"cancelled" json:"cancelled"`
+	Pending       int     `bson:"pending" json:"pending"`
+	ApprovalRate  float64 `bson:"-" json:"approval_rate"`
+	RejectionRate float64 `bson:"-" json:"rejection_rate"`
+}
+
+// PARRow is the portfolio at risk of disbursed, active term loans as of a date, grouped by disbursement date.
+// A loan is at risk from the day its oldest unpaid installment is more than 30 (or 90) days late.
+type PARRow struct {
+	Product              string  `bson:"product,omitempty" json:"product,omitempty"`
+	Month                string  `bson:"month,omitempty" json:"month,omitempty"`
+	ActiveLoans          int     `bson:"active_loans" json:"active_loans"`
+	OutstandingPrincipal float64 `bson:"outstanding_principal" json:"outstanding_principal"`
+	PAR30Principal       float64 `bson:"par30_principal" json:"par30_principal"`
+	PAR90Principal       float64 `bson:"par90_principal" json:"par90_principal"`
+	PAR30                float64 `bson:"-" json:"par30"`
+	PAR90                float64 `bson:"-" json:"par90"`
+}
+
+// CollectionsRow compares what fell due with what was collected against it, grouped by due date.
+type CollectionsRow struct {
+	Product         string  `bson:"product,omitempty" json:"product,omitempty"`
+	Month           string  `bson:"month,omitempty" json:"month,omitempty"`
+	Installments    int     `bson:"installments" json:"installments"`
+	AmountDue       float64 `bson:"amount_due" json:"amount_due"`
+	AmountCollected float64 `bson:"amount_collected" json:"amount_collected"`
+	CollectionsRate float64 `bson:"-" json:"collections_rate"`
+}
+
+// VintageCurve follows the loans disbursed in one month (and product) and how much of
+// their principal has been repaid by each month on book.
+type VintageCurve struct {
+	Cohort         string         `bson:"cohort" json:"cohort"`
+	Product        string         `bson:"product,omitempty" json:"product,omitempty"`
+	LoansDisbursed int            `bson:"loans_disbursed" json:"loans_disbursed"`
+	TotalDisbursed float64        `bson:"total_disbursed" json:"total_disbursed"`
+	Points         []VintagePoint `bson:"-" json:"points"`
+}
+
+type VintagePoint struct {
+	MonthsOnBook     int     `bson:"months_on_book" json:"months_on_book"`
+	Repaid           float64 `bson:"repaid" json:"repaid"`
+	CumulativeRepaid float64 `bson:"-" json:"cumulative_repaid"`
+	RepaidRate       float64 `bson:"-" json:"repaid_rate"` // cumulative share of the cohort's principal
+}
+
+// VintageRepayments is what a cohort repaid in one month on book.
+type VintageRepayments struct {
+	Cohort       string  `bson:"cohort"`
+	Product      string  `bson:"product,omitempty"`
+	MonthsOnBook int     `bson:"months_on_book"`
+	Repaid       float64 `bson:"repaid"`
+}
+
+type ReportRepository interface {
+	Portfolio(ctx context.Context, filter ReportFilter) ([]PortfolioRow, error)
+	Approvals(ctx context.Context, filter ReportFilter) ([]ApprovalRow, error)
+	PortfolioAtRisk(ctx context.Context, filter ReportFilter) ([]PARRow, error)
+	Collections(ctx context.Context, filter ReportFilter) ([]CollectionsRow, error)
+	VintageCohorts(ctx context.Context, filter ReportFilter) ([]VintageCurve, error)
+	VintageRepayments(ctx context.Context, filter ReportFilter) ([]VintageRepayments, error)
+}
+
+type ReportUsecase interface {
+	Portfolio(ctx context.Context, filter ReportFilter) ([]PortfolioRow, error)
+	Approvals(ctx context.Context, filter ReportFilter) ([]ApprovalRow, error)
+	PortfolioAtRisk(ctx context.Context, filter ReportFilter) ([]PARRow, error)
+	Collections(ctx context.Context, filter ReportFilter) ([]CollectionsRow, error)
+	Vintage(ctx context.Context, filter ReportFilter) ([]VintageCurve, error)
+}
@@ -2,6 +2,7 @@ package domain
 
 import (
 	"context"
+	"time"
 
 	"go.mongodb.org/mongo-driver/bson/primitive"
 )
@@ -12,8 +13,32 @@ type User struct {
 	Email        string             `json:"email,omitempty"`
 	Password     string             `json:"password,omitempty"`
 	IsAdmin      bool               `json:"isadmin,omitempty"`
+	Role         string             `bson:"role,omitempty" json:"role,omitempty"` // admins only: "officer" or "supervisor"
 	RefreshToken string             `json:"refreshtoken,omitempty"`
 	IsVerified   bool               `bson:"isverified,omitempty" json:"isverified,omitempty"`
+	Locale       string             `bson:"locale,omitempty" json:"locale,omitempty"` // language of the emails the user receives, e.g. "fr"
+	KYC          *KYCProfile        `bson:"kyc,omitempty" json:"kyc,omitempty"`
+
+	// Phone is only set once verified by a texted code; until then the number waits in PhoneVerification
+	Phone             string             `bson:"phone,omitempty" json:"-"`
+	PhoneVerification *PhoneVerification `bson:"phone_verification,omitempty" json:"-"`
+	PushDevices       []PushDevice       `bson:"push_devices,omitempty" json:"-"`
+}
+
+// PhoneVerification is a number waiting for the user to enter the code texted to it.
+type PhoneVerification struct {
+	Phone     string    `bson:"phone"`
+	CodeHash  string    `bson:"code_hash"`
+	SentAt    time.Time `bson:"sent_at"`
+	ExpiresAt time.Time `bson:"expires_at"`
+	Attempts  int       `bson:"attempts"`
+}
+
+// PushDevice is an app installation that receives push notifications.
+type PushDevice struct {
+	Token    string    `bson:"token" json:"token" binding:"required"`
+	Platform string    `bson:"platform" json:"platform"` // "ios", "android" or "web"
+	AddedAt  time.Time `bson:"added_at" json:"added_at"`
 }
 
 type ResponseUser struct {
@@ -22,6 +47,20 @@ type ResponseUser struct {
 	Email      string             `json:"email,omitempty"`
 	IsAdmin    bool               `bson:"isadmin,omitempty" json:"isadmin"`
 	IsVerified bool               `bson:"isverified,omitempty" json:"isverified"`
+	Role       string             `bson:"role,omitempty" json:"role,omitempty"`
+	Locale     string             `bson:"locale,omitempty" json:"locale,omitempty"`
+	KYC        *KYCProfile        `bson:"kyc,omitempty" json:"kyc,omitempty"`
+	Phone      string             `bson:"phone,omitempty" json:"phone,omitempty"`
+
+	GuarantorExposure *GuarantorExposure `bson:"-" json:"guarantor_exposure,omitempty"`
+}
+
+// UserFilter narrows the admin user listing; nil flags match either value.
+type UserFilter struct {
+	IsAdmin    *bool
+	IsVerified *bool
+	Role       string
+	KYCStatus  string
 }
 
 type RestRequest struct {
@@ -30,26 +69,61 @@ type RestRequest struct {
 }
 
 type UserUsecase interface {
-	RegisterUser(c context.Context, user *User) error
+	// RegisterUser and PasswordResetRequest email a link for the platform ("web" or "mobile")
+	// the request came from.
+	RegisterUser(c context.Context, user *User, platform string) error
 	VerifyUserEmail(c context.Context, token string) error
 	LoginUser(c context.Context, user User) (string, error)
 	TokenRefresh(c context.Context, token string) (string, error)
 	UserProfile(c context.Context, user User) (ResponseUser, error)
-	PasswordResetRequest(c context.Context, email string) error
+	PasswordResetRequest(c context.Context, email, platform string) error
 	PasswordReset(c context.Context, token string, newPassword string) error
-	GetAllUsers(c context.Context) ([]ResponseUser, error)
-	DeleteUser(c context.Context, user User) error
+	GetAllUsers(c context.Context, adminID primitive.ObjectID, filter UserFilter) ([]ResponseUser, error)
+	DeleteUser(c context.Context, adminID primitive.ObjectID, user User) error
+	SetRole(c context.Context, adminID primitive.ObjectID, user User, role string) error
 }
 
 type UserRepository interface {
-	RegisterUser(user *User) error
+	// RegisterUser takes a context so the new user can be stored in the same transaction as its event.
+	RegisterUser(ctx context.Context, user *User) error
 	VerifyUserEmail(token string) error
 	LoginUser(user User) (string, error)
 	TokenRefresh(user User, token string) error
 	UserProfile(user User) (ResponseUser, error)
-	PasswordResetRequest(email string) error
 	PasswordReset(token string, newPassword string) error
 	GetAllUsers() ([]ResponseUser, error)
+	FindUsers(filter UserFilter) ([]ResponseUser, error)
+	StreamUsers(filter UserFilter, fn func(ResponseUser) error) error
 	DeleteUser(user User) error
 	FindByID(user User) (User, error)
+	FindByEmail(email string) (User, error)
+	FindByUserName(username string) (User, error)
+	UpdateKYC(user User, kyc KYCProfile) error
+	GetUsersByKYCStatus(status string) ([]ResponseUser, error)
+	UpdateRole(user User, role string) error
+	// UpdatePhone sets the verified phone number and the pending verification; empty values remove them.
+	UpdatePhone(user User, phone string, verification *PhoneVerification) error
+	// AddPushDevice registers a device, replacing an earlier registration of the same token.
+	AddPushDevice(user User, device PushDevice) error
+	RemovePushDevice(user User, token string) error
+}
+
+type PhoneRequest struct {
+	Phone string `json:"phone" binding:"required"`
+}
+
+type PhoneCodeRequest struct {
+	Code string `json:"code" binding:"required"`
+}
+
+// ContactUsecase manages the phone number and devices a user is reached on by SMS and push.
+type ContactUsecase interface {
+	// RequestPhoneVerification texts a one-time code to the number; it replaces the user's phone once verified.
+	RequestPhoneVerification(ctx context.Context, userID primitive.ObjectID, phone string) error
+	// VerifyPhone checks the code and returns the number that is now the user's phone.
+	VerifyPhone(ctx context.Context, userID primitive.ObjectID, code string) (string, error)
+	RemovePhone(ctx context.Context, userID primitive.ObjectID) error
+	GetPushDevices(ctx context.Context, userID primitive.ObjectID) ([]PushDevice, error)
+	RegisterPushDevice(ctx context.Context, userID primitive.ObjectID, device PushDevice) error
+	RemovePushDevice(ctx context.Context, userID primitive.ObjectID, token string) error
 }
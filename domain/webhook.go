@@ -0,0 +1,108 @@
+package domain
+
+import (
+	"context"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// WebhookSubscription sends the chosen domain events to an external system. Every request is
+// signed with the subscription's secret so the receiver can check it came from us.
+type WebhookSubscription struct {
+	ID          primitive.ObjectID `bson:"_id,omitempty" json:"id,omitempty"`
+	URL         string             `bson:"url" json:"url"`
+	Secret      string             `bson:"secret" json:"-"`                // returned only when the subscription is created
+	EventTypes  []string           `bson:"event_types" json:"event_types"` // empty for every event type
+	Description string             `bson:"description,omitempty" json:"description,omitempty"`
+	Active      bool               `bson:"active" json:"active"`
+	CreatedBy   primitive.ObjectID `bson:"created_by" json:"created_by"`
+	CreatedAt   time.Time          `bson:"created_at" json:"created_at"`
+	UpdatedAt   time.Time          `bson:"updated_at" json:"updated_at"`
+}
+
+// WebhookSubscriptionRequest creates or replaces a subscription. A secret is generated when none
+// is given on creation; leaving it out of an update keeps the current one.
+type WebhookSubscriptionRequest struct {
+	URL         string   `json:"url" binding:"required"`
+	Secret      string   `json:"secret"`
+	EventTypes  []string `json:"event_types"`
+	Description string   `json:"description"`
+	Active      *bool    `json:"active"` // defaults to true
+}
+
+// WebhookDelivery is one event sent to one subscription. Failed attempts are retried with
+// exponential backoff until the delivery succeeds or is given up as "dead".
+type WebhookDelivery struct {
+	ID             primitive.ObjectID `bson:"_id,omitempty" json:"id,omitempty"`
+	SubscriptionID primitive.ObjectID `bson:"subscription_id" json:"subscription_id"`
+	EventID        primitive.ObjectID `bson:"event_id" json:"event_id"`
+	EventType      string             `bson:"event_type" json:"event_type"`
+	Payload        string             `bson:"payload" json:"payload"` // the exact body sent, so replays are identical
+	// Status is "pending", "retrying", "delivered", "dead" once the attempts run out, or
+	// "cancelled" when the subscription was deleted or deactivated first.
+	Status        string             `bson:"status" json:"status"`
+	Attempts      []WebhookAttempt   `bson:"attempts" json:"attempts"`
+	NextAttemptAt time.Time          `bson:"next_attempt_at,omitempty" json:"next_attempt_at,omitempty"`
+	LastError     string             `bson:"last_error,omitempty" json:"last_error,omitempty"`
+	DeliveredAt   time.Time          `bson:"delivered_at,omitempty" json:"delivered_at,omitempty"`
+	ReplayOf      primitive.ObjectID `bson:"replay_of,omitempty" json:"replay_of,omitempty"`
+	ReplayedBy    primitive.ObjectID `bson:"replayed_by,omitempty" json:"replayed_by,omitempty"`
+	CreatedAt     time.Time          `bson:"created_at" json:"created_at"`
+	UpdatedAt     time.Time          `bson:"updated_at" json:"updated_at"`
+}
+
+type WebhookAttempt struct {
+	Number      int       `bson:"number" json:"number"`
+	AttemptedAt time.Time `bson:"attempted_at" json:"attempted_at"`
+	StatusCode  int       `bson:"status_code,omitempty" json:"status_code,omitempty"` // empty when no response was received
+	Error       string    `bson:"error,omitempty" json:"error,omitempty"`
+	DurationMS  int64     `bson:"duration_ms" json:"duration_ms"`
+}
+
+type WebhookDeliveryFilter struct {
+	SubscriptionID primitive.ObjectID
+	EventType      string
+	Status         string
+}
+
+type WebhookRepository interface {
+	CreateSubscription(ctx context.Context, subscription WebhookSubscription) (primitive.ObjectID, error)
+	GetSubscriptionByID(ctx context.Context, id primitive.ObjectID) (WebhookSubscription, error)
+	GetSubscriptions(ctx context.Context) ([]WebhookSubscription, error)
+	// GetActiveSubscriptions returns the active subscriptions to the event type.
+	GetActiveSubscriptions(ctx context.Context, eventType string) ([]WebhookSubscription, error)
+	UpdateSubscription(ctx context.Context, subscription WebhookSubscription) error
+	DeleteSubscription(ctx context.Context, id primitive.ObjectID) error
+
+	// QueueDelivery adds a delivery unless the event is already queued for the subscription.
+	QueueDelivery(ctx context.Context, delivery WebhookDelivery) error
+	// CreateDelivery adds a delivery unconditionally, as replays do.
+	CreateDelivery(ctx context.Context, delivery WebhookDelivery) (primitive.ObjectID, error)
+	GetDeliveryByID(ctx context.Context, id primitive.ObjectID) (WebhookDelivery, error)
+	FindDeliveries(ctx context.Context, filter WebhookDeliveryFilter) ([]WebhookDelivery, error)
+	// GetDueDeliveries returns pending and retrying deliveries whose next attempt is due, oldest first.
+	GetDueDeliveries(ctx context.Context, now time.Time, limit int) ([]WebhookDelivery, error)
+	// ClaimDelivery leases a due delivery to this worker until the given time. It fails if another
+	// worker claimed it first.
+	ClaimDelivery(ctx context.Context, delivery WebhookDelivery, until time.Time) error
+	UpdateDelivery(ctx context.Context, delivery WebhookDelivery) error
+	// CancelDeliveries cancels a subscription's deliveries that have not been made yet.
+	CancelDeliveries(ctx context.Context, subscriptionID primitive.ObjectID, reason string) error
+}
+
+type WebhookUsecase interface {
+	// CreateSubscription returns the new subscription and its signing secret.
+	CreateSubscription(ctx context.Context, adminID primitive.ObjectID, req WebhookSubscriptionRequest) (WebhookSubscription, string, error)
+	GetSubscriptions(ctx context.Context) ([]WebhookSubscription, error)
+	UpdateSubscription(ctx context.Context, id, adminID primitive.ObjectID, req WebhookSubscriptionRequest) (WebhookSubscription, error)
+	DeleteSubscription(ctx context.Context, id, adminID primitive.ObjectID) error
+
+	// HandleEvent queues an event for every subscription to its type; it is subscribed to the event bus.
+	HandleEvent(ctx context.Context, event Event) error
+	// DeliverWebhooks sends the deliveries that are due.
+	DeliverWebhooks(ctx context.Context) error
+	GetDeliveries(ctx context.Context, filter WebhookDeliveryFilter) ([]WebhookDelivery, error)
+	// ReplayDelivery sends a delivery's payload again as a new delivery.
+	ReplayDelivery(ctx context.Context, id, adminID primitive.ObjectID) (WebhookDelivery, error)
+}
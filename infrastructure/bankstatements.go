@@ -0,0 +1,477 @@
+package infrastructure
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// StatementLine is one booked transaction read from a bank statement. Amount is positive for
+// money received (credits) and negative for money paid out (debits).
+type StatementLine struct {
+	BookingDate         time.Time
+	Amount              float64
+	Currency            string
+	Reference           string // remittance information entered by the payer
+	CounterpartyName    string
+	CounterpartyAccount string
+	BankReference       string // the bank's own id for the transaction, when the format has one
+}
+
+// DetectStatementFormat guesses "csv", "mt940" or "camt053" from the file name and content
+func DetectStatementFormat(fileName string, data []byte) string {
+	name := strings.ToLower(fileName)
+	trimmed := bytes.TrimSpace(bytes.TrimPrefix(data, []byte("\xef\xbb\xbf")))
+	switch {
+	case strings.HasSuffix(name, ".xml") || bytes.HasPrefix(trimmed, []byte("<")):
+		return "camt053"
+	case strings.HasSuffix(name, ".sta") || strings.HasSuffix(name, ".mt940") || strings.HasSuffix(name, ".940") ||
+		(bytes.Contains(data, []byte(":20:")) && bytes.Contains(data, []byte(":61:"))):
+		return "mt940"
+	default:
+		return "csv"
+	}
+}
+
+// ParseBankStatement reads the transactions of a statement in the given format
+func ParseBankStatement(format string, data []byte) ([]StatementLine, error) {
+	data = bytes.TrimPrefix(data, []byte("\xef\xbb\xbf"))
+	switch format {
+	case "csv":
+		return parseStatementCSV(data)
+	case "mt940":
+		return parseMT940(data)
+	case "camt053":
+		return parseCAMT053(data)
+	default:
+		return nil, errors.New("invalid format, you can only enter csv, mt940 or camt053")
+	}
+}
+
+// csvColumns maps the accepted header names of a CSV statement to the field they fill
+var csvColumns = map[string]string{
+	"date": "date", "booking date": "date", "booking_date": "date", "transaction date": "date",
+	"amount": "amount", "credit": "credit", "debit": "debit",
+	"currency":  "currency",
+	"reference": "reference", "description": "reference", "remittance": "reference", "details": "reference", "narrative": "reference",
+	"name": "name", "counterparty": "name", "payer": "name", "counterparty name": "name",
+	"account": "account", "iban": "account", "counterparty account": "account",
+	"id": "id", "transaction id": "id", "bank reference": "id", "bank_reference": "id",
+}
+
+// parseStatementCSV reads a CSV export with a header row. It needs a date column and either a
+// signed amount column or separate credit and debit columns; ";" separated files are accepted.
+func parseStatementCSV(data []byte) ([]StatementLine, error) {
+	reader := csv.NewReader(bytes.NewReader(data))
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+	if first, _, _ := bytes.Cut(data, []byte("\n")); bytes.Count(first, []byte(";")) > bytes.Count(first, []byte(",")) {
+		reader.Comma = ';'
+	}
+
+	header, err := reader.Read()
+	if err != nil {
+		return nil, errors.New("the CSV file is empty")
+	}
+	columns := map[string]int{}
+	for i, name := range header {
+		if field, ok := csvColumns[strings.ToLower(strings.TrimSpace(name))]; ok {
+			if _, seen := columns[field]; !seen {
+				columns[field] = i
+			}
+		}
+	}
+	_, hasAmount := columns["amount"]
+	_, hasCredit := columns["credit"]
+	if _, ok := columns["date"]; !ok || (!hasAmount && !hasCredit) {
+		return nil, errors.New("the CSV header needs a date column and an amount or credit column")
+	}
+
+	var lines []StatementLine
+	for row := 2; ; row++ {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("row %d: %w", row, err)
+		}
+		value := func(field string) string {
+			if i, ok := columns[field]; ok && i < len(record) {
+				return strings.TrimSpace(record[i])
+			}
+			return ""
+		}
+		if strings.Join(record, "") == "" {
+			continue
+		}
+
+		var line StatementLine
+		if line.BookingDate, err = parseStatementDate(value("date")); err != nil {
+			return nil, fmt.Errorf("row %d: %w", row, err)
+		}
+		if hasAmount {
+			line.Amount, err = parseStatementAmount(value("amount"))
+		} else {
+			var credit, debit float64
+			if credit, err = parseStatementAmount(value("credit")); err == nil {
+				debit, err = parseStatementAmount(value("debit"))
+			}
+			line.Amount = math.Abs(credit) - math.Abs(debit)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("row %d: %w", row, err)
+		}
+		line.Currency = strings.ToUpper(value("currency"))
+		line.Reference = value("reference")
+		line.CounterpartyName = value("name")
+		line.CounterpartyAccount = value("account")
+		line.BankReference = value("id")
+		lines = append(lines, line)
+	}
+	return lines, nil
+}
+
+// parseMT940 reads a SWIFT MT940 customer statement. Each :61: statement line may be followed by
+// an :86: information field; German-style "?20".."?33" subfields in it are split into the
+// remittance text and the payer's name and account.
+func parseMT940(data []byte) ([]StatementLine, error) {
+	var lines []StatementLine
+	var currency string
+	var tag, value string
+	flush := func() error {
+		switch tag {
+		case "60F", "60M":
+			// C231001EUR1234,56: the opening balance carries the account currency
+			if len(value) >= 10 {
+				currency = value[7:10]
+			}
+		case "61":
+			line, err := parseMT940Line(value)
+			if err != nil {
+				return err
+			}
+			line.Currency = currency
+			lines = append(lines, line)
+		case "86":
+			if len(lines) > 0 {
+				applyMT940Information(&lines[len(lines)-1], value)
+			}
+		}
+		return nil
+	}
+
+	text := strings.ReplaceAll(string(data), "\r\n", "\n")
+	for _, raw := range strings.Split(text, "\n") {
+		raw = strings.TrimRight(raw, " ")
+		if raw == "-" || raw == "-}" || strings.HasPrefix(raw, "{") {
+			continue
+		}
+		if strings.HasPrefix(raw, ":") {
+			if end := strings.Index(raw[1:], ":"); end > 0 {
+				if err := flush(); err != nil {
+					return nil, err
+				}
+				tag, value = raw[1:end+1], raw[end+2:]
+				continue
+			}
+		}
+		// Continuation of a multi-line field
+		if raw != "" {
+			value += "\n" + raw
+		}
+	}
+	if err := flush(); err != nil {
+		return nil, err
+	}
+	if len(lines) == 0 && !strings.Contains(text, ":20:") {
+		return nil, errors.New("this does not look like an MT940 statement")
+	}
+	return lines, nil
+}
+
+// parseMT940Line decodes the fixed layout of a :61: field, e.g. "2310021002CR1234,56NTRFNONREF//B123"
+func parseMT940Line(value string) (StatementLine, error) {
+	var line StatementLine
+	first, rest, _ := strings.Cut(value, "\n")
+	if len(first) < 6 {
+		return line, fmt.Errorf("invalid :61: field %q", first)
+	}
+	valueDate, err := time.Parse("060102", first[:6])
+	if err != nil {
+		return line, fmt.Errorf("invalid :61: value date %q", first[:6])
+	}
+	line.BookingDate = valueDate
+	s := first[6:]
+	// The optional MMDD entry date is the booking date; its year is the one that puts it
+	// closest to the value date
+	if len(s) >= 4 && isDigits(s[:4]) {
+		entry, err := time.Parse("20060102", valueDate.Format("2006")+s[:4])
+		if err != nil {
+			return line, fmt.Errorf("invalid :61: entry date %q", s[:4])
+		}
+		switch {
+		case entry.Sub(valueDate) > 183*24*time.Hour:
+			entry = entry.AddDate(-1, 0, 0)
+		case valueDate.Sub(entry) > 183*24*time.Hour:
+			entry = entry.AddDate(1, 0, 0)
+		}
+		line.BookingDate = entry
+		s = s[4:]
+	}
+	sign := 1.0
+	switch {
+	case strings.HasPrefix(s, "RC"):
+		sign, s = -1, s[2:]
+	case strings.HasPrefix(s, "RD"):
+		s = s[2:]
+	case strings.HasPrefix(s, "C"):
+		s = s[1:]
+	case strings.HasPrefix(s, "D"):
+		sign, s = -1, s[1:]
+	default:
+		return line, fmt.Errorf("invalid debit/credit mark in :61: field %q", first)
+	}
+	// Optional funds code, e.g. the "R" in "CR"
+	if s != "" && (s[0] < '0' || s[0] > '9') {
+		s = s[1:]
+	}
+	end := 0
+	for end < len(s) && (s[end] >= '0' && s[end] <= '9' || s[end] == ',') {
+		end++
+	}
+	amount, err := parseMT940Amount(s[:end])
+	if err != nil {
+		return line, err
+	}
+	line.Amount = sign * amount
+	s = s[end:]
+	// Four character transaction type, then "customer reference//bank reference"
+	if len(s) >= 4 {
+		s = s[4:]
+	}
+	customer, bank, _ := strings.Cut(s, "//")
+	if customer != "NONREF" {
+		line.Reference = customer
+	}
+	line.BankReference = strings.TrimSpace(bank)
+	if rest != "" && line.BankReference == "" {
+		line.BankReference = strings.TrimSpace(rest)
+	}
+	return line, nil
+}
+
+func applyMT940Information(line *StatementLine, info string) {
+	if !strings.Contains(info, "?") {
+		line.Reference = strings.TrimSpace(line.Reference + " " + strings.ReplaceAll(info, "\n", " "))
+		return
+	}
+	// Structured subfields may be wrapped anywhere, including inside a value
+	info = strings.ReplaceAll(info, "\n", "")
+	var remittance []string
+	var name string
+	for _, part := range strings.Split(info, "?")[1:] {
+		if len(part) < 2 {
+			continue
+		}
+		code, text := part[:2], part[2:]
+		switch {
+		case code >= "20" && code <= "29", code >= "60" && code <= "63":
+			remittance = append(remittance, text)
+		case code == "31":
+			line.CounterpartyAccount = text
+		case code == "32" || code == "33":
+			name += text
+		}
+	}
+	line.CounterpartyName = strings.TrimSpace(name)
+	if len(remittance) > 0 {
+		line.Reference = strings.TrimSpace(strings.Join(remittance, ""))
+	}
+}
+
+type camtDocument struct {
+	Statements []struct {
+		Entries []camtEntry `xml:"Ntry"`
+	} `xml:"BkToCstmrStmt>Stmt"`
+}
+
+type camtEntry struct {
+	Amount      camtAmount `xml:"Amt"`
+	CreditDebit string     `xml:"CdtDbtInd"`
+	Reversal    bool       `xml:"RvslInd"`
+	BookingDate camtDate   `xml:"BookgDt"`
+	ValueDate   camtDate   `xml:"ValDt"`
+	BankRef     string     `xml:"AcctSvcrRef"`
+	Details     []struct {
+		Amount     camtAmount `xml:"Amt"`
+		TxAmount   camtAmount `xml:"AmtDtls>TxAmt>Amt"`
+		BankRef    string     `xml:"Refs>AcctSvcrRef"`
+		EndToEndID string     `xml:"Refs>EndToEndId"`
+		Debtor     string     `xml:"RltdPties>Dbtr>Nm"`
+		DebtorPty  string     `xml:"RltdPties>Dbtr>Pty>Nm"`
+		IBAN       string     `xml:"RltdPties>DbtrAcct>Id>IBAN"`
+		Other      string     `xml:"RltdPties>DbtrAcct>Id>Othr>Id"`
+		Ustrd      []string   `xml:"RmtInf>Ustrd"`
+		CreditRef  string     `xml:"RmtInf>Strd>CdtrRefInf>Ref"`
+	} `xml:"NtryDtls>TxDtls"`
+}
+
+type camtAmount struct {
+	Value    string `xml:",chardata"`
+	Currency string `xml:"Ccy,attr"`
+}
+
+type camtDate struct {
+	Date     string `xml:"Dt"`
+	DateTime string `xml:"DtTm"`
+}
+
+// parseCAMT053 reads an ISO 20022 camt.053 statement of any version. A batch entry with several
+// transaction details becomes one line per transaction. Amounts are XML decimals with a decimal point.
+func parseCAMT053(data []byte) ([]StatementLine, error) {
+	var doc camtDocument
+	if err := xml.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("invalid camt.053 file: %w", err)
+	}
+	if len(doc.Statements) == 0 {
+		return nil, errors.New("this does not look like a camt.053 statement")
+	}
+
+	var lines []StatementLine
+	for _, stmt := range doc.Statements {
+		for _, entry := range stmt.Entries {
+			date, err := entry.BookingDate.parse()
+			if err != nil {
+				if date, err = entry.ValueDate.parse(); err != nil {
+					return nil, errors.New("camt.053 entry without a booking date")
+				}
+			}
+			sign := 1.0
+			if (entry.CreditDebit == "DBIT") != entry.Reversal {
+				sign = -1
+			}
+			base := StatementLine{BookingDate: date, Currency: entry.Amount.Currency, BankReference: entry.BankRef}
+			if len(entry.Details) == 0 {
+				amount, err := parseDecimalAmount(strings.TrimSpace(entry.Amount.Value))
+				if err != nil {
+					return nil, err
+				}
+				base.Amount = sign * amount
+				lines = append(lines, base)
+				continue
+			}
+			for _, tx := range entry.Details {
+				line := base
+				value := entry.Amount
+				if len(entry.Details) > 1 {
+					if value = tx.Amount; value.Value == "" {
+						value = tx.TxAmount
+					}
+				}
+				amount, err := parseDecimalAmount(strings.TrimSpace(value.Value))
+				if err != nil {
+					return nil, err
+				}
+				line.Amount = sign * amount
+				if value.Currency != "" {
+					line.Currency = value.Currency
+				}
+				if tx.BankRef != "" {
+					line.BankReference = tx.BankRef
+				}
+				line.CounterpartyName = firstNonEmpty(tx.Debtor, tx.DebtorPty)
+				line.CounterpartyAccount = firstNonEmpty(tx.IBAN, tx.Other)
+				line.Reference = strings.TrimSpace(strings.Join(append(tx.Ustrd, tx.CreditRef), " "))
+				if line.Reference == "" && tx.EndToEndID != "NOTPROVIDED" {
+					line.Reference = tx.EndToEndID
+				}
+				lines = append(lines, line)
+			}
+		}
+	}
+	return lines, nil
+}
+
+func (d camtDate) parse() (time.Time, error) {
+	if d.Date != "" {
+		return time.Parse("2006-01-02", strings.TrimSpace(d.Date))
+	}
+	if d.DateTime != "" {
+		value := strings.TrimSpace(d.DateTime)
+		if t, err := time.Parse(time.RFC3339, value); err == nil {
+			return t, nil
+		}
+		return time.Parse("2006-01-02T15:04:05", value)
+	}
+	return time.Time{}, errors.New("missing date")
+}
+
+var statementDateLayouts = []string{"2006-01-02", "02.01.2006", "02/01/2006", "2006/01/02", "20060102"}
+
+func parseStatementDate(value string) (time.Time, error) {
+	for _, layout := range statementDateLayouts {
+		if t, err := time.Parse(layout, value); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("invalid date %q", value)
+}
+
+// parseStatementAmount reads an amount whose decimal separator is not fixed by the format:
+// "1234.56", "1,234.56", "1234,56" and "1.234,56"; empty is zero. A comma followed by exactly
+// three digits and no dot, as in "1,234", separates thousands.
+func parseStatementAmount(value string) (float64, error) {
+	value = strings.ReplaceAll(strings.TrimSpace(value), " ", "")
+	if value == "" {
+		return 0, nil
+	}
+	comma, dot := strings.LastIndex(value, ","), strings.LastIndex(value, ".")
+	decimalComma := comma > dot && (dot >= 0 || strings.Count(value, ",") == 1 && len(value)-comma-1 != 3)
+	if decimalComma {
+		value = strings.ReplaceAll(value[:comma], ".", "") + "." + value[comma+1:]
+	}
+	return parseDecimalAmount(strings.ReplaceAll(value, ",", ""))
+}
+
+// parseMT940Amount reads an MT940 amount, which always has a decimal comma: "1234,56" or "1234,"
+func parseMT940Amount(value string) (float64, error) {
+	if strings.Count(value, ",") != 1 {
+		return 0, fmt.Errorf("invalid amount %q", value)
+	}
+	return parseDecimalAmount(strings.Replace(value, ",", ".", 1))
+}
+
+// parseDecimalAmount reads an amount with a decimal point and no separators, rounded to cents
+func parseDecimalAmount(value string) (float64, error) {
+	amount, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid amount %q", value)
+	}
+	return math.Round(amount*100) / 100, nil
+}
+
+func isDigits(s string) bool {
+	for _, r := range s {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return s != ""
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, v := range values {
+		if strings.TrimSpace(v) != "" {
+			return strings.TrimSpace(v)
+		}
+	}
+	return ""
+}
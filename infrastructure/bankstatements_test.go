@@ -0,0 +1,335 @@
+package infrastructure
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func date(year int, month time.Month, day int) time.Time {
+	return time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+}
+
+// checkLines compares parsed statement lines field by field
+func checkLines(t *testing.T, got, want []StatementLine) {
+	t.Helper()
+	if len(got) != len(want) {
+		t.Fatalf("got %d lines, want %d: %+v", len(got), len(want), got)
+	}
+	for i := range want {
+		g, w := got[i], want[i]
+		if !g.BookingDate.Equal(w.BookingDate) {
+			t.Errorf("line %d: booking date %s, want %s", i+1, g.BookingDate.Format("2006-01-02"), w.BookingDate.Format("2006-01-02"))
+		}
+		if g.Amount != w.Amount || g.Currency != w.Currency {
+			t.Errorf("line %d: amount %.2f %s, want %.2f %s", i+1, g.Amount, g.Currency, w.Amount, w.Currency)
+		}
+		if g.Reference != w.Reference {
+			t.Errorf("line %d: reference %q, want %q", i+1, g.Reference, w.Reference)
+		}
+		if g.CounterpartyName != w.CounterpartyName || g.CounterpartyAccount != w.CounterpartyAccount {
+			t.Errorf("line %d: counterparty %q %q, want %q %q", i+1, g.CounterpartyName, g.CounterpartyAccount, w.CounterpartyName, w.CounterpartyAccount)
+		}
+		if g.BankReference != w.BankReference {
+			t.Errorf("line %d: bank reference %q, want %q", i+1, g.BankReference, w.BankReference)
+		}
+	}
+}
+
+func TestParseStatementAmount(t *testing.T) {
+	tests := []struct {
+		value string
+		want  float64
+	}{
+		{"", 0},
+		{"1234.56", 1234.56},
+		{"-1234.56", -1234.56},
+		{"1,234.56", 1234.56},
+		{"1,234", 1234},
+		{"1,234,567", 1234567},
+		{"1,234,567.8", 1234567.8},
+		{"1234,56", 1234.56},
+		{"1234,5", 1234.5},
+		{"12,3456", 12.35},
+		{"1.234,56", 1234.56},
+		{"1.234.567,89", 1234567.89},
+		{"1 234,56", 1234.56},
+		{" 50 ", 50},
+	}
+	for _, tt := range tests {
+		got, err := parseStatementAmount(tt.value)
+		if err != nil {
+			t.Errorf("parseStatementAmount(%q): %v", tt.value, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("parseStatementAmount(%q) = %v, want %v", tt.value, got, tt.want)
+		}
+	}
+	for _, value := range []string{"abc", "--5"} {
+		if got, err := parseStatementAmount(value); err == nil {
+			t.Errorf("parseStatementAmount(%q) = %v, want an error", value, got)
+		}
+	}
+}
+
+func TestParseMT940Amount(t *testing.T) {
+	tests := []struct {
+		value string
+		want  float64
+	}{
+		{"1234,56", 1234.56},
+		{"1,234", 1.23},
+		{"500,", 500},
+		{"0,5", 0.5},
+	}
+	for _, tt := range tests {
+		got, err := parseMT940Amount(tt.value)
+		if err != nil || got != tt.want {
+			t.Errorf("parseMT940Amount(%q) = %v, %v, want %v", tt.value, got, err, tt.want)
+		}
+	}
+	for _, value := range []string{"1234.56", "1234", "1,234,5"} {
+		if _, err := parseMT940Amount(value); err == nil {
+			t.Errorf("parseMT940Amount(%q) succeeded, want an error", value)
+		}
+	}
+}
+
+func TestParseStatementCSV(t *testing.T) {
+	tests := []struct {
+		name string
+		data string
+		want []StatementLine
+	}{
+		{
+			name: "US export with thousands separators",
+			data: "Date,Description,Amount,Payer,Transaction ID\r\n" +
+				"2024-01-05,\"Loan 65a1f0c2e4b0a1b2c3d4e5f6, installment 3\",\"1,234\",Abebe Kebede,TX-1001\r\n" +
+				"2024-01-06,Monthly account fee,-12.50,,TX-1002\r\n" +
+				"2024-01-08,Transfer,\"2,500.75\",Sara Tesfaye,TX-1003\r\n",
+			want: []StatementLine{
+				{BookingDate: date(2024, 1, 5), Amount: 1234, Reference: "Loan 65a1f0c2e4b0a1b2c3d4e5f6, installment 3", CounterpartyName: "Abebe Kebede", BankReference: "TX-1001"},
+				{BookingDate: date(2024, 1, 6), Amount: -12.5, Reference: "Monthly account fee", BankReference: "TX-1002"},
+				{BookingDate: date(2024, 1, 8), Amount: 2500.75, Reference: "Transfer", CounterpartyName: "Sara Tesfaye", BankReference: "TX-1003"},
+			},
+		},
+		{
+			name: "European export with semicolons and decimal commas",
+			data: "\xef\xbb\xbfBooking date;Amount;Currency;Reference;Name;IBAN\n" +
+				"02.01.2024;1.234,56;eur;Rate Januar;Abebe Kebede;DE89370400440532013000\n" +
+				";;;;;\n" +
+				"03.01.2024;-45,5;EUR;Kontoführung;;\n",
+			want: []StatementLine{
+				{BookingDate: date(2024, 1, 2), Amount: 1234.56, Currency: "EUR", Reference: "Rate Januar", CounterpartyName: "Abebe Kebede", CounterpartyAccount: "DE89370400440532013000"},
+				{BookingDate: date(2024, 1, 3), Amount: -45.5, Currency: "EUR", Reference: "Kontoführung"},
+			},
+		},
+		{
+			name: "separate credit and debit columns",
+			data: "date,credit,debit,narrative\n" +
+				"2024/01/07,100.00,,Repayment\n" +
+				"2024/01/08,,30.25,Charges\n" +
+				"20240109,\"1,000\",,Repayment\n",
+			want: []StatementLine{
+				{BookingDate: date(2024, 1, 7), Amount: 100, Reference: "Repayment"},
+				{BookingDate: date(2024, 1, 8), Amount: -30.25, Reference: "Charges"},
+				{BookingDate: date(2024, 1, 9), Amount: 1000, Reference: "Repayment"},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data := []byte(tt.data)
+			if format := DetectStatementFormat("statement.csv", data); format != "csv" {
+				t.Fatalf("detected %q, want csv", format)
+			}
+			lines, err := ParseBankStatement("csv", data)
+			if err != nil {
+				t.Fatalf("ParseBankStatement: %v", err)
+			}
+			checkLines(t, lines, tt.want)
+		})
+	}
+}
+
+func TestParseStatementCSVErrors(t *testing.T) {
+	tests := map[string]string{
+		"empty file":     "",
+		"missing amount": "date,reference\n2024-01-05,x\n",
+		"bad date":       "date,amount\n5th of January,10\n",
+		"bad amount":     "date,amount\n2024-01-05,ten\n",
+	}
+	for name, data := range tests {
+		if _, err := ParseBankStatement("csv", []byte(data)); err == nil {
+			t.Errorf("%s: parsed without an error", name)
+		}
+	}
+}
+
+// mt940Statement is a German bank's MT940 export: structured ?-subfields wrapped across lines,
+// an entry date in the previous year, a plain text :86:, a reversal and a line without one.
+const mt940Statement = `{1:F01DEUTDEFFAXXX0000000000}{2:O9401200240103DEUTDEFFAXXX00000000002401031200N}{4:
+:20:STARTUMSE
+:25:10020030/1234567
+:28C:00001/001
+:60F:C231229EUR1000,00
+:61:2401021229CR1234,56NTRFNONREF//B4A02-0001
+:86:166?00GUTSCHRIFT?109075?20LOAN 65a1f0c2e4b0
+a1b2c3d4e5f6?21 RATE 3?30DEUTDEFF?31DE89370400440532013000
+?32ABEBE KEB?33EDE
+:61:240103D50,NMSCREF123//B4A03-0002
+:86:Bank fees January
+continued here
+:61:2401040104RD20,00NCHGNONREF//B4A04-0003
+:61:240105C7,5NTRFNONREF
+SUPPLEMENTARY-77
+:62F:C240105EUR2172,06
+-}`
+
+func TestParseMT940(t *testing.T) {
+	data := []byte(strings.ReplaceAll(mt940Statement, "\n", "\r\n"))
+	if format := DetectStatementFormat("export.txt", data); format != "mt940" {
+		t.Fatalf("detected %q, want mt940", format)
+	}
+	lines, err := ParseBankStatement("mt940", data)
+	if err != nil {
+		t.Fatalf("ParseBankStatement: %v", err)
+	}
+	checkLines(t, lines, []StatementLine{
+		{
+			BookingDate: date(2023, 12, 29), Amount: 1234.56, Currency: "EUR",
+			Reference:        "LOAN 65a1f0c2e4b0a1b2c3d4e5f6 RATE 3",
+			CounterpartyName: "ABEBE KEBEDE", CounterpartyAccount: "DE89370400440532013000",
+			BankReference: "B4A02-0001",
+		},
+		{BookingDate: date(2024, 1, 3), Amount: -50, Currency: "EUR", Reference: "REF123 Bank fees January continued here", BankReference: "B4A03-0002"},
+		{BookingDate: date(2024, 1, 4), Amount: 20, Currency: "EUR", BankReference: "B4A04-0003"},
+		{BookingDate: date(2024, 1, 5), Amount: 7.5, Currency: "EUR", BankReference: "SUPPLEMENTARY-77"},
+	})
+}
+
+func TestParseMT940LineDates(t *testing.T) {
+	tests := []struct {
+		field string
+		want  time.Time
+	}{
+		{"240315C10,NTRFNONREF", date(2024, 3, 15)},
+		{"2403150314C10,NTRFNONREF", date(2024, 3, 14)},
+		{"2312310102C10,NTRFNONREF", date(2024, 1, 2)},
+		{"2401021229C10,NTRFNONREF", date(2023, 12, 29)},
+	}
+	for _, tt := range tests {
+		line, err := parseMT940Line(tt.field)
+		if err != nil {
+			t.Errorf("parseMT940Line(%q): %v", tt.field, err)
+			continue
+		}
+		if !line.BookingDate.Equal(tt.want) {
+			t.Errorf("parseMT940Line(%q) booked on %s, want %s", tt.field, line.BookingDate.Format("2006-01-02"), tt.want.Format("2006-01-02"))
+		}
+	}
+	for _, field := range []string{"24031", "240315X10,NTRF", "2403151332C10,NTRF", "240315C10.00NTRF"} {
+		if _, err := parseMT940Line(field); err == nil {
+			t.Errorf("parseMT940Line(%q) succeeded, want an error", field)
+		}
+	}
+}
+
+// camtStatement is a camt.053.001.02 statement with a single credit, a batch credit of two
+// transfers, a debit booked with a timestamp and a reversed credit.
+const camtStatement = `<?xml version="1.0" encoding="UTF-8"?>
+<Document xmlns="urn:iso:std:iso:20022:tech:xsd:camt.053.001.02">
+  <BkToCstmrStmt>
+    <GrpHdr><MsgId>STMT-20240105</MsgId><CreDtTm>2024-01-05T18:00:00</CreDtTm></GrpHdr>
+    <Stmt>
+      <Id>STMT-20240105-1</Id>
+      <Acct><Id><IBAN>DE02120300000000202051</IBAN></Id></Acct>
+      <Ntry>
+        <Amt Ccy="EUR">250.00</Amt>
+        <CdtDbtInd>CRDT</CdtDbtInd>
+        <Sts>BOOK</Sts>
+        <BookgDt><Dt>2024-01-05</Dt></BookgDt>
+        <ValDt><Dt>2024-01-05</Dt></ValDt>
+        <AcctSvcrRef>ENTRY-1</AcctSvcrRef>
+        <NtryDtls><TxDtls>
+          <Refs><EndToEndId>NOTPROVIDED</EndToEndId></Refs>
+          <RltdPties>
+            <Dbtr><Nm>Abebe Kebede</Nm></Dbtr>
+            <DbtrAcct><Id><IBAN>DE89370400440532013000</IBAN></Id></DbtrAcct>
+          </RltdPties>
+          <RmtInf><Ustrd>Loan 65a1f0c2e4b0a1b2c3d4e5f6</Ustrd><Ustrd>installment 2</Ustrd></RmtInf>
+        </TxDtls></NtryDtls>
+      </Ntry>
+      <Ntry>
+        <Amt Ccy="EUR">300.00</Amt>
+        <CdtDbtInd>CRDT</CdtDbtInd>
+        <BookgDt><Dt>2024-01-05</Dt></BookgDt>
+        <AcctSvcrRef>BATCH-7</AcctSvcrRef>
+        <NtryDtls>
+          <Btch><NbOfTxs>2</NbOfTxs></Btch>
+          <TxDtls>
+            <Refs><AcctSvcrRef>BATCH-7-1</AcctSvcrRef><EndToEndId>E2E-0001</EndToEndId></Refs>
+            <Amt Ccy="EUR">100.00</Amt>
+            <RltdPties><Dbtr><Pty><Nm>Sara Tesfaye</Nm></Pty></Dbtr><DbtrAcct><Id><Othr><Id>0012345678</Id></Othr></Id></DbtrAcct></RltdPties>
+          </TxDtls>
+          <TxDtls>
+            <Refs><AcctSvcrRef>BATCH-7-2</AcctSvcrRef></Refs>
+            <AmtDtls><TxAmt><Amt Ccy="EUR">200.00</Amt></TxAmt></AmtDtls>
+            <RmtInf><Strd><CdtrRefInf><Ref>RF18539007547034</Ref></CdtrRefInf></Strd></RmtInf>
+          </TxDtls>
+        </NtryDtls>
+      </Ntry>
+      <Ntry>
+        <Amt Ccy="EUR">15.00</Amt>
+        <CdtDbtInd>DBIT</CdtDbtInd>
+        <BookgDt><DtTm>2024-01-06T09:30:00Z</DtTm></BookgDt>
+        <AcctSvcrRef>FEE-1</AcctSvcrRef>
+      </Ntry>
+      <Ntry>
+        <Amt Ccy="EUR">40.00</Amt>
+        <CdtDbtInd>CRDT</CdtDbtInd>
+        <RvslInd>true</RvslInd>
+        <ValDt><Dt>2024-01-07</Dt></ValDt>
+        <AcctSvcrRef>RETURN-1</AcctSvcrRef>
+      </Ntry>
+    </Stmt>
+  </BkToCstmrStmt>
+</Document>`
+
+func TestParseCAMT053(t *testing.T) {
+	data := []byte(camtStatement)
+	if format := DetectStatementFormat("camt053.xml", data); format != "camt053" {
+		t.Fatalf("detected %q, want camt053", format)
+	}
+	lines, err := ParseBankStatement("camt053", data)
+	if err != nil {
+		t.Fatalf("ParseBankStatement: %v", err)
+	}
+	checkLines(t, lines, []StatementLine{
+		{
+			BookingDate: date(2024, 1, 5), Amount: 250, Currency: "EUR",
+			Reference:        "Loan 65a1f0c2e4b0a1b2c3d4e5f6 installment 2",
+			CounterpartyName: "Abebe Kebede", CounterpartyAccount: "DE89370400440532013000",
+			BankReference: "ENTRY-1",
+		},
+		{BookingDate: date(2024, 1, 5), Amount: 100, Currency: "EUR", Reference: "E2E-0001", CounterpartyName: "Sara Tesfaye", CounterpartyAccount: "0012345678", BankReference: "BATCH-7-1"},
+		{BookingDate: date(2024, 1, 5), Amount: 200, Currency: "EUR", Reference: "RF18539007547034", BankReference: "BATCH-7-2"},
+		{BookingDate: time.Date(2024, 1, 6, 9, 30, 0, 0, time.UTC), Amount: -15, Currency: "EUR", BankReference: "FEE-1"},
+		{BookingDate: date(2024, 1, 7), Amount: -40, Currency: "EUR", BankReference: "RETURN-1"},
+	})
+}
+
+func TestParseCAMT053Errors(t *testing.T) {
+	tests := map[string]string{
+		"not xml":       "<Document><BkToCstmrStmt>",
+		"no statements": `<Document xmlns="urn:iso:std:iso:20022:tech:xsd:camt.052.001.02"><BkToCstmrAcctRpt/></Document>`,
+		"no date":       `<Document><BkToCstmrStmt><Stmt><Ntry><Amt Ccy="EUR">1.00</Amt><CdtDbtInd>CRDT</CdtDbtInd></Ntry></Stmt></BkToCstmrStmt></Document>`,
+		"comma amount":  `<Document><BkToCstmrStmt><Stmt><Ntry><Amt Ccy="EUR">1,00</Amt><CdtDbtInd>CRDT</CdtDbtInd><BookgDt><Dt>2024-01-05</Dt></BookgDt></Ntry></Stmt></BkToCstmrStmt></Document>`,
+	}
+	for name, data := range tests {
+		if _, err := ParseBankStatement("camt053", []byte(data)); err == nil {
+			t.Errorf("%s: parsed without an error", name)
+		}
+	}
+}
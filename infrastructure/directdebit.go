@@ -0,0 +1,69 @@
+package infrastructure
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// DebitProvider submits direct debit mandates and collections to a bank or debit scheme. Banks
+// answer asynchronously, so outcomes are picked up later through FetchResults. Amounts are in
+// minor units (cents).
+type DebitProvider interface {
+	Name() string
+	// RegisterMandate submits the borrower's authorization and returns the provider's reference for it.
+	RegisterMandate(ctx context.Context, req MandateInstruction) (string, error)
+	CancelMandate(ctx context.Context, providerReference string) error
+	// SubmitDebit asks for a collection under an active mandate. Reference is unique per attempt,
+	// so providers can drop a debit that is submitted twice.
+	SubmitDebit(ctx context.Context, req DebitInstruction) (string, error)
+	// FetchResults returns the outcomes reported so far that have not been acknowledged.
+	FetchResults(ctx context.Context) ([]DebitResult, error)
+	// AcknowledgeResult marks a result as applied so it is not returned again.
+	AcknowledgeResult(ctx context.Context, result DebitResult) error
+}
+
+type MandateInstruction struct {
+	Reference     string `json:"reference"` // our mandate id
+	AccountHolder string `json:"account_holder"`
+	AccountNumber string `json:"account_number"`
+	BankCode      string `json:"bank_code"`
+}
+
+type DebitInstruction struct {
+	Reference        string    `json:"reference"`
+	MandateReference string    `json:"mandate_reference"`
+	Amount           int64     `json:"amount"`
+	Currency         string    `json:"currency"`
+	Description      string    `json:"description"`
+	CollectOn        time.Time `json:"collect_on"`
+}
+
+// DebitResult is the bank's answer to a mandate or a debit.
+type DebitResult struct {
+	ID                string `json:"-"`    // the provider's handle for acknowledging the result
+	Kind              string `json:"kind"` // "mandate" or "debit"
+	ProviderReference string `json:"provider_reference"`
+	// Status is "active", "rejected" or "cancelled" for mandates and "succeeded" or "failed" for debits
+	Status      string    `json:"status"`
+	FailureCode string    `json:"failure_code,omitempty"`
+	Amount      int64     `json:"amount,omitempty"`
+	OccurredAt  time.Time `json:"occurred_at"`
+}
+
+// debitProviders builds each supported debit provider from its settings; register new providers here.
+var debitProviders = map[string]func() (DebitProvider, error){
+	"file": func() (DebitProvider, error) {
+		return NewFileDebitProvider(DotEnvLoaderDefault("DIRECT_DEBIT_DIR", "direct_debits"))
+	},
+}
+
+// NewDebitProvider returns the provider named by DEBIT_PROVIDER, the file stand-in by default.
+func NewDebitProvider() (DebitProvider, error) {
+	name := DotEnvLoaderDefault("DEBIT_PROVIDER", "file")
+	build, ok := debitProviders[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown debit provider %q", name)
+	}
+	return build()
+}
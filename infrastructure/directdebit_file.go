@@ -0,0 +1,119 @@
+package infrastructure
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// FileDebitProvider is a stand-in for a bank's direct debit file exchange. Every instruction is
+// written as a JSON file to <dir>/outgoing. The bank's answers are read from JSON files dropped
+// into <dir>/incoming, one DebitResult per file, for example
+//
+//	{"kind": "debit", "provider_reference": "ddr_...", "status": "failed", "failure_code": "insufficient_funds"}
+//
+// and moved to <dir>/processed once applied.
+type FileDebitProvider struct {
+	dir string
+}
+
+// NewFileDebitProvider creates the exchange directories under dir if they do not exist yet
+func NewFileDebitProvider(dir string) (*FileDebitProvider, error) {
+	for _, sub := range []string{"outgoing", "incoming", "processed"} {
+		if err := os.MkdirAll(filepath.Join(dir, sub), 0o750); err != nil {
+			return nil, err
+		}
+	}
+	return &FileDebitProvider{dir: dir}, nil
+}
+
+func (p *FileDebitProvider) Name() string {
+	return "file"
+}
+
+func (p *FileDebitProvider) RegisterMandate(ctx context.Context, req MandateInstruction) (string, error) {
+	ref, err := randomID("mdt_")
+	if err != nil {
+		return "", err
+	}
+	return ref, p.writeInstruction(ref, "mandate", req)
+}
+
+func (p *FileDebitProvider) CancelMandate(ctx context.Context, providerReference string) error {
+	return p.writeInstruction(providerReference+"_cancel", "mandate_cancellation", map[string]string{
+		"mandate_reference": providerReference,
+	})
+}
+
+func (p *FileDebitProvider) SubmitDebit(ctx context.Context, req DebitInstruction) (string, error) {
+	if req.Amount <= 0 {
+		return "", errors.New("amount must be greater than zero")
+	}
+	ref, err := randomID("ddr_")
+	if err != nil {
+		return "", err
+	}
+	return ref, p.writeInstruction(ref, "debit", req)
+}
+
+// FetchResults reads the answers waiting in the incoming directory, oldest file name first.
+// Files that cannot be parsed are left in place and logged.
+func (p *FileDebitProvider) FetchResults(ctx context.Context) ([]DebitResult, error) {
+	entries, err := os.ReadDir(filepath.Join(p.dir, "incoming"))
+	if err != nil {
+		return nil, err
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].Name() < entries[j].Name() })
+
+	var results []DebitResult
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.HasSuffix(entry.Name(), ".json") {
+			continue
+		}
+		data, err := os.ReadFile(filepath.Join(p.dir, "incoming", entry.Name()))
+		if err != nil {
+			return nil, err
+		}
+		var result DebitResult
+		if err := json.Unmarshal(data, &result); err != nil || result.ProviderReference == "" {
+			log.Println("Skipping unreadable direct debit result", entry.Name())
+			continue
+		}
+		result.ID = entry.Name()
+		if result.OccurredAt.IsZero() {
+			if info, err := entry.Info(); err == nil {
+				result.OccurredAt = info.ModTime()
+			}
+		}
+		results = append(results, result)
+	}
+	return results, nil
+}
+
+func (p *FileDebitProvider) AcknowledgeResult(ctx context.Context, result DebitResult) error {
+	name := filepath.Base(result.ID)
+	return os.Rename(filepath.Join(p.dir, "incoming", name), filepath.Join(p.dir, "processed", name))
+}
+
+// writeInstruction writes through a temporary file so the bank side never reads a partial file
+func (p *FileDebitProvider) writeInstruction(name, kind string, instruction interface{}) error {
+	data, err := json.MarshalIndent(map[string]interface{}{
+		"kind":        kind,
+		"instruction": instruction,
+		"created_at":  time.Now().UTC(),
+	}, "", "  ")
+	if err != nil {
+		return err
+	}
+	path := filepath.Join(p.dir, "outgoing", name+".json")
+	if err := os.WriteFile(path+".tmp", data, 0o640); err != nil {
+		return err
+	}
+	return os.Rename(path+".tmp", path)
+}
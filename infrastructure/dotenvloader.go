@@ -3,6 +3,7 @@ package infrastructure
 import (
 	"log"
 	"os"
+	"strconv"
 
 	"github.com/joho/godotenv"
 )
@@ -20,3 +21,25 @@ func DotEnvLoader(identifier string) string {
 
 	return result
 }
+
+// DotEnvLoaderDefault reads an optional setting from the environment or .env file,
+// returning fallback when it is not set
+func DotEnvLoaderDefault(identifier, fallback string) string {
+	_ = godotenv.Load()
+	result, exists := os.LookupEnv(identifier)
+	if !exists || result == "" {
+		return fallback
+	}
+
+	return result
+}
+
+// IntSetting reads an optional integer setting, falling back on a missing or bad value
+func IntSetting(identifier string, fallback int) int {
+	value, err := strconv.Atoi(DotEnvLoaderDefault(identifier, strconv.Itoa(fallback)))
+	if err != nil {
+		log.Printf("Invalid %s, using %d", identifier, fallback)
+		return fallback
+	}
+	return value
+}
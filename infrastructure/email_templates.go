@@ -0,0 +1,547 @@
+package infrastructure
+
+import (
+	"bytes"
+	"fmt"
+	htmltemplate "html/template"
+	"sort"
+	"strings"
+	texttemplate "text/template"
+	"time"
+)
+
+// emailTemplate is one language variant of an email. Subject and Text are text templates; HTML
+// is an html/template filling the "content" block of emailLayout, so values are escaped.
+type emailTemplate struct {
+	Subject string
+	Text    string
+	HTML    string
+}
+
+// emailTemplates holds every email kind in each language it is translated into, keyed by kind
+// and then by language. Every kind must have an English variant, the fallback for other locales.
+//
+// Templates are given the data of the builder below plus "Brand" (Name, Color, LogoURL,
+// SupportEmail) from the BRAND_* settings.
+var emailTemplates = map[string]map[string]emailTemplate{
+	"email_verification": {
+		"en": {
+			Subject: "Email Verification",
+			Text: `Click the following link to verify your email:
+{{.URL}}
+
+If you did not sign up for this account, please ignore this email.`,
+			HTML: `<p>Welcome to {{.Brand.Name}}! Please confirm your email address to finish signing up.</p>
+<p>{{button .URL "Verify email"}}</p>
+<p class="muted">If you did not sign up for this account, please ignore this email.</p>`,
+		},
+		"fr": {
+			Subject: "Vérification de votre adresse e-mail",
+			Text: `Cliquez sur le lien suivant pour vérifier votre adresse e-mail :
+{{.URL}}
+
+Si vous n'avez pas créé ce compte, ignorez cet e-mail.`,
+			HTML: `<p>Bienvenue sur {{.Brand.Name}} ! Confirmez votre adresse e-mail pour terminer votre inscription.</p>
+<p>{{button .URL "Vérifier mon adresse"}}</p>
+<p class="muted">Si vous n'avez pas créé ce compte, ignorez cet e-mail.</p>`,
+		},
+	},
+	"password_reset": {
+		"en": {
+			Subject: "Password Reset Request",
+			Text: `Click the following link to reset your password:
+{{.URL}}
+
+If you did not request a password reset, please ignore this email.`,
+			HTML: `<p>We received a request to reset the password of your {{.Brand.Name}} account.</p>
+<p>{{button .URL "Reset password"}}</p>
+<p class="muted">The link is valid for one hour. If you did not request a password reset, please ignore this email.</p>`,
+		},
+		"fr": {
+			Subject: "Réinitialisation de votre mot de passe",
+			Text: `Cliquez sur le lien suivant pour réinitialiser votre mot de passe :
+{{.URL}}
+
+Si vous n'avez pas demandé de réinitialisation, ignorez cet e-mail.`,
+			HTML: `<p>Nous avons reçu une demande de réinitialisation du mot de passe de votre compte {{.Brand.Name}}.</p>
+<p>{{button .URL "Réinitialiser le mot de passe"}}</p>
+<p class="muted">Le lien est valable une heure. Si vous n'avez pas demandé de réinitialisation, ignorez cet e-mail.</p>`,
+		},
+	},
+	"guarantor_invitation": {
+		"en": {
+			Subject: "Guarantor Invitation",
+			Text: `{{.BorrowerName}} has asked you to guarantee {{money .Share}}% of a loan of {{money .Amount}}.
+
+Log in to your account and open your guarantee invitations to accept or decline.
+
+If you do not know this person, please decline the invitation.`,
+			HTML: `<p><strong>{{.BorrowerName}}</strong> has asked you to guarantee <strong>{{money .Share}}%</strong> of a loan of <strong>{{money .Amount}}</strong>.</p>
+<p>Log in to your account and open your guarantee invitations to accept or decline.</p>
+<p class="muted">If you do not know this person, please decline the invitation.</p>`,
+		},
+		"fr": {
+			Subject: "Invitation à vous porter garant",
+			Text: `{{.BorrowerName}} vous demande de garantir {{money .Share}} % d'un prêt de {{money .Amount}}.
+
+Connectez-vous à votre compte et ouvrez vos invitations de garantie pour accepter ou refuser.
+
+Si vous ne connaissez pas cette personne, refusez l'invitation.`,
+			HTML: `<p><strong>{{.BorrowerName}}</strong> vous demande de garantir <strong>{{money .Share}} %</strong> d'un prêt de <strong>{{money .Amount}}</strong>.</p>
+<p>Connectez-vous à votre compte et ouvrez vos invitations de garantie pour accepter ou refuser.</p>
+<p class="muted">Si vous ne connaissez pas cette personne, refusez l'invitation.</p>`,
+		},
+	},
+	"application_expired": {
+		"en": {
+			Subject: "Loan Application Expired",
+			Text: `Your loan application {{.LoanID}} for {{money .Amount}} was not reviewed within {{.SLADays}} days and has expired.
+
+You are welcome to apply again at any time.`,
+			HTML: `<p>Your loan application <strong>{{.LoanID}}</strong> for <strong>{{money .Amount}}</strong> was not reviewed within {{.SLADays}} days and has expired.</p>
+<p>You are welcome to apply again at any time.</p>`,
+		},
+		"fr": {
+			Subject: "Demande de prêt expirée",
+			Text: `Votre demande de prêt {{.LoanID}} de {{money .Amount}} n'a pas été examinée dans un délai de {{.SLADays}} jours et a expiré.
+
+Vous pouvez déposer une nouvelle demande à tout moment.`,
+			HTML: `<p>Votre demande de prêt <strong>{{.LoanID}}</strong> de <strong>{{money .Amount}}</strong> n'a pas été examinée dans un délai de {{.SLADays}} jours et a expiré.</p>
+<p>Vous pouvez déposer une nouvelle demande à tout moment.</p>`,
+		},
+	},
+	"sla_escalation": {
+		"en": {
+			Subject: "Loan Applications Nearing SLA",
+			Text: `The following loan applications are close to breaching their review SLA:
+
+{{.Summary}}`,
+			HTML: `<p>The following loan applications are close to breaching their review SLA:</p>
+<p class="pre">{{.Summary}}</p>`,
+		},
+		"fr": {
+			Subject: "Demandes de prêt proches de leur délai d'examen",
+			Text: `Les demandes de prêt suivantes arrivent bientôt au terme de leur délai d'examen :
+
+{{.Summary}}`,
+			HTML: `<p>Les demandes de prêt suivantes arrivent bientôt au terme de leur délai d'examen :</p>
+<p class="pre">{{.Summary}}</p>`,
+		},
+	},
+	"note_mention": {
+		"en": {
+			Subject: "You were mentioned on a loan",
+			Text: `{{.Author}} mentioned you in an internal note on loan {{.LoanID}}:
+
+{{.Note}}`,
+			HTML: `<p><strong>{{.Author}}</strong> mentioned you in an internal note on loan <strong>{{.LoanID}}</strong>:</p>
+<blockquote class="pre">{{.Note}}</blockquote>`,
+		},
+		"fr": {
+			Subject: "Vous avez été mentionné sur un prêt",
+			Text: `{{.Author}} vous a mentionné dans une note interne sur le prêt {{.LoanID}} :
+
+{{.Note}}`,
+			HTML: `<p><strong>{{.Author}}</strong> vous a mentionné dans une note interne sur le prêt <strong>{{.LoanID}}</strong> :</p>
+<blockquote class="pre">{{.Note}}</blockquote>`,
+		},
+	},
+	"acceptance_code": {
+		"en": {
+			Subject: "Loan Agreement Acceptance Code",
+			Text: `Your code to accept the agreement for loan {{.LoanID}} is:
+
+{{.Code}}
+
+The code is valid for {{.Minutes}} minutes. Entering it counts as your signature on the agreement.
+
+If you did not request this code, please do not share it and contact us.`,
+			HTML: `<p>Your code to accept the agreement for loan <strong>{{.LoanID}}</strong> is:</p>
+<p class="code">{{.Code}}</p>
+<p>The code is valid for {{.Minutes}} minutes. Entering it counts as your signature on the agreement.</p>
+<p class="muted">If you did not request this code, please do not share it and contact us.</p>`,
+		},
+		"fr": {
+			Subject: "Code d'acceptation du contrat de prêt",
+			Text: `Votre code pour accepter le contrat du prêt {{.LoanID}} est :
+
+{{.Code}}
+
+Le code est valable {{.Minutes}} minutes. Le saisir vaut signature du contrat.
+
+Si vous n'avez pas demandé ce code, ne le communiquez à personne et contactez-nous.`,
+			HTML: `<p>Votre code pour accepter le contrat du prêt <strong>{{.LoanID}}</strong> est :</p>
+<p class="code">{{.Code}}</p>
+<p>Le code est valable {{.Minutes}} minutes. Le saisir vaut signature du contrat.</p>
+<p class="muted">Si vous n'avez pas demandé ce code, ne le communiquez à personne et contactez-nous.</p>`,
+		},
+	},
+	"loan_approved": {
+		"en": {
+			Subject: "Your loan has been approved",
+			Text: `Good news: your loan application {{.LoanID}} for {{money .Amount}} has been approved.
+
+The next step is to review and accept your loan agreement, after which the funds will be released.`,
+			HTML: `<p>Good news: your loan application <strong>{{.LoanID}}</strong> for <strong>{{money .Amount}}</strong> has been approved.</p>
+<p>The next step is to review and accept your loan agreement, after which the funds will be released.</p>`,
+		},
+		"fr": {
+			Subject: "Votre prêt a été approuvé",
+			Text: `Bonne nouvelle : votre demande de prêt {{.LoanID}} de {{money .Amount}} a été approuvée.
+
+Il vous reste à consulter et accepter votre contrat de prêt, après quoi les fonds seront versés.`,
+			HTML: `<p>Bonne nouvelle : votre demande de prêt <strong>{{.LoanID}}</strong> de <strong>{{money .Amount}}</strong> a été approuvée.</p>
+<p>Il vous reste à consulter et accepter votre contrat de prêt, après quoi les fonds seront versés.</p>`,
+		},
+	},
+	"loan_rejected": {
+		"en": {
+			Subject: "Your loan application was not approved",
+			Text: `We are sorry: your loan application {{.LoanID}} for {{money .Amount}} was not approved.
+
+You are welcome to apply again at any time.`,
+			HTML: `<p>We are sorry: your loan application <strong>{{.LoanID}}</strong> for <strong>{{money .Amount}}</strong> was not approved.</p>
+<p>You are welcome to apply again at any time.</p>`,
+		},
+		"fr": {
+			Subject: "Votre demande de prêt n'a pas été approuvée",
+			Text: `Nous sommes désolés : votre demande de prêt {{.LoanID}} de {{money .Amount}} n'a pas été approuvée.
+
+Vous pouvez déposer une nouvelle demande à tout moment.`,
+			HTML: `<p>Nous sommes désolés : votre demande de prêt <strong>{{.LoanID}}</strong> de <strong>{{money .Amount}}</strong> n'a pas été approuvée.</p>
+<p>Vous pouvez déposer une nouvelle demande à tout moment.</p>`,
+		},
+	},
+	"loan_disbursed": {
+		"en": {
+			Subject: "Your loan has been disbursed",
+			Text: `{{money .Amount}} from loan {{.LoanID}} has been released to you.
+{{- if .FirstAmount}}
+
+Your first installment of {{money .FirstAmount}} is due on {{date .FirstDueDate}}.{{end}}`,
+			HTML: `<p><strong>{{money .Amount}}</strong> from loan <strong>{{.LoanID}}</strong> has been released to you.</p>
+{{- if .FirstAmount}}
+<p>Your first installment of <strong>{{money .FirstAmount}}</strong> is due on <strong>{{date .FirstDueDate}}</strong>.</p>{{end}}`,
+		},
+		"fr": {
+			Subject: "Votre prêt a été versé",
+			Text: `{{money .Amount}} du prêt {{.LoanID}} vous ont été versés.
+{{- if .FirstAmount}}
+
+Votre première échéance de {{money .FirstAmount}} est due le {{date .FirstDueDate}}.{{end}}`,
+			HTML: `<p><strong>{{money .Amount}}</strong> du prêt <strong>{{.LoanID}}</strong> vous ont été versés.</p>
+{{- if .FirstAmount}}
+<p>Votre première échéance de <strong>{{money .FirstAmount}}</strong> est due le <strong>{{date .FirstDueDate}}</strong>.</p>{{end}}`,
+		},
+	},
+	"loan_closed": {
+		"en": {
+			Subject: "Your loan is closed",
+			Text: `{{if .Refinanced}}Loan {{.LoanID}} has been paid off by your new loan and is now closed.{{else}}Loan {{.LoanID}} has been repaid in full and is now closed.{{end}}
+
+Thank you for borrowing with us.`,
+			HTML: `<p>{{if .Refinanced}}Loan <strong>{{.LoanID}}</strong> has been paid off by your new loan and is now closed.{{else}}Loan <strong>{{.LoanID}}</strong> has been repaid in full and is now closed.{{end}}</p>
+<p>Thank you for borrowing with us.</p>`,
+		},
+		"fr": {
+			Subject: "Votre prêt est clôturé",
+			Text: `{{if .Refinanced}}Le prêt {{.LoanID}} a été soldé par votre nouveau prêt et est désormais clôturé.{{else}}Le prêt {{.LoanID}} a été entièrement remboursé et est désormais clôturé.{{end}}
+
+Merci de nous avoir fait confiance.`,
+			HTML: `<p>{{if .Refinanced}}Le prêt <strong>{{.LoanID}}</strong> a été soldé par votre nouveau prêt et est désormais clôturé.{{else}}Le prêt <strong>{{.LoanID}}</strong> a été entièrement remboursé et est désormais clôturé.{{end}}</p>
+<p>Merci de nous avoir fait confiance.</p>`,
+		},
+	},
+	"payment_reminder": {
+		"en": {
+			Subject: "Payment reminder: installment due {{date .DueDate}}",
+			Text: `Installment {{.Installment}} of loan {{.LoanID}} is due on {{date .DueDate}}.
+
+Amount due: {{money .AmountDue}}
+
+Please make sure the payment reaches us on time.`,
+			HTML: `<p>Installment {{.Installment}} of loan <strong>{{.LoanID}}</strong> is due on <strong>{{date .DueDate}}</strong>.</p>
+<p>Amount due: <strong>{{money .AmountDue}}</strong></p>
+<p class="muted">Please make sure the payment reaches us on time.</p>`,
+		},
+		"fr": {
+			Subject: "Rappel : échéance du {{date .DueDate}}",
+			Text: `L'échéance {{.Installment}} du prêt {{.LoanID}} est due le {{date .DueDate}}.
+
+Montant dû : {{money .AmountDue}}
+
+Merci de veiller à ce que le paiement nous parvienne à temps.`,
+			HTML: `<p>L'échéance {{.Installment}} du prêt <strong>{{.LoanID}}</strong> est due le <strong>{{date .DueDate}}</strong>.</p>
+<p>Montant dû : <strong>{{money .AmountDue}}</strong></p>
+<p class="muted">Merci de veiller à ce que le paiement nous parvienne à temps.</p>`,
+		},
+	},
+	"payment_overdue": {
+		"en": {
+			Subject: "Overdue payment on loan {{.LoanID}}",
+			Text: `Installment {{.Installment}} of loan {{.LoanID}} was due on {{date .DueDate}} and {{money .AmountDue}} is still unpaid.
+
+Please pay as soon as possible. If you have already paid, please ignore this message.`,
+			HTML: `<p>Installment {{.Installment}} of loan <strong>{{.LoanID}}</strong> was due on <strong>{{date .DueDate}}</strong> and <strong>{{money .AmountDue}}</strong> is still unpaid.</p>
+<p>Please pay as soon as possible.</p>
+<p class="muted">If you have already paid, please ignore this message.</p>`,
+		},
+		"fr": {
+			Subject: "Paiement en retard sur le prêt {{.LoanID}}",
+			Text: `L'échéance {{.Installment}} du prêt {{.LoanID}} était due le {{date .DueDate}} et {{money .AmountDue}} restent impayés.
+
+Merci de régler ce montant dès que possible. Si vous avez déjà payé, ignorez ce message.`,
+			HTML: `<p>L'échéance {{.Installment}} du prêt <strong>{{.LoanID}}</strong> était due le <strong>{{date .DueDate}}</strong> et <strong>{{money .AmountDue}}</strong> restent impayés.</p>
+<p>Merci de régler ce montant dès que possible.</p>
+<p class="muted">Si vous avez déjà payé, ignorez ce message.</p>`,
+		},
+	},
+	"payment_receipt": {
+		"en": {
+			Subject: "Payment received",
+			Text: `We received your payment of {{money .Amount}} for loan {{.LoanID}} on {{date .PaidAt}}.
+{{- if .Reference}} Reference: {{.Reference}}.{{end}}
+
+Outstanding balance: {{money .OutstandingBalance}}`,
+			HTML: `<p>We received your payment of <strong>{{money .Amount}}</strong> for loan <strong>{{.LoanID}}</strong> on {{date .PaidAt}}.</p>
+{{- if .Reference}}
+<p class="muted">Reference: {{.Reference}}</p>{{end}}
+<p>Outstanding balance: <strong>{{money .OutstandingBalance}}</strong></p>`,
+		},
+		"fr": {
+			Subject: "Paiement reçu",
+			Text: `Nous avons reçu votre paiement de {{money .Amount}} pour le prêt {{.LoanID}} le {{date .PaidAt}}.
+{{- if .Reference}} Référence : {{.Reference}}.{{end}}
+
+Solde restant dû : {{money .OutstandingBalance}}`,
+			HTML: `<p>Nous avons reçu votre paiement de <strong>{{money .Amount}}</strong> pour le prêt <strong>{{.LoanID}}</strong> le {{date .PaidAt}}.</p>
+{{- if .Reference}}
+<p class="muted">Référence : {{.Reference}}</p>{{end}}
+<p>Solde restant dû : <strong>{{money .OutstandingBalance}}</strong></p>`,
+		},
+	},
+}
+
+// emailFooters closes every HTML email, per language
+var emailFooters = map[string]string{
+	"en": "You are receiving this email because of your account with",
+	"fr": "Vous recevez cet e-mail en raison de votre compte chez",
+}
+
+// emailLayout wraps the HTML of every email. Styles are inline as many mail clients drop <style>.
+const emailLayout = `<!DOCTYPE html>
+<html lang="{{.Locale}}">
+<head><meta charset="utf-8"><meta name="viewport" content="width=device-width"><title>{{.Subject}}</title></head>
+<body style="margin:0;padding:24px;background:#f4f5f7;font-family:Helvetica,Arial,sans-serif;color:#1f2933;">
+<table role="presentation" width="100%" cellpadding="0" cellspacing="0" style="max-width:600px;margin:0 auto;background:#ffffff;border-radius:6px;">
+<tr><td style="padding:20px 32px;border-bottom:4px solid {{.Brand.Color}};">
+{{- if .Brand.LogoURL}}<img src="{{.Brand.LogoURL}}" alt="{{.Brand.Name}}" height="32">{{else}}<strong style="font-size:20px;color:{{.Brand.Color}};">{{.Brand.Name}}</strong>{{end -}}
+</td></tr>
+<tr><td style="padding:24px 32px;font-size:15px;line-height:1.5;">
+{{template "content" .}}
+</td></tr>
+<tr><td style="padding:16px 32px;font-size:12px;color:#7b8794;">
+{{.Footer}} {{.Brand.Name}}.{{if .Brand.SupportEmail}} <a href="mailto:{{.Brand.SupportEmail}}" style="color:#7b8794;">{{.Brand.SupportEmail}}</a>{{end}}
+</td></tr>
+</table>
+</body>
+</html>`
+
+// emailClasses are the inline styles behind the class names templates use, applied on render
+var emailClasses = map[string]string{
+	`class="muted"`: `style="color:#7b8794;font-size:13px;"`,
+	`class="pre"`:   `style="white-space:pre-line;font-family:Menlo,Consolas,monospace;font-size:13px;"`,
+	`class="code"`:  `style="font-size:28px;letter-spacing:6px;font-weight:bold;"`,
+}
+
+// emailSamples is the data used to preview each kind of email; emailSampleLinks adds the link of
+// the kinds that have one, built from the current link settings
+var emailSamples = map[string]map[string]interface{}{
+	"email_verification":   {},
+	"password_reset":       {},
+	"guarantor_invitation": {"BorrowerName": "jane.doe", "Amount": 5000.0, "Share": 50.0},
+	"application_expired":  {"LoanID": "64b7f0c2a1b2c3d4e5f60718", "Amount": 2500.0, "SLADays": 14},
+	"sla_escalation": {"Summary": "- 64b7f0c2a1b2c3d4e5f60718: 2500.00 (PERSONAL), applied 2024-05-01, expires 2024-05-15 09:30\n" +
+		"- 64b7f0c2a1b2c3d4e5f60719: 800.00 (no product), applied 2024-05-02, expires 2024-05-16 14:05"},
+	"note_mention":    {"Author": "officer.smith", "LoanID": "64b7f0c2a1b2c3d4e5f60718", "Note": "@reviewer please check the <income> documents.\nThanks!"},
+	"acceptance_code": {"LoanID": "64b7f0c2a1b2c3d4e5f60718", "Code": "482913", "Minutes": 10},
+	"loan_approved":   {"LoanID": "64b7f0c2a1b2c3d4e5f60718", "Amount": 2500.0},
+	"loan_rejected":   {"LoanID": "64b7f0c2a1b2c3d4e5f60718", "Amount": 2500.0},
+	"loan_disbursed": {"LoanID": "64b7f0c2a1b2c3d4e5f60718", "Amount": 2450.0,
+		"FirstDueDate": time.Date(2024, 7, 1, 0, 0, 0, 0, time.UTC), "FirstAmount": 215.72},
+	"loan_closed":      {"LoanID": "64b7f0c2a1b2c3d4e5f60718", "Refinanced": false},
+	"payment_reminder": {"LoanID": "64b7f0c2a1b2c3d4e5f60718", "Installment": 3, "DueDate": time.Date(2024, 9, 1, 0, 0, 0, 0, time.UTC), "AmountDue": 215.72},
+	"payment_overdue":  {"LoanID": "64b7f0c2a1b2c3d4e5f60718", "Installment": 3, "DueDate": time.Date(2024, 9, 1, 0, 0, 0, 0, time.UTC), "AmountDue": 115.72},
+	"payment_receipt": {"LoanID": "64b7f0c2a1b2c3d4e5f60718", "Amount": 215.72, "PaidAt": time.Date(2024, 8, 30, 10, 15, 0, 0, time.UTC),
+		"Reference": "pay_3f9a2c", "OutstandingBalance": 1868.14},
+}
+
+var emailSampleLinks = map[string]string{
+	"email_verification": RouteVerifyEmail,
+	"password_reset":     RoutePasswordReset,
+}
+
+type emailBrand struct {
+	Name         string
+	Color        string
+	LogoURL      string
+	SupportEmail string
+}
+
+type compiledEmail struct {
+	subject *texttemplate.Template
+	text    *texttemplate.Template
+	html    *htmltemplate.Template
+}
+
+var emailFuncs = map[string]interface{}{
+	"money": func(v float64) string { return fmt.Sprintf("%.2f", v) },
+	"date":  func(t time.Time) string { return t.Format("2006-01-02") },
+	"button": func(url, label string) htmltemplate.HTML {
+		return htmltemplate.HTML(fmt.Sprintf(
+			`<a href="%s" style="display:inline-block;padding:10px 20px;background:%s;color:#ffffff;text-decoration:none;border-radius:4px;">%s</a>`,
+			htmltemplate.HTMLEscapeString(url), htmltemplate.HTMLEscapeString(brand().Color), htmltemplate.HTMLEscapeString(label)))
+	},
+}
+
+// compiledEmails are parsed once at startup, so a broken template stops the server instead of an email
+var compiledEmails = compileEmailTemplates()
+
+func compileEmailTemplates() map[string]compiledEmail {
+	layout := htmltemplate.Must(htmltemplate.New("layout").Funcs(emailFuncs).Parse(emailLayout))
+	compiled := make(map[string]compiledEmail)
+	for kind, variants := range emailTemplates {
+		if _, ok := variants[defaultEmailLocale]; !ok {
+			panic("email template " + kind + " has no " + defaultEmailLocale + " variant")
+		}
+		for locale, tmpl := range variants {
+			name := kind + "/" + locale
+			content := tmpl.HTML
+			for class, style := range emailClasses {
+				content = strings.ReplaceAll(content, class, style)
+			}
+			html := htmltemplate.Must(layout.Clone())
+			htmltemplate.Must(html.New("content").Parse(content))
+			compiled[name] = compiledEmail{
+				subject: texttemplate.Must(texttemplate.New(name).Funcs(emailFuncs).Parse(tmpl.Subject)),
+				text:    texttemplate.Must(texttemplate.New(name).Funcs(emailFuncs).Parse(tmpl.Text)),
+				html:    html,
+			}
+		}
+	}
+	return compiled
+}
+
+// defaultEmailLocale is the language every email kind is written in
+const defaultEmailLocale = "en"
+
+func brand() emailBrand {
+	return emailBrand{
+		Name:         DotEnvLoaderDefault("BRAND_NAME", "Loan Tracker"),
+		Color:        DotEnvLoaderDefault("BRAND_COLOR", "#1f6feb"),
+		LogoURL:      DotEnvLoaderDefault("BRAND_LOGO_URL", ""),
+		SupportEmail: DotEnvLoaderDefault("SUPPORT_EMAIL", ""),
+	}
+}
+
+// DefaultLocale is the language used for users who have not chosen one, DEFAULT_LOCALE or English
+func DefaultLocale() string {
+	locale := strings.ToLower(DotEnvLoaderDefault("DEFAULT_LOCALE", defaultEmailLocale))
+	for _, supported := range SupportedLocales() {
+		if supported == locale {
+			return locale
+		}
+	}
+	return defaultEmailLocale
+}
+
+// SupportedLocales lists the languages emails are available in
+func SupportedLocales() []string {
+	return []string{"en", "fr"}
+}
+
+// MatchLocale picks the first supported language from a locale such as "fr-CA" or an
+// Accept-Language list such as "fr-CH, fr;q=0.9, en;q=0.8", or the default locale.
+func MatchLocale(preferences string) string {
+	for _, part := range strings.Split(preferences, ",") {
+		tag, _, _ := strings.Cut(strings.TrimSpace(part), ";")
+		language, _, _ := strings.Cut(strings.ToLower(tag), "-")
+		language, _, _ = strings.Cut(language, "_")
+		for _, supported := range SupportedLocales() {
+			if supported == language {
+				return language
+			}
+		}
+	}
+	return DefaultLocale()
+}
+
+// renderEmail fills the template of the given kind in the recipient's language, falling back to
+// English when the kind has not been translated into it
+func renderEmail(kind, to, locale string, data map[string]interface{}) (Email, error) {
+	locale = MatchLocale(locale)
+	tmpl, ok := compiledEmails[kind+"/"+locale]
+	if !ok {
+		locale = defaultEmailLocale
+		if tmpl, ok = compiledEmails[kind+"/"+locale]; !ok {
+			return Email{}, fmt.Errorf("no email template %q", kind)
+		}
+	}
+
+	values := map[string]interface{}{
+		"Brand":  brand(),
+		"Locale": locale,
+		"Footer": emailFooters[locale],
+	}
+	for key, value := range data {
+		values[key] = value
+	}
+
+	var subject, text, html bytes.Buffer
+	if err := tmpl.subject.Execute(&subject, values); err != nil {
+		return Email{}, err
+	}
+	values["Subject"] = subject.String()
+	if err := tmpl.text.Execute(&text, values); err != nil {
+		return Email{}, err
+	}
+	if err := tmpl.html.Execute(&html, values); err != nil {
+		return Email{}, err
+	}
+	return Email{
+		To:      to,
+		Kind:    kind,
+		Locale:  locale,
+		Subject: subject.String(),
+		Body:    text.String(),
+		HTML:    html.String(),
+	}, nil
+}
+
+// EmailTemplateLocales lists each email kind with the languages it is available in
+func EmailTemplateLocales() map[string][]string {
+	kinds := make(map[string][]string)
+	for kind, variants := range emailTemplates {
+		for locale := range variants {
+			kinds[kind] = append(kinds[kind], locale)
+		}
+		sort.Strings(kinds[kind])
+	}
+	return kinds
+}
+
+// PreviewEmail renders an email kind in the given language with sample data
+func PreviewEmail(kind, locale string) (Email, error) {
+	sample, ok := emailSamples[kind]
+	if !ok {
+		return Email{}, fmt.Errorf("no email template %q", kind)
+	}
+	if route, ok := emailSampleLinks[kind]; ok {
+		link, err := AppLink(PlatformWeb, route, map[string]string{"token": "sample"})
+		if err != nil {
+			return Email{}, err
+		}
+		sample = map[string]interface{}{"URL": link}
+	}
+	return renderEmail(kind, "preview@example.com", locale, sample)
+}
@@ -1,9 +1,10 @@
 package infrastructure
 
 import (
+	"context"
 	"fmt"
-	"net/url"
 	"strconv"
+	"time"
 
 	"gopkg.in/gomail.v2"
 )
@@ -31,45 +32,96 @@ func NewEmailConfig() (*EmailConfig, error) {
 	}, nil
 }
 
-// EmailService provides methods to send different types of emails.
-type EmailService struct {
+// SMTPMailer delivers email through an SMTP server using gomail.
+type SMTPMailer struct {
 	config *EmailConfig
 }
 
-// NewEmailService initializes and returns a new EmailService instance.
-func NewEmailService(config *EmailConfig) *EmailService {
-	return &EmailService{config: config}
+// NewSMTPMailer initializes and returns a new SMTPMailer instance.
+func NewSMTPMailer(config *EmailConfig) *SMTPMailer {
+	return &SMTPMailer{config: config}
 }
 
-// SendResetEmail sends the password reset email using gomail.
-func (es *EmailService) SendResetEmail(userEmail, resetToken string) error {
-	resetURL := fmt.Sprintf("http://localhost:8080/users/password-reset?token=%s", url.QueryEscape(resetToken))
-	body := fmt.Sprintf(
-		"Click the following link to reset your password:\n%s\n\n"+
-			"If you did not request a password reset, please ignore this email.",
-		resetURL)
+// Email is a message ready to be queued for sending. Kind names the purpose of the message so
+// the outbox can be filtered by it. Body is the plain-text part; HTML, when set, is sent as an
+// alternative for mail clients that display it.
+type Email struct {
+	To      string
+	Kind    string
+	Locale  string
+	Subject string
+	Body    string
+	HTML    string
+}
+
+// ResetEmail builds the password reset email, linking to the reset page of the platform the
+// request came from.
+func ResetEmail(userEmail, locale, platform, resetToken string) (Email, error) {
+	resetURL, err := AppLink(platform, RoutePasswordReset, map[string]string{"token": resetToken})
+	if err != nil {
+		return Email{}, err
+	}
+	return renderEmail("password_reset", userEmail, locale, map[string]interface{}{"URL": resetURL})
+}
 
-	return es.sendEmail(userEmail, "Password Reset Request", body)
+// VerificationEmail builds the email verification email, linking to the verification page of the
+// platform the user registered from.
+func VerificationEmail(userEmail, locale, platform, verificationToken string) (Email, error) {
+	verificationURL, err := AppLink(platform, RouteVerifyEmail, map[string]string{"token": verificationToken})
+	if err != nil {
+		return Email{}, err
+	}
+	return renderEmail("email_verification", userEmail, locale, map[string]interface{}{"URL": verificationURL})
 }
 
-// SendVerificationEmail sends the email verification email using gomail.
-func (es *EmailService) SendVerificationEmail(userEmail, verificationToken string) error {
-	verificationURL := fmt.Sprintf("http://localhost:8080/users/verify-email?token=%s", verificationToken)
-	body := fmt.Sprintf(
-		"Click the following link to verify your email:\n%s\n\n"+
-			"If you did not sign up for this account, please ignore this email.",
-		verificationURL)
+// GuarantorInvitationEmail asks a registered user to stand guarantor for a loan.
+func GuarantorInvitationEmail(guarantorEmail, locale, borrowerName string, amount, share float64) (Email, error) {
+	return renderEmail("guarantor_invitation", guarantorEmail, locale, map[string]interface{}{
+		"BorrowerName": borrowerName,
+		"Amount":       amount,
+		"Share":        share,
+	})
+}
 
-	return es.sendEmail(userEmail, "Email Verification", body)
+// SLAEscalationEmail sends an admin the list of applications that are about to breach their SLA.
+func SLAEscalationEmail(adminEmail, locale, summary string) (Email, error) {
+	return renderEmail("sla_escalation", adminEmail, locale, map[string]interface{}{"Summary": summary})
 }
 
-// sendEmail is a helper method to send an email with the given subject and body.
-func (es *EmailService) sendEmail(toEmail, subject, body string) error {
+// NoteMentionEmail tells an admin they were mentioned in an internal note on a loan.
+func NoteMentionEmail(adminEmail, locale, author, loanID, note string) (Email, error) {
+	return renderEmail("note_mention", adminEmail, locale, map[string]interface{}{
+		"Author": author,
+		"LoanID": loanID,
+		"Note":   note,
+	})
+}
+
+// AcceptanceCodeEmail sends the one-time code a borrower enters to accept their loan agreement.
+func AcceptanceCodeEmail(userEmail, locale, loanID, code string, validFor time.Duration) (Email, error) {
+	return renderEmail("acceptance_code", userEmail, locale, map[string]interface{}{
+		"LoanID":  loanID,
+		"Code":    code,
+		"Minutes": int(validFor.Minutes()),
+	})
+}
+
+// NotificationEmail builds the email of a borrower notification from the template of the given
+// kind, e.g. "loan_approved" or "payment_reminder", filled with data.
+func NotificationEmail(kind, userEmail, locale string, data map[string]interface{}) (Email, error) {
+	return renderEmail(kind, userEmail, locale, data)
+}
+
+// Send delivers an email over SMTP.
+func (es *SMTPMailer) Send(ctx context.Context, email Email) error {
 	m := gomail.NewMessage()
 	m.SetHeader("From", fmt.Sprintf("LOAN TRACKER <%s>", es.config.SenderEmail))
-	m.SetHeader("To", toEmail)
-	m.SetHeader("Subject", subject)
-	m.SetBody("text/plain", body)
+	m.SetHeader("To", email.To)
+	m.SetHeader("Subject", email.Subject)
+	m.SetBody("text/plain", email.Body)
+	if email.HTML != "" {
+		m.AddAlternative("text/html", email.HTML)
+	}
 
 	d := gomail.NewDialer(es.config.SMTPHost, es.config.SMTPPort, es.config.SenderEmail, es.config.SenderPassword)
 
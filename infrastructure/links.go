@@ -0,0 +1,120 @@
+package infrastructure
+
+import (
+	"fmt"
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+// Frontend routes that emails link to
+const (
+	RouteVerifyEmail   = "verify_email"
+	RoutePasswordReset = "password_reset"
+)
+
+// Platforms a link can be built for; mobile links open the app instead of the web frontend
+const (
+	PlatformWeb    = "web"
+	PlatformMobile = "mobile"
+)
+
+// appRoutes are the default frontend routes. Each can be overridden with APP_ROUTE_<NAME> (e.g.
+// APP_ROUTE_PASSWORD_RESET="/auth/reset/{token}") and, for the app only, APP_MOBILE_ROUTE_<NAME>.
+// Parameters in braces are filled in and escaped for the part of the link they sit in.
+var appRoutes = map[string]string{
+	RouteVerifyEmail:   "/users/verify-email?token={token}",
+	RoutePasswordReset: "/users/password-reset?token={token}",
+}
+
+var routeParam = regexp.MustCompile(`\{([a-z_]+)\}`)
+
+// PublicBaseURL is where this API is reachable from outside, PUBLIC_BASE_URL or http://localhost:8080
+func PublicBaseURL() string {
+	return strings.TrimSuffix(DotEnvLoaderDefault("PUBLIC_BASE_URL", "http://localhost:8080"), "/")
+}
+
+// ClientPlatform maps a client's self-description such as "ios" or "android" to a link platform
+func ClientPlatform(client string) string {
+	switch strings.ToLower(strings.TrimSpace(client)) {
+	case PlatformMobile, "ios", "android":
+		return PlatformMobile
+	default:
+		return PlatformWeb
+	}
+}
+
+// AppLink builds the link to a frontend route for a platform. Web links start at APP_WEB_URL (the
+// public base URL by default) and, when APP_WEB_ROUTING is "hash", put the route after "#/" for
+// SPAs using hash routing. Mobile links start at APP_MOBILE_URL, either a custom scheme such as
+// "loantracker://" or a universal link; without one, mobile users get the web link.
+func AppLink(platform, route string, params map[string]string) (string, error) {
+	pattern, ok := appRoutes[route]
+	if !ok {
+		return "", fmt.Errorf("unknown app route %q", route)
+	}
+	setting := strings.ToUpper(route)
+	pattern = DotEnvLoaderDefault("APP_ROUTE_"+setting, pattern)
+
+	base := DotEnvLoaderDefault("APP_WEB_URL", PublicBaseURL())
+	hashRouting := strings.EqualFold(DotEnvLoaderDefault("APP_WEB_ROUTING", "path"), "hash")
+	if mobile := DotEnvLoaderDefault("APP_MOBILE_URL", ""); platform == PlatformMobile && mobile != "" {
+		base = mobile
+		pattern = DotEnvLoaderDefault("APP_MOBILE_ROUTE_"+setting, pattern)
+		hashRouting = false
+	}
+	if parsed, err := url.Parse(base); err != nil || parsed.Scheme == "" {
+		return "", fmt.Errorf("invalid app base URL %q", base)
+	}
+
+	path, query, hasQuery := strings.Cut(pattern, "?")
+	path, err := fillRoute(path, params, url.PathEscape)
+	if err != nil {
+		return "", fmt.Errorf("app route %s: %w", route, err)
+	}
+	if hasQuery {
+		if query, err = fillRoute(query, params, url.QueryEscape); err != nil {
+			return "", fmt.Errorf("app route %s: %w", route, err)
+		}
+		path += "?" + query
+	}
+
+	base = strings.TrimSuffix(base, "/")
+	path = "/" + strings.TrimPrefix(path, "/")
+	if hashRouting {
+		return base + "/#" + path, nil
+	}
+	return base + path, nil
+}
+
+// CheckAppLinks builds every route for every platform so a bad link setting stops startup
+func CheckAppLinks() error {
+	for route, pattern := range appRoutes {
+		params := make(map[string]string)
+		for _, match := range routeParam.FindAllStringSubmatch(pattern, -1) {
+			params[match[1]] = "check"
+		}
+		for _, platform := range []string{PlatformWeb, PlatformMobile} {
+			if _, err := AppLink(platform, route, params); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func fillRoute(pattern string, params map[string]string, escape func(string) string) (string, error) {
+	var missing string
+	filled := routeParam.ReplaceAllStringFunc(pattern, func(placeholder string) string {
+		name := strings.Trim(placeholder, "{}")
+		value, ok := params[name]
+		if !ok {
+			missing = name
+		}
+		return escape(value)
+	})
+	if missing != "" {
+		return "", fmt.Errorf("no value for {%s}", missing)
+	}
+	return filled, nil
+}
@@ -0,0 +1,37 @@
+package infrastructure
+
+import (
+	"context"
+	"fmt"
+)
+
+// Mailer delivers composed emails. The email outbox worker is its only caller, so a Mailer does
+// not need to retry on its own.
+type Mailer interface {
+	Send(ctx context.Context, email Email) error
+}
+
+// mailers builds each mailer MAILER can select from its settings; register new mailers here.
+// The memory mailer is left out on purpose: it would silently drop every email in production.
+var mailers = map[string]func() (Mailer, error){
+	"smtp": func() (Mailer, error) {
+		config, err := NewEmailConfig()
+		if err != nil {
+			return nil, err
+		}
+		return NewSMTPMailer(config), nil
+	},
+	"file": func() (Mailer, error) {
+		return NewFileMailer(DotEnvLoaderDefault("MAIL_DIR", "mail"), DotEnvLoaderDefault("MAIL_FROM", "no-reply@loan-tracker.local"))
+	},
+}
+
+// NewMailer returns the mailer named by MAILER, SMTP by default.
+func NewMailer() (Mailer, error) {
+	name := DotEnvLoaderDefault("MAILER", "smtp")
+	build, ok := mailers[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown mailer %q", name)
+	}
+	return build()
+}
@@ -0,0 +1,85 @@
+package infrastructure
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/textproto"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// FileMailer is a sink for local development. Each email is written as a complete message to a
+// maildir at dir (new messages land in <dir>/new), which most mail clients can open directly.
+type FileMailer struct {
+	dir  string
+	from string
+}
+
+// NewFileMailer creates the maildir under dir if it does not exist yet
+func NewFileMailer(dir, from string) (*FileMailer, error) {
+	for _, sub := range []string{"tmp", "new", "cur"} {
+		if err := os.MkdirAll(filepath.Join(dir, sub), 0o750); err != nil {
+			return nil, err
+		}
+	}
+	return &FileMailer{dir: dir, from: from}, nil
+}
+
+func (m *FileMailer) Send(ctx context.Context, email Email) error {
+	id, err := randomID("")
+	if err != nil {
+		return err
+	}
+	now := time.Now()
+
+	var msg bytes.Buffer
+	fmt.Fprintf(&msg, "From: LOAN TRACKER <%s>\r\n", m.from)
+	fmt.Fprintf(&msg, "To: %s\r\n", email.To)
+	fmt.Fprintf(&msg, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", email.Subject))
+	fmt.Fprintf(&msg, "Date: %s\r\n", now.Format(time.RFC1123Z))
+	fmt.Fprintf(&msg, "Message-ID: <%s@loan-tracker>\r\n", id)
+	fmt.Fprintf(&msg, "X-Loan-Tracker-Kind: %s\r\n", email.Kind)
+	msg.WriteString("MIME-Version: 1.0\r\n")
+	if email.HTML == "" {
+		msg.WriteString("Content-Type: text/plain; charset=utf-8\r\n")
+		msg.WriteString("Content-Transfer-Encoding: 8bit\r\n\r\n")
+		msg.WriteString(crlf(email.Body))
+	} else {
+		parts := multipart.NewWriter(&msg)
+		fmt.Fprintf(&msg, "Content-Type: multipart/alternative; boundary=%s\r\n\r\n", parts.Boundary())
+		for _, part := range []struct{ contentType, content string }{{"text/plain", email.Body}, {"text/html", email.HTML}} {
+			w, err := parts.CreatePart(textproto.MIMEHeader{
+				"Content-Type":              {part.contentType + "; charset=utf-8"},
+				"Content-Transfer-Encoding": {"8bit"},
+			})
+			if err != nil {
+				return err
+			}
+			if _, err := io.WriteString(w, crlf(part.content)); err != nil {
+				return err
+			}
+		}
+		if err := parts.Close(); err != nil {
+			return err
+		}
+	}
+
+	// Maildir delivery: write under tmp, then move into new so readers never see a partial file
+	name := fmt.Sprintf("%d.%s.loan-tracker", now.UnixNano(), id)
+	tmp := filepath.Join(m.dir, "tmp", name)
+	if err := os.WriteFile(tmp, msg.Bytes(), 0o640); err != nil {
+		return err
+	}
+	return os.Rename(tmp, filepath.Join(m.dir, "new", name))
+}
+
+// crlf converts line endings to the CRLF that mail requires
+func crlf(text string) string {
+	return strings.ReplaceAll(strings.ReplaceAll(text, "\r\n", "\n"), "\n", "\r\n") + "\r\n"
+}
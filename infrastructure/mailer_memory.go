@@ -0,0 +1,63 @@
+package infrastructure
+
+import (
+	"context"
+	"sync"
+)
+
+// MemoryMailer keeps sent emails in memory instead of delivering them, so tests can assert on
+// the verification, reset and other emails an action produced. It is for tests only and cannot be
+// selected through MAILER.
+type MemoryMailer struct {
+	mu   sync.Mutex
+	sent []Email
+	err  error
+}
+
+func NewMemoryMailer() *MemoryMailer {
+	return &MemoryMailer{}
+}
+
+func (m *MemoryMailer) Send(ctx context.Context, email Email) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.err != nil {
+		return m.err
+	}
+	m.sent = append(m.sent, email)
+	return nil
+}
+
+// Sent returns every email captured so far, oldest first.
+func (m *MemoryMailer) Sent() []Email {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return append([]Email(nil), m.sent...)
+}
+
+// SentTo returns the emails captured for one address, oldest first.
+func (m *MemoryMailer) SentTo(address string) []Email {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var emails []Email
+	for _, email := range m.sent {
+		if email.To == address {
+			emails = append(emails, email)
+		}
+	}
+	return emails
+}
+
+// FailWith makes every following Send return err, or succeed again when err is nil.
+func (m *MemoryMailer) FailWith(err error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.err = err
+}
+
+// Reset forgets the captured emails.
+func (m *MemoryMailer) Reset() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.sent = nil
+}
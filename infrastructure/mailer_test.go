@@ -0,0 +1,10 @@
+package infrastructure
+
+import "testing"
+
+func TestNewMailerRejectsMemoryMailer(t *testing.T) {
+	t.Setenv("MAILER", "memory")
+	if mailer, err := NewMailer(); err == nil {
+		t.Errorf("MAILER=memory selected %T, want an error", mailer)
+	}
+}
@@ -97,6 +97,7 @@ func AuthMiddleware(client *mongo.Client) gin.HandlerFunc {
 		}
 
 		c.Set("isadmin", user.IsAdmin)
+		c.Set("role", user.Role)
 		c.Set("userid", user.ID.Hex())
 		log.Println(c.GetString("userid"), claims.UserID)
 
@@ -116,3 +117,15 @@ func AdminMiddleware() gin.HandlerFunc {
 		c.Next()
 	}
 }
+
+// SupervisorMiddleware only lets admins with the supervisor role through
+func SupervisorMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !c.GetBool("isadmin") || c.GetString("role") != "supervisor" {
+			c.JSON(403, gin.H{"error": "Forbidden: You don't have supervisor privileges"})
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
@@ -0,0 +1,57 @@
+package middleware
+
+import (
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+type rateWindow struct {
+	start time.Time
+	count int
+}
+
+// RateLimitMiddleware allows each client IP at most limit requests per window.
+// Counters are kept in memory, so the limit applies per running instance.
+func RateLimitMiddleware(limit int, window time.Duration) gin.HandlerFunc {
+	var mu sync.Mutex
+	clients := make(map[string]*rateWindow)
+	lastSweep := time.Now()
+
+	return func(c *gin.Context) {
+		now := time.Now()
+		ip := c.ClientIP()
+
+		mu.Lock()
+		// Drop expired windows now and then so idle clients don't accumulate
+		if now.Sub(lastSweep) > window {
+			for key, w := range clients {
+				if now.Sub(w.start) > window {
+					delete(clients, key)
+				}
+			}
+			lastSweep = now
+		}
+
+		w, ok := clients[ip]
+		if !ok || now.Sub(w.start) > window {
+			w = &rateWindow{start: now}
+			clients[ip] = w
+		}
+		w.count++
+		count, reset := w.count, w.start.Add(window)
+		mu.Unlock()
+
+		c.Header("X-RateLimit-Limit", strconv.Itoa(limit))
+		if count > limit {
+			c.Header("Retry-After", strconv.Itoa(int(time.Until(reset).Seconds())+1))
+			c.JSON(429, gin.H{"error": "Too many requests, please try again later"})
+			c.Abort()
+			return
+		}
+		c.Header("X-RateLimit-Remaining", strconv.Itoa(limit-count))
+		c.Next()
+	}
+}
@@ -0,0 +1,51 @@
+package infrastructure
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// FileNotificationProvider is a stand-in for an SMS or push gateway during development. Every
+// message is appended as one JSON line to the file at path, e.g. messages/sms.jsonl.
+type FileNotificationProvider struct {
+	mu   sync.Mutex
+	path string
+}
+
+// NewFileNotificationProvider creates the directory of path if it does not exist yet
+func NewFileNotificationProvider(path string) (*FileNotificationProvider, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0o750); err != nil {
+		return nil, err
+	}
+	return &FileNotificationProvider{path: path}, nil
+}
+
+func (p *FileNotificationProvider) Name() string {
+	return "file"
+}
+
+func (p *FileNotificationProvider) Send(ctx context.Context, message Message) error {
+	line, err := json.Marshal(struct {
+		Message
+		SentAt time.Time `json:"sent_at"`
+	}{message, time.Now()})
+	if err != nil {
+		return err
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	f, err := os.OpenFile(p.path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o640)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(append(line, '\n')); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
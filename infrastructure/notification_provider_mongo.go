@@ -0,0 +1,30 @@
+package infrastructure
+
+import (
+	"context"
+	"time"
+
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// MongoNotificationProvider is a stand-in for an SMS or push gateway on shared environments. It
+// stores every message in a collection, where testers can look up the codes and alerts sent.
+type MongoNotificationProvider struct {
+	collection *mongo.Collection
+}
+
+func NewMongoNotificationProvider(collection *mongo.Collection) *MongoNotificationProvider {
+	return &MongoNotificationProvider{collection: collection}
+}
+
+func (p *MongoNotificationProvider) Name() string {
+	return "mongo"
+}
+
+func (p *MongoNotificationProvider) Send(ctx context.Context, message Message) error {
+	_, err := p.collection.InsertOne(ctx, struct {
+		Message `bson:",inline"`
+		SentAt  time.Time `bson:"sent_at"`
+	}{message, time.Now()})
+	return err
+}
@@ -0,0 +1,75 @@
+package infrastructure
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// NotificationProvider delivers short messages on one channel, text messages for "sms" or
+// notifications to a device for "push". Unlike email there is no outbox behind it: a failed Send
+// is reported straight back so the caller can fall back to another channel.
+type NotificationProvider interface {
+	Name() string
+	Send(ctx context.Context, message Message) error
+}
+
+// Message is one SMS or push notification.
+type Message struct {
+	Channel string            `json:"channel" bson:"channel"`
+	To      string            `json:"to" bson:"to"` // an E.164 phone number for SMS, a device token for push
+	Kind    string            `json:"kind" bson:"kind"`
+	Title   string            `json:"title,omitempty" bson:"title,omitempty"` // push only
+	Body    string            `json:"body" bson:"body"`
+	Data    map[string]string `json:"data,omitempty" bson:"data,omitempty"` // push payload for the app, e.g. the loan id
+}
+
+// notificationProviders builds each supported provider for a channel from its settings; register
+// real SMS and push gateways here.
+var notificationProviders = map[string]func(channel string, client *mongo.Client) (NotificationProvider, error){
+	"file": func(channel string, client *mongo.Client) (NotificationProvider, error) {
+		return NewFileNotificationProvider(filepath.Join(DotEnvLoaderDefault("MESSAGE_DIR", "messages"), channel+".jsonl"))
+	},
+	"mongo": func(channel string, client *mongo.Client) (NotificationProvider, error) {
+		return NewMongoNotificationProvider(client.Database("loan-tracker").Collection("sent_messages")), nil
+	},
+}
+
+// NewNotificationProvider returns the provider of a channel named by SMS_PROVIDER or
+// PUSH_PROVIDER, or nil when it is not set and the channel is disabled. There is no default, so
+// messages are never left in the file or mongo stand-ins by accident.
+func NewNotificationProvider(channel string, client *mongo.Client) (NotificationProvider, error) {
+	name := DotEnvLoaderDefault(strings.ToUpper(channel)+"_PROVIDER", "")
+	if name == "" {
+		return nil, nil
+	}
+	build, ok := notificationProviders[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown %s provider %q", channel, name)
+	}
+	return build(channel, client)
+}
+
+// phoneCodeTexts is the text message carrying a phone verification code, per language
+var phoneCodeTexts = map[string]string{
+	"en": "%s: your verification code is %s. It expires in %d minutes.",
+	"fr": "%s : votre code de vérification est %s. Il expire dans %d minutes.",
+}
+
+// PhoneVerificationSMS builds the text message that confirms a phone number belongs to the user.
+func PhoneVerificationSMS(phone, locale, code string, validFor time.Duration) Message {
+	text, ok := phoneCodeTexts[MatchLocale(locale)]
+	if !ok {
+		text = phoneCodeTexts[defaultEmailLocale]
+	}
+	return Message{
+		Channel: "sms",
+		To:      phone,
+		Kind:    "phone_verification",
+		Body:    fmt.Sprintf(text, brand().Name, code, int(validFor.Minutes())),
+	}
+}
@@ -0,0 +1,18 @@
+package infrastructure
+
+import "testing"
+
+func TestNewNotificationProviderIsDisabledWhenUnset(t *testing.T) {
+	t.Setenv("SMS_PROVIDER", "")
+	provider, err := NewNotificationProvider("sms", nil)
+	if err != nil || provider != nil {
+		t.Errorf("NewNotificationProvider(sms) = %v, %v, want no provider and no error", provider, err)
+	}
+}
+
+func TestNewNotificationProviderRejectsUnknownNames(t *testing.T) {
+	t.Setenv("PUSH_PROVIDER", "fcm-typo")
+	if provider, err := NewNotificationProvider("push", nil); err == nil {
+		t.Errorf("PUSH_PROVIDER=fcm-typo selected %T, want an error", provider)
+	}
+}
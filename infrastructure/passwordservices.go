@@ -25,21 +25,13 @@ func PasswordHasher(password string) (string, error) {
 	return string(hash), nil
 }
 
-// Handels forgot password
-func ForgotPasswordHandler(email string) error {
+// EmailToken generates a token, valid for 1 hour, proving control of the email address. It is
+// sent in verification and password reset links and checked by VerifyToken.
+func EmailToken(email string) string {
 	secretKey := DotEnvLoader("Reset_Password")
 
-	// Generate a token valid for 1 hour
 	hashedEmail = sha256.Sum256([]byte(email))
-
-	emailConfig, _ := NewEmailConfig()
-	emailserv := NewEmailService(emailConfig)
-
-	token := passwordreset.NewToken(email, time.Hour*1, hashedEmail[:], []byte(secretKey))
-	if err := emailserv.SendResetEmail(email, token); err != nil {
-		return err
-	}
-	return nil
+	return passwordreset.NewToken(email, time.Hour*1, hashedEmail[:], []byte(secretKey))
 }
 
 func VerifyToken(token string) (string, error) {
@@ -105,17 +97,3 @@ func VerifyToken(token string) (string, error) {
 
 // 	return nil
 // }
-
-func UserVerification(email string) error {
-	secretKey := DotEnvLoader("Reset_Password")
-
-	// Generate a token valid for 1 hour
-	hashedEmail = sha256.Sum256([]byte(email))
-	emailConfig, _ := NewEmailConfig()
-	emailserv := NewEmailService(emailConfig)
-	token := passwordreset.NewToken(email, time.Hour*1, hashedEmail[:], []byte(secretKey))
-	if err := emailserv.SendVerificationEmail(email, token); err != nil {
-		return err
-	}
-	return nil
-}
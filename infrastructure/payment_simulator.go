@@ -0,0 +1,115 @@
+package infrastructure
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	simulatorSignatureHeader = "X-Simulator-Signature"
+	// webhookTolerance rejects replays of old signed webhooks
+	webhookTolerance = 5 * time.Minute
+)
+
+// SimulatorProvider is an offline stand-in for a payment gateway. A checkout is completed by
+// calling SimulatePayment, which produces the same signed webhook a real provider would send.
+type SimulatorProvider struct {
+	secret      []byte
+	checkoutURL string
+}
+
+func NewSimulatorProvider(secret, checkoutURL string) *SimulatorProvider {
+	return &SimulatorProvider{secret: []byte(secret), checkoutURL: strings.TrimSuffix(checkoutURL, "/")}
+}
+
+func (p *SimulatorProvider) Name() string {
+	return "simulator"
+}
+
+func (p *SimulatorProvider) CreateCheckout(ctx context.Context, req CheckoutRequest) (Checkout, error) {
+	if req.Amount <= 0 {
+		return Checkout{}, errors.New("amount must be greater than zero")
+	}
+	ref, err := randomID("sim_")
+	if err != nil {
+		return Checkout{}, err
+	}
+	return Checkout{
+		ProviderReference: ref,
+		CheckoutURL:       p.checkoutURL + "/" + ref,
+		ExpiresAt:         time.Now().Add(time.Hour),
+	}, nil
+}
+
+// ParseWebhook checks the "t=<unix time>,v1=<hex HMAC-SHA256 of t.body>" signature header
+func (p *SimulatorProvider) ParseWebhook(header http.Header, body []byte) (PaymentEvent, error) {
+	if err := VerifySignature(p.secret, header.Get(simulatorSignatureHeader), body, webhookTolerance); err != nil {
+		return PaymentEvent{}, err
+	}
+
+	var event PaymentEvent
+	if err := json.Unmarshal(body, &event); err != nil {
+		return PaymentEvent{}, fmt.Errorf("invalid webhook body: %w", err)
+	}
+	return event, nil
+}
+
+// SimulatePayment settles a checkout with the given outcome ("succeeded" or "failed") and
+// returns the signed webhook the simulator would deliver for it.
+func (p *SimulatorProvider) SimulatePayment(providerReference, reference string, amount int64, currency, outcome string) (http.Header, []byte, error) {
+	event := PaymentEvent{
+		ProviderReference: providerReference,
+		Reference:         reference,
+		Status:            outcome,
+		Amount:            amount,
+		Currency:          currency,
+		OccurredAt:        time.Now().UTC(),
+	}
+	switch outcome {
+	case "succeeded":
+	case "failed":
+		event.FailureReason = "card_declined"
+	default:
+		return nil, nil, errors.New("invalid outcome, you can only enter succeeded or failed")
+	}
+	var err error
+	if event.EventID, err = randomID("evt_"); err != nil {
+		return nil, nil, err
+	}
+	body, err := json.Marshal(event)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+	header := http.Header{}
+	header.Set("Content-Type", "application/json")
+	header.Set(simulatorSignatureHeader, "t="+timestamp+",v1="+SignPayload(p.secret, timestamp, body))
+	return header, body, nil
+}
+
+// SignPayload returns the hex HMAC-SHA256 of "timestamp.body"
+func SignPayload(secret []byte, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(timestamp + "."))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+func randomID(prefix string) (string, error) {
+	b := make([]byte, 12)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return prefix + hex.EncodeToString(b), nil
+}
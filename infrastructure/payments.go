@@ -0,0 +1,84 @@
+package infrastructure
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// PaymentProvider is an online payment gateway. Amounts are in minor units (cents) so
+// no rounding happens on the way to or from the provider.
+type PaymentProvider interface {
+	Name() string
+	// CreateCheckout starts a payment and returns where to send the payer to complete it.
+	CreateCheckout(ctx context.Context, req CheckoutRequest) (Checkout, error)
+	// ParseWebhook verifies a callback's signature and decodes the payment outcome it reports.
+	ParseWebhook(header http.Header, body []byte) (PaymentEvent, error)
+}
+
+type CheckoutRequest struct {
+	Reference     string // our payment id, echoed back in webhooks
+	Amount        int64
+	Currency      string
+	Description   string
+	CustomerEmail string
+}
+
+type Checkout struct {
+	ProviderReference string
+	CheckoutURL       string
+	ExpiresAt         time.Time
+}
+
+// PaymentEvent is a provider's report that a payment succeeded or failed.
+type PaymentEvent struct {
+	EventID           string    `json:"id"`
+	ProviderReference string    `json:"provider_reference"`
+	Reference         string    `json:"reference"`
+	Status            string    `json:"status"` // "succeeded" or "failed"
+	Amount            int64     `json:"amount"`
+	Currency          string    `json:"currency"`
+	FailureReason     string    `json:"failure_reason,omitempty"`
+	OccurredAt        time.Time `json:"occurred_at"`
+}
+
+// ErrInvalidSignature is returned for webhooks that were not signed by the provider.
+var ErrInvalidSignature = errors.New("invalid webhook signature")
+
+// ErrPaymentsDisabled is returned for online payment requests when no PAYMENT_PROVIDER is set.
+var ErrPaymentsDisabled = errors.New("online payments are not enabled")
+
+// defaultSimulatorSecret is the secret the simulator once shipped with. Anyone could sign
+// webhooks with it, so it is refused.
+const defaultSimulatorSecret = "simulator-secret"
+
+// paymentProviders builds each supported gateway from its settings; register new providers here.
+var paymentProviders = map[string]func() (PaymentProvider, error){
+	"simulator": func() (PaymentProvider, error) {
+		secret := DotEnvLoaderDefault("PAYMENT_SIMULATOR_SECRET", "")
+		if len(secret) < 16 || secret == defaultSimulatorSecret {
+			return nil, errors.New("PAYMENT_SIMULATOR_SECRET must be set to a secret of at least 16 characters to use the payment simulator")
+		}
+		return NewSimulatorProvider(
+			secret,
+			DotEnvLoaderDefault("PAYMENT_SIMULATOR_URL", PublicBaseURL()+"/admin/payments/simulator"),
+		), nil
+	},
+}
+
+// NewPaymentProvider returns the gateway named by PAYMENT_PROVIDER, or nil when it is not set and
+// online payments are disabled. There is no default, so the simulator, which settles checkouts
+// without collecting money, is never used by accident.
+func NewPaymentProvider() (PaymentProvider, error) {
+	name := DotEnvLoaderDefault("PAYMENT_PROVIDER", "")
+	if name == "" {
+		return nil, nil
+	}
+	build, ok := paymentProviders[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown payment provider %q", name)
+	}
+	return build()
+}
@@ -0,0 +1,18 @@
+package infrastructure
+
+import "testing"
+
+func TestNewPaymentProviderIsDisabledWhenUnset(t *testing.T) {
+	t.Setenv("PAYMENT_PROVIDER", "")
+	provider, err := NewPaymentProvider()
+	if err != nil || provider != nil {
+		t.Errorf("NewPaymentProvider() = %v, %v, want no provider and no error", provider, err)
+	}
+}
+
+func TestNewPaymentProviderRejectsUnknownNames(t *testing.T) {
+	t.Setenv("PAYMENT_PROVIDER", "stripe-typo")
+	if provider, err := NewPaymentProvider(); err == nil {
+		t.Errorf("PAYMENT_PROVIDER=stripe-typo selected %T, want an error", provider)
+	}
+}
@@ -0,0 +1,166 @@
+package infrastructure
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+)
+
+// RenderPDF lays out a line-oriented document on A4 pages using the standard PDF fonts,
+// so no font files or external tools are needed. Each line of text may start with a marker:
+//
+//	"# "  heading
+//	"## " subheading
+//	"| "  monospaced row, for tables
+//
+// Any other line is body text; long lines are wrapped and empty lines add spacing.
+func RenderPDF(title, text string) []byte {
+	pages := layoutPDF(text)
+
+	var buf bytes.Buffer
+	var offsets []int
+	object := func(body string) {
+		offsets = append(offsets, buf.Len())
+		fmt.Fprintf(&buf, "%d 0 obj\n%s\nendobj\n", len(offsets), body)
+	}
+
+	buf.WriteString("%PDF-1.4\n%\xe2\xe3\xcf\xd3\n")
+	// Objects 1-5 are fixed; every page then takes a page object and a content stream
+	kids := make([]string, len(pages))
+	for i := range pages {
+		kids[i] = fmt.Sprintf("%d 0 R", 6+2*i)
+	}
+	object("<< /Type /Catalog /Pages 2 0 R >>")
+	object(fmt.Sprintf("<< /Type /Pages /Kids [%s] /Count %d >>", strings.Join(kids, " "), len(pages)))
+	object("<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica /Encoding /WinAnsiEncoding >>")
+	object("<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica-Bold /Encoding /WinAnsiEncoding >>")
+	object("<< /Type /Font /Subtype /Type1 /BaseFont /Courier /Encoding /WinAnsiEncoding >>")
+	for i, page := range pages {
+		footer := fmt.Sprintf("BT /F1 8 Tf %d %d Td (%s) Tj ET\n", pdfMargin, pdfMargin/2,
+			pdfEscape(fmt.Sprintf("%s - page %d of %d", title, i+1, len(pages))))
+		content := page + footer
+		object(fmt.Sprintf("<< /Type /Page /Parent 2 0 R /MediaBox [0 0 %d %d] "+
+			"/Resources << /Font << /F1 3 0 R /F2 4 0 R /F3 5 0 R >> >> /Contents %d 0 R >>",
+			pdfPageWidth, pdfPageHeight, 7+2*i))
+		object(fmt.Sprintf("<< /Length %d >>\nstream\n%sendstream", len(content), content))
+	}
+	object(fmt.Sprintf("<< /Title (%s) /Producer (Loan Tracker) >>", pdfEscape(title)))
+
+	xref := buf.Len()
+	fmt.Fprintf(&buf, "xref\n0 %d\n0000000000 65535 f \n", len(offsets)+1)
+	for _, offset := range offsets {
+		fmt.Fprintf(&buf, "%010d 00000 n \n", offset)
+	}
+	fmt.Fprintf(&buf, "trailer\n<< /Size %d /Root 1 0 R /Info %d 0 R >>\nstartxref\n%d\n%%%%EOF\n",
+		len(offsets)+1, len(offsets), xref)
+	return buf.Bytes()
+}
+
+const (
+	pdfPageWidth  = 595
+	pdfPageHeight = 842
+	pdfMargin     = 50
+)
+
+type pdfStyle struct {
+	font      string
+	size      float64
+	leading   float64
+	charWidth float64 // average glyph width as a fraction of the size, used for wrapping
+}
+
+var (
+	pdfHeading    = pdfStyle{"F2", 15, 24, 0.6}
+	pdfSubheading = pdfStyle{"F2", 11, 18, 0.6}
+	pdfBody       = pdfStyle{"F1", 10, 14, 0.5}
+	pdfMono       = pdfStyle{"F3", 8.5, 11, 0.6}
+)
+
+// layoutPDF turns the marked-up text into one content stream per page
+func layoutPDF(text string) []string {
+	var pages []string
+	var page strings.Builder
+	y := float64(pdfPageHeight - pdfMargin)
+	newPage := func() {
+		pages = append(pages, page.String())
+		page.Reset()
+		y = pdfPageHeight - pdfMargin
+	}
+
+	for _, line := range strings.Split(strings.ReplaceAll(text, "\r\n", "\n"), "\n") {
+		style := pdfBody
+		switch {
+		case strings.HasPrefix(line, "## "):
+			style, line = pdfSubheading, line[3:]
+		case strings.HasPrefix(line, "# "):
+			style, line = pdfHeading, line[2:]
+		case strings.HasPrefix(line, "| "):
+			style, line = pdfMono, line[2:]
+		}
+		if strings.TrimSpace(line) == "" {
+			y -= pdfBody.leading / 2
+			continue
+		}
+
+		width := int((pdfPageWidth - 2*pdfMargin) / (style.size * style.charWidth))
+		for _, part := range wrapPDFLine(line, width) {
+			if y-style.leading < pdfMargin {
+				newPage()
+			}
+			y -= style.leading
+			fmt.Fprintf(&page, "BT /%s %.1f Tf %d %.1f Td (%s) Tj ET\n", style.font, style.size, pdfMargin, y, pdfEscape(part))
+		}
+	}
+	if page.Len() > 0 || len(pages) == 0 {
+		newPage()
+	}
+	return pages
+}
+
+// wrapPDFLine breaks a line at spaces so no part is longer than width characters
+func wrapPDFLine(line string, width int) []string {
+	if width <= 0 || len([]rune(line)) <= width {
+		return []string{line}
+	}
+	var parts []string
+	var current string
+	for _, word := range strings.Split(line, " ") {
+		for len([]rune(word)) > width {
+			if current != "" {
+				parts = append(parts, current)
+				current = ""
+			}
+			parts = append(parts, string([]rune(word)[:width]))
+			word = string([]rune(word)[width:])
+		}
+		switch {
+		case current == "":
+			current = word
+		case len([]rune(current))+1+len([]rune(word)) <= width:
+			current += " " + word
+		default:
+			parts = append(parts, current)
+			current = word
+		}
+	}
+	return append(parts, current)
+}
+
+// pdfEscape encodes text as a WinAnsi PDF string body; characters outside Latin-1 become "?"
+func pdfEscape(s string) string {
+	var b strings.Builder
+	for _, r := range s {
+		switch {
+		case r == '(' || r == ')' || r == '\\':
+			b.WriteByte('\\')
+			b.WriteRune(r)
+		case r >= 0x20 && r < 0x7f:
+			b.WriteRune(r)
+		case r >= 0xa0 && r <= 0xff:
+			fmt.Fprintf(&b, "\\%03o", r)
+		default:
+			b.WriteByte('?')
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,56 @@
+package infrastructure
+
+import (
+	"context"
+	"log"
+	"strings"
+	"time"
+)
+
+// RunPeriodically runs job every interval in the background until ctx is cancelled.
+// Errors are logged so a failing run does not stop later ones.
+func RunPeriodically(ctx context.Context, name string, interval time.Duration, job func(context.Context) error) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				if err := job(ctx); err != nil {
+					log.Printf("Scheduled job %s failed: %v", name, err)
+				}
+			}
+		}
+	}()
+}
+
+// IntervalSetting reads a duration such as "1h" or "30m" from the environment, falling back on a bad value
+func IntervalSetting(identifier string, fallback time.Duration) time.Duration {
+	interval, err := time.ParseDuration(DotEnvLoaderDefault(identifier, fallback.String()))
+	if err != nil || interval <= 0 {
+		log.Printf("Invalid %s, using %s", identifier, fallback)
+		return fallback
+	}
+	return interval
+}
+
+// IntervalListSetting reads a comma-separated list of durations such as "72h,120h", falling back on a bad value
+func IntervalListSetting(identifier string, fallback []time.Duration) []time.Duration {
+	value := DotEnvLoaderDefault(identifier, "")
+	if value == "" {
+		return fallback
+	}
+	var intervals []time.Duration
+	for _, part := range strings.Split(value, ",") {
+		interval, err := time.ParseDuration(strings.TrimSpace(part))
+		if err != nil || interval <= 0 {
+			log.Printf("Invalid %s, using %v", identifier, fallback)
+			return fallback
+		}
+		intervals = append(intervals, interval)
+	}
+	return intervals
+}
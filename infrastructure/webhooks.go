@@ -0,0 +1,93 @@
+package infrastructure
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Headers sent with every outgoing webhook. The signature has the same
+// "t=<unix time>,v1=<hex HMAC-SHA256 of t.body>" form the payment simulator uses.
+const (
+	WebhookSignatureHeader = "X-Webhook-Signature"
+	WebhookEventHeader     = "X-Webhook-Event"
+	WebhookDeliveryHeader  = "X-Webhook-Delivery"
+)
+
+// WebhookSender posts signed webhooks to subscribers.
+type WebhookSender struct {
+	client *http.Client
+}
+
+// NewWebhookSender sends through the given client, or through one that gives up after
+// WEBHOOK_TIMEOUT (10s by default) when client is nil.
+func NewWebhookSender(client *http.Client) *WebhookSender {
+	if client == nil {
+		client = &http.Client{Timeout: IntervalSetting("WEBHOOK_TIMEOUT", 10*time.Second)}
+	}
+	return &WebhookSender{client: client}
+}
+
+// Send posts the body and returns the response status code. Any status outside 2xx is an error;
+// the code is 0 when no response was received.
+func (s *WebhookSender) Send(ctx context.Context, url, secret, eventType, deliveryID string, body []byte) (int, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return 0, err
+	}
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "loan-tracker-webhooks")
+	req.Header.Set(WebhookEventHeader, eventType)
+	req.Header.Set(WebhookDeliveryHeader, deliveryID)
+	req.Header.Set(WebhookSignatureHeader, "t="+timestamp+",v1="+SignPayload([]byte(secret), timestamp, body))
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	snippet, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return resp.StatusCode, fmt.Errorf("receiver responded %s: %s", resp.Status, strings.TrimSpace(string(snippet)))
+	}
+	return resp.StatusCode, nil
+}
+
+// VerifySignature checks a "t=<unix time>,v1=<signature>" header against the body, rejecting
+// signatures older than the tolerance. Receivers of our webhooks can use it as a reference.
+func VerifySignature(secret []byte, header string, body []byte, tolerance time.Duration) error {
+	var timestamp, signature string
+	for _, part := range strings.Split(header, ",") {
+		if key, value, ok := strings.Cut(part, "="); ok {
+			switch key {
+			case "t":
+				timestamp = value
+			case "v1":
+				signature = value
+			}
+		}
+	}
+	unix, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil || signature == "" {
+		return ErrInvalidSignature
+	}
+	if age := time.Since(time.Unix(unix, 0)); age > tolerance || age < -tolerance {
+		return ErrInvalidSignature
+	}
+	if !hmac.Equal([]byte(signature), []byte(SignPayload(secret, timestamp, body))) {
+		return ErrInvalidSignature
+	}
+	return nil
+}
+
+// NewWebhookSecret generates a signing secret for a webhook subscription
+func NewWebhookSecret() (string, error) {
+	return randomID("whsec_")
+}
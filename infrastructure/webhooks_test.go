@@ -0,0 +1,74 @@
+package infrastructure
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestWebhookSenderSignsBody(t *testing.T) {
+	var header http.Header
+	var body []byte
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		header = r.Header.Clone()
+		body, _ = io.ReadAll(r.Body)
+	}))
+	defer server.Close()
+
+	payload := []byte(`{"id":"1","type":"loan.approved"}`)
+	status, err := NewWebhookSender(server.Client()).Send(context.Background(), server.URL, "whsec_test", "loan.approved", "delivery-1", payload)
+	if err != nil {
+		t.Fatalf("Send: %v", err)
+	}
+	if status != http.StatusOK {
+		t.Errorf("status = %d, want %d", status, http.StatusOK)
+	}
+	if string(body) != string(payload) {
+		t.Errorf("body = %s, want %s", body, payload)
+	}
+	if got := header.Get(WebhookEventHeader); got != "loan.approved" {
+		t.Errorf("%s = %q, want loan.approved", WebhookEventHeader, got)
+	}
+	if got := header.Get(WebhookDeliveryHeader); got != "delivery-1" {
+		t.Errorf("%s = %q, want delivery-1", WebhookDeliveryHeader, got)
+	}
+
+	signature := header.Get(WebhookSignatureHeader)
+	if err := VerifySignature([]byte("whsec_test"), signature, body, time.Minute); err != nil {
+		t.Errorf("signature %q does not verify: %v", signature, err)
+	}
+	if err := VerifySignature([]byte("another secret"), signature, body, time.Minute); err != ErrInvalidSignature {
+		t.Errorf("signature verified with the wrong secret, got %v", err)
+	}
+	if err := VerifySignature([]byte("whsec_test"), signature, []byte(`{"tampered":true}`), time.Minute); err != ErrInvalidSignature {
+		t.Errorf("signature verified for a different body, got %v", err)
+	}
+}
+
+func TestWebhookSenderReportsServerErrors(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "down for maintenance", http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	status, err := NewWebhookSender(server.Client()).Send(context.Background(), server.URL, "whsec_test", "loan.approved", "delivery-1", []byte(`{}`))
+	if err == nil {
+		t.Fatal("Send succeeded on a 503 response")
+	}
+	if status != http.StatusServiceUnavailable {
+		t.Errorf("status = %d, want %d", status, http.StatusServiceUnavailable)
+	}
+}
+
+func TestVerifySignatureRejectsOldTimestamps(t *testing.T) {
+	body := []byte(`{}`)
+	stamp := strconv.FormatInt(time.Now().Add(-time.Hour).Unix(), 10)
+	header := "t=" + stamp + ",v1=" + SignPayload([]byte("whsec_test"), stamp, body)
+	if err := VerifySignature([]byte("whsec_test"), header, body, 5*time.Minute); err != ErrInvalidSignature {
+		t.Errorf("VerifySignature accepted a signature from an hour ago, got %v", err)
+	}
+}
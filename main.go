@@ -1,11 +1,15 @@
 package main
 
 import (
+	"context"
 	"loan-tracker/deliveries/controllers"
 	"loan-tracker/deliveries/router"
+	"loan-tracker/domain"
 	"loan-tracker/infrastructure"
 	"loan-tracker/repositories"
 	"loan-tracker/usecase"
+	"log"
+	"time"
 
 	"github.com/gin-gonic/gin"
 )
@@ -17,15 +21,154 @@ func main() {
 	logUsecase := usecase.NewLogUsecase(logRepo)
 	LogController := controllers.NewLogController(logUsecase)
 
+	outboxRepo := repositories.NewOutboxRepository(client)
+	transactor, err := repositories.NewTransactor(client, infrastructure.DotEnvLoaderDefault("MONGODB_ALLOW_STANDALONE", "false") == "true")
+	if err != nil {
+		log.Fatal(err)
+	}
+	eventBus := usecase.NewEventBus(outboxRepo)
+	eventBus.Subscribe("system_log", logUsecase.RecordEvent)
+	EventController := controllers.NewEventController(eventBus)
+
+	webhookRepo := repositories.NewWebhookRepository(client)
+	webhookUsecase := usecase.NewWebhookUsecase(webhookRepo, outboxRepo, transactor, infrastructure.NewWebhookSender(nil))
+	eventBus.Subscribe("webhooks", webhookUsecase.HandleEvent, domain.EventTypes...)
+	WebhookController := controllers.NewWebhookController(webhookUsecase)
+
+	mailer, err := infrastructure.NewMailer()
+	if err != nil {
+		log.Fatal(err)
+	}
+	if err := infrastructure.CheckAppLinks(); err != nil {
+		log.Fatal(err)
+	}
+	emailRepo := repositories.NewEmailRepository(client)
+	emailUsecase := usecase.NewEmailUsecase(emailRepo, outboxRepo, transactor, mailer)
+	EmailController := controllers.NewEmailController(emailUsecase)
+
+	guaranteeRepo := repositories.NewGuaranteeRepository(client)
+
 	userRepo := repositories.NewUserRepository(client)
-	userUsecase := usecase.NewUserUsecase(userRepo)
-	UserController := controllers.NewUserController(userUsecase, logUsecase)
+	userUsecase := usecase.NewUserUsecase(userRepo, guaranteeRepo, outboxRepo, emailRepo, transactor)
+	UserController := controllers.NewUserController(userUsecase)
+
+	smsProvider, err := infrastructure.NewNotificationProvider(domain.ChannelSMS, client)
+	if err != nil {
+		log.Fatal(err)
+	}
+	pushProvider, err := infrastructure.NewNotificationProvider(domain.ChannelPush, client)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if smsProvider == nil {
+		log.Println("SMS_PROVIDER is not set, text messages are disabled")
+	}
+	if pushProvider == nil {
+		log.Println("PUSH_PROVIDER is not set, push notifications are disabled")
+	}
+	contactUsecase := usecase.NewContactUsecase(userRepo, smsProvider)
+	ContactController := controllers.NewContactController(contactUsecase)
+
+	kycUsecase := usecase.NewKYCUsecase(userRepo, outboxRepo)
+	KYCController := controllers.NewKYCController(kycUsecase)
+
+	productRepo := repositories.NewProductRepository(client)
+	productUsecase := usecase.NewProductUsecase(productRepo, outboxRepo, transactor)
+	ProductController := controllers.NewProductController(productUsecase)
+
+	calculatorUsecase := usecase.NewCalculatorUsecase(productRepo)
+	CalculatorController := controllers.NewCalculatorController(calculatorUsecase)
+
+	creditLineRepo := repositories.NewCreditLineRepository(client)
+	creditLineUsecase := usecase.NewCreditLineUsecase(creditLineRepo, outboxRepo, transactor)
+	CreditLineController := controllers.NewCreditLineController(creditLineUsecase)
 
 	loanRepo := repositories.NewLoanRepository(client)
-	loanUsecase := usecase.NewLoanUsecase(loanRepo)
-	LoanController := controllers.NewLoanController(loanUsecase, logUsecase)
+	repaymentRepo := repositories.NewRepaymentRepository(client)
+	loanHistoryRepo := repositories.NewLoanHistoryRepository(client)
+	loanDocumentRepo := repositories.NewLoanDocumentRepository(client)
+	acceptanceRepo := repositories.NewAcceptanceRepository(client)
+	loanUsecase := usecase.NewLoanUsecase(loanRepo, productRepo, guaranteeRepo, userRepo, creditLineRepo, repaymentRepo, loanHistoryRepo,
+		loanDocumentRepo, acceptanceRepo, outboxRepo, emailRepo, transactor)
+	LoanController := controllers.NewLoanController(loanUsecase)
+
+	repaymentUsecase := usecase.NewRepaymentUsecase(repaymentRepo, loanRepo, guaranteeRepo, loanHistoryRepo, outboxRepo, transactor)
+	RepaymentController := controllers.NewRepaymentController(repaymentUsecase)
+
+	paymentProvider, err := infrastructure.NewPaymentProvider()
+	if err != nil {
+		log.Fatal(err)
+	}
+	if paymentProvider == nil {
+		log.Println("PAYMENT_PROVIDER is not set, online payments are disabled")
+	}
+	paymentRepo := repositories.NewPaymentRepository(client)
+	paymentUsecase := usecase.NewPaymentUsecase(paymentRepo, loanRepo, repaymentRepo, guaranteeRepo, userRepo, loanHistoryRepo,
+		outboxRepo, transactor, paymentProvider)
+	PaymentController := controllers.NewPaymentController(paymentUsecase)
+
+	reconciliationRepo := repositories.NewReconciliationRepository(client)
+	reconciliationUsecase := usecase.NewReconciliationUsecase(reconciliationRepo, loanRepo, repaymentRepo, guaranteeRepo, userRepo, loanHistoryRepo,
+		outboxRepo, transactor)
+	ReconciliationController := controllers.NewReconciliationController(reconciliationUsecase)
+
+	debitProvider, err := infrastructure.NewDebitProvider()
+	if err != nil {
+		log.Fatal(err)
+	}
+	mandateRepo := repositories.NewMandateRepository(client)
+	mandateUsecase := usecase.NewMandateUsecase(mandateRepo, loanRepo, repaymentRepo, guaranteeRepo, loanHistoryRepo, outboxRepo, transactor, debitProvider)
+	MandateController := controllers.NewMandateController(mandateUsecase)
+
+	loanNoteRepo := repositories.NewLoanNoteRepository(client)
+	loanNoteUsecase := usecase.NewLoanNoteUsecase(loanNoteRepo, loanRepo, userRepo, emailRepo)
+	LoanNoteController := controllers.NewLoanNoteController(loanNoteUsecase)
+
+	assignmentUsecase := usecase.NewAssignmentUsecase(loanRepo, userRepo, loanHistoryRepo, outboxRepo)
+	AssignmentController := controllers.NewAssignmentController(assignmentUsecase)
+
+	reportRepo := repositories.NewReportRepository(client)
+	reportUsecase := usecase.NewReportUsecase(reportRepo)
+	ReportController := controllers.NewReportController(reportUsecase)
+
+	exportUsecase := usecase.NewExportUsecase(loanRepo, repaymentRepo, userRepo, logRepo, acceptanceRepo, outboxRepo)
+	ExportController := controllers.NewExportController(exportUsecase)
+
+	documentUsecase := usecase.NewDocumentUsecase(loanDocumentRepo, loanRepo, repaymentRepo, userRepo, productRepo, guaranteeRepo, loanHistoryRepo)
+	DocumentController := controllers.NewDocumentController(documentUsecase)
+
+	acceptanceUsecase := usecase.NewAcceptanceUsecase(acceptanceRepo, loanDocumentRepo, loanRepo, userRepo, loanHistoryRepo, emailRepo)
+	AcceptanceController := controllers.NewAcceptanceController(acceptanceUsecase, loanUsecase)
+
+	notificationRepo := repositories.NewNotificationRepository(client)
+	notificationUsecase := usecase.NewNotificationUsecase(notificationRepo, loanRepo, userRepo, emailRepo, transactor, smsProvider, pushProvider)
+	eventBus.Subscribe("notifications", notificationUsecase.HandleEvent,
+		domain.EventLoanApproved, domain.EventLoanRejected, domain.EventLoanExpired, domain.EventLoanDisbursed,
+		domain.EventLoanClosed, domain.EventRepaymentReceived)
+	NotificationController := controllers.NewNotificationController(notificationUsecase)
+
+	guaranteeUsecase := usecase.NewGuaranteeUsecase(guaranteeRepo, loanRepo, userRepo, loanHistoryRepo, emailRepo, outboxRepo, transactor)
+	GuaranteeController := controllers.NewGuaranteeController(guaranteeUsecase)
+
+	jobs := context.Background()
+	infrastructure.RunPeriodically(jobs, "event_dispatch",
+		infrastructure.IntervalSetting("EVENT_DISPATCH_INTERVAL", 5*time.Second), eventBus.Dispatch)
+	infrastructure.RunPeriodically(jobs, "webhook_deliveries",
+		infrastructure.IntervalSetting("WEBHOOK_DELIVERY_INTERVAL", 10*time.Second), webhookUsecase.DeliverWebhooks)
+	infrastructure.RunPeriodically(jobs, "email_outbox",
+		infrastructure.IntervalSetting("EMAIL_SEND_INTERVAL", 10*time.Second), emailUsecase.SendQueuedEmails)
+	infrastructure.RunPeriodically(jobs, "expire_stale_applications",
+		infrastructure.IntervalSetting("EXPIRY_JOB_INTERVAL", time.Hour), loanUsecase.ExpireStaleApplications)
+	infrastructure.RunPeriodically(jobs, "sla_escalation",
+		infrastructure.IntervalSetting("SLA_ESCALATION_INTERVAL", 24*time.Hour), loanUsecase.EscalateApplicationsNearSLA)
+	infrastructure.RunPeriodically(jobs, "monthly_statements",
+		infrastructure.IntervalSetting("STATEMENT_JOB_INTERVAL", 24*time.Hour), documentUsecase.GenerateMonthlyStatements)
+	infrastructure.RunPeriodically(jobs, "direct_debit_collections",
+		infrastructure.IntervalSetting("DEBIT_COLLECTION_INTERVAL", time.Hour), mandateUsecase.RunCollections)
+	infrastructure.RunPeriodically(jobs, "payment_reminders",
+		infrastructure.IntervalSetting("PAYMENT_REMINDER_INTERVAL", time.Hour), notificationUsecase.SendPaymentReminders)
 
 	route := gin.Default()
-	router.SetRouter(route, *UserController, *LoanController, *LogController, client)
+	router.SetRouter(route, *UserController, *LoanController, *LogController, *ProductController, *GuaranteeController, *KYCController, *CreditLineController, *RepaymentController, *CalculatorController, *LoanNoteController, *AssignmentController, *ReportController, *ExportController, *DocumentController, *AcceptanceController, *PaymentController, *ReconciliationController, *MandateController, *EventController, *WebhookController, *EmailController, *NotificationController, *ContactController, client)
 	route.Run()
 }
@@ -0,0 +1,103 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"loan-tracker/domain"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type acceptanceRepository struct {
+	db *mongo.Collection
+}
+
+func NewAcceptanceRepository(db *mongo.Client) domain.AcceptanceRepository {
+	return &acceptanceRepository{
+		db: db.Database("loan-tracker").Collection("agreement_acceptances"),
+	}
+}
+
+func (r *acceptanceRepository) CreateAcceptance(ctx context.Context, acceptance domain.AgreementAcceptance) (primitive.ObjectID, error) {
+	result, err := r.db.InsertOne(ctx, acceptance)
+	if err != nil {
+		return primitive.NilObjectID, err
+	}
+	return result.InsertedID.(primitive.ObjectID), nil
+}
+
+func (r *acceptanceRepository) GetPendingAcceptance(ctx context.Context, loanID primitive.ObjectID) (domain.AgreementAcceptance, error) {
+	var acceptance domain.AgreementAcceptance
+	opts := options.FindOne().SetSort(bson.M{"code_sent_at": -1})
+	err := r.db.FindOne(ctx, bson.M{"loan_id": loanID, "status": "pending"}, opts).Decode(&acceptance)
+	return acceptance, err
+}
+
+func (r *acceptanceRepository) RecordFailedAttempt(ctx context.Context, id primitive.ObjectID) error {
+	_, err := r.db.UpdateOne(ctx, bson.M{"_id": id, "status": "pending"}, bson.M{"$inc": bson.M{"attempts": 1}})
+	return err
+}
+
+func (r *acceptanceRepository) ConfirmAcceptance(ctx context.Context, acceptance domain.AgreementAcceptance) error {
+	update := bson.M{"$set": bson.M{
+		"status":      "accepted",
+		"accepted_at": acceptance.AcceptedAt,
+		"ip_address":  acceptance.IPAddress,
+		"user_agent":  acceptance.UserAgent,
+	}}
+	result, err := r.db.UpdateOne(ctx, bson.M{"_id": acceptance.ID, "status": "pending"}, update)
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return errors.New("this code has already been used")
+	}
+	return nil
+}
+
+func (r *acceptanceRepository) GetAcceptedAgreement(ctx context.Context, loanID primitive.ObjectID, checksum string) (domain.AgreementAcceptance, error) {
+	var acceptance domain.AgreementAcceptance
+	filter := bson.M{"loan_id": loanID, "agreement_checksum": checksum, "status": "accepted"}
+	err := r.db.FindOne(ctx, filter).Decode(&acceptance)
+	return acceptance, err
+}
+
+func (r *acceptanceRepository) GetAcceptancesByLoan(ctx context.Context, loanID primitive.ObjectID) ([]domain.AgreementAcceptance, error) {
+	var acceptances []domain.AgreementAcceptance
+	opts := options.Find().SetSort(bson.M{"accepted_at": -1})
+	cursor, err := r.db.Find(ctx, bson.M{"loan_id": loanID, "status": "accepted"}, opts)
+	if err != nil {
+		return nil, err
+	}
+	err = cursor.All(ctx, &acceptances)
+	return acceptances, err
+}
+
+func (r *acceptanceRepository) StreamAcceptances(ctx context.Context, filter domain.AcceptanceFilter, fn func(domain.AgreementAcceptance) error) error {
+	query := bson.M{"status": "accepted"}
+	if !filter.LoanID.IsZero() {
+		query["loan_id"] = filter.LoanID
+	}
+	if between := timeRange(filter.From, filter.To); between != nil {
+		query["accepted_at"] = between
+	}
+	cursor, err := r.db.Find(ctx, query, options.Find().SetSort(bson.M{"accepted_at": -1}))
+	if err != nil {
+		return err
+	}
+	defer cursor.Close(ctx)
+
+	for cursor.Next(ctx) {
+		var acceptance domain.AgreementAcceptance
+		if err := cursor.Decode(&acceptance); err != nil {
+			return err
+		}
+		if err := fn(acceptance); err != nil {
+			return err
+		}
+	}
+	return cursor.Err()
+}
@@ -0,0 +1,87 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"loan-tracker/domain"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type creditLineRepository struct {
+	db           *mongo.Collection
+	transactions *mongo.Collection
+}
+
+func NewCreditLineRepository(db *mongo.Client) domain.CreditLineRepository {
+	return &creditLineRepository{
+		db:           db.Database("loan-tracker").Collection("credit_lines"),
+		transactions: db.Database("loan-tracker").Collection("credit_line_transactions"),
+	}
+}
+
+func (r *creditLineRepository) CreateCreditLine(ctx context.Context, line domain.CreditLine) (primitive.ObjectID, error) {
+	result, err := r.db.InsertOne(ctx, line)
+	if err != nil {
+		return primitive.NilObjectID, err
+	}
+	return result.InsertedID.(primitive.ObjectID), nil
+}
+
+func (r *creditLineRepository) GetCreditLineByID(ctx context.Context, id primitive.ObjectID) (domain.CreditLine, error) {
+	var line domain.CreditLine
+	err := r.db.FindOne(ctx, bson.M{"_id": id}).Decode(&line)
+	return line, err
+}
+
+func (r *creditLineRepository) GetCreditLinesByUser(ctx context.Context, userID primitive.ObjectID) ([]domain.CreditLine, error) {
+	var lines []domain.CreditLine
+	cursor, err := r.db.Find(ctx, bson.M{"user_id": userID})
+	if err != nil {
+		return nil, err
+	}
+	err = cursor.All(ctx, &lines)
+	return lines, err
+}
+
+func (r *creditLineRepository) GetAllCreditLines(ctx context.Context) ([]domain.CreditLine, error) {
+	var lines []domain.CreditLine
+	cursor, err := r.db.Find(ctx, bson.M{})
+	if err != nil {
+		return nil, err
+	}
+	err = cursor.All(ctx, &lines)
+	return lines, err
+}
+
+func (r *creditLineRepository) UpdateCreditLine(ctx context.Context, line domain.CreditLine, lastUpdated time.Time) error {
+	filter := bson.M{"_id": line.ID, "updated_at": lastUpdated}
+	result, err := r.db.ReplaceOne(ctx, filter, line)
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return errors.New("credit line was modified by another request, please retry")
+	}
+	return nil
+}
+
+func (r *creditLineRepository) CreateTransaction(ctx context.Context, tx domain.CreditLineTransaction) error {
+	_, err := r.transactions.InsertOne(ctx, tx)
+	return err
+}
+
+func (r *creditLineRepository) GetTransactions(ctx context.Context, creditLineID primitive.ObjectID) ([]domain.CreditLineTransaction, error) {
+	var txs []domain.CreditLineTransaction
+	opts := options.Find().SetSort(bson.M{"created_at": 1})
+	cursor, err := r.transactions.Find(ctx, bson.M{"credit_line_id": creditLineID}, opts)
+	if err != nil {
+		return nil, err
+	}
+	err = cursor.All(ctx, &txs)
+	return txs, err
+}
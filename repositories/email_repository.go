@@ -0,0 +1,82 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"loan-tracker/domain"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type emailRepository struct {
+	db *mongo.Collection
+}
+
+func NewEmailRepository(db *mongo.Client) domain.EmailRepository {
+	return &emailRepository{
+		db: db.Database("loan-tracker").Collection("email_outbox"),
+	}
+}
+
+func (r *emailRepository) QueueEmail(ctx context.Context, message domain.EmailMessage) error {
+	_, err := r.db.InsertOne(ctx, message)
+	return err
+}
+
+func (r *emailRepository) GetEmailByID(ctx context.Context, id primitive.ObjectID) (domain.EmailMessage, error) {
+	var message domain.EmailMessage
+	err := r.db.FindOne(ctx, bson.M{"_id": id}).Decode(&message)
+	return message, err
+}
+
+func (r *emailRepository) FindEmails(ctx context.Context, filter domain.EmailFilter) ([]domain.EmailMessage, error) {
+	query := bson.M{}
+	if filter.To != "" {
+		query["to"] = filter.To
+	}
+	if filter.Kind != "" {
+		query["kind"] = filter.Kind
+	}
+	if filter.Status != "" {
+		query["status"] = filter.Status
+	}
+	return r.findEmails(ctx, query, options.Find().SetSort(bson.M{"created_at": -1}).SetLimit(500))
+}
+
+func (r *emailRepository) GetDueEmails(ctx context.Context, now time.Time, limit int) ([]domain.EmailMessage, error) {
+	return r.findEmails(ctx,
+		bson.M{"status": bson.M{"$in": []string{"queued", "retrying"}}, "next_attempt_at": bson.M{"$lte": now}},
+		options.Find().SetSort(bson.D{{Key: "created_at", Value: 1}, {Key: "_id", Value: 1}}).SetLimit(int64(limit)))
+}
+
+func (r *emailRepository) ClaimEmail(ctx context.Context, message domain.EmailMessage, until time.Time) error {
+	result, err := r.db.UpdateOne(ctx,
+		bson.M{"_id": message.ID, "status": message.Status, "next_attempt_at": message.NextAttemptAt},
+		bson.M{"$set": bson.M{"next_attempt_at": until}})
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return errors.New("email has been claimed by another worker")
+	}
+	return nil
+}
+
+func (r *emailRepository) UpdateEmail(ctx context.Context, message domain.EmailMessage) error {
+	_, err := r.db.ReplaceOne(ctx, bson.M{"_id": message.ID}, message)
+	return err
+}
+
+func (r *emailRepository) findEmails(ctx context.Context, query bson.M, opts *options.FindOptions) ([]domain.EmailMessage, error) {
+	var messages []domain.EmailMessage
+	cursor, err := r.db.Find(ctx, query, opts)
+	if err != nil {
+		return nil, err
+	}
+	err = cursor.All(ctx, &messages)
+	return messages, err
+}
@@ -0,0 +1,84 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"loan-tracker/domain"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+type guaranteeRepository struct {
+	db *mongo.Collection
+}
+
+func NewGuaranteeRepository(db *mongo.Client) domain.GuaranteeRepository {
+	return &guaranteeRepository{
+		db: db.Database("loan-tracker").Collection("guarantees"),
+	}
+}
+
+func (r *guaranteeRepository) CreateGuarantee(ctx context.Context, guarantee domain.Guarantee) (primitive.ObjectID, error) {
+	result, err := r.db.InsertOne(ctx, guarantee)
+	if err != nil {
+		return primitive.NilObjectID, err
+	}
+	return result.InsertedID.(primitive.ObjectID), nil
+}
+
+func (r *guaranteeRepository) GetGuaranteeByID(ctx context.Context, id primitive.ObjectID) (domain.Guarantee, error) {
+	var guarantee domain.Guarantee
+	err := r.db.FindOne(ctx, bson.M{"_id": id}).Decode(&guarantee)
+	return guarantee, err
+}
+
+func (r *guaranteeRepository) GetGuaranteesByLoan(ctx context.Context, loanID primitive.ObjectID) ([]domain.Guarantee, error) {
+	return r.find(ctx, bson.M{"loan_id": loanID})
+}
+
+func (r *guaranteeRepository) GetGuaranteesByGuarantor(ctx context.Context, guarantorID primitive.ObjectID) ([]domain.Guarantee, error) {
+	return r.find(ctx, bson.M{"guarantor_id": guarantorID})
+}
+
+func (r *guaranteeRepository) UpdateGuaranteeStatus(ctx context.Context, id primitive.ObjectID, status string) error {
+	update := bson.M{"$set": bson.M{"status": status, "responded_at": time.Now()}}
+	result, err := r.db.UpdateOne(ctx, bson.M{"_id": id, "status": "pending"}, update)
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return errors.New("this guarantee is no longer awaiting an answer")
+	}
+	return nil
+}
+
+// ReleaseLoanGuarantees frees every guarantor still bound to the given loan.
+func (r *guaranteeRepository) ReleaseLoanGuarantees(ctx context.Context, loanID primitive.ObjectID) error {
+	filter := bson.M{"loan_id": loanID, "status": bson.M{"$in": []string{"pending", "accepted"}}}
+	_, err := r.db.UpdateMany(ctx, filter, bson.M{"$set": bson.M{"status": "released"}})
+	return err
+}
+
+func (r *guaranteeRepository) ResetLoanGuarantees(ctx context.Context, loanID primitive.ObjectID, amount float64) error {
+	filter := bson.M{"loan_id": loanID, "status": bson.M{"$in": []string{"pending", "accepted"}}}
+	update := mongo.Pipeline{{{Key: "$set", Value: bson.M{
+		"status":           "pending",
+		"liability_amount": bson.M{"$divide": bson.A{bson.M{"$multiply": bson.A{"$liability_share", amount}}, 100}},
+		"invited_at":       time.Now(),
+	}}}}
+	_, err := r.db.UpdateMany(ctx, filter, update)
+	return err
+}
+
+func (r *guaranteeRepository) find(ctx context.Context, filter bson.M) ([]domain.Guarantee, error) {
+	var guarantees []domain.Guarantee
+	cursor, err := r.db.Find(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+	err = cursor.All(ctx, &guarantees)
+	return guarantees, err
+}
@@ -0,0 +1,59 @@
+package repositories
+
+import (
+	"context"
+	"loan-tracker/domain"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type loanDocumentRepository struct {
+	db *mongo.Collection
+}
+
+func NewLoanDocumentRepository(db *mongo.Client) domain.LoanDocumentRepository {
+	return &loanDocumentRepository{
+		db: db.Database("loan-tracker").Collection("loan_documents"),
+	}
+}
+
+func (r *loanDocumentRepository) CreateDocument(ctx context.Context, doc domain.LoanDocument) (primitive.ObjectID, error) {
+	result, err := r.db.InsertOne(ctx, doc)
+	if err != nil {
+		return primitive.NilObjectID, err
+	}
+	return result.InsertedID.(primitive.ObjectID), nil
+}
+
+func (r *loanDocumentRepository) GetDocumentsByLoan(ctx context.Context, loanID primitive.ObjectID) ([]domain.LoanDocument, error) {
+	var docs []domain.LoanDocument
+	opts := options.Find().SetSort(bson.M{"generated_at": -1}).SetProjection(bson.M{"content": 0})
+	cursor, err := r.db.Find(ctx, bson.M{"loan_id": loanID}, opts)
+	if err != nil {
+		return nil, err
+	}
+	err = cursor.All(ctx, &docs)
+	return docs, err
+}
+
+func (r *loanDocumentRepository) GetDocumentByID(ctx context.Context, id primitive.ObjectID) (domain.LoanDocument, error) {
+	var doc domain.LoanDocument
+	err := r.db.FindOne(ctx, bson.M{"_id": id}).Decode(&doc)
+	return doc, err
+}
+
+func (r *loanDocumentRepository) GetLatestDocument(ctx context.Context, loanID primitive.ObjectID, kind string) (domain.LoanDocument, error) {
+	var doc domain.LoanDocument
+	opts := options.FindOne().SetSort(bson.M{"generated_at": -1}).SetProjection(bson.M{"content": 0})
+	err := r.db.FindOne(ctx, bson.M{"loan_id": loanID, "kind": kind}, opts).Decode(&doc)
+	return doc, err
+}
+
+func (r *loanDocumentRepository) StatementExists(ctx context.Context, loanID primitive.ObjectID, periodFrom time.Time) (bool, error) {
+	count, err := r.db.CountDocuments(ctx, bson.M{"loan_id": loanID, "kind": "statement", "period_from": periodFrom})
+	return count > 0, err
+}
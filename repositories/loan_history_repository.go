@@ -0,0 +1,37 @@
+package repositories
+
+import (
+	"context"
+	"loan-tracker/domain"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type loanHistoryRepository struct {
+	db *mongo.Collection
+}
+
+func NewLoanHistoryRepository(db *mongo.Client) domain.LoanHistoryRepository {
+	return &loanHistoryRepository{
+		db: db.Database("loan-tracker").Collection("loan_history"),
+	}
+}
+
+func (r *loanHistoryRepository) AppendEvent(ctx context.Context, event domain.LoanEvent) error {
+	_, err := r.db.InsertOne(ctx, event)
+	return err
+}
+
+func (r *loanHistoryRepository) GetEventsByLoan(ctx context.Context, loanID primitive.ObjectID) ([]domain.LoanEvent, error) {
+	var events []domain.LoanEvent
+	opts := options.Find().SetSort(bson.D{{Key: "timestamp", Value: 1}, {Key: "_id", Value: 1}})
+	cursor, err := r.db.Find(ctx, bson.M{"loan_id": loanID}, opts)
+	if err != nil {
+		return nil, err
+	}
+	err = cursor.All(ctx, &events)
+	return events, err
+}
@@ -0,0 +1,55 @@
+package repositories
+
+import (
+	"context"
+	"loan-tracker/domain"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type loanNoteRepository struct {
+	db *mongo.Collection
+}
+
+func NewLoanNoteRepository(db *mongo.Client) domain.LoanNoteRepository {
+	return &loanNoteRepository{
+		db: db.Database("loan-tracker").Collection("loan_notes"),
+	}
+}
+
+func (r *loanNoteRepository) CreateNote(ctx context.Context, note domain.LoanNote) (primitive.ObjectID, error) {
+	result, err := r.db.InsertOne(ctx, note)
+	if err != nil {
+		return primitive.NilObjectID, err
+	}
+	return result.InsertedID.(primitive.ObjectID), nil
+}
+
+func (r *loanNoteRepository) GetNoteByID(ctx context.Context, id primitive.ObjectID) (domain.LoanNote, error) {
+	var note domain.LoanNote
+	err := r.db.FindOne(ctx, bson.M{"_id": id}).Decode(&note)
+	return note, err
+}
+
+func (r *loanNoteRepository) GetNotesByLoan(ctx context.Context, loanID primitive.ObjectID, visibility string) ([]domain.LoanNote, error) {
+	var notes []domain.LoanNote
+	opts := options.Find().SetSort(bson.M{"created_at": 1})
+	cursor, err := r.db.Find(ctx, bson.M{"loan_id": loanID, "visibility": visibility}, opts)
+	if err != nil {
+		return nil, err
+	}
+	err = cursor.All(ctx, &notes)
+	return notes, err
+}
+
+func (r *loanNoteRepository) UpdateNoteBody(ctx context.Context, id primitive.ObjectID, body string, revision domain.NoteRevision) error {
+	update := bson.M{
+		"$set":  bson.M{"body": body, "updated_at": revision.EditedAt},
+		"$push": bson.M{"edits": revision},
+	}
+	_, err := r.db.UpdateOne(ctx, bson.M{"_id": id}, update)
+	return err
+}
@@ -2,7 +2,10 @@ package repositories
 
 import (
 	"context"
+	"errors"
 	"loan-tracker/domain"
+	"math"
+	"time"
 
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
@@ -45,6 +48,81 @@ func (r *loanRepository) GetAllLoans(ctx context.Context) ([]domain.Loan, error)
 	return loans, err
 }
 
+func (r *loanRepository) FindLoans(ctx context.Context, filter domain.LoanFilter) ([]domain.Loan, error) {
+	query, opts := loanQuery(filter)
+
+	var loans []domain.Loan
+	cursor, err := r.db.Find(ctx, query, opts)
+	if err != nil {
+		return nil, err
+	}
+	err = cursor.All(ctx, &loans)
+	return loans, err
+}
+
+func (r *loanRepository) StreamLoans(ctx context.Context, filter domain.LoanFilter, fn func(domain.Loan) error) error {
+	query, opts := loanQuery(filter)
+	cursor, err := r.db.Find(ctx, query, opts)
+	if err != nil {
+		return err
+	}
+	defer cursor.Close(ctx)
+
+	for cursor.Next(ctx) {
+		var loan domain.Loan
+		if err := cursor.Decode(&loan); err != nil {
+			return err
+		}
+		if err := fn(loan); err != nil {
+			return err
+		}
+	}
+	return cursor.Err()
+}
+
+func loanQuery(filter domain.LoanFilter) (bson.M, *options.FindOptions) {
+	query := bson.M{}
+	if filter.Status != "" {
+		query["status"] = filter.Status
+	}
+	if !filter.UserID.IsZero() {
+		query["user_id"] = filter.UserID
+	}
+	if !filter.RefinancesLoanID.IsZero() {
+		query["refinances_loan_id"] = filter.RefinancesLoanID
+	}
+	if !filter.AssignedOfficerID.IsZero() {
+		query["assigned_officer_id"] = filter.AssignedOfficerID
+	}
+	if filter.ProductCode != "" {
+		query["product_code"] = filter.ProductCode
+	}
+	if created := timeRange(filter.From, filter.To); created != nil {
+		query["createdat"] = created
+	}
+
+	order := -1
+	if filter.Order == "asc" {
+		order = 1
+	}
+	return query, options.Find().SetSort(bson.M{"createdat": order})
+}
+
+// timeRange matches times from (inclusive) to (exclusive); either end may be left open
+func timeRange(from, to time.Time) bson.M {
+	if from.IsZero() && to.IsZero() {
+		return nil
+	}
+	between := bson.M{}
+	if !from.IsZero() {
+		between["$gte"] = from
+	}
+	if !to.IsZero() {
+		between["$lt"] = to
+	}
+	return between
+}
+
 func (r *loanRepository) UpdateLoanStatus(ctx context.Context, id primitive.ObjectID, status string) error {
 	_, err := r.db.UpdateOne(ctx, bson.M{"_id": id}, bson.M{"$set": bson.M{"status": status}})
 	return err
@@ -54,3 +132,173 @@ func (r *loanRepository) DeleteLoan(ctx context.Context, id primitive.ObjectID)
 	_, err := r.db.DeleteOne(ctx, bson.M{"_id": id})
 	return err
 }
+
+func (r *loanRepository) UpdateLoan(ctx context.Context, loan domain.Loan, from string) error {
+	result, err := r.db.ReplaceOne(ctx, bson.M{"_id": loan.ID, "status": from}, loan)
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return domain.LoanStatusError{Expected: from}
+	}
+	return nil
+}
+
+func (r *loanRepository) EditPendingLoan(ctx context.Context, loan domain.Loan) error {
+	update := bson.M{"$set": bson.M{
+		"amount":      loan.Amount,
+		"description": loan.Description,
+		"tenor":       loan.Tenor,
+		"frequency":   loan.Frequency,
+		"updatedat":   loan.UpdatedAt,
+	}}
+	result, err := r.db.UpdateOne(ctx, bson.M{"_id": loan.ID, "status": "pending"}, update)
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return domain.LoanStatusError{Expected: "pending"}
+	}
+	return nil
+}
+
+func (r *loanRepository) ApplyRepayment(ctx context.Context, id primitive.ObjectID, amount float64) (domain.Loan, error) {
+	// Compare in cents so rounding noise cannot block paying off the exact balance
+	amount = math.Round(amount*100) / 100
+	filter := bson.M{
+		"_id":                 id,
+		"status":              "approved",
+		"disbursed_at":        bson.M{"$exists": true},
+		"outstanding_balance": bson.M{"$gte": amount - 0.005},
+	}
+	update := bson.M{
+		"$inc": bson.M{"amount_repaid": amount, "outstanding_balance": -amount},
+		"$set": bson.M{"updatedat": time.Now()},
+	}
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+
+	var loan domain.Loan
+	err := r.db.FindOneAndUpdate(ctx, filter, update, opts).Decode(&loan)
+	if err == mongo.ErrNoDocuments {
+		return domain.Loan{}, errors.New("loan is not active, has not been disbursed or the amount exceeds the outstanding balance")
+	}
+	return loan, err
+}
+
+func (r *loanRepository) CloseLoan(ctx context.Context, id primitive.ObjectID, reason string, refinancedBy primitive.ObjectID) error {
+	// Interest that was not yet earned when a loan is refinanced is not owed
+	set := bson.M{
+		"status":                "closed",
+		"closed_reason":         reason,
+		"closed_at":             time.Now(),
+		"updatedat":             time.Now(),
+		"outstanding_balance":   0.0,
+		"outstanding_principal": 0.0,
+	}
+	if !refinancedBy.IsZero() {
+		set["refinanced_by_loan_id"] = refinancedBy
+	}
+	_, err := r.db.UpdateOne(ctx, bson.M{"_id": id}, bson.M{"$set": set})
+	return err
+}
+
+func (r *loanRepository) UpdateSchedule(ctx context.Context, id primitive.ObjectID, schedule []domain.Installment, outstandingPrincipal float64) error {
+	set := bson.M{"outstanding_principal": outstandingPrincipal}
+	if len(schedule) > 0 {
+		set["schedule"] = schedule
+	}
+	_, err := r.db.UpdateOne(ctx, bson.M{"_id": id}, bson.M{"$set": set})
+	return err
+}
+
+func (r *loanRepository) TransitionLoanStatus(ctx context.Context, id primitive.ObjectID, from, to string) error {
+	filter := bson.M{"_id": id, "status": from}
+	update := bson.M{"$set": bson.M{"status": to, "updatedat": time.Now()}}
+	result, err := r.db.UpdateOne(ctx, filter, update)
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return domain.LoanStatusError{Expected: from}
+	}
+	return nil
+}
+
+func (r *loanRepository) MarkDisbursed(ctx context.Context, id primitive.ObjectID, at time.Time, netDisbursement, refinancePayoff float64) error {
+	filter := bson.M{"_id": id, "status": "approved", "disbursed_at": bson.M{"$exists": false}}
+	update := bson.M{"$set": bson.M{"disbursed_at": at, "net_disbursement": netDisbursement, "refinance_payoff": refinancePayoff, "updatedat": at}}
+	result, err := r.db.UpdateOne(ctx, filter, update)
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return errors.New("loan is not approved or has already been disbursed")
+	}
+	return nil
+}
+
+func (r *loanRepository) UpdateAssignment(ctx context.Context, loan domain.Loan) error {
+	set := bson.M{
+		"stage":  loan.Stage,
+		"stages": loan.Stages,
+	}
+	if !loan.AssignedOfficerID.IsZero() {
+		set["assigned_officer_id"] = loan.AssignedOfficerID
+		set["assigned_at"] = loan.AssignedAt
+	}
+	_, err := r.db.UpdateOne(ctx, bson.M{"_id": loan.ID}, bson.M{"$set": set})
+	return err
+}
+
+func (r *loanRepository) OfficerWorkloads(ctx context.Context) ([]domain.OfficerWorkload, error) {
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: bson.M{"assigned_officer_id": bson.M{"$exists": true}}}},
+		{{Key: "$group", Value: bson.M{
+			"_id":              "$assigned_officer_id",
+			"open_loans":       bson.M{"$sum": bson.M{"$cond": bson.A{bson.M{"$eq": bson.A{"$status", "pending"}}, 1, 0}}},
+			"total_loans":      bson.M{"$sum": 1},
+			"last_assigned_at": bson.M{"$max": "$assigned_at"},
+		}}},
+	}
+	cursor, err := r.db.Aggregate(ctx, pipeline)
+	if err != nil {
+		return nil, err
+	}
+	var workloads []domain.OfficerWorkload
+	err = cursor.All(ctx, &workloads)
+	return workloads, err
+}
+
+func (r *loanRepository) StageTimes(ctx context.Context, byOfficer bool) ([]domain.StageTime, error) {
+	group := bson.M{"stage": "$stages.stage"}
+	if byOfficer {
+		group["officer_id"] = "$stages.officer_id"
+	}
+	pipeline := mongo.Pipeline{
+		{{Key: "$unwind", Value: "$stages"}},
+		// Only intervals that have ended have a duration
+		{{Key: "$match", Value: bson.M{"stages.exited_at": bson.M{"$exists": true}}}},
+		{{Key: "$group", Value: bson.M{
+			"_id":             group,
+			"count":           bson.M{"$sum": 1},
+			"average_seconds": bson.M{"$avg": "$stages.seconds"},
+			"max_seconds":     bson.M{"$max": "$stages.seconds"},
+		}}},
+		{{Key: "$project", Value: bson.M{
+			"_id":             0,
+			"stage":           "$_id.stage",
+			"officer_id":      "$_id.officer_id",
+			"count":           1,
+			"average_seconds": 1,
+			"max_seconds":     1,
+		}}},
+		{{Key: "$sort", Value: bson.D{{Key: "stage", Value: 1}, {Key: "officer_id", Value: 1}}}},
+	}
+	cursor, err := r.db.Aggregate(ctx, pipeline)
+	if err != nil {
+		return nil, err
+	}
+	var times []domain.StageTime
+	err = cursor.All(ctx, &times)
+	return times, err
+}
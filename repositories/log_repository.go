@@ -6,6 +6,7 @@ import (
 
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
 type logRepository struct {
@@ -23,12 +24,42 @@ func (r *logRepository) CreateLog(ctx context.Context, log domain.Log) error {
 	return err
 }
 
-func (r *logRepository) GetAllLogs(ctx context.Context) ([]domain.Log, error) {
+func (r *logRepository) FindLogs(ctx context.Context, filter domain.LogFilter) ([]domain.Log, error) {
 	var logs []domain.Log
-	cursor, err := r.db.Find(ctx, bson.M{})
+	cursor, err := r.db.Find(ctx, logQuery(filter), options.Find().SetSort(bson.M{"timestamp": -1}))
 	if err != nil {
 		return nil, err
 	}
 	err = cursor.All(ctx, &logs)
 	return logs, err
 }
+
+func (r *logRepository) StreamLogs(ctx context.Context, filter domain.LogFilter, fn func(domain.Log) error) error {
+	cursor, err := r.db.Find(ctx, logQuery(filter), options.Find().SetSort(bson.M{"timestamp": -1}))
+	if err != nil {
+		return err
+	}
+	defer cursor.Close(ctx)
+
+	for cursor.Next(ctx) {
+		var entry domain.Log
+		if err := cursor.Decode(&entry); err != nil {
+			return err
+		}
+		if err := fn(entry); err != nil {
+			return err
+		}
+	}
+	return cursor.Err()
+}
+
+func logQuery(filter domain.LogFilter) bson.M {
+	query := bson.M{}
+	if filter.Type != "" {
+		query["type"] = filter.Type
+	}
+	if logged := timeRange(filter.From, filter.To); logged != nil {
+		query["timestamp"] = logged
+	}
+	return query
+}
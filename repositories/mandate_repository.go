@@ -0,0 +1,163 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"loan-tracker/domain"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type mandateRepository struct {
+	mandates    *mongo.Collection
+	collections *mongo.Collection
+}
+
+func NewMandateRepository(db *mongo.Client) domain.MandateRepository {
+	database := db.Database("loan-tracker")
+	return &mandateRepository{
+		mandates:    database.Collection("mandates"),
+		collections: database.Collection("debit_collections"),
+	}
+}
+
+func (r *mandateRepository) CreateMandate(ctx context.Context, mandate domain.Mandate) (primitive.ObjectID, error) {
+	result, err := r.mandates.InsertOne(ctx, mandate)
+	if err != nil {
+		return primitive.NilObjectID, err
+	}
+	return result.InsertedID.(primitive.ObjectID), nil
+}
+
+func (r *mandateRepository) GetMandateByID(ctx context.Context, id primitive.ObjectID) (domain.Mandate, error) {
+	var mandate domain.Mandate
+	err := r.mandates.FindOne(ctx, bson.M{"_id": id}).Decode(&mandate)
+	return mandate, err
+}
+
+func (r *mandateRepository) GetMandateByProviderReference(ctx context.Context, provider, reference string) (domain.Mandate, error) {
+	var mandate domain.Mandate
+	err := r.mandates.FindOne(ctx, bson.M{"provider": provider, "provider_reference": reference}).Decode(&mandate)
+	return mandate, err
+}
+
+func (r *mandateRepository) GetMandatesByLoan(ctx context.Context, loanID primitive.ObjectID) ([]domain.Mandate, error) {
+	var mandates []domain.Mandate
+	cursor, err := r.mandates.Find(ctx, bson.M{"loan_id": loanID}, options.Find().SetSort(bson.M{"created_at": -1}))
+	if err != nil {
+		return nil, err
+	}
+	err = cursor.All(ctx, &mandates)
+	return mandates, err
+}
+
+func (r *mandateRepository) GetActiveMandates(ctx context.Context) ([]domain.Mandate, error) {
+	var mandates []domain.Mandate
+	cursor, err := r.mandates.Find(ctx, bson.M{"status": "active"})
+	if err != nil {
+		return nil, err
+	}
+	err = cursor.All(ctx, &mandates)
+	return mandates, err
+}
+
+func (r *mandateRepository) UpdateMandateStatus(ctx context.Context, mandate domain.Mandate, from string) error {
+	set := bson.M{"status": mandate.Status}
+	if !mandate.ActivatedAt.IsZero() {
+		set["activated_at"] = mandate.ActivatedAt
+	}
+	if !mandate.RevokedAt.IsZero() {
+		set["revoked_at"] = mandate.RevokedAt
+	}
+	if !mandate.RevokedBy.IsZero() {
+		set["revoked_by"] = mandate.RevokedBy
+	}
+	if mandate.RevocationReason != "" {
+		set["revocation_reason"] = mandate.RevocationReason
+	}
+	result, err := r.mandates.UpdateOne(ctx, bson.M{"_id": mandate.ID, "status": from}, bson.M{"$set": set})
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return errors.New("mandate is no longer " + from)
+	}
+	return nil
+}
+
+func (r *mandateRepository) CreateCollection(ctx context.Context, collection domain.DebitCollection) (primitive.ObjectID, error) {
+	result, err := r.collections.InsertOne(ctx, collection)
+	if err != nil {
+		return primitive.NilObjectID, err
+	}
+	return result.InsertedID.(primitive.ObjectID), nil
+}
+
+func (r *mandateRepository) GetCollectionByProviderReference(ctx context.Context, provider, reference string) (domain.DebitCollection, error) {
+	var collection domain.DebitCollection
+	err := r.collections.FindOne(ctx, bson.M{"provider": provider, "provider_reference": reference}).Decode(&collection)
+	return collection, err
+}
+
+func (r *mandateRepository) GetCollectionsByLoan(ctx context.Context, loanID primitive.ObjectID) ([]domain.DebitCollection, error) {
+	return r.findCollections(ctx, bson.M{"loan_id": loanID})
+}
+
+func (r *mandateRepository) GetCollectionsByStatus(ctx context.Context, status string) ([]domain.DebitCollection, error) {
+	query := bson.M{}
+	if status != "" {
+		query["status"] = status
+	}
+	return r.findCollections(ctx, query)
+}
+
+func (r *mandateRepository) GetDueCollections(ctx context.Context, now time.Time) ([]domain.DebitCollection, error) {
+	return r.findCollections(ctx, bson.M{
+		"status":          bson.M{"$in": []string{"scheduled", "retrying"}},
+		"next_attempt_at": bson.M{"$lte": now},
+	})
+}
+
+func (r *mandateRepository) HasCollection(ctx context.Context, loanID primitive.ObjectID, installment int) (bool, error) {
+	count, err := r.collections.CountDocuments(ctx, bson.M{
+		"loan_id":     loanID,
+		"installment": installment,
+		"status":      bson.M{"$nin": []string{"cancelled", "succeeded"}},
+	})
+	return count > 0, err
+}
+
+func (r *mandateRepository) UpdateCollection(ctx context.Context, collection domain.DebitCollection, from string) error {
+	result, err := r.collections.ReplaceOne(ctx, bson.M{"_id": collection.ID, "status": from}, collection)
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return errors.New("collection is no longer " + from)
+	}
+	return nil
+}
+
+func (r *mandateRepository) CancelCollections(ctx context.Context, mandateID primitive.ObjectID, reason string) error {
+	_, err := r.collections.UpdateMany(ctx,
+		bson.M{"mandate_id": mandateID, "status": bson.M{"$in": []string{"scheduled", "retrying"}}},
+		bson.M{
+			"$set":   bson.M{"status": "cancelled", "failure_code": reason, "updated_at": time.Now()},
+			"$unset": bson.M{"next_attempt_at": ""},
+		})
+	return err
+}
+
+func (r *mandateRepository) findCollections(ctx context.Context, query bson.M) ([]domain.DebitCollection, error) {
+	var collections []domain.DebitCollection
+	cursor, err := r.collections.Find(ctx, query, options.Find().SetSort(bson.M{"created_at": -1}))
+	if err != nil {
+		return nil, err
+	}
+	err = cursor.All(ctx, &collections)
+	return collections, err
+}
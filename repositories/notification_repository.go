@@ -0,0 +1,109 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"loan-tracker/domain"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type notificationRepository struct {
+	notifications *mongo.Collection
+	preferences   *mongo.Collection
+}
+
+func NewNotificationRepository(db *mongo.Client) domain.NotificationRepository {
+	database := db.Database("loan-tracker")
+	return &notificationRepository{
+		notifications: database.Collection("notifications"),
+		preferences:   database.Collection("notification_preferences"),
+	}
+}
+
+func (r *notificationRepository) AddNotification(ctx context.Context, notification domain.Notification) (bool, error) {
+	result, err := r.notifications.UpdateOne(ctx,
+		bson.M{"user_id": notification.UserID, "key": notification.Key},
+		bson.M{"$setOnInsert": notification},
+		options.Update().SetUpsert(true))
+	if err != nil {
+		return false, err
+	}
+	return result.UpsertedCount > 0, nil
+}
+
+func (r *notificationRepository) FindNotifications(ctx context.Context, filter domain.NotificationFilter) ([]domain.Notification, error) {
+	var notifications []domain.Notification
+	cursor, err := r.notifications.Find(ctx, notificationQuery(filter),
+		options.Find().SetSort(bson.D{{Key: "created_at", Value: -1}, {Key: "_id", Value: -1}}).SetLimit(200))
+	if err != nil {
+		return nil, err
+	}
+	err = cursor.All(ctx, &notifications)
+	return notifications, err
+}
+
+func (r *notificationRepository) CountNotifications(ctx context.Context, filter domain.NotificationFilter) (int64, error) {
+	return r.notifications.CountDocuments(ctx, notificationQuery(filter))
+}
+
+func (r *notificationRepository) SetDeliveries(ctx context.Context, id primitive.ObjectID, channels []string, deliveries []domain.NotificationDelivery) error {
+	_, err := r.notifications.UpdateOne(ctx, bson.M{"_id": id},
+		bson.M{"$set": bson.M{"channels": channels, "deliveries": deliveries}})
+	return err
+}
+
+func (r *notificationRepository) MarkRead(ctx context.Context, id, userID primitive.ObjectID, at time.Time) error {
+	result, err := r.notifications.UpdateOne(ctx,
+		bson.M{"_id": id, "user_id": userID},
+		bson.M{"$set": bson.M{"read": true, "read_at": at}})
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return errors.New("notification not found")
+	}
+	return nil
+}
+
+func (r *notificationRepository) MarkAllRead(ctx context.Context, userID primitive.ObjectID, at time.Time) (int64, error) {
+	result, err := r.notifications.UpdateMany(ctx,
+		bson.M{"user_id": userID, "read": false},
+		bson.M{"$set": bson.M{"read": true, "read_at": at}})
+	if err != nil {
+		return 0, err
+	}
+	return result.ModifiedCount, nil
+}
+
+func (r *notificationRepository) GetPreferences(ctx context.Context, userID primitive.ObjectID) (domain.NotificationPreferences, error) {
+	preferences := domain.NotificationPreferences{UserID: userID}
+	err := r.preferences.FindOne(ctx, bson.M{"_id": userID}).Decode(&preferences)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return preferences, nil
+	}
+	return preferences, err
+}
+
+func (r *notificationRepository) SavePreferences(ctx context.Context, preferences domain.NotificationPreferences) error {
+	_, err := r.preferences.ReplaceOne(ctx, bson.M{"_id": preferences.UserID}, preferences, options.Replace().SetUpsert(true))
+	return err
+}
+
+func notificationQuery(filter domain.NotificationFilter) bson.M {
+	query := bson.M{"user_id": filter.UserID}
+	if filter.Channel != "" {
+		query["channels"] = filter.Channel
+	}
+	switch filter.Status {
+	case "read":
+		query["read"] = true
+	case "unread":
+		query["read"] = false
+	}
+	return query
+}
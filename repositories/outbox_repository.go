@@ -0,0 +1,102 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"loan-tracker/domain"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type outboxRepository struct {
+	db *mongo.Collection
+}
+
+func NewOutboxRepository(db *mongo.Client) domain.OutboxRepository {
+	return &outboxRepository{
+		db: db.Database("loan-tracker").Collection("outbox"),
+	}
+}
+
+func (r *outboxRepository) AddEvents(ctx context.Context, events ...domain.Event) error {
+	if len(events) == 0 {
+		return nil
+	}
+	docs := make([]interface{}, len(events))
+	for i, event := range events {
+		docs[i] = event
+	}
+	_, err := r.db.InsertMany(ctx, docs)
+	return err
+}
+
+func (r *outboxRepository) GetEventByID(ctx context.Context, id primitive.ObjectID) (domain.Event, error) {
+	var event domain.Event
+	err := r.db.FindOne(ctx, bson.M{"_id": id}).Decode(&event)
+	return event, err
+}
+
+func (r *outboxRepository) FindEvents(ctx context.Context, filter domain.EventFilter) ([]domain.Event, error) {
+	query := bson.M{}
+	if filter.Type != "" {
+		query["type"] = filter.Type
+	}
+	if filter.Status != "" {
+		query["status"] = filter.Status
+	}
+	var events []domain.Event
+	opts := options.Find().SetSort(bson.M{"occurred_at": -1}).SetLimit(500)
+	cursor, err := r.db.Find(ctx, query, opts)
+	if err != nil {
+		return nil, err
+	}
+	err = cursor.All(ctx, &events)
+	return events, err
+}
+
+func (r *outboxRepository) GetDueEvents(ctx context.Context, now time.Time, limit int) ([]domain.Event, error) {
+	var events []domain.Event
+	opts := options.Find().SetSort(bson.D{{Key: "occurred_at", Value: 1}, {Key: "_id", Value: 1}}).SetLimit(int64(limit))
+	cursor, err := r.db.Find(ctx, bson.M{"status": "pending", "next_attempt_at": bson.M{"$lte": now}}, opts)
+	if err != nil {
+		return nil, err
+	}
+	err = cursor.All(ctx, &events)
+	return events, err
+}
+
+func (r *outboxRepository) ClaimEvent(ctx context.Context, event domain.Event, until time.Time) error {
+	result, err := r.db.UpdateOne(ctx,
+		bson.M{"_id": event.ID, "status": "pending", "next_attempt_at": event.NextAttemptAt},
+		bson.M{"$set": bson.M{"next_attempt_at": until}})
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return errors.New("event has been claimed by another dispatcher")
+	}
+	return nil
+}
+
+func (r *outboxRepository) MarkHandled(ctx context.Context, id primitive.ObjectID, subscriber string) error {
+	_, err := r.db.UpdateOne(ctx, bson.M{"_id": id}, bson.M{"$addToSet": bson.M{"handled": subscriber}})
+	return err
+}
+
+func (r *outboxRepository) UpdateEventStatus(ctx context.Context, event domain.Event) error {
+	set := bson.M{
+		"status":          event.Status,
+		"attempts":        event.Attempts,
+		"next_attempt_at": event.NextAttemptAt,
+		"last_error":      event.LastError,
+	}
+	if !event.DispatchedAt.IsZero() {
+		set["dispatched_at"] = event.DispatchedAt
+	}
+	_, err := r.db.UpdateOne(ctx, bson.M{"_id": event.ID}, bson.M{"$set": set})
+	return err
+}
@@ -0,0 +1,76 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"loan-tracker/domain"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type paymentRepository struct {
+	db *mongo.Collection
+}
+
+func NewPaymentRepository(db *mongo.Client) domain.PaymentRepository {
+	return &paymentRepository{
+		db: db.Database("loan-tracker").Collection("payments"),
+	}
+}
+
+func (r *paymentRepository) CreatePayment(ctx context.Context, payment domain.Payment) (primitive.ObjectID, error) {
+	result, err := r.db.InsertOne(ctx, payment)
+	if err != nil {
+		return primitive.NilObjectID, err
+	}
+	return result.InsertedID.(primitive.ObjectID), nil
+}
+
+func (r *paymentRepository) GetPaymentByID(ctx context.Context, id primitive.ObjectID) (domain.Payment, error) {
+	var payment domain.Payment
+	err := r.db.FindOne(ctx, bson.M{"_id": id}).Decode(&payment)
+	return payment, err
+}
+
+func (r *paymentRepository) GetPaymentByProviderReference(ctx context.Context, provider, reference string) (domain.Payment, error) {
+	var payment domain.Payment
+	err := r.db.FindOne(ctx, bson.M{"provider": provider, "provider_reference": reference}).Decode(&payment)
+	return payment, err
+}
+
+func (r *paymentRepository) GetPaymentsByLoan(ctx context.Context, loanID primitive.ObjectID) ([]domain.Payment, error) {
+	var payments []domain.Payment
+	cursor, err := r.db.Find(ctx, bson.M{"loan_id": loanID}, options.Find().SetSort(bson.M{"created_at": -1}))
+	if err != nil {
+		return nil, err
+	}
+	err = cursor.All(ctx, &payments)
+	return payments, err
+}
+
+func (r *paymentRepository) UpdatePaymentStatus(ctx context.Context, payment domain.Payment, from string) error {
+	set := bson.M{
+		"status":     payment.Status,
+		"updated_at": payment.UpdatedAt,
+	}
+	if payment.FailureReason != "" {
+		set["failure_reason"] = payment.FailureReason
+	}
+	if !payment.PaidAt.IsZero() {
+		set["paid_at"] = payment.PaidAt
+	}
+	if !payment.RepaymentID.IsZero() {
+		set["repayment_id"] = payment.RepaymentID
+	}
+	result, err := r.db.UpdateOne(ctx, bson.M{"_id": payment.ID, "status": from}, bson.M{"$set": set})
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return errors.New("payment is no longer " + from)
+	}
+	return nil
+}
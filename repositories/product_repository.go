@@ -0,0 +1,55 @@
+package repositories
+
+import (
+	"context"
+	"loan-tracker/domain"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+type productRepository struct {
+	db *mongo.Collection
+}
+
+func NewProductRepository(db *mongo.Client) domain.ProductRepository {
+	return &productRepository{
+		db: db.Database("loan-tracker").Collection("products"),
+	}
+}
+
+func (r *productRepository) CreateProduct(ctx context.Context, product domain.LoanProduct) (primitive.ObjectID, error) {
+	result, err := r.db.InsertOne(ctx, product)
+	if err != nil {
+		return primitive.NilObjectID, err
+	}
+	return result.InsertedID.(primitive.ObjectID), nil
+}
+
+func (r *productRepository) GetProductByCode(ctx context.Context, code string) (domain.LoanProduct, error) {
+	var product domain.LoanProduct
+	err := r.db.FindOne(ctx, bson.M{"code": code}).Decode(&product)
+	return product, err
+}
+
+func (r *productRepository) GetAllProducts(ctx context.Context) ([]domain.LoanProduct, error) {
+	var products []domain.LoanProduct
+	cursor, err := r.db.Find(ctx, bson.M{})
+	if err != nil {
+		return nil, err
+	}
+	err = cursor.All(ctx, &products)
+	return products, err
+}
+
+func (r *productRepository) UpdateProduct(ctx context.Context, code string, product domain.LoanProduct) error {
+	result, err := r.db.ReplaceOne(ctx, bson.M{"code": code}, product)
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return mongo.ErrNoDocuments
+	}
+	return nil
+}
@@ -0,0 +1,121 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"loan-tracker/domain"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type reconciliationRepository struct {
+	statements   *mongo.Collection
+	transactions *mongo.Collection
+}
+
+func NewReconciliationRepository(db *mongo.Client) domain.ReconciliationRepository {
+	database := db.Database("loan-tracker")
+	return &reconciliationRepository{
+		statements:   database.Collection("bank_statements"),
+		transactions: database.Collection("bank_transactions"),
+	}
+}
+
+func (r *reconciliationRepository) CreateStatement(ctx context.Context, statement domain.BankStatement) (primitive.ObjectID, error) {
+	result, err := r.statements.InsertOne(ctx, statement)
+	if err != nil {
+		return primitive.NilObjectID, err
+	}
+	return result.InsertedID.(primitive.ObjectID), nil
+}
+
+func (r *reconciliationRepository) UpdateStatement(ctx context.Context, statement domain.BankStatement) error {
+	_, err := r.statements.ReplaceOne(ctx, bson.M{"_id": statement.ID}, statement)
+	return err
+}
+
+func (r *reconciliationRepository) StatementExists(ctx context.Context, checksum string) (bool, error) {
+	count, err := r.statements.CountDocuments(ctx, bson.M{"checksum": checksum})
+	return count > 0, err
+}
+
+func (r *reconciliationRepository) GetStatements(ctx context.Context) ([]domain.BankStatement, error) {
+	var statements []domain.BankStatement
+	cursor, err := r.statements.Find(ctx, bson.M{}, options.Find().SetSort(bson.M{"imported_at": -1}))
+	if err != nil {
+		return nil, err
+	}
+	err = cursor.All(ctx, &statements)
+	return statements, err
+}
+
+func (r *reconciliationRepository) CreateTransactions(ctx context.Context, transactions []domain.BankTransaction) error {
+	if len(transactions) == 0 {
+		return nil
+	}
+	docs := make([]interface{}, len(transactions))
+	for i, t := range transactions {
+		docs[i] = t
+	}
+	_, err := r.transactions.InsertMany(ctx, docs)
+	return err
+}
+
+func (r *reconciliationRepository) TransactionExists(ctx context.Context, fingerprint string) (bool, error) {
+	count, err := r.transactions.CountDocuments(ctx, bson.M{"fingerprint": fingerprint})
+	return count > 0, err
+}
+
+func (r *reconciliationRepository) GetTransactionByID(ctx context.Context, id primitive.ObjectID) (domain.BankTransaction, error) {
+	var transaction domain.BankTransaction
+	err := r.transactions.FindOne(ctx, bson.M{"_id": id}).Decode(&transaction)
+	return transaction, err
+}
+
+func (r *reconciliationRepository) FindTransactions(ctx context.Context, filter domain.BankTransactionFilter) ([]domain.BankTransaction, error) {
+	query := bson.M{}
+	if !filter.StatementID.IsZero() {
+		query["statement_id"] = filter.StatementID
+	}
+	if filter.Status != "" {
+		query["status"] = filter.Status
+	}
+	var transactions []domain.BankTransaction
+	opts := options.Find().SetSort(bson.D{{Key: "booking_date", Value: 1}, {Key: "line_number", Value: 1}})
+	cursor, err := r.transactions.Find(ctx, query, opts)
+	if err != nil {
+		return nil, err
+	}
+	err = cursor.All(ctx, &transactions)
+	return transactions, err
+}
+
+func (r *reconciliationRepository) UpdateTransactionStatus(ctx context.Context, transaction domain.BankTransaction, from string) error {
+	set := bson.M{"status": transaction.Status}
+	if transaction.Note != "" {
+		set["note"] = transaction.Note
+	}
+	if !transaction.ResolvedAt.IsZero() {
+		set["resolved_at"] = transaction.ResolvedAt
+	}
+	if !transaction.ResolvedBy.IsZero() {
+		set["resolved_by"] = transaction.ResolvedBy
+	}
+	if !transaction.LoanID.IsZero() {
+		set["loan_id"] = transaction.LoanID
+	}
+	if !transaction.RepaymentID.IsZero() {
+		set["repayment_id"] = transaction.RepaymentID
+	}
+	result, err := r.transactions.UpdateOne(ctx, bson.M{"_id": transaction.ID, "status": from}, bson.M{"$set": set})
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return errors.New("this line has already been reconciled")
+	}
+	return nil
+}
@@ -0,0 +1,86 @@
+package repositories
+
+import (
+	"context"
+	"loan-tracker/domain"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type repaymentRepository struct {
+	db *mongo.Collection
+}
+
+func NewRepaymentRepository(db *mongo.Client) domain.RepaymentRepository {
+	return &repaymentRepository{
+		db: db.Database("loan-tracker").Collection("repayments"),
+	}
+}
+
+func (r *repaymentRepository) CreateRepayment(ctx context.Context, repayment domain.Repayment) (primitive.ObjectID, error) {
+	result, err := r.db.InsertOne(ctx, repayment)
+	if err != nil {
+		return primitive.NilObjectID, err
+	}
+	return result.InsertedID.(primitive.ObjectID), nil
+}
+
+func (r *repaymentRepository) GetRepaymentsByLoan(ctx context.Context, loanID primitive.ObjectID) ([]domain.Repayment, error) {
+	var repayments []domain.Repayment
+	opts := options.Find().SetSort(bson.M{"paid_at": 1})
+	cursor, err := r.db.Find(ctx, bson.M{"loan_id": loanID}, opts)
+	if err != nil {
+		return nil, err
+	}
+	err = cursor.All(ctx, &repayments)
+	return repayments, err
+}
+
+func (r *repaymentRepository) FindRepayments(ctx context.Context, filter domain.RepaymentFilter) ([]domain.Repayment, error) {
+	var repayments []domain.Repayment
+	cursor, err := r.db.Find(ctx, repaymentQuery(filter), options.Find().SetSort(bson.M{"paid_at": -1}))
+	if err != nil {
+		return nil, err
+	}
+	err = cursor.All(ctx, &repayments)
+	return repayments, err
+}
+
+func (r *repaymentRepository) StreamRepayments(ctx context.Context, filter domain.RepaymentFilter, fn func(domain.Repayment) error) error {
+	cursor, err := r.db.Find(ctx, repaymentQuery(filter), options.Find().SetSort(bson.M{"paid_at": -1}))
+	if err != nil {
+		return err
+	}
+	defer cursor.Close(ctx)
+
+	for cursor.Next(ctx) {
+		var repayment domain.Repayment
+		if err := cursor.Decode(&repayment); err != nil {
+			return err
+		}
+		if err := fn(repayment); err != nil {
+			return err
+		}
+	}
+	return cursor.Err()
+}
+
+func repaymentQuery(filter domain.RepaymentFilter) bson.M {
+	query := bson.M{}
+	if !filter.LoanID.IsZero() {
+		query["loan_id"] = filter.LoanID
+	}
+	if !filter.UserID.IsZero() {
+		query["user_id"] = filter.UserID
+	}
+	if filter.Method != "" {
+		query["method"] = filter.Method
+	}
+	if paid := timeRange(filter.From, filter.To); paid != nil {
+		query["paid_at"] = paid
+	}
+	return query
+}
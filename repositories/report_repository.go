@@ -0,0 +1,280 @@
+package repositories
+
+import (
+	"context"
+	"loan-tracker/domain"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+type reportRepository struct {
+	loans      *mongo.Collection
+	repayments *mongo.Collection
+}
+
+func NewReportRepository(client *mongo.Client) domain.ReportRepository {
+	db := client.Database("loan-tracker")
+	return &reportRepository{
+		loans:      db.Collection("loans"),
+		repayments: db.Collection("repayments"),
+	}
+}
+
+var (
+	// disbursedTermLoans matches term loans whose funds have been released, whether still active or
+	// closed since. Approved loans awaiting the borrower's acceptance are left out.
+	disbursedTermLoans = bson.M{
+		"status":       bson.M{"$in": bson.A{"approved", "closed"}},
+		"type":         bson.M{"$ne": "credit_line"},
+		"disbursed_at": bson.M{"$exists": true},
+	}
+
+	// activeTermLoans matches disbursed term loans that are still being repaid
+	activeTermLoans = bson.M{
+		"status":       "approved",
+		"type":         bson.M{"$ne": "credit_line"},
+		"disbursed_at": bson.M{"$exists": true},
+	}
+
+	disbursedDate = "$disbursed_at"
+
+	// outstandingPrincipal is the principal still owed on an active loan. A payment on an
+	// installment is taken to cover its interest first.
+	outstandingPrincipal = bson.M{"$cond": bson.A{
+		bson.M{"$ne": bson.A{"$status", "approved"}},
+		0,
+		bson.M{"$cond": bson.A{
+			bson.M{"$gt": bson.A{bson.M{"$size": bson.M{"$ifNull": bson.A{"$schedule", bson.A{}}}}, 0}},
+			bson.M{"$sum": bson.M{"$map": bson.M{
+				"input": "$schedule",
+				"as":    "i",
+				"in": bson.M{"$max": bson.A{0, bson.M{"$subtract": bson.A{
+					"$$i.principal",
+					bson.M{"$max": bson.A{0, bson.M{"$subtract": bson.A{"$$i.paid_amount", "$$i.interest"}}}},
+				}}}},
+			}}},
+			"$outstanding_balance",
+		}},
+	}}
+)
+
+func (r *reportRepository) Portfolio(ctx context.Context, filter domain.ReportFilter) ([]domain.PortfolioRow, error) {
+	pipeline := mongo.Pipeline{{{Key: "$match", Value: disbursedTermLoans}}}
+	pipeline = append(pipeline, dateRange(disbursedDate, filter)...)
+	pipeline = append(pipeline,
+		bson.D{{Key: "$group", Value: bson.M{
+			"_id":                   groupKey(filter, "$product_code", disbursedDate),
+			"loans_disbursed":       bson.M{"$sum": 1},
+			"total_disbursed":       bson.M{"$sum": "$amount"},
+			"average_ticket_size":   bson.M{"$avg": "$amount"},
+			"active_loans":          bson.M{"$sum": bson.M{"$cond": bson.A{bson.M{"$eq": bson.A{"$status", "approved"}}, 1, 0}}},
+			"outstanding_principal": bson.M{"$sum": outstandingPrincipal},
+			"outstanding_balance":   bson.M{"$sum": bson.M{"$cond": bson.A{bson.M{"$eq": bson.A{"$status", "approved"}}, "$outstanding_balance", 0}}},
+		}}},
+	)
+	pipeline = append(pipeline, ungroup()...)
+
+	var rows []domain.PortfolioRow
+	err := r.aggregate(ctx, r.loans, pipeline, &rows)
+	return rows, err
+}
+
+func (r *reportRepository) Approvals(ctx context.Context, filter domain.ReportFilter) ([]domain.ApprovalRow, error) {
+	countStatus := func(statuses ...interface{}) bson.M {
+		return bson.M{"$sum": bson.M{"$cond": bson.A{bson.M{"$in": bson.A{"$status", bson.A(statuses)}}, 1, 0}}}
+	}
+
+	pipeline := dateRange("$createdat", filter)
+	pipeline = append(pipeline,
+		bson.D{{Key: "$group", Value: bson.M{
+			"_id":          groupKey(filter, "$product_code", "$createdat"),
+			"applications": bson.M{"$sum": 1},
+			// A closed loan was approved before it was repaid or refinanced
+			"approved":  countStatus("approved", "closed"),
+			"rejected":  countStatus("rejected"),
+			"expired":   countStatus("expired"),
+			"cancelled": countStatus("cancelled"),
+			"pending":   countStatus("pending"),
+		}}},
+	)
+	pipeline = append(pipeline, ungroup()...)
+
+	var rows []domain.ApprovalRow
+	err := r.aggregate(ctx, r.loans, pipeline, &rows)
+	return rows, err
+}
+
+func (r *reportRepository) PortfolioAtRisk(ctx context.Context, filter domain.ReportFilter) ([]domain.PARRow, error) {
+	atRisk := func(days int) bson.M {
+		cutoff := filter.AsOf.AddDate(0, 0, -days)
+		late := bson.M{"$and": bson.A{
+			bson.M{"$ne": bson.A{"$oldest_unpaid", nil}},
+			bson.M{"$lt": bson.A{"$oldest_unpaid", cutoff}},
+		}}
+		return bson.M{"$sum": bson.M{"$cond": bson.A{late, "$principal", 0}}}
+	}
+
+	pipeline := mongo.Pipeline{{{Key: "$match", Value: activeTermLoans}}}
+	pipeline = append(pipeline, dateRange(disbursedDate, filter)...)
+	pipeline = append(pipeline,
+		bson.D{{Key: "$addFields", Value: bson.M{
+			"principal": outstandingPrincipal,
+			"oldest_unpaid": bson.M{"$min": bson.M{"$map": bson.M{
+				"input": bson.M{"$filter": bson.M{
+					"input": bson.M{"$ifNull": bson.A{"$schedule", bson.A{}}},
+					"as":    "i",
+					"cond":  bson.M{"$ne": bson.A{"$$i.status", "paid"}},
+				}},
+				"as": "i",
+				"in": "$$i.due_date",
+			}}},
+		}}},
+		bson.D{{Key: "$group", Value: bson.M{
+			"_id":                   groupKey(filter, "$product_code", disbursedDate),
+			"active_loans":          bson.M{"$sum": 1},
+			"outstanding_principal": bson.M{"$sum": "$principal"},
+			"par30_principal":       atRisk(30),
+			"par90_principal":       atRisk(90),
+		}}},
+	)
+	pipeline = append(pipeline, ungroup()...)
+
+	var rows []domain.PARRow
+	err := r.aggregate(ctx, r.loans, pipeline, &rows)
+	return rows, err
+}
+
+func (r *reportRepository) Collections(ctx context.Context, filter domain.ReportFilter) ([]domain.CollectionsRow, error) {
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: disbursedTermLoans}},
+		{{Key: "$unwind", Value: "$schedule"}},
+		// Installments that are not due yet cannot have been collected
+		{{Key: "$match", Value: bson.M{"schedule.due_date": bson.M{"$lte": filter.AsOf}}}},
+	}
+	pipeline = append(pipeline, dateRange("$schedule.due_date", filter)...)
+	pipeline = append(pipeline,
+		bson.D{{Key: "$group", Value: bson.M{
+			"_id":              groupKey(filter, "$product_code", "$schedule.due_date"),
+			"installments":     bson.M{"$sum": 1},
+			"amount_due":       bson.M{"$sum": "$schedule.amount"},
+			"amount_collected": bson.M{"$sum": bson.M{"$min": bson.A{"$schedule.paid_amount", "$schedule.amount"}}},
+		}}},
+	)
+	pipeline = append(pipeline, ungroup()...)
+
+	var rows []domain.CollectionsRow
+	err := r.aggregate(ctx, r.loans, pipeline, &rows)
+	return rows, err
+}
+
+func (r *reportRepository) VintageCohorts(ctx context.Context, filter domain.ReportFilter) ([]domain.VintageCurve, error) {
+	key := bson.M{"cohort": monthOf(disbursedDate)}
+	if filter.GroupByProduct {
+		key["product"] = bson.M{"$ifNull": bson.A{"$product_code", ""}}
+	}
+
+	pipeline := mongo.Pipeline{{{Key: "$match", Value: disbursedTermLoans}}}
+	pipeline = append(pipeline, dateRange(disbursedDate, filter)...)
+	pipeline = append(pipeline,
+		bson.D{{Key: "$group", Value: bson.M{
+			"_id":             key,
+			"loans_disbursed": bson.M{"$sum": 1},
+			"total_disbursed": bson.M{"$sum": "$amount"},
+		}}},
+		bson.D{{Key: "$addFields", Value: bson.M{"cohort": "$_id.cohort", "product": "$_id.product"}}},
+		bson.D{{Key: "$sort", Value: bson.D{{Key: "cohort", Value: 1}, {Key: "product", Value: 1}}}},
+	)
+
+	var cohorts []domain.VintageCurve
+	err := r.aggregate(ctx, r.loans, pipeline, &cohorts)
+	return cohorts, err
+}
+
+func (r *reportRepository) VintageRepayments(ctx context.Context, filter domain.ReportFilter) ([]domain.VintageRepayments, error) {
+	disbursed := "$loan.disbursed_at"
+	key := bson.M{
+		"cohort": monthOf(disbursed),
+		"months_on_book": bson.M{"$max": bson.A{0, bson.M{"$add": bson.A{
+			bson.M{"$multiply": bson.A{12, bson.M{"$subtract": bson.A{bson.M{"$year": "$paid_at"}, bson.M{"$year": disbursed}}}}},
+			bson.M{"$subtract": bson.A{bson.M{"$month": "$paid_at"}, bson.M{"$month": disbursed}}},
+		}}}},
+	}
+	if filter.GroupByProduct {
+		key["product"] = bson.M{"$ifNull": bson.A{"$loan.product_code", ""}}
+	}
+
+	pipeline := mongo.Pipeline{
+		{{Key: "$lookup", Value: bson.M{"from": "loans", "localField": "loan_id", "foreignField": "_id", "as": "loan"}}},
+		{{Key: "$unwind", Value: "$loan"}},
+		{{Key: "$match", Value: bson.M{
+			"loan.status":       bson.M{"$in": bson.A{"approved", "closed"}},
+			"loan.type":         bson.M{"$ne": "credit_line"},
+			"loan.disbursed_at": bson.M{"$exists": true},
+		}}},
+	}
+	pipeline = append(pipeline, dateRange(disbursed, filter)...)
+	pipeline = append(pipeline,
+		bson.D{{Key: "$group", Value: bson.M{
+			"_id":    key,
+			"repaid": bson.M{"$sum": "$amount"},
+		}}},
+		bson.D{{Key: "$addFields", Value: bson.M{
+			"cohort":         "$_id.cohort",
+			"product":        "$_id.product",
+			"months_on_book": "$_id.months_on_book",
+		}}},
+		bson.D{{Key: "$sort", Value: bson.D{{Key: "cohort", Value: 1}, {Key: "months_on_book", Value: 1}}}},
+	)
+
+	var repayments []domain.VintageRepayments
+	err := r.aggregate(ctx, r.repayments, pipeline, &repayments)
+	return repayments, err
+}
+
+func (r *reportRepository) aggregate(ctx context.Context, col *mongo.Collection, pipeline mongo.Pipeline, results interface{}) error {
+	cursor, err := col.Aggregate(ctx, pipeline)
+	if err != nil {
+		return err
+	}
+	return cursor.All(ctx, results)
+}
+
+// dateRange keeps the documents whose date expression falls inside the filter's range
+func dateRange(date interface{}, filter domain.ReportFilter) mongo.Pipeline {
+	var conditions bson.A
+	if !filter.From.IsZero() {
+		conditions = append(conditions, bson.M{"$gte": bson.A{date, filter.From}})
+	}
+	if !filter.To.IsZero() {
+		conditions = append(conditions, bson.M{"$lt": bson.A{date, filter.To}})
+	}
+	if len(conditions) == 0 {
+		return nil
+	}
+	return mongo.Pipeline{{{Key: "$match", Value: bson.M{"$expr": bson.M{"$and": conditions}}}}}
+}
+
+// groupKey groups by product and/or by the month of the date expression; an empty key gives a single total row
+func groupKey(filter domain.ReportFilter, product string, date interface{}) bson.M {
+	key := bson.M{}
+	if filter.GroupByProduct {
+		key["product"] = bson.M{"$ifNull": bson.A{product, ""}}
+	}
+	if filter.GroupByMonth {
+		key["month"] = monthOf(date)
+	}
+	return key
+}
+
+// ungroup lifts the group key back into product and month fields and sorts by them
+func ungroup() mongo.Pipeline {
+	return mongo.Pipeline{
+		{{Key: "$addFields", Value: bson.M{"product": "$_id.product", "month": "$_id.month"}}},
+		{{Key: "$sort", Value: bson.D{{Key: "product", Value: 1}, {Key: "month", Value: 1}}}},
+	}
+}
+
+func monthOf(date interface{}) bson.M {
+	return bson.M{"$dateToString": bson.M{"format": "%Y-%m", "date": date}}
+}
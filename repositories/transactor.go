@@ -0,0 +1,57 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"loan-tracker/domain"
+	"log"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+type mongoTransactor struct {
+	client    *mongo.Client
+	supported bool
+}
+
+// NewTransactor runs transactions on the client's deployment. MongoDB only supports them on replica
+// sets and sharded clusters. Outbox events must be written atomically with the changes they
+// describe, so a standalone server is refused unless allowStandalone is set, in which case the
+// writes are made one by one instead.
+func NewTransactor(client *mongo.Client, allowStandalone bool) (domain.Transactor, error) {
+	var hello struct {
+		SetName string `bson:"setName"`
+		Msg     string `bson:"msg"`
+	}
+	err := client.Database("admin").RunCommand(context.Background(), bson.D{{Key: "hello", Value: 1}}).Decode(&hello)
+	if err != nil {
+		return nil, fmt.Errorf("checking MongoDB transaction support: %w", err)
+	}
+	supported := hello.SetName != "" || hello.Msg == "isdbgrid"
+	if !supported {
+		if !allowStandalone {
+			return nil, errors.New("MongoDB must run as a replica set so outbox events are written atomically; " +
+				"set MONGODB_ALLOW_STANDALONE=true to run without transactions")
+		}
+		log.Println("MongoDB does not support transactions here; outbox events will not be written atomically")
+	}
+	return &mongoTransactor{client: client, supported: supported}, nil
+}
+
+func (t *mongoTransactor) WithTransaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	// A nested call joins the transaction already in progress
+	if !t.supported || mongo.SessionFromContext(ctx) != nil {
+		return fn(ctx)
+	}
+	session, err := t.client.StartSession()
+	if err != nil {
+		return err
+	}
+	defer session.EndSession(ctx)
+	_, err = session.WithTransaction(ctx, func(sc mongo.SessionContext) (interface{}, error) {
+		return nil, fn(sc)
+	})
+	return err
+}
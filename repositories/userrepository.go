@@ -9,6 +9,7 @@ import (
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
 type UserRepository struct {
@@ -21,10 +22,10 @@ func NewUserRepository(client *mongo.Client) domain.UserRepository {
 	}
 }
 
-func (ur *UserRepository) RegisterUser(user *domain.User) error {
+func (ur *UserRepository) RegisterUser(ctx context.Context, user *domain.User) error {
 	filterUser := bson.M{"email": user.Email}
 	var result domain.User
-	err := ur.Col.FindOne(context.Background(), filterUser).Decode(&result)
+	err := ur.Col.FindOne(ctx, filterUser).Decode(&result)
 	if err == nil {
 		if result.IsVerified {
 			return errors.New("user already exists")
@@ -34,6 +35,8 @@ func (ur *UserRepository) RegisterUser(user *domain.User) error {
 	user.ID = primitive.NewObjectID()
 	user.IsVerified = false
 	user.IsAdmin = false
+	user.Role = ""
+	user.KYC = nil
 
 	password, err := infrastructure.PasswordHasher(user.Password)
 	if err != nil {
@@ -41,12 +44,7 @@ func (ur *UserRepository) RegisterUser(user *domain.User) error {
 	}
 	user.Password = password
 
-	err = infrastructure.UserVerification(user.Email)
-	if err != nil {
-		return err
-	}
-
-	_, err = ur.Col.InsertOne(context.TODO(), user)
+	_, err = ur.Col.InsertOne(ctx, user)
 	if err != nil {
 		return err
 	}
@@ -136,21 +134,6 @@ func (ur *UserRepository) FindByID(user domain.User) (domain.User, error) {
 	}
 	return fuser, nil
 }
-func (ur *UserRepository) PasswordResetRequest(email string) error {
-	var user domain.User
-
-	query := bson.M{"email": email}
-	if err := ur.Col.FindOne(context.TODO(), query).Decode(&user); err != nil {
-		return errors.New("user not found")
-	}
-
-	err := infrastructure.ForgotPasswordHandler(email)
-	if err != nil {
-		return err
-	}
-	return nil
-}
-
 func (ur *UserRepository) PasswordReset(token string, newPassword string) error {
 	email, err := infrastructure.VerifyToken(token)
 	if err != nil {
@@ -205,3 +188,166 @@ func (ur *UserRepository) DeleteUser(user domain.User) error {
 	}
 	return nil
 }
+
+func (ur *UserRepository) FindByEmail(email string) (domain.User, error) {
+	filter := bson.M{"email": email}
+	var fuser domain.User
+	err := ur.Col.FindOne(context.Background(), filter).Decode(&fuser)
+	if err != nil {
+		return domain.User{}, err
+	}
+	return fuser, nil
+}
+
+func (ur *UserRepository) FindByUserName(username string) (domain.User, error) {
+	filter := bson.M{"username": username}
+	var fuser domain.User
+	err := ur.Col.FindOne(context.Background(), filter).Decode(&fuser)
+	if err != nil {
+		return domain.User{}, err
+	}
+	return fuser, nil
+}
+
+func (ur *UserRepository) UpdateKYC(user domain.User, kyc domain.KYCProfile) error {
+	filter := bson.M{"_id": user.ID}
+	update := bson.M{"$set": bson.M{"kyc": kyc}}
+
+	result, err := ur.Col.UpdateOne(context.Background(), filter, update)
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return errors.New("user not found")
+	}
+	return nil
+}
+
+func (ur *UserRepository) GetUsersByKYCStatus(status string) ([]domain.ResponseUser, error) {
+	opts := options.Find().SetSort(bson.M{"kyc.submitted_at": 1})
+	cur, err := ur.Col.Find(context.Background(), bson.M{"kyc.status": status}, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cur.Close(context.Background())
+
+	var users []domain.ResponseUser
+	if err := cur.All(context.Background(), &users); err != nil {
+		return nil, err
+	}
+	return users, nil
+}
+
+func (ur *UserRepository) UpdateRole(user domain.User, role string) error {
+	filter := bson.M{"_id": user.ID}
+	update := bson.M{"$set": bson.M{"role": role}}
+
+	result, err := ur.Col.UpdateOne(context.Background(), filter, update)
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return errors.New("user not found")
+	}
+	return nil
+}
+
+func (ur *UserRepository) FindUsers(filter domain.UserFilter) ([]domain.ResponseUser, error) {
+	var users []domain.ResponseUser
+	err := ur.StreamUsers(filter, func(user domain.ResponseUser) error {
+		users = append(users, user)
+		return nil
+	})
+	return users, err
+}
+
+func (ur *UserRepository) StreamUsers(filter domain.UserFilter, fn func(domain.ResponseUser) error) error {
+	query := bson.M{}
+	if filter.IsAdmin != nil {
+		query["isadmin"] = userFlag(*filter.IsAdmin)
+	}
+	if filter.IsVerified != nil {
+		query["isverified"] = userFlag(*filter.IsVerified)
+	}
+	if filter.Role != "" {
+		query["role"] = filter.Role
+	}
+	if filter.KYCStatus != "" {
+		query["kyc.status"] = filter.KYCStatus
+	}
+
+	cur, err := ur.Col.Find(context.Background(), query)
+	if err != nil {
+		return err
+	}
+	defer cur.Close(context.Background())
+
+	for cur.Next(context.Background()) {
+		var user domain.ResponseUser
+		if err := cur.Decode(&user); err != nil {
+			return err
+		}
+		if err := fn(user); err != nil {
+			return err
+		}
+	}
+	return cur.Err()
+}
+
+// userFlag matches a boolean that is left out of the document when false
+func userFlag(value bool) interface{} {
+	if value {
+		return true
+	}
+	return bson.M{"$ne": true}
+}
+
+func (ur *UserRepository) UpdatePhone(user domain.User, phone string, verification *domain.PhoneVerification) error {
+	set, unset := bson.M{}, bson.M{}
+	if phone != "" {
+		set["phone"] = phone
+	} else {
+		unset["phone"] = ""
+	}
+	if verification != nil {
+		set["phone_verification"] = verification
+	} else {
+		unset["phone_verification"] = ""
+	}
+	update := bson.M{}
+	if len(set) > 0 {
+		update["$set"] = set
+	}
+	if len(unset) > 0 {
+		update["$unset"] = unset
+	}
+
+	result, err := ur.Col.UpdateOne(context.Background(), bson.M{"_id": user.ID}, update)
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return errors.New("user not found")
+	}
+	return nil
+}
+
+func (ur *UserRepository) AddPushDevice(user domain.User, device domain.PushDevice) error {
+	if err := ur.RemovePushDevice(user, device.Token); err != nil {
+		return err
+	}
+	_, err := ur.Col.UpdateOne(context.Background(), bson.M{"_id": user.ID}, bson.M{"$push": bson.M{"push_devices": device}})
+	return err
+}
+
+func (ur *UserRepository) RemovePushDevice(user domain.User, token string) error {
+	result, err := ur.Col.UpdateOne(context.Background(), bson.M{"_id": user.ID},
+		bson.M{"$pull": bson.M{"push_devices": bson.M{"token": token}}})
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return errors.New("user not found")
+	}
+	return nil
+}
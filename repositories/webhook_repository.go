@@ -0,0 +1,167 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"loan-tracker/domain"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type webhookRepository struct {
+	subscriptions *mongo.Collection
+	deliveries    *mongo.Collection
+}
+
+func NewWebhookRepository(db *mongo.Client) domain.WebhookRepository {
+	database := db.Database("loan-tracker")
+	return &webhookRepository{
+		subscriptions: database.Collection("webhook_subscriptions"),
+		deliveries:    database.Collection("webhook_deliveries"),
+	}
+}
+
+func (r *webhookRepository) CreateSubscription(ctx context.Context, subscription domain.WebhookSubscription) (primitive.ObjectID, error) {
+	result, err := r.subscriptions.InsertOne(ctx, subscription)
+	if err != nil {
+		return primitive.NilObjectID, err
+	}
+	return result.InsertedID.(primitive.ObjectID), nil
+}
+
+func (r *webhookRepository) GetSubscriptionByID(ctx context.Context, id primitive.ObjectID) (domain.WebhookSubscription, error) {
+	var subscription domain.WebhookSubscription
+	err := r.subscriptions.FindOne(ctx, bson.M{"_id": id}).Decode(&subscription)
+	return subscription, err
+}
+
+func (r *webhookRepository) GetSubscriptions(ctx context.Context) ([]domain.WebhookSubscription, error) {
+	return r.findSubscriptions(ctx, bson.M{})
+}
+
+func (r *webhookRepository) GetActiveSubscriptions(ctx context.Context, eventType string) ([]domain.WebhookSubscription, error) {
+	return r.findSubscriptions(ctx, bson.M{
+		"active": true,
+		"$or": []bson.M{
+			{"event_types": eventType},
+			{"event_types": bson.M{"$size": 0}},
+			{"event_types": nil},
+		},
+	})
+}
+
+func (r *webhookRepository) UpdateSubscription(ctx context.Context, subscription domain.WebhookSubscription) error {
+	result, err := r.subscriptions.ReplaceOne(ctx, bson.M{"_id": subscription.ID}, subscription)
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return errors.New("webhook subscription not found")
+	}
+	return nil
+}
+
+func (r *webhookRepository) DeleteSubscription(ctx context.Context, id primitive.ObjectID) error {
+	result, err := r.subscriptions.DeleteOne(ctx, bson.M{"_id": id})
+	if err != nil {
+		return err
+	}
+	if result.DeletedCount == 0 {
+		return errors.New("webhook subscription not found")
+	}
+	return nil
+}
+
+func (r *webhookRepository) QueueDelivery(ctx context.Context, delivery domain.WebhookDelivery) error {
+	_, err := r.deliveries.UpdateOne(ctx,
+		bson.M{"subscription_id": delivery.SubscriptionID, "event_id": delivery.EventID, "replay_of": bson.M{"$exists": false}},
+		bson.M{"$setOnInsert": delivery},
+		options.Update().SetUpsert(true))
+	return err
+}
+
+func (r *webhookRepository) CreateDelivery(ctx context.Context, delivery domain.WebhookDelivery) (primitive.ObjectID, error) {
+	result, err := r.deliveries.InsertOne(ctx, delivery)
+	if err != nil {
+		return primitive.NilObjectID, err
+	}
+	return result.InsertedID.(primitive.ObjectID), nil
+}
+
+func (r *webhookRepository) GetDeliveryByID(ctx context.Context, id primitive.ObjectID) (domain.WebhookDelivery, error) {
+	var delivery domain.WebhookDelivery
+	err := r.deliveries.FindOne(ctx, bson.M{"_id": id}).Decode(&delivery)
+	return delivery, err
+}
+
+func (r *webhookRepository) FindDeliveries(ctx context.Context, filter domain.WebhookDeliveryFilter) ([]domain.WebhookDelivery, error) {
+	query := bson.M{}
+	if !filter.SubscriptionID.IsZero() {
+		query["subscription_id"] = filter.SubscriptionID
+	}
+	if filter.EventType != "" {
+		query["event_type"] = filter.EventType
+	}
+	if filter.Status != "" {
+		query["status"] = filter.Status
+	}
+	return r.findDeliveries(ctx, query, options.Find().SetSort(bson.M{"created_at": -1}).SetLimit(500))
+}
+
+func (r *webhookRepository) GetDueDeliveries(ctx context.Context, now time.Time, limit int) ([]domain.WebhookDelivery, error) {
+	return r.findDeliveries(ctx,
+		bson.M{"status": bson.M{"$in": []string{"pending", "retrying"}}, "next_attempt_at": bson.M{"$lte": now}},
+		options.Find().SetSort(bson.D{{Key: "created_at", Value: 1}, {Key: "_id", Value: 1}}).SetLimit(int64(limit)))
+}
+
+func (r *webhookRepository) ClaimDelivery(ctx context.Context, delivery domain.WebhookDelivery, until time.Time) error {
+	result, err := r.deliveries.UpdateOne(ctx,
+		bson.M{"_id": delivery.ID, "status": delivery.Status, "next_attempt_at": delivery.NextAttemptAt},
+		bson.M{"$set": bson.M{"next_attempt_at": until}})
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return errors.New("delivery has been claimed by another worker")
+	}
+	return nil
+}
+
+func (r *webhookRepository) UpdateDelivery(ctx context.Context, delivery domain.WebhookDelivery) error {
+	_, err := r.deliveries.ReplaceOne(ctx, bson.M{"_id": delivery.ID}, delivery)
+	return err
+}
+
+func (r *webhookRepository) CancelDeliveries(ctx context.Context, subscriptionID primitive.ObjectID, reason string) error {
+	_, err := r.deliveries.UpdateMany(ctx,
+		bson.M{"subscription_id": subscriptionID, "status": bson.M{"$in": []string{"pending", "retrying"}}},
+		bson.M{
+			"$set":   bson.M{"status": "cancelled", "last_error": reason, "updated_at": time.Now()},
+			"$unset": bson.M{"next_attempt_at": ""},
+		})
+	return err
+}
+
+func (r *webhookRepository) findSubscriptions(ctx context.Context, query bson.M) ([]domain.WebhookSubscription, error) {
+	var subscriptions []domain.WebhookSubscription
+	cursor, err := r.subscriptions.Find(ctx, query, options.Find().SetSort(bson.M{"created_at": 1}))
+	if err != nil {
+		return nil, err
+	}
+	err = cursor.All(ctx, &subscriptions)
+	return subscriptions, err
+}
+
+func (r *webhookRepository) findDeliveries(ctx context.Context, query bson.M, opts *options.FindOptions) ([]domain.WebhookDelivery, error) {
+	var deliveries []domain.WebhookDelivery
+	cursor, err := r.deliveries.Find(ctx, query, opts)
+	if err != nil {
+		return nil, err
+	}
+	err = cursor.All(ctx, &deliveries)
+	return deliveries, err
+}
@@ -0,0 +1,185 @@
+package usecase
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"loan-tracker/domain"
+	"loan-tracker/infrastructure"
+	"math/big"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const (
+	// maxCodeAttempts is how many wrong codes are allowed before a new one must be requested
+	maxCodeAttempts = 5
+	// codeResendDelay stops a borrower from flooding their inbox with codes
+	codeResendDelay = time.Minute
+)
+
+type acceptanceUsecase struct {
+	acceptanceRepo domain.AcceptanceRepository
+	documentRepo   domain.LoanDocumentRepository
+	loanRepo       domain.LoanRepository
+	userRepo       domain.UserRepository
+	historyRepo    domain.LoanHistoryRepository
+	emailRepo      domain.EmailRepository
+}
+
+// NewAcceptanceUsecase creates a new instance of AcceptanceUsecase
+func NewAcceptanceUsecase(acceptanceRepo domain.AcceptanceRepository, documentRepo domain.LoanDocumentRepository,
+	loanRepo domain.LoanRepository, userRepo domain.UserRepository, historyRepo domain.LoanHistoryRepository,
+	emailRepo domain.EmailRepository) domain.AcceptanceUsecase {
+	return &acceptanceUsecase{
+		acceptanceRepo: acceptanceRepo,
+		documentRepo:   documentRepo,
+		loanRepo:       loanRepo,
+		userRepo:       userRepo,
+		historyRepo:    historyRepo,
+		emailRepo:      emailRepo,
+	}
+}
+
+// RequestCode emails the borrower a one-time code tied to the loan's current agreement
+func (uc *acceptanceUsecase) RequestCode(ctx context.Context, loanID, userID primitive.ObjectID) (domain.AgreementAcceptance, error) {
+	loan, agreement, err := uc.acceptableAgreement(ctx, loanID, userID)
+	if err != nil {
+		return domain.AgreementAcceptance{}, err
+	}
+	if _, err := uc.acceptanceRepo.GetAcceptedAgreement(ctx, loanID, agreement.Checksum); err == nil {
+		return domain.AgreementAcceptance{}, errors.New("you have already accepted this agreement")
+	}
+	if pending, err := uc.acceptanceRepo.GetPendingAcceptance(ctx, loanID); err == nil && time.Since(pending.CodeSentAt) < codeResendDelay {
+		return domain.AgreementAcceptance{}, errors.New("a code was sent less than a minute ago, please check your email")
+	}
+	borrower, err := uc.userRepo.FindByID(domain.User{ID: loan.UserID})
+	if err != nil {
+		return domain.AgreementAcceptance{}, errors.New("borrower not found")
+	}
+
+	code, err := oneTimeCode()
+	if err != nil {
+		return domain.AgreementAcceptance{}, err
+	}
+	validFor := infrastructure.IntervalSetting("ACCEPTANCE_CODE_TTL", 10*time.Minute)
+	now := time.Now()
+	acceptance := domain.AgreementAcceptance{
+		ID:                primitive.NewObjectID(),
+		LoanID:            loanID,
+		UserID:            loan.UserID,
+		DocumentID:        agreement.ID,
+		AgreementChecksum: agreement.Checksum,
+		TemplateVersion:   agreement.TemplateVersion,
+		Status:            "pending",
+		Email:             borrower.Email,
+		CodeSentAt:        now,
+		CodeExpiresAt:     now.Add(validFor),
+	}
+	acceptance.CodeHash = codeHash(acceptance.ID, code)
+	if _, err := uc.acceptanceRepo.CreateAcceptance(ctx, acceptance); err != nil {
+		return domain.AgreementAcceptance{}, err
+	}
+	email, err := infrastructure.AcceptanceCodeEmail(borrower.Email, borrower.Locale, loanID.Hex(), code, validFor)
+	if err != nil {
+		return domain.AgreementAcceptance{}, err
+	}
+	if err := queueEmail(ctx, uc.emailRepo, email); err != nil {
+		return domain.AgreementAcceptance{}, err
+	}
+	return acceptance, nil
+}
+
+// AcceptAgreement checks the emailed code and records the acceptance with its evidence
+func (uc *acceptanceUsecase) AcceptAgreement(ctx context.Context, loanID, userID primitive.ObjectID, req domain.AcceptanceRequest,
+	ipAddress, userAgent string) (domain.AgreementAcceptance, error) {
+	if req.Code == "" || req.AgreementChecksum == "" {
+		return domain.AgreementAcceptance{}, errors.New("please provide the code and the agreement_checksum of the agreement you read")
+	}
+	_, agreement, err := uc.acceptableAgreement(ctx, loanID, userID)
+	if err != nil {
+		return domain.AgreementAcceptance{}, err
+	}
+	if req.AgreementChecksum != agreement.Checksum {
+		return domain.AgreementAcceptance{}, errors.New("the agreement has been reissued, please download and review the current version")
+	}
+	pending, err := uc.acceptanceRepo.GetPendingAcceptance(ctx, loanID)
+	if err != nil {
+		return domain.AgreementAcceptance{}, errors.New("no code has been requested for this agreement")
+	}
+	switch {
+	case pending.DocumentID != agreement.ID:
+		return domain.AgreementAcceptance{}, errors.New("the agreement has changed since the code was sent, please request a new code")
+	case time.Now().After(pending.CodeExpiresAt):
+		return domain.AgreementAcceptance{}, errors.New("the code has expired, please request a new code")
+	case pending.Attempts >= maxCodeAttempts:
+		return domain.AgreementAcceptance{}, errors.New("too many wrong codes, please request a new code")
+	}
+	if subtle.ConstantTimeCompare([]byte(codeHash(pending.ID, req.Code)), []byte(pending.CodeHash)) != 1 {
+		if err := uc.acceptanceRepo.RecordFailedAttempt(ctx, pending.ID); err != nil {
+			return domain.AgreementAcceptance{}, err
+		}
+		return domain.AgreementAcceptance{}, errors.New("invalid code")
+	}
+
+	pending.Status = "accepted"
+	pending.AcceptedAt = time.Now()
+	pending.IPAddress = ipAddress
+	pending.UserAgent = userAgent
+	if err := uc.acceptanceRepo.ConfirmAcceptance(ctx, pending); err != nil {
+		return domain.AgreementAcceptance{}, err
+	}
+	recordLoanEvent(ctx, uc.historyRepo, domain.LoanEvent{
+		LoanID:    loanID,
+		Type:      "agreement_accepted",
+		ActorID:   userID,
+		Note:      fmt.Sprintf("agreement sha256 %s from %s", pending.AgreementChecksum, ipAddress),
+		Timestamp: pending.AcceptedAt,
+	})
+	return pending, nil
+}
+
+// GetAcceptances lists the recorded acceptances of a loan's agreements, newest first
+func (uc *acceptanceUsecase) GetAcceptances(ctx context.Context, loanID, userID primitive.ObjectID, isAdmin bool) ([]domain.AgreementAcceptance, error) {
+	loan, err := uc.loanRepo.GetLoanByID(ctx, loanID)
+	if err != nil || (!isAdmin && loan.UserID != userID) {
+		return nil, errors.New("loan not found")
+	}
+	return uc.acceptanceRepo.GetAcceptancesByLoan(ctx, loanID)
+}
+
+// acceptableAgreement returns the borrower's loan and its latest agreement while it can still be accepted
+func (uc *acceptanceUsecase) acceptableAgreement(ctx context.Context, loanID, userID primitive.ObjectID) (domain.Loan, domain.LoanDocument, error) {
+	loan, err := uc.loanRepo.GetLoanByID(ctx, loanID)
+	if err != nil || loan.UserID != userID {
+		return domain.Loan{}, domain.LoanDocument{}, errors.New("loan not found")
+	}
+	if loan.Status != "approved" {
+		return domain.Loan{}, domain.LoanDocument{}, errors.New("only approved loans have an agreement to accept")
+	}
+	if !loan.DisbursedAt.IsZero() {
+		return domain.Loan{}, domain.LoanDocument{}, errors.New("loan has already been disbursed")
+	}
+	agreement, err := uc.documentRepo.GetLatestDocument(ctx, loanID, "agreement")
+	if err != nil {
+		return domain.Loan{}, domain.LoanDocument{}, errors.New("the agreement for this loan is not available yet")
+	}
+	return loan, agreement, nil
+}
+
+// oneTimeCode returns a random six-digit code
+func oneTimeCode() (string, error) {
+	n, err := rand.Int(rand.Reader, big.NewInt(1000000))
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%06d", n.Int64()), nil
+}
+
+// codeHash keys the code to its acceptance record so a stored hash cannot be reused for another
+func codeHash(id primitive.ObjectID, code string) string {
+	return checksum([]byte(id.Hex() + ":" + code))
+}
@@ -0,0 +1,317 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"loan-tracker/domain"
+	"loan-tracker/infrastructure"
+	"log"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type assignmentUsecase struct {
+	loanRepo    domain.LoanRepository
+	userRepo    domain.UserRepository
+	historyRepo domain.LoanHistoryRepository
+	outboxRepo  domain.OutboxRepository
+}
+
+// NewAssignmentUsecase creates a new instance of AssignmentUsecase
+func NewAssignmentUsecase(loanRepo domain.LoanRepository, userRepo domain.UserRepository, historyRepo domain.LoanHistoryRepository,
+	outboxRepo domain.OutboxRepository) domain.AssignmentUsecase {
+	return &assignmentUsecase{
+		loanRepo:    loanRepo,
+		userRepo:    userRepo,
+		historyRepo: historyRepo,
+		outboxRepo:  outboxRepo,
+	}
+}
+
+// AssignLoan assigns a pending loan to the given admin by hand
+func (uc *assignmentUsecase) AssignLoan(ctx context.Context, id, officerID, actorID primitive.ObjectID, isSupervisor bool) (domain.Loan, error) {
+	loan, err := uc.loanRepo.GetLoanByID(ctx, id)
+	if err != nil {
+		return domain.Loan{}, errors.New("loan not found")
+	}
+	if loan.Status != "pending" {
+		return domain.Loan{}, errors.New("only pending applications can be assigned")
+	}
+	if loan.AssignedOfficerID == officerID {
+		return domain.Loan{}, errors.New("loan is already assigned to this officer")
+	}
+	if !loan.AssignedOfficerID.IsZero() && !isSupervisor {
+		return domain.Loan{}, errors.New("only supervisors can reassign loans")
+	}
+
+	officer, err := uc.userRepo.FindByID(domain.User{ID: officerID})
+	if err != nil || !officer.IsAdmin {
+		return domain.Loan{}, errors.New("officer not found")
+	}
+
+	previous := loan.AssignedOfficerID
+	if err := assignLoan(ctx, uc.loanRepo, uc.historyRepo, &loan, officerID, actorID, "manual"); err != nil {
+		return domain.Loan{}, err
+	}
+	recordEvent(ctx, uc.outboxRepo, loanEvent(domain.EventLoanAssigned, loan, actorID, map[string]interface{}{
+		"officer_id":          officerID.Hex(),
+		"previous_officer_id": hexOrEmpty(previous),
+	}))
+	return loan, nil
+}
+
+// AutoAssignPending spreads the unassigned pending loans over the officers, oldest application first
+func (uc *assignmentUsecase) AutoAssignPending(ctx context.Context, actorID primitive.ObjectID, strategy string) (int, error) {
+	switch strategy {
+	case "":
+		strategy = assignmentStrategy()
+		if strategy == "manual" {
+			strategy = "workload"
+		}
+	case "round_robin", "workload":
+	default:
+		return 0, errors.New("invalid strategy, you can only enter round_robin or workload")
+	}
+
+	roster, err := loadRoster(ctx, uc.loanRepo, uc.userRepo)
+	if err != nil {
+		return 0, err
+	}
+	if len(roster.officers) == 0 {
+		return 0, errors.New("there are no loan officers to assign to")
+	}
+
+	pending, err := uc.loanRepo.FindLoans(ctx, domain.LoanFilter{Status: "pending", Order: "asc"})
+	if err != nil {
+		return 0, err
+	}
+	assigned := 0
+	for i := range pending {
+		if !pending[i].AssignedOfficerID.IsZero() {
+			continue
+		}
+		officerID := roster.next(strategy, time.Now())
+		if err := assignLoan(ctx, uc.loanRepo, uc.historyRepo, &pending[i], officerID, actorID, strategy); err != nil {
+			return assigned, err
+		}
+		assigned++
+	}
+	return assigned, nil
+}
+
+// GetQueue lists the pending loans assigned to the officer, oldest first
+func (uc *assignmentUsecase) GetQueue(ctx context.Context, officerID primitive.ObjectID) ([]domain.Loan, error) {
+	return uc.loanRepo.FindLoans(ctx, domain.LoanFilter{Status: "pending", AssignedOfficerID: officerID, Order: "asc"})
+}
+
+// StartReview moves a loan the officer has been assigned into review
+func (uc *assignmentUsecase) StartReview(ctx context.Context, id, officerID primitive.ObjectID) (domain.Loan, error) {
+	loan, err := uc.loanRepo.GetLoanByID(ctx, id)
+	if err != nil {
+		return domain.Loan{}, errors.New("loan not found")
+	}
+	if loan.Status != "pending" {
+		return domain.Loan{}, errors.New("only pending applications can be reviewed")
+	}
+	if loan.AssignedOfficerID != officerID {
+		return domain.Loan{}, errors.New("loan is not assigned to you")
+	}
+	if loan.Stage == "in_review" {
+		return domain.Loan{}, errors.New("review has already started")
+	}
+
+	now := time.Now()
+	enterStage(&loan, "in_review", officerID, now)
+	if err := uc.loanRepo.UpdateAssignment(ctx, loan); err != nil {
+		return domain.Loan{}, err
+	}
+	recordLoanEvent(ctx, uc.historyRepo, domain.LoanEvent{
+		LoanID:    loan.ID,
+		Type:      "review_started",
+		ActorID:   officerID,
+		Changes:   []domain.FieldChange{{Field: "stage", OldValue: "assigned", NewValue: "in_review"}},
+		Timestamp: now,
+	})
+	return loan, nil
+}
+
+// GetWorkloads lists every admin with the number of loans assigned to them
+func (uc *assignmentUsecase) GetWorkloads(ctx context.Context) ([]domain.OfficerWorkload, error) {
+	users, err := uc.userRepo.GetAllUsers()
+	if err != nil {
+		return nil, err
+	}
+	counts, err := uc.loanRepo.OfficerWorkloads(ctx)
+	if err != nil {
+		return nil, err
+	}
+	byOfficer := make(map[primitive.ObjectID]domain.OfficerWorkload)
+	for _, w := range counts {
+		byOfficer[w.OfficerID] = w
+	}
+
+	workloads := []domain.OfficerWorkload{}
+	for _, user := range users {
+		if !user.IsAdmin {
+			continue
+		}
+		w := byOfficer[user.ID]
+		w.OfficerID = user.ID
+		w.UserName = user.UserName
+		workloads = append(workloads, w)
+	}
+	return workloads, nil
+}
+
+// GetStageTimes reports how long completed review stages took on average
+func (uc *assignmentUsecase) GetStageTimes(ctx context.Context, byOfficer bool) ([]domain.StageTime, error) {
+	return uc.loanRepo.StageTimes(ctx, byOfficer)
+}
+
+// assignLoan gives the loan to the officer, starting a new "assigned" stage, and records it in the history.
+// how is "manual" or the auto-assignment strategy that picked the officer.
+func assignLoan(ctx context.Context, loanRepo domain.LoanRepository, historyRepo domain.LoanHistoryRepository,
+	loan *domain.Loan, officerID, actorID primitive.ObjectID, how string) error {
+	previous := loan.AssignedOfficerID
+	now := time.Now()
+	loan.AssignedOfficerID = officerID
+	loan.AssignedAt = now
+	enterStage(loan, "assigned", officerID, now)
+	if err := loanRepo.UpdateAssignment(ctx, *loan); err != nil {
+		return err
+	}
+
+	change := domain.FieldChange{Field: "assigned_officer_id", NewValue: officerID.Hex()}
+	eventType := "assigned"
+	if !previous.IsZero() {
+		change.OldValue = previous.Hex()
+		eventType = "reassigned"
+	}
+	recordLoanEvent(ctx, historyRepo, domain.LoanEvent{
+		LoanID:    loan.ID,
+		Type:      eventType,
+		ActorID:   actorID,
+		Changes:   []domain.FieldChange{change},
+		Note:      how,
+		Timestamp: now,
+	})
+	return nil
+}
+
+// autoAssign hands a new application to an officer using the configured strategy.
+// The application is already saved, so a failure only leaves it unassigned.
+func autoAssign(ctx context.Context, loanRepo domain.LoanRepository, userRepo domain.UserRepository,
+	historyRepo domain.LoanHistoryRepository, loan *domain.Loan) {
+	strategy := assignmentStrategy()
+	if strategy == "manual" {
+		return
+	}
+	roster, err := loadRoster(ctx, loanRepo, userRepo)
+	if err != nil {
+		log.Println("Error loading loan officers:", err)
+		return
+	}
+	officerID := roster.next(strategy, time.Now())
+	if officerID.IsZero() {
+		return
+	}
+	if err := assignLoan(ctx, loanRepo, historyRepo, loan, officerID, primitive.NilObjectID, strategy); err != nil {
+		log.Println("Error assigning loan:", err)
+	}
+}
+
+// assignmentStrategy reads ASSIGNMENT_STRATEGY: "manual", "round_robin" or "workload"
+func assignmentStrategy() string {
+	strategy := infrastructure.DotEnvLoaderDefault("ASSIGNMENT_STRATEGY", "workload")
+	switch strategy {
+	case "manual", "round_robin", "workload":
+		return strategy
+	}
+	log.Printf("Invalid ASSIGNMENT_STRATEGY %q, using workload", strategy)
+	return "workload"
+}
+
+// officerRoster is the set of admins that take part in auto-assignment; supervisors only get loans by hand.
+type officerRoster struct {
+	officers  []primitive.ObjectID
+	workloads map[primitive.ObjectID]*domain.OfficerWorkload
+}
+
+func loadRoster(ctx context.Context, loanRepo domain.LoanRepository, userRepo domain.UserRepository) (*officerRoster, error) {
+	users, err := userRepo.GetAllUsers()
+	if err != nil {
+		return nil, err
+	}
+	counts, err := loanRepo.OfficerWorkloads(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	roster := &officerRoster{workloads: make(map[primitive.ObjectID]*domain.OfficerWorkload)}
+	for _, user := range users {
+		if user.IsAdmin && user.Role != "supervisor" {
+			roster.officers = append(roster.officers, user.ID)
+			roster.workloads[user.ID] = &domain.OfficerWorkload{OfficerID: user.ID}
+		}
+	}
+	for _, w := range counts {
+		if current, ok := roster.workloads[w.OfficerID]; ok {
+			*current = w
+		}
+	}
+	return roster, nil
+}
+
+// next picks the officer for the next loan and counts the assignment against them.
+// Round-robin takes whoever was assigned least recently; workload takes whoever has the fewest
+// pending loans, breaking ties the same way.
+func (r *officerRoster) next(strategy string, now time.Time) primitive.ObjectID {
+	var best *domain.OfficerWorkload
+	for _, id := range r.officers {
+		w := r.workloads[id]
+		switch {
+		case best == nil:
+			best = w
+		case strategy == "workload" && w.OpenLoans != best.OpenLoans:
+			if w.OpenLoans < best.OpenLoans {
+				best = w
+			}
+		case w.LastAssignedAt.Before(best.LastAssignedAt):
+			best = w
+		}
+	}
+	if best == nil {
+		return primitive.NilObjectID
+	}
+	best.OpenLoans++
+	best.TotalLoans++
+	best.LastAssignedAt = now
+	return best.OfficerID
+}
+
+// enterStage ends the loan's current review stage and starts the next one
+func enterStage(loan *domain.Loan, stage string, officerID primitive.ObjectID, now time.Time) {
+	closeStage(loan, now)
+	loan.Stage = stage
+	loan.Stages = append(loan.Stages, domain.StageInterval{Stage: stage, OfficerID: officerID, EnteredAt: now})
+}
+
+// closeStage ends the open review stage, if any, and records its duration
+func closeStage(loan *domain.Loan, now time.Time) {
+	n := len(loan.Stages)
+	if n == 0 || !loan.Stages[n-1].ExitedAt.IsZero() {
+		return
+	}
+	loan.Stages[n-1].ExitedAt = now
+	loan.Stages[n-1].Seconds = int64(now.Sub(loan.Stages[n-1].EnteredAt).Seconds())
+}
+
+// finishReview closes the review stages of an application that has left pending
+func finishReview(ctx context.Context, loanRepo domain.LoanRepository, loan domain.Loan) {
+	closeStage(&loan, time.Now())
+	loan.Stage = "decided"
+	if err := loanRepo.UpdateAssignment(ctx, loan); err != nil {
+		log.Println("Error recording review stage:", err)
+	}
+}
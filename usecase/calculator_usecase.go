@@ -0,0 +1,57 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"loan-tracker/domain"
+	"time"
+)
+
+type calculatorUsecase struct {
+	productRepo domain.ProductRepository
+}
+
+// NewCalculatorUsecase creates a new instance of CalculatorUsecase
+func NewCalculatorUsecase(productRepo domain.ProductRepository) domain.CalculatorUsecase {
+	return &calculatorUsecase{
+		productRepo: productRepo,
+	}
+}
+
+// Quote prices a prospective loan with the same schedule generation used when a loan is approved
+func (uc *calculatorUsecase) Quote(ctx context.Context, req domain.QuoteRequest) (domain.Quote, error) {
+	if req.Frequency == "" {
+		req.Frequency = "monthly"
+	}
+
+	rate := req.InterestRate
+	var feePercent float64
+	if req.Product != "" {
+		product, err := uc.productRepo.GetProductByCode(ctx, req.Product)
+		if err != nil {
+			return domain.Quote{}, errors.New("loan product not found")
+		}
+		rate = product.InterestRate
+		feePercent = product.ProcessingFee
+	}
+
+	schedule, err := GenerateSchedule(req.Amount, rate, req.Tenor, req.Frequency, time.Now())
+	if err != nil {
+		return domain.Quote{}, err
+	}
+
+	total := scheduleTotal(schedule)
+	fee := roundCents(req.Amount * feePercent / 100)
+	return domain.Quote{
+		Amount:            req.Amount,
+		InterestRate:      rate,
+		Tenor:             req.Tenor,
+		Frequency:         req.Frequency,
+		InstallmentAmount: schedule[0].Amount,
+		TotalInterest:     roundCents(total - req.Amount),
+		ProcessingFee:     fee,
+		TotalPayable:      total,
+		APR:               computeAPR(req.Amount, fee, schedule, req.Frequency),
+		Schedule:          schedule,
+	}, nil
+}
@@ -0,0 +1,145 @@
+package usecase
+
+import (
+	"context"
+	"crypto/subtle"
+	"errors"
+	"loan-tracker/domain"
+	"loan-tracker/infrastructure"
+	"regexp"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// e164 is an international phone number: a plus sign, a country code and up to 15 digits in all
+var e164 = regexp.MustCompile(`^\+[1-9][0-9]{7,14}$`)
+
+var pushPlatforms = map[string]bool{"ios": true, "android": true, "web": true}
+
+type contactUsecase struct {
+	userRepo domain.UserRepository
+	sms      infrastructure.NotificationProvider
+}
+
+// NewContactUsecase creates a new instance of ContactUsecase texting codes through the given SMS provider
+func NewContactUsecase(userRepo domain.UserRepository, sms infrastructure.NotificationProvider) domain.ContactUsecase {
+	return &contactUsecase{
+		userRepo: userRepo,
+		sms:      sms,
+	}
+}
+
+// RequestPhoneVerification texts a code valid for PHONE_CODE_TTL (10m). The current phone, if
+// any, keeps receiving messages until the new one is verified.
+func (uc *contactUsecase) RequestPhoneVerification(ctx context.Context, userID primitive.ObjectID, phone string) error {
+	if uc.sms == nil {
+		return errors.New("phone verification is not available, text messages are disabled")
+	}
+	phone = normalizePhone(phone)
+	if !e164.MatchString(phone) {
+		return errors.New("invalid phone number, use the international format, e.g. +14155550123")
+	}
+	user, err := uc.userRepo.FindByID(domain.User{ID: userID})
+	if err != nil {
+		return errors.New("user not found")
+	}
+	if user.Phone == phone {
+		return errors.New("this phone number is already verified")
+	}
+	if pending := user.PhoneVerification; pending != nil && time.Since(pending.SentAt) < codeResendDelay {
+		return errors.New("a code was sent less than a minute ago, please check your messages")
+	}
+
+	code, err := oneTimeCode()
+	if err != nil {
+		return err
+	}
+	validFor := infrastructure.IntervalSetting("PHONE_CODE_TTL", 10*time.Minute)
+	now := time.Now()
+	verification := &domain.PhoneVerification{
+		Phone:     phone,
+		CodeHash:  codeHash(user.ID, phone+":"+code),
+		SentAt:    now,
+		ExpiresAt: now.Add(validFor),
+	}
+	if err := uc.userRepo.UpdatePhone(user, user.Phone, verification); err != nil {
+		return err
+	}
+	if err := uc.sms.Send(ctx, infrastructure.PhoneVerificationSMS(phone, user.Locale, code, validFor)); err != nil {
+		return errors.New("the verification code could not be sent, please try again")
+	}
+	return nil
+}
+
+func (uc *contactUsecase) VerifyPhone(ctx context.Context, userID primitive.ObjectID, code string) (string, error) {
+	user, err := uc.userRepo.FindByID(domain.User{ID: userID})
+	if err != nil {
+		return "", errors.New("user not found")
+	}
+	pending := user.PhoneVerification
+	switch {
+	case pending == nil:
+		return "", errors.New("no code has been requested, please add a phone number first")
+	case time.Now().After(pending.ExpiresAt):
+		return "", errors.New("the code has expired, please request a new code")
+	case pending.Attempts >= maxCodeAttempts:
+		return "", errors.New("too many wrong codes, please request a new code")
+	}
+	if subtle.ConstantTimeCompare([]byte(codeHash(user.ID, pending.Phone+":"+strings.TrimSpace(code))), []byte(pending.CodeHash)) != 1 {
+		pending.Attempts++
+		if err := uc.userRepo.UpdatePhone(user, user.Phone, pending); err != nil {
+			return "", err
+		}
+		return "", errors.New("invalid code")
+	}
+
+	if err := uc.userRepo.UpdatePhone(user, pending.Phone, nil); err != nil {
+		return "", err
+	}
+	return pending.Phone, nil
+}
+
+func (uc *contactUsecase) RemovePhone(ctx context.Context, userID primitive.ObjectID) error {
+	return uc.userRepo.UpdatePhone(domain.User{ID: userID}, "", nil)
+}
+
+func (uc *contactUsecase) GetPushDevices(ctx context.Context, userID primitive.ObjectID) ([]domain.PushDevice, error) {
+	user, err := uc.userRepo.FindByID(domain.User{ID: userID})
+	if err != nil {
+		return nil, errors.New("user not found")
+	}
+	if user.PushDevices == nil {
+		return []domain.PushDevice{}, nil
+	}
+	return user.PushDevices, nil
+}
+
+func (uc *contactUsecase) RegisterPushDevice(ctx context.Context, userID primitive.ObjectID, device domain.PushDevice) error {
+	device.Token = strings.TrimSpace(device.Token)
+	if device.Token == "" {
+		return errors.New("please provide the device token")
+	}
+	device.Platform = strings.ToLower(device.Platform)
+	if !pushPlatforms[device.Platform] {
+		return errors.New("invalid platform, you can only enter ios, android or web")
+	}
+	device.AddedAt = time.Now()
+	return uc.userRepo.AddPushDevice(domain.User{ID: userID}, device)
+}
+
+func (uc *contactUsecase) RemovePushDevice(ctx context.Context, userID primitive.ObjectID, token string) error {
+	return uc.userRepo.RemovePushDevice(domain.User{ID: userID}, token)
+}
+
+// normalizePhone drops the spaces, dashes, dots and brackets people type into phone numbers
+func normalizePhone(phone string) string {
+	return strings.Map(func(r rune) rune {
+		switch r {
+		case ' ', '-', '.', '(', ')':
+			return -1
+		}
+		return r
+	}, strings.TrimSpace(phone))
+}
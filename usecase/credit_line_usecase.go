@@ -0,0 +1,242 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"loan-tracker/domain"
+	"math"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type creditLineUsecase struct {
+	creditLineRepo domain.CreditLineRepository
+	outboxRepo     domain.OutboxRepository
+	transactor     domain.Transactor
+}
+
+// NewCreditLineUsecase creates a new instance of CreditLineUsecase
+func NewCreditLineUsecase(creditLineRepo domain.CreditLineRepository, outboxRepo domain.OutboxRepository, transactor domain.Transactor) domain.CreditLineUsecase {
+	return &creditLineUsecase{
+		creditLineRepo: creditLineRepo,
+		outboxRepo:     outboxRepo,
+		transactor:     transactor,
+	}
+}
+
+// creditLineEventTypes maps each kind of credit line movement to the event published for it
+var creditLineEventTypes = map[string]string{
+	"draw":         domain.EventCreditLineDrawn,
+	"repayment":    domain.EventCreditLineRepaid,
+	"limit_change": domain.EventCreditLineLimitChanged,
+	"freeze":       domain.EventCreditLineStatusChanged,
+	"unfreeze":     domain.EventCreditLineStatusChanged,
+}
+
+// GetMyCreditLines lists the borrower's credit lines with interest accrued up to now
+func (uc *creditLineUsecase) GetMyCreditLines(ctx context.Context, userID primitive.ObjectID) ([]domain.CreditLine, error) {
+	lines, err := uc.creditLineRepo.GetCreditLinesByUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	for i := range lines {
+		accrueInterest(&lines[i], now)
+	}
+	return lines, nil
+}
+
+// GetCreditLine retrieves one of the borrower's credit lines with interest accrued up to now
+func (uc *creditLineUsecase) GetCreditLine(ctx context.Context, id, userID primitive.ObjectID) (domain.CreditLine, error) {
+	line, err := uc.getOwnedLine(ctx, id, userID)
+	if err != nil {
+		return domain.CreditLine{}, err
+	}
+	accrueInterest(&line, time.Now())
+	return line, nil
+}
+
+// GetTransactions lists the movements on one of the borrower's credit lines
+func (uc *creditLineUsecase) GetTransactions(ctx context.Context, id, userID primitive.ObjectID) ([]domain.CreditLineTransaction, error) {
+	if _, err := uc.getOwnedLine(ctx, id, userID); err != nil {
+		return nil, err
+	}
+	return uc.creditLineRepo.GetTransactions(ctx, id)
+}
+
+// Draw moves funds from the available balance to the drawn balance
+func (uc *creditLineUsecase) Draw(ctx context.Context, id, userID primitive.ObjectID, amount float64) (domain.CreditLine, error) {
+	if amount <= 0 {
+		return domain.CreditLine{}, errors.New("amount must be greater than zero")
+	}
+	line, err := uc.getOwnedLine(ctx, id, userID)
+	if err != nil {
+		return domain.CreditLine{}, err
+	}
+	if line.Status == "frozen" {
+		return domain.CreditLine{}, errors.New("this credit line is frozen and cannot be drawn on")
+	}
+
+	lastUpdated := line.UpdatedAt
+	accrueInterest(&line, time.Now())
+	if amount > line.AvailableBalance {
+		return domain.CreditLine{}, errors.New("amount exceeds the available balance")
+	}
+	line.DrawnBalance = roundCents(line.DrawnBalance + amount)
+
+	tx := domain.CreditLineTransaction{Type: "draw", Amount: amount}
+	return uc.save(ctx, line, lastUpdated, tx, userID)
+}
+
+// Repay settles accrued interest first and applies the remainder to the drawn balance
+func (uc *creditLineUsecase) Repay(ctx context.Context, id, userID primitive.ObjectID, amount float64) (domain.CreditLine, error) {
+	if amount <= 0 {
+		return domain.CreditLine{}, errors.New("amount must be greater than zero")
+	}
+	line, err := uc.getOwnedLine(ctx, id, userID)
+	if err != nil {
+		return domain.CreditLine{}, err
+	}
+
+	lastUpdated := line.UpdatedAt
+	accrueInterest(&line, time.Now())
+	owed := roundCents(line.AccruedInterest + line.DrawnBalance)
+	if amount > owed {
+		return domain.CreditLine{}, errors.New("amount exceeds the outstanding balance")
+	}
+
+	interest := math.Min(amount, roundCents(line.AccruedInterest))
+	principal := roundCents(amount - interest)
+	line.AccruedInterest = math.Max(0, line.AccruedInterest-interest)
+	line.DrawnBalance = roundCents(line.DrawnBalance - principal)
+
+	tx := domain.CreditLineTransaction{Type: "repayment", Amount: amount, Interest: interest, Principal: principal}
+	return uc.save(ctx, line, lastUpdated, tx, userID)
+}
+
+// GetAllCreditLines lists every credit line for admins
+func (uc *creditLineUsecase) GetAllCreditLines(ctx context.Context) ([]domain.CreditLine, error) {
+	lines, err := uc.creditLineRepo.GetAllCreditLines(ctx)
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	for i := range lines {
+		accrueInterest(&lines[i], now)
+	}
+	return lines, nil
+}
+
+// ChangeLimit raises or lowers the limit; it cannot go below what is already drawn
+func (uc *creditLineUsecase) ChangeLimit(ctx context.Context, id, adminID primitive.ObjectID, limit float64) (domain.CreditLine, error) {
+	if limit <= 0 {
+		return domain.CreditLine{}, errors.New("limit must be greater than zero")
+	}
+	line, err := uc.creditLineRepo.GetCreditLineByID(ctx, id)
+	if err != nil {
+		return domain.CreditLine{}, errors.New("credit line not found")
+	}
+
+	lastUpdated := line.UpdatedAt
+	accrueInterest(&line, time.Now())
+	if limit < line.DrawnBalance {
+		return domain.CreditLine{}, errors.New("limit cannot be lower than the drawn balance")
+	}
+	line.Limit = limit
+
+	tx := domain.CreditLineTransaction{Type: "limit_change", Amount: limit}
+	return uc.save(ctx, line, lastUpdated, tx, adminID)
+}
+
+// SetFrozen freezes or unfreezes a credit line; frozen lines accept repayments but not draws
+func (uc *creditLineUsecase) SetFrozen(ctx context.Context, id, adminID primitive.ObjectID, frozen bool) (domain.CreditLine, error) {
+	line, err := uc.creditLineRepo.GetCreditLineByID(ctx, id)
+	if err != nil {
+		return domain.CreditLine{}, errors.New("credit line not found")
+	}
+
+	lastUpdated := line.UpdatedAt
+	accrueInterest(&line, time.Now())
+	tx := domain.CreditLineTransaction{Type: "unfreeze"}
+	line.Status = "active"
+	if frozen {
+		tx.Type = "freeze"
+		line.Status = "frozen"
+	}
+	return uc.save(ctx, line, lastUpdated, tx, adminID)
+}
+
+func (uc *creditLineUsecase) getOwnedLine(ctx context.Context, id, userID primitive.ObjectID) (domain.CreditLine, error) {
+	line, err := uc.creditLineRepo.GetCreditLineByID(ctx, id)
+	if err != nil {
+		return domain.CreditLine{}, errors.New("credit line not found")
+	}
+	if line.UserID != userID {
+		return domain.CreditLine{}, errors.New("credit line not found")
+	}
+	return line, nil
+}
+
+func (uc *creditLineUsecase) save(ctx context.Context, line domain.CreditLine, lastUpdated time.Time, tx domain.CreditLineTransaction, actorID primitive.ObjectID) (domain.CreditLine, error) {
+	line.AvailableBalance = math.Max(0, roundCents(line.Limit-line.DrawnBalance))
+	line.UpdatedAt = time.Now()
+	tx.ID = primitive.NewObjectID()
+	tx.CreditLineID = line.ID
+	tx.DrawnAfter = line.DrawnBalance
+	tx.ActorID = actorID
+	tx.CreatedAt = line.UpdatedAt
+
+	err := uc.transactor.WithTransaction(ctx, func(ctx context.Context) error {
+		if err := uc.creditLineRepo.UpdateCreditLine(ctx, line, lastUpdated); err != nil {
+			return err
+		}
+		if err := uc.creditLineRepo.CreateTransaction(ctx, tx); err != nil {
+			return err
+		}
+		return publishEvent(ctx, uc.outboxRepo, auditEvent(creditLineEventTypes[tx.Type], "credit_line", line.ID, actorID,
+			map[string]interface{}{
+				"transaction": tx.Type,
+				"amount":      tx.Amount,
+				"drawn":       line.DrawnBalance,
+				"limit":       line.Limit,
+				"status":      line.Status,
+			}))
+	})
+	if err != nil {
+		return domain.CreditLine{}, err
+	}
+	return line, nil
+}
+
+// openCreditLine creates the credit line for an approved "credit_line" loan application
+func openCreditLine(ctx context.Context, repo domain.CreditLineRepository, loan domain.Loan, product domain.LoanProduct) (primitive.ObjectID, error) {
+	now := time.Now()
+	line := domain.CreditLine{
+		ID:               primitive.NewObjectID(),
+		LoanID:           loan.ID,
+		UserID:           loan.UserID,
+		Limit:            loan.Amount,
+		AvailableBalance: loan.Amount,
+		InterestRate:     product.InterestRate,
+		Status:           "active",
+		LastAccrualAt:    now,
+		CreatedAt:        now,
+		UpdatedAt:        now,
+	}
+	return repo.CreateCreditLine(ctx, line)
+}
+
+// accrueInterest charges simple daily interest on the drawn balance since the last accrual
+func accrueInterest(line *domain.CreditLine, now time.Time) {
+	days := math.Floor(now.Sub(line.LastAccrualAt).Hours() / 24)
+	if days >= 1 {
+		line.AccruedInterest = roundCents(line.AccruedInterest + line.DrawnBalance*line.InterestRate/100*days/365)
+		line.LastAccrualAt = line.LastAccrualAt.Add(time.Duration(days) * 24 * time.Hour)
+	}
+	line.AvailableBalance = math.Max(0, roundCents(line.Limit-line.DrawnBalance))
+}
+
+func roundCents(v float64) float64 {
+	return math.Round(v*100) / 100
+}
@@ -0,0 +1,201 @@
+package usecase
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"text/template"
+	"time"
+)
+
+// documentTemplates holds every published version of each document template, keyed by
+// "kind/version". Documents record the version they were rendered from, so a published
+// template must never change: add a new version and point currentTemplates at it instead.
+//
+// Templates produce the line markup understood by infrastructure.RenderPDF.
+var documentTemplates = map[string]string{
+	"agreement/v1": `# Loan Agreement
+Reference: {{.Loan.ID.Hex}}
+Date: {{date .GeneratedAt}}
+
+## Parties
+Lender: {{.Lender}}
+Borrower: {{.BorrowerName}} ({{.BorrowerEmail}})
+
+## Terms
+{{- if eq .Loan.Type "credit_line"}}
+Product: {{.ProductName}}
+Credit limit: {{money .Loan.Amount}}
+Annual interest rate: {{pct .Loan.InterestRate}}, charged daily on the drawn balance
+The borrower may draw and repay within the limit while the credit line is open.
+{{- else}}
+Product: {{.ProductName}}
+Principal: {{money .Loan.Amount}}
+Annual interest rate: {{pct .Loan.InterestRate}}
+Processing fee: {{money .ProcessingFee}}, deducted at disbursement
+{{- if .RefinancePayoff}}
+Used to settle loan {{.Loan.RefinancesLoanID.Hex}}: {{money .RefinancePayoff}}
+{{- end}}
+Paid out to the borrower: {{money .PaidOut}}
+Installments: {{.Loan.Tenor}}, {{.Loan.Frequency}}
+Total repayable: {{money .TotalPayable}}
+Annual percentage rate (APR): {{pct .APR}}
+{{- end}}
+{{- if .Guarantors}}
+
+## Guarantors
+{{- range .Guarantors}}
+{{.GuarantorEmail}}: {{pct .LiabilityShare}} of the loan, up to {{money .LiabilityAmount}}
+{{- end}}
+{{- end}}
+{{- if .Loan.Schedule}}
+
+## Repayment schedule
+| {{printf "%-4s %-12s %14s %14s %14s %14s" "No." "Due date" "Installment" "Principal" "Interest" "Balance"}}
+{{- range .Loan.Schedule}}
+| {{printf "%-4d %-12s %14s %14s %14s %14s" .Number (date .DueDate) (money .Amount) (money .Principal) (money .Interest) (money .Balance)}}
+{{- end}}
+{{- end}}
+
+## Conditions
+1. The borrower shall pay each installment in full on or before its due date.
+2. Payments are applied to the oldest amount due first.
+3. The borrower may repay the outstanding balance early at any time.
+4. Late payments are reported and may be recovered from the guarantors named above.
+5. This agreement takes effect once the borrower has accepted it and the loan has been disbursed.
+`,
+
+	"agreement/v2": `# Loan Agreement
+Reference: {{.Loan.ID.Hex}}
+Date: {{date .GeneratedAt}}
+
+## Parties
+Lender: {{.Lender}}
+Borrower: {{.BorrowerName}} ({{.BorrowerEmail}})
+
+## Terms
+{{- if eq .Loan.Type "credit_line"}}
+Product: {{.ProductName}}
+Credit limit: {{money .Loan.Amount}}
+Annual interest rate: {{pct .Loan.InterestRate}}, charged daily on the drawn balance
+The borrower may draw and repay within the limit while the credit line is open.
+{{- else}}
+Product: {{.ProductName}}
+Principal: {{money .Loan.Amount}}
+Annual interest rate: {{pct .Loan.InterestRate}}
+Processing fee: {{money .ProcessingFee}}, deducted at disbursement
+{{- if .RefinancePayoff}}
+Used to settle loan {{.Loan.RefinancesLoanID.Hex}}: {{money .RefinancePayoff}}
+{{- end}}
+Paid out to the borrower: {{money .PaidOut}}
+Installments: {{.Loan.Tenor}}, {{.Loan.Frequency}}
+Total repayable: {{money .TotalPayable}}
+Annual percentage rate (APR): {{pct .APR}}
+{{- end}}
+{{- if .Guarantors}}
+
+## Guarantors
+{{- range .Guarantors}}
+{{.GuarantorEmail}}: {{pct .LiabilityShare}} of the loan, up to {{money .LiabilityAmount}}
+{{- end}}
+{{- end}}
+{{- if .Loan.Schedule}}
+
+## Repayment schedule
+Each installment falls due the stated time after the day the loan is disbursed.
+| {{printf "%-4s %-12s %14s %14s %14s %14s" "No." "Due after" "Installment" "Principal" "Interest" "Balance"}}
+{{- range .Loan.Schedule}}
+| {{printf "%-4d %-12s %14s %14s %14s %14s" .Number (periods .Number $.Loan.Frequency) (money .Amount) (money .Principal) (money .Interest) (money .Balance)}}
+{{- end}}
+{{- end}}
+
+## Conditions
+1. The borrower shall pay each installment in full on or before its due date.
+2. Payments are applied to the oldest amount due first.
+3. The borrower may repay the outstanding balance early at any time.
+4. Late payments are reported and may be recovered from the guarantors named above.
+5. This agreement takes effect once the borrower has accepted it and the loan has been disbursed.
+`,
+
+	"statement/v1": `# Loan Statement
+Loan: {{.Loan.ID.Hex}}{{if .Loan.ProductCode}} ({{.Loan.ProductCode}}){{end}}
+Borrower: {{.BorrowerName}} ({{.BorrowerEmail}})
+Period: {{date .From}} to {{date .LastDay}}
+Issued: {{date .GeneratedAt}}
+
+## Summary
+Opening balance: {{money .OpeningBalance}}
+Payments received: {{money .TotalPaid}}
+Closing balance: {{money .ClosingBalance}}
+
+## Payments
+{{- if .Repayments}}
+| {{printf "%-12s %-12s %14s  %s" "Date" "Method" "Amount" "Reference"}}
+{{- range .Repayments}}
+| {{printf "%-12s %-12s %14s  %s" (date .PaidAt) .Method (money .Amount) .Reference}}
+{{- end}}
+{{- else}}
+No payments were received in this period.
+{{- end}}
+{{- if .Installments}}
+
+## Installments due in this period
+| {{printf "%-4s %-12s %14s %14s  %s" "No." "Due date" "Amount" "Paid" "Status"}}
+{{- range .Installments}}
+| {{printf "%-4d %-12s %14s %14s  %s" .Number (date .DueDate) (money .Amount) (money .PaidAmount) .Status}}
+{{- end}}
+Installment status is shown as of the issue date.
+{{- end}}
+`,
+}
+
+// currentTemplates is the version used for newly generated documents of each kind
+var currentTemplates = map[string]string{
+	"agreement": "v2",
+	"statement": "v1",
+}
+
+var documentFuncs = template.FuncMap{
+	"money": func(v float64) string { return fmt.Sprintf("%.2f", v) },
+	"pct": func(v float64) string {
+		return strings.TrimSuffix(strings.TrimRight(fmt.Sprintf("%.4f", v), "0"), ".") + "%"
+	},
+	"date": func(t time.Time) string { return t.Format("2006-01-02") },
+	// periods is how long after disbursement installment n falls due, matching dueDate
+	"periods": func(n int, frequency string) string {
+		switch frequency {
+		case "weekly":
+			return plural(n, "week")
+		case "biweekly":
+			return plural(2*n, "week")
+		default:
+			return plural(n, "month")
+		}
+	},
+}
+
+// renderDocument fills the current template of the given kind and returns its version with the text
+func renderDocument(kind string, data interface{}) (string, string, error) {
+	version := currentTemplates[kind]
+	source, ok := documentTemplates[kind+"/"+version]
+	if !ok {
+		return "", "", fmt.Errorf("no %s template version %s", kind, version)
+	}
+	tmpl, err := template.New(kind).Funcs(documentFuncs).Parse(source)
+	if err != nil {
+		return "", "", err
+	}
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, data); err != nil {
+		return "", "", err
+	}
+	return version, buf.String(), nil
+}
+
+// plural counts a unit, as in "1 week" or "3 weeks"
+func plural(n int, unit string) string {
+	if n == 1 {
+		return "1 " + unit
+	}
+	return fmt.Sprintf("%d %ss", n, unit)
+}
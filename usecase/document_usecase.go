@@ -0,0 +1,320 @@
+package usecase
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"loan-tracker/domain"
+	"loan-tracker/infrastructure"
+	"log"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type documentUsecase struct {
+	documentRepo  domain.LoanDocumentRepository
+	loanRepo      domain.LoanRepository
+	repaymentRepo domain.RepaymentRepository
+	userRepo      domain.UserRepository
+	productRepo   domain.ProductRepository
+	guaranteeRepo domain.GuaranteeRepository
+	historyRepo   domain.LoanHistoryRepository
+}
+
+// NewDocumentUsecase creates a new instance of DocumentUsecase
+func NewDocumentUsecase(documentRepo domain.LoanDocumentRepository, loanRepo domain.LoanRepository, repaymentRepo domain.RepaymentRepository,
+	userRepo domain.UserRepository, productRepo domain.ProductRepository, guaranteeRepo domain.GuaranteeRepository,
+	historyRepo domain.LoanHistoryRepository) domain.DocumentUsecase {
+	return &documentUsecase{
+		documentRepo:  documentRepo,
+		loanRepo:      loanRepo,
+		repaymentRepo: repaymentRepo,
+		userRepo:      userRepo,
+		productRepo:   productRepo,
+		guaranteeRepo: guaranteeRepo,
+		historyRepo:   historyRepo,
+	}
+}
+
+// GetDocuments lists the documents generated for a loan
+func (uc *documentUsecase) GetDocuments(ctx context.Context, loanID, userID primitive.ObjectID, isAdmin bool) ([]domain.LoanDocument, error) {
+	if _, err := uc.visibleLoan(ctx, loanID, userID, isAdmin); err != nil {
+		return nil, err
+	}
+	return uc.documentRepo.GetDocumentsByLoan(ctx, loanID)
+}
+
+// GetDocument returns a document with its file, refusing one whose content no longer matches its checksum
+func (uc *documentUsecase) GetDocument(ctx context.Context, loanID, docID, userID primitive.ObjectID, isAdmin bool) (domain.LoanDocument, error) {
+	if _, err := uc.visibleLoan(ctx, loanID, userID, isAdmin); err != nil {
+		return domain.LoanDocument{}, err
+	}
+	doc, err := uc.documentRepo.GetDocumentByID(ctx, docID)
+	if err != nil || doc.LoanID != loanID {
+		return domain.LoanDocument{}, errors.New("document not found")
+	}
+	if checksum(doc.Content) != doc.Checksum {
+		log.Println("Checksum mismatch on loan document", doc.ID.Hex())
+		return domain.LoanDocument{}, errors.New("document failed its integrity check")
+	}
+	return doc, nil
+}
+
+// GenerateStatement issues a statement of the loan's payments between from (inclusive) and to (exclusive)
+func (uc *documentUsecase) GenerateStatement(ctx context.Context, loanID, userID primitive.ObjectID, isAdmin bool, from, to time.Time) (domain.LoanDocument, error) {
+	loan, err := uc.visibleLoan(ctx, loanID, userID, isAdmin)
+	if err != nil {
+		return domain.LoanDocument{}, err
+	}
+	if !from.Before(to) {
+		return domain.LoanDocument{}, errors.New("from must be before to")
+	}
+	return uc.issueStatement(ctx, loan, from, to, userID)
+}
+
+// RegenerateAgreement issues a fresh agreement for an approved loan, e.g. after generation failed at approval
+func (uc *documentUsecase) RegenerateAgreement(ctx context.Context, loanID, adminID primitive.ObjectID) (domain.LoanDocument, error) {
+	loan, err := uc.loanRepo.GetLoanByID(ctx, loanID)
+	if err != nil {
+		return domain.LoanDocument{}, errors.New("loan not found")
+	}
+	if loan.Status != "approved" {
+		return domain.LoanDocument{}, errors.New("agreements are only issued for approved loans")
+	}
+	var product domain.LoanProduct
+	if loan.ProductCode != "" {
+		if product, err = uc.productRepo.GetProductByCode(ctx, loan.ProductCode); err != nil {
+			return domain.LoanDocument{}, err
+		}
+	}
+	return issueAgreement(ctx, uc.documentRepo, uc.historyRepo, uc.userRepo, uc.guaranteeRepo, loan, product, adminID)
+}
+
+// GenerateMonthlyStatements issues last calendar month's statement for every active term loan that lacks one
+func (uc *documentUsecase) GenerateMonthlyStatements(ctx context.Context) error {
+	now := time.Now()
+	to := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, now.Location())
+	from := to.AddDate(0, -1, 0)
+
+	loans, err := uc.loanRepo.FindLoans(ctx, domain.LoanFilter{Status: "approved", Order: "asc"})
+	if err != nil {
+		return err
+	}
+	for _, loan := range loans {
+		if loan.Type == "credit_line" || !approvedOn(loan).Before(to) {
+			continue
+		}
+		exists, err := uc.documentRepo.StatementExists(ctx, loan.ID, from)
+		if err != nil {
+			return err
+		}
+		if exists {
+			continue
+		}
+		if _, err := uc.issueStatement(ctx, loan, from, to, primitive.NilObjectID); err != nil {
+			log.Println("Error generating statement for loan", loan.ID.Hex()+":", err)
+		}
+	}
+	return nil
+}
+
+func (uc *documentUsecase) visibleLoan(ctx context.Context, loanID, userID primitive.ObjectID, isAdmin bool) (domain.Loan, error) {
+	loan, err := uc.loanRepo.GetLoanByID(ctx, loanID)
+	if err != nil || (!isAdmin && loan.UserID != userID) {
+		return domain.Loan{}, errors.New("loan not found")
+	}
+	return loan, nil
+}
+
+type statementData struct {
+	Loan           domain.Loan
+	BorrowerName   string
+	BorrowerEmail  string
+	From           time.Time
+	LastDay        time.Time
+	OpeningBalance float64
+	TotalPaid      float64
+	ClosingBalance float64
+	Repayments     []domain.Repayment
+	Installments   []domain.Installment
+	GeneratedAt    time.Time
+}
+
+func (uc *documentUsecase) issueStatement(ctx context.Context, loan domain.Loan, from, to time.Time, actorID primitive.ObjectID) (domain.LoanDocument, error) {
+	if loan.Type == "credit_line" {
+		return domain.LoanDocument{}, errors.New("statements are only issued for term loans; see the credit line transactions instead")
+	}
+	if loan.Status != "approved" && loan.Status != "closed" {
+		return domain.LoanDocument{}, errors.New("statements are only issued for approved loans")
+	}
+	borrower, err := uc.userRepo.FindByID(domain.User{ID: loan.UserID})
+	if err != nil {
+		return domain.LoanDocument{}, errors.New("borrower not found")
+	}
+
+	earlier, err := uc.repaymentRepo.FindRepayments(ctx, domain.RepaymentFilter{LoanID: loan.ID, To: from})
+	if err != nil {
+		return domain.LoanDocument{}, err
+	}
+	during, err := uc.repaymentRepo.FindRepayments(ctx, domain.RepaymentFilter{LoanID: loan.ID, From: from, To: to})
+	if err != nil {
+		return domain.LoanDocument{}, err
+	}
+
+	owed := loan.Amount
+	if len(loan.Schedule) > 0 {
+		owed = scheduleTotal(loan.Schedule)
+	}
+	data := statementData{
+		Loan:          loan,
+		BorrowerName:  borrowerName(borrower),
+		BorrowerEmail: borrower.Email,
+		From:          from,
+		LastDay:       to.AddDate(0, 0, -1),
+		GeneratedAt:   time.Now(),
+	}
+	for _, r := range earlier {
+		owed -= r.Amount
+	}
+	data.OpeningBalance = roundCents(owed)
+	// Listed oldest first; the repository returns the newest first
+	for i := len(during) - 1; i >= 0; i-- {
+		data.Repayments = append(data.Repayments, during[i])
+		data.TotalPaid += during[i].Amount
+	}
+	data.TotalPaid = roundCents(data.TotalPaid)
+	data.ClosingBalance = roundCents(data.OpeningBalance - data.TotalPaid)
+	for _, inst := range loan.Schedule {
+		if !inst.DueDate.Before(from) && inst.DueDate.Before(to) {
+			data.Installments = append(data.Installments, inst)
+		}
+	}
+
+	version, text, err := renderDocument("statement", data)
+	if err != nil {
+		return domain.LoanDocument{}, err
+	}
+	doc := domain.LoanDocument{
+		LoanID:          loan.ID,
+		UserID:          loan.UserID,
+		Kind:            "statement",
+		TemplateVersion: version,
+		PeriodFrom:      from,
+		PeriodTo:        to,
+		FileName:        "statement-" + loan.ID.Hex() + "-" + from.Format("2006-01-02") + ".pdf",
+		GeneratedBy:     actorID,
+	}
+	return storeDocument(ctx, uc.documentRepo, uc.historyRepo, doc, "Loan statement", text)
+}
+
+type agreementData struct {
+	Loan            domain.Loan
+	Lender          string
+	BorrowerName    string
+	BorrowerEmail   string
+	ProductName     string
+	ProcessingFee   float64
+	RefinancePayoff float64
+	PaidOut         float64
+	TotalPayable    float64
+	APR             float64
+	Guarantors      []domain.Guarantee
+	GeneratedAt     time.Time
+}
+
+// issueAgreement renders and files the agreement for an approved loan
+func issueAgreement(ctx context.Context, documentRepo domain.LoanDocumentRepository, historyRepo domain.LoanHistoryRepository,
+	userRepo domain.UserRepository, guaranteeRepo domain.GuaranteeRepository, loan domain.Loan, product domain.LoanProduct, actorID primitive.ObjectID) (domain.LoanDocument, error) {
+	borrower, err := userRepo.FindByID(domain.User{ID: loan.UserID})
+	if err != nil {
+		return domain.LoanDocument{}, errors.New("borrower not found")
+	}
+	guarantees, err := guaranteeRepo.GetGuaranteesByLoan(ctx, loan.ID)
+	if err != nil {
+		return domain.LoanDocument{}, err
+	}
+
+	data := agreementData{
+		Loan:            loan,
+		Lender:          infrastructure.DotEnvLoaderDefault("LENDER_NAME", "Loan Tracker"),
+		BorrowerName:    borrowerName(borrower),
+		BorrowerEmail:   borrower.Email,
+		ProductName:     productLabel(loan.ProductCode),
+		ProcessingFee:   loan.ProcessingFee,
+		RefinancePayoff: loan.RefinancePayoff,
+		PaidOut:         loan.NetDisbursement,
+		TotalPayable:    loan.Amount,
+		GeneratedAt:     time.Now(),
+	}
+	if product.Name != "" {
+		data.ProductName = product.Name
+	}
+	if len(loan.Schedule) > 0 {
+		data.TotalPayable = scheduleTotal(loan.Schedule)
+		data.APR = computeAPR(loan.Amount, data.ProcessingFee, loan.Schedule, loan.Frequency)
+	}
+	for _, g := range guarantees {
+		if g.Status == "accepted" {
+			data.Guarantors = append(data.Guarantors, g)
+		}
+	}
+
+	version, text, err := renderDocument("agreement", data)
+	if err != nil {
+		return domain.LoanDocument{}, err
+	}
+	doc := domain.LoanDocument{
+		LoanID:          loan.ID,
+		UserID:          loan.UserID,
+		Kind:            "agreement",
+		TemplateVersion: version,
+		FileName:        "agreement-" + loan.ID.Hex() + ".pdf",
+		GeneratedBy:     actorID,
+	}
+	return storeDocument(ctx, documentRepo, historyRepo, doc, "Loan agreement", text)
+}
+
+// storeDocument renders the PDF, records its checksum and files it with the loan
+func storeDocument(ctx context.Context, documentRepo domain.LoanDocumentRepository, historyRepo domain.LoanHistoryRepository,
+	doc domain.LoanDocument, title, text string) (domain.LoanDocument, error) {
+	doc.ID = primitive.NewObjectID()
+	doc.GeneratedAt = time.Now()
+	doc.Content = infrastructure.RenderPDF(title, text)
+	doc.Size = len(doc.Content)
+	doc.Checksum = checksum(doc.Content)
+	if _, err := documentRepo.CreateDocument(ctx, doc); err != nil {
+		return domain.LoanDocument{}, err
+	}
+
+	recordLoanEvent(ctx, historyRepo, domain.LoanEvent{
+		LoanID:    doc.LoanID,
+		Type:      "document_generated",
+		ActorID:   doc.GeneratedBy,
+		Note:      doc.Kind + " " + doc.FileName + " (template " + doc.TemplateVersion + ", sha256 " + doc.Checksum + ")",
+		Timestamp: doc.GeneratedAt,
+	})
+	return doc, nil
+}
+
+func checksum(content []byte) string {
+	sum := sha256.Sum256(content)
+	return hex.EncodeToString(sum[:])
+}
+
+// borrowerName prefers the verified legal name over the username
+func borrowerName(user domain.User) string {
+	if kyc := kycOf(user); kyc.LegalName != "" {
+		return kyc.LegalName
+	}
+	return user.UserName
+}
+
+// approvedOn is when the loan was approved; older loans only have their application date
+func approvedOn(loan domain.Loan) time.Time {
+	if !loan.ApprovedAt.IsZero() {
+		return loan.ApprovedAt
+	}
+	return loan.CreatedAt
+}
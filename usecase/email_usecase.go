@@ -0,0 +1,162 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"loan-tracker/domain"
+	"loan-tracker/infrastructure"
+	"log"
+	"sort"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const (
+	// emailLease is how long a worker holds a message; it must outlast an SMTP exchange
+	emailLease     = 2 * time.Minute
+	emailBatchSize = 50
+)
+
+type emailUsecase struct {
+	emailRepo  domain.EmailRepository
+	outboxRepo domain.OutboxRepository
+	transactor domain.Transactor
+	mailer     infrastructure.Mailer
+}
+
+// NewEmailUsecase creates a new instance of EmailUsecase sending through the given mailer
+func NewEmailUsecase(emailRepo domain.EmailRepository, outboxRepo domain.OutboxRepository, transactor domain.Transactor,
+	mailer infrastructure.Mailer) domain.EmailUsecase {
+	return &emailUsecase{
+		emailRepo:  emailRepo,
+		outboxRepo: outboxRepo,
+		transactor: transactor,
+		mailer:     mailer,
+	}
+}
+
+// SendQueuedEmails sends each due message once. A failure is retried after a delay that doubles
+// from EMAIL_RETRY_DELAY (1m) up to an hour, until EMAIL_MAX_ATTEMPTS (6) is reached.
+func (uc *emailUsecase) SendQueuedEmails(ctx context.Context) error {
+	now := time.Now()
+	messages, err := uc.emailRepo.GetDueEmails(ctx, now, emailBatchSize)
+	if err != nil {
+		return err
+	}
+	maxAttempts := infrastructure.IntSetting("EMAIL_MAX_ATTEMPTS", 6)
+	retryDelay := infrastructure.IntervalSetting("EMAIL_RETRY_DELAY", time.Minute)
+	for _, message := range messages {
+		if err := uc.emailRepo.ClaimEmail(ctx, message, now.Add(emailLease)); err != nil {
+			continue
+		}
+
+		err := uc.mailer.Send(ctx, infrastructure.Email{
+			To:      message.To,
+			Kind:    message.Kind,
+			Locale:  message.Locale,
+			Subject: message.Subject,
+			Body:    message.Body,
+			HTML:    message.HTML,
+		})
+		message.Attempts++
+		message.UpdatedAt = time.Now()
+		switch {
+		case err == nil:
+			message.Status = "sent"
+			message.SentAt = message.UpdatedAt
+			message.NextAttemptAt = time.Time{}
+			message.LastError = ""
+		case message.Attempts >= maxAttempts:
+			message.Status = "failed"
+			message.NextAttemptAt = time.Time{}
+			message.LastError = err.Error()
+			log.Printf("Giving up on %s email %s to %s after %d attempts: %v", message.Kind, message.ID.Hex(), message.To, message.Attempts, err)
+		default:
+			message.Status = "retrying"
+			message.NextAttemptAt = message.UpdatedAt.Add(backoff(message.Attempts, retryDelay, time.Hour))
+			message.LastError = err.Error()
+		}
+		if err := uc.emailRepo.UpdateEmail(ctx, message); err != nil {
+			log.Println("Error updating email", message.ID.Hex()+":", err)
+		}
+	}
+	return nil
+}
+
+func (uc *emailUsecase) GetEmails(ctx context.Context, filter domain.EmailFilter) ([]domain.EmailMessage, error) {
+	return uc.emailRepo.FindEmails(ctx, filter)
+}
+
+func (uc *emailUsecase) ResendEmail(ctx context.Context, id, adminID primitive.ObjectID) (domain.EmailMessage, error) {
+	message, err := uc.emailRepo.GetEmailByID(ctx, id)
+	if err != nil {
+		return domain.EmailMessage{}, errors.New("email not found")
+	}
+	if message.Status != "failed" {
+		return domain.EmailMessage{}, errors.New("only failed emails can be resent")
+	}
+	message.Status = "queued"
+	message.Attempts = 0
+	message.NextAttemptAt = time.Now()
+	message.ResentBy = adminID
+	message.UpdatedAt = time.Now()
+	err = uc.transactor.WithTransaction(ctx, func(ctx context.Context) error {
+		if err := uc.emailRepo.UpdateEmail(ctx, message); err != nil {
+			return err
+		}
+		return publishEvent(ctx, uc.outboxRepo, auditEvent(domain.EventEmailResent, "email", message.ID, adminID,
+			map[string]interface{}{"kind": message.Kind, "to": message.To}))
+	})
+	if err != nil {
+		return domain.EmailMessage{}, err
+	}
+	return message, nil
+}
+
+func (uc *emailUsecase) GetTemplates(ctx context.Context) []domain.EmailTemplate {
+	var templates []domain.EmailTemplate
+	for kind, locales := range infrastructure.EmailTemplateLocales() {
+		templates = append(templates, domain.EmailTemplate{Kind: kind, Locales: locales})
+	}
+	sort.Slice(templates, func(i, j int) bool { return templates[i].Kind < templates[j].Kind })
+	return templates
+}
+
+func (uc *emailUsecase) PreviewTemplate(ctx context.Context, kind, locale string) (domain.EmailPreview, error) {
+	email, err := infrastructure.PreviewEmail(kind, locale)
+	if err != nil {
+		return domain.EmailPreview{}, err
+	}
+	return domain.EmailPreview{
+		Kind:    email.Kind,
+		Locale:  email.Locale,
+		Subject: email.Subject,
+		Text:    email.Body,
+		HTML:    email.HTML,
+	}, nil
+}
+
+// queueEmail adds an email to the outbox for the background worker to send. Called inside a
+// transaction, the email is only sent if the change that prompted it is kept.
+func queueEmail(ctx context.Context, emailRepo domain.EmailRepository, email infrastructure.Email) error {
+	now := time.Now()
+	message := domain.EmailMessage{
+		ID:            primitive.NewObjectID(),
+		To:            email.To,
+		Kind:          email.Kind,
+		Locale:        email.Locale,
+		Subject:       email.Subject,
+		Body:          email.Body,
+		HTML:          email.HTML,
+		Status:        "queued",
+		NextAttemptAt: now,
+		CreatedAt:     now,
+		UpdatedAt:     now,
+	}
+	if err := emailRepo.QueueEmail(ctx, message); err != nil {
+		return fmt.Errorf("queueing %s email: %w", email.Kind, err)
+	}
+	return nil
+}
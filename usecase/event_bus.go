@@ -0,0 +1,189 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"loan-tracker/domain"
+	"loan-tracker/infrastructure"
+	"log"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const (
+	// eventLease is how long a dispatcher holds an event before another may pick it up
+	eventLease     = time.Minute
+	eventBatchSize = 100
+)
+
+type subscriber struct {
+	name    string
+	types   map[string]bool // empty for every event type
+	handler domain.EventHandler
+}
+
+type eventBus struct {
+	outboxRepo  domain.OutboxRepository
+	subscribers []subscriber
+}
+
+// NewEventBus creates an event bus delivering the events recorded in the outbox
+func NewEventBus(outboxRepo domain.OutboxRepository) domain.EventBus {
+	return &eventBus{
+		outboxRepo: outboxRepo,
+	}
+}
+
+func (b *eventBus) Subscribe(name string, handler domain.EventHandler, types ...string) {
+	s := subscriber{name: name, types: make(map[string]bool), handler: handler}
+	for _, t := range types {
+		s.types[t] = true
+	}
+	b.subscribers = append(b.subscribers, s)
+}
+
+// Dispatch hands each due event to the subscribers that have not processed it yet. When one of them
+// fails, the event is retried later for that subscriber only, with exponential backoff, until
+// EVENT_MAX_ATTEMPTS is reached and the event is marked failed.
+func (b *eventBus) Dispatch(ctx context.Context) error {
+	now := time.Now()
+	events, err := b.outboxRepo.GetDueEvents(ctx, now, eventBatchSize)
+	if err != nil {
+		return err
+	}
+	maxAttempts := infrastructure.IntSetting("EVENT_MAX_ATTEMPTS", 8)
+	for _, event := range events {
+		if err := b.outboxRepo.ClaimEvent(ctx, event, now.Add(eventLease)); err != nil {
+			continue
+		}
+
+		handled := make(map[string]bool)
+		for _, name := range event.Handled {
+			handled[name] = true
+		}
+		var failures []string
+		for _, s := range b.subscribers {
+			if handled[s.name] || (len(s.types) > 0 && !s.types[event.Type]) {
+				continue
+			}
+			if err := s.handler(ctx, event); err != nil {
+				failures = append(failures, s.name+": "+err.Error())
+				continue
+			}
+			if err := b.outboxRepo.MarkHandled(ctx, event.ID, s.name); err != nil {
+				log.Println("Error recording event", event.ID.Hex(), "as handled:", err)
+			}
+		}
+
+		if len(failures) == 0 {
+			event.Status = "dispatched"
+			event.DispatchedAt = time.Now()
+			event.LastError = ""
+		} else {
+			event.Attempts++
+			event.LastError = strings.Join(failures, "; ")
+			if event.Attempts >= maxAttempts {
+				event.Status = "failed"
+				log.Printf("Giving up on event %s (%s) after %d attempts: %s", event.ID.Hex(), event.Type, event.Attempts, event.LastError)
+			} else {
+				event.NextAttemptAt = time.Now().Add(backoff(event.Attempts, 10*time.Second, time.Hour))
+			}
+		}
+		if err := b.outboxRepo.UpdateEventStatus(ctx, event); err != nil {
+			log.Println("Error updating event", event.ID.Hex()+":", err)
+		}
+	}
+	return nil
+}
+
+func (b *eventBus) GetEvents(ctx context.Context, filter domain.EventFilter) ([]domain.Event, error) {
+	return b.outboxRepo.FindEvents(ctx, filter)
+}
+
+func (b *eventBus) RetryEvent(ctx context.Context, id primitive.ObjectID) (domain.Event, error) {
+	event, err := b.outboxRepo.GetEventByID(ctx, id)
+	if err != nil {
+		return domain.Event{}, errors.New("event not found")
+	}
+	if event.Status != "failed" {
+		return domain.Event{}, errors.New("only failed events can be retried")
+	}
+	event.Status = "pending"
+	event.Attempts = 0
+	event.NextAttemptAt = time.Now()
+	if err := b.outboxRepo.UpdateEventStatus(ctx, event); err != nil {
+		return domain.Event{}, err
+	}
+	return event, nil
+}
+
+// backoff doubles the wait after every failed attempt, starting at initial and capped at limit
+func backoff(attempts int, initial, limit time.Duration) time.Duration {
+	delay := initial
+	for i := 1; i < attempts && delay < limit; i++ {
+		delay *= 2
+	}
+	if delay > limit {
+		delay = limit
+	}
+	return delay
+}
+
+// publishEvent records a domain event in the outbox. Call it with the context of the transaction
+// making the change, so the event is only kept if the change is.
+func publishEvent(ctx context.Context, outboxRepo domain.OutboxRepository, event domain.Event) error {
+	event.ID = primitive.NewObjectID()
+	if event.OccurredAt.IsZero() {
+		event.OccurredAt = time.Now()
+	}
+	event.Status = "pending"
+	event.NextAttemptAt = event.OccurredAt
+	if err := outboxRepo.AddEvents(ctx, event); err != nil {
+		return fmt.Errorf("recording %s event: %w", event.Type, err)
+	}
+	return nil
+}
+
+// recordEvent publishes an audit event about a read, or about a change saved outside a transaction.
+// There is nothing to roll back by then, so a failure is logged rather than returned.
+func recordEvent(ctx context.Context, outboxRepo domain.OutboxRepository, event domain.Event) {
+	if err := publishEvent(ctx, outboxRepo, event); err != nil {
+		log.Println("Error recording", event.Type, "event:", err)
+	}
+}
+
+// auditEvent describes an action on a record other than a loan for the outbox
+func auditEvent(eventType, aggregateType string, aggregateID, actorID primitive.ObjectID, data map[string]interface{}) domain.Event {
+	return domain.Event{
+		Type:          eventType,
+		AggregateType: aggregateType,
+		AggregateID:   aggregateID,
+		ActorID:       actorID,
+		Data:          data,
+	}
+}
+
+// loanEvent describes a change to a loan for the outbox
+func loanEvent(eventType string, loan domain.Loan, actorID primitive.ObjectID, data map[string]interface{}) domain.Event {
+	if data == nil {
+		data = make(map[string]interface{})
+	}
+	data["loan_id"] = loan.ID.Hex()
+	data["user_id"] = loan.UserID.Hex()
+	data["type"] = loan.Type
+	data["amount"] = loan.Amount
+	data["status"] = loan.Status
+	if loan.ProductCode != "" {
+		data["product_code"] = loan.ProductCode
+	}
+	return domain.Event{
+		Type:          eventType,
+		AggregateType: "loan",
+		AggregateID:   loan.ID,
+		ActorID:       actorID,
+		Data:          data,
+	}
+}
@@ -0,0 +1,132 @@
+package usecase
+
+import (
+	"context"
+	"loan-tracker/domain"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type exportUsecase struct {
+	loanRepo       domain.LoanRepository
+	repaymentRepo  domain.RepaymentRepository
+	userRepo       domain.UserRepository
+	logRepo        domain.LogRepository
+	acceptanceRepo domain.AcceptanceRepository
+	outboxRepo     domain.OutboxRepository
+}
+
+// NewExportUsecase creates a new instance of ExportUsecase
+func NewExportUsecase(loanRepo domain.LoanRepository, repaymentRepo domain.RepaymentRepository, userRepo domain.UserRepository,
+	logRepo domain.LogRepository, acceptanceRepo domain.AcceptanceRepository, outboxRepo domain.OutboxRepository) domain.ExportUsecase {
+	return &exportUsecase{
+		loanRepo:       loanRepo,
+		repaymentRepo:  repaymentRepo,
+		userRepo:       userRepo,
+		logRepo:        logRepo,
+		acceptanceRepo: acceptanceRepo,
+		outboxRepo:     outboxRepo,
+	}
+}
+
+// ExportLoans writes one row per loan matching the admin listing filter
+func (uc *exportUsecase) ExportLoans(ctx context.Context, adminID primitive.ObjectID, filter domain.LoanFilter, w domain.RowWriter) error {
+	err := w.WriteRow("id", "user_id", "product", "type", "description", "amount", "status",
+		"interest_rate", "tenor", "frequency", "amount_repaid", "outstanding_balance", "net_disbursement",
+		"assigned_officer_id", "stage", "created_at", "approved_at", "disbursed_at", "closed_at", "closed_reason")
+	if err != nil {
+		return err
+	}
+	err = uc.loanRepo.StreamLoans(ctx, filter, func(loan domain.Loan) error {
+		return w.WriteRow(loan.ID.Hex(), loan.UserID.Hex(), loan.ProductCode, loan.Type, loan.Description, loan.Amount, loan.Status,
+			loan.InterestRate, loan.Tenor, loan.Frequency, loan.AmountRepaid, loan.OutstandingBalance, loan.NetDisbursement,
+			hexOrEmpty(loan.AssignedOfficerID), loan.Stage, loan.CreatedAt, loan.ApprovedAt, loan.DisbursedAt, loan.ClosedAt, loan.ClosedReason)
+	})
+	if err != nil {
+		return err
+	}
+	return uc.finish(ctx, w, adminID, "loans", filter)
+}
+
+// ExportRepayments writes one row per repayment matching the filter
+func (uc *exportUsecase) ExportRepayments(ctx context.Context, adminID primitive.ObjectID, filter domain.RepaymentFilter, w domain.RowWriter) error {
+	err := w.WriteRow("id", "loan_id", "user_id", "amount", "method", "reference", "recorded_by", "paid_at", "created_at")
+	if err != nil {
+		return err
+	}
+	err = uc.repaymentRepo.StreamRepayments(ctx, filter, func(r domain.Repayment) error {
+		return w.WriteRow(r.ID.Hex(), r.LoanID.Hex(), r.UserID.Hex(), r.Amount, r.Method, r.Reference,
+			hexOrEmpty(r.RecordedBy), r.PaidAt, r.CreatedAt)
+	})
+	if err != nil {
+		return err
+	}
+	return uc.finish(ctx, w, adminID, "repayments", filter)
+}
+
+// ExportUsers writes one row per user; KYC details are limited to the review status
+func (uc *exportUsecase) ExportUsers(ctx context.Context, adminID primitive.ObjectID, filter domain.UserFilter, w domain.RowWriter) error {
+	err := w.WriteRow("id", "username", "email", "isadmin", "role", "isverified", "kyc_status")
+	if err != nil {
+		return err
+	}
+	err = uc.userRepo.StreamUsers(filter, func(user domain.ResponseUser) error {
+		kycStatus := ""
+		if user.KYC != nil {
+			kycStatus = user.KYC.Status
+		}
+		return w.WriteRow(user.ID.Hex(), user.UserName, user.Email, user.IsAdmin, user.Role, user.IsVerified, kycStatus)
+	})
+	if err != nil {
+		return err
+	}
+	return uc.finish(ctx, w, adminID, "users", filter)
+}
+
+// ExportLogs writes one row per system log entry
+func (uc *exportUsecase) ExportLogs(ctx context.Context, adminID primitive.ObjectID, filter domain.LogFilter, w domain.RowWriter) error {
+	if err := w.WriteRow("id", "timestamp", "type", "details"); err != nil {
+		return err
+	}
+	err := uc.logRepo.StreamLogs(ctx, filter, func(entry domain.Log) error {
+		return w.WriteRow(entry.ID.Hex(), entry.Timestamp, entry.Type, entry.Details)
+	})
+	if err != nil {
+		return err
+	}
+	return uc.finish(ctx, w, adminID, "logs", filter)
+}
+
+// ExportAcceptances writes the evidence of each accepted loan agreement
+func (uc *exportUsecase) ExportAcceptances(ctx context.Context, adminID primitive.ObjectID, filter domain.AcceptanceFilter, w domain.RowWriter) error {
+	err := w.WriteRow("id", "loan_id", "user_id", "document_id", "agreement_checksum", "template_version",
+		"email", "code_sent_at", "accepted_at", "ip_address", "user_agent")
+	if err != nil {
+		return err
+	}
+	err = uc.acceptanceRepo.StreamAcceptances(ctx, filter, func(a domain.AgreementAcceptance) error {
+		return w.WriteRow(a.ID.Hex(), a.LoanID.Hex(), a.UserID.Hex(), a.DocumentID.Hex(), a.AgreementChecksum, a.TemplateVersion,
+			a.Email, a.CodeSentAt, a.AcceptedAt, a.IPAddress, a.UserAgent)
+	})
+	if err != nil {
+		return err
+	}
+	return uc.finish(ctx, w, adminID, "acceptances", filter)
+}
+
+// finish completes the file and records who exported which rows
+func (uc *exportUsecase) finish(ctx context.Context, w domain.RowWriter, adminID primitive.ObjectID, dataset string, filter interface{}) error {
+	if err := w.Close(); err != nil {
+		return err
+	}
+	recordEvent(ctx, uc.outboxRepo, auditEvent(domain.EventDataExported, "user", adminID, adminID,
+		map[string]interface{}{"dataset": dataset, "filter": filter}))
+	return nil
+}
+
+func hexOrEmpty(id primitive.ObjectID) string {
+	if id.IsZero() {
+		return ""
+	}
+	return id.Hex()
+}
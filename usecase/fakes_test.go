@@ -0,0 +1,157 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"loan-tracker/domain"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// In-memory stand-ins for the repositories, so usecases can be tested without MongoDB.
+
+var errNotFound = errors.New("not found")
+
+// fakeTransactor runs the function directly; the fakes have nothing to roll back.
+type fakeTransactor struct{}
+
+func (fakeTransactor) WithTransaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	return fn(ctx)
+}
+
+type fakeOutbox struct {
+	events []domain.Event
+}
+
+func (o *fakeOutbox) AddEvents(ctx context.Context, events ...domain.Event) error {
+	o.events = append(o.events, events...)
+	return nil
+}
+
+func (o *fakeOutbox) GetEventByID(ctx context.Context, id primitive.ObjectID) (domain.Event, error) {
+	for _, e := range o.events {
+		if e.ID == id {
+			return e, nil
+		}
+	}
+	return domain.Event{}, errNotFound
+}
+
+func (o *fakeOutbox) FindEvents(ctx context.Context, filter domain.EventFilter) ([]domain.Event, error) {
+	var events []domain.Event
+	for _, e := range o.events {
+		if (filter.Type == "" || e.Type == filter.Type) && (filter.Status == "" || e.Status == filter.Status) {
+			events = append(events, e)
+		}
+	}
+	return events, nil
+}
+
+func (o *fakeOutbox) GetDueEvents(ctx context.Context, now time.Time, limit int) ([]domain.Event, error) {
+	return nil, nil
+}
+
+func (o *fakeOutbox) ClaimEvent(ctx context.Context, event domain.Event, until time.Time) error {
+	return nil
+}
+
+func (o *fakeOutbox) MarkHandled(ctx context.Context, id primitive.ObjectID, subscriber string) error {
+	return nil
+}
+
+func (o *fakeOutbox) UpdateEventStatus(ctx context.Context, event domain.Event) error {
+	return nil
+}
+
+// types lists the types of the recorded events in order
+func (o *fakeOutbox) types() []string {
+	var types []string
+	for _, e := range o.events {
+		types = append(types, e.Type)
+	}
+	return types
+}
+
+// fakeUserRepo keeps users by email. Methods the tests do not need fall through to the nil
+// interface and panic.
+type fakeUserRepo struct {
+	domain.UserRepository
+	users       map[string]domain.User
+	registerErr error
+}
+
+func newFakeUserRepo() *fakeUserRepo {
+	return &fakeUserRepo{users: make(map[string]domain.User)}
+}
+
+func (r *fakeUserRepo) RegisterUser(ctx context.Context, user *domain.User) error {
+	if r.registerErr != nil {
+		return r.registerErr
+	}
+	user.ID = primitive.NewObjectID()
+	r.users[user.Email] = *user
+	return nil
+}
+
+func (r *fakeUserRepo) FindByEmail(email string) (domain.User, error) {
+	user, ok := r.users[email]
+	if !ok {
+		return domain.User{}, errNotFound
+	}
+	return user, nil
+}
+
+type fakeEmailRepo struct {
+	messages map[primitive.ObjectID]domain.EmailMessage
+}
+
+func newFakeEmailRepo() *fakeEmailRepo {
+	return &fakeEmailRepo{messages: make(map[primitive.ObjectID]domain.EmailMessage)}
+}
+
+func (r *fakeEmailRepo) QueueEmail(ctx context.Context, message domain.EmailMessage) error {
+	r.messages[message.ID] = message
+	return nil
+}
+
+func (r *fakeEmailRepo) GetEmailByID(ctx context.Context, id primitive.ObjectID) (domain.EmailMessage, error) {
+	message, ok := r.messages[id]
+	if !ok {
+		return domain.EmailMessage{}, errNotFound
+	}
+	return message, nil
+}
+
+func (r *fakeEmailRepo) FindEmails(ctx context.Context, filter domain.EmailFilter) ([]domain.EmailMessage, error) {
+	var messages []domain.EmailMessage
+	for _, m := range r.messages {
+		messages = append(messages, m)
+	}
+	return messages, nil
+}
+
+func (r *fakeEmailRepo) GetDueEmails(ctx context.Context, now time.Time, limit int) ([]domain.EmailMessage, error) {
+	var due []domain.EmailMessage
+	for _, m := range r.messages {
+		if (m.Status == "queued" || m.Status == "retrying") && !m.NextAttemptAt.After(now) {
+			due = append(due, m)
+		}
+	}
+	if len(due) > limit {
+		due = due[:limit]
+	}
+	return due, nil
+}
+
+func (r *fakeEmailRepo) ClaimEmail(ctx context.Context, message domain.EmailMessage, until time.Time) error {
+	stored := r.messages[message.ID]
+	stored.NextAttemptAt = until
+	r.messages[message.ID] = stored
+	return nil
+}
+
+func (r *fakeEmailRepo) UpdateEmail(ctx context.Context, message domain.EmailMessage) error {
+	r.messages[message.ID] = message
+	return nil
+}
@@ -0,0 +1,247 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"loan-tracker/domain"
+	"loan-tracker/infrastructure"
+	"log"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type guaranteeUsecase struct {
+	guaranteeRepo domain.GuaranteeRepository
+	loanRepo      domain.LoanRepository
+	userRepo      domain.UserRepository
+	historyRepo   domain.LoanHistoryRepository
+	emailRepo     domain.EmailRepository
+	outboxRepo    domain.OutboxRepository
+	transactor    domain.Transactor
+}
+
+// NewGuaranteeUsecase creates a new instance of GuaranteeUsecase
+func NewGuaranteeUsecase(guaranteeRepo domain.GuaranteeRepository, loanRepo domain.LoanRepository, userRepo domain.UserRepository,
+	historyRepo domain.LoanHistoryRepository, emailRepo domain.EmailRepository, outboxRepo domain.OutboxRepository,
+	transactor domain.Transactor) domain.GuaranteeUsecase {
+	return &guaranteeUsecase{
+		guaranteeRepo: guaranteeRepo,
+		loanRepo:      loanRepo,
+		userRepo:      userRepo,
+		historyRepo:   historyRepo,
+		emailRepo:     emailRepo,
+		outboxRepo:    outboxRepo,
+		transactor:    transactor,
+	}
+}
+
+// GetMyGuarantees lists the invitations addressed to a guarantor
+func (uc *guaranteeUsecase) GetMyGuarantees(ctx context.Context, guarantorID primitive.ObjectID) ([]domain.Guarantee, error) {
+	return uc.guaranteeRepo.GetGuaranteesByGuarantor(ctx, guarantorID)
+}
+
+// GetLoanGuarantees lists every guarantee attached to a loan to an admin, the borrower or one of
+// the loan's guarantors
+func (uc *guaranteeUsecase) GetLoanGuarantees(ctx context.Context, loanID, userID primitive.ObjectID, isAdmin bool) ([]domain.Guarantee, error) {
+	loan, err := uc.loanRepo.GetLoanByID(ctx, loanID)
+	if err != nil {
+		return nil, errors.New("loan not found")
+	}
+	guarantees, err := uc.guaranteeRepo.GetGuaranteesByLoan(ctx, loanID)
+	if err != nil {
+		return nil, err
+	}
+	if isAdmin || loan.UserID == userID {
+		return guarantees, nil
+	}
+	for _, g := range guarantees {
+		if g.GuarantorID == userID {
+			return guarantees, nil
+		}
+	}
+	return nil, errors.New("loan not found")
+}
+
+// InviteGuarantor lets a borrower invite another guarantor while the loan is still pending,
+// for example to replace one who declined
+func (uc *guaranteeUsecase) InviteGuarantor(ctx context.Context, loanID, borrowerID primitive.ObjectID, invite domain.GuarantorInvite) (primitive.ObjectID, error) {
+	loan, err := uc.loanRepo.GetLoanByID(ctx, loanID)
+	if err != nil {
+		return primitive.NilObjectID, errors.New("loan not found")
+	}
+	if loan.UserID != borrowerID {
+		return primitive.NilObjectID, errors.New("you can only invite guarantors to your own loan")
+	}
+	if loan.Status != "pending" {
+		return primitive.NilObjectID, errors.New("guarantors can only be invited while the loan is pending")
+	}
+
+	existing, err := uc.guaranteeRepo.GetGuaranteesByLoan(ctx, loanID)
+	if err != nil {
+		return primitive.NilObjectID, err
+	}
+	total := invite.LiabilityShare
+	for _, g := range existing {
+		if g.Status != "pending" && g.Status != "accepted" {
+			continue
+		}
+		if strings.EqualFold(g.GuarantorEmail, invite.Email) {
+			return primitive.NilObjectID, errors.New("this user has already been invited to guarantee the loan")
+		}
+		total += g.LiabilityShare
+	}
+	if roundCents(total) > 100 {
+		return primitive.NilObjectID, errors.New("total liability share cannot exceed 100 percent")
+	}
+
+	borrower, err := uc.userRepo.FindByID(domain.User{ID: borrowerID})
+	if err != nil {
+		return primitive.NilObjectID, errors.New("borrower not found")
+	}
+	guarantors, err := resolveGuarantors(uc.userRepo, borrower, []domain.GuarantorInvite{invite})
+	if err != nil {
+		return primitive.NilObjectID, err
+	}
+	var id primitive.ObjectID
+	err = uc.transactor.WithTransaction(ctx, func(ctx context.Context) error {
+		id, err = createGuarantee(ctx, uc.guaranteeRepo, uc.historyRepo, uc.emailRepo, loan, borrower, guarantors[0], invite.LiabilityShare)
+		if err != nil {
+			return err
+		}
+		return publishEvent(ctx, uc.outboxRepo, auditEvent(domain.EventGuarantorInvited, "guarantee", id, borrowerID,
+			map[string]interface{}{
+				"loan_id":         loan.ID.Hex(),
+				"guarantor_id":    guarantors[0].ID.Hex(),
+				"liability_share": invite.LiabilityShare,
+			}))
+	})
+	if err != nil {
+		return primitive.NilObjectID, err
+	}
+	return id, nil
+}
+
+// AcceptGuarantee records the guarantor's agreement to cover their share of the loan
+func (uc *guaranteeUsecase) AcceptGuarantee(ctx context.Context, id, guarantorID primitive.ObjectID) error {
+	return uc.respond(ctx, id, guarantorID, "accepted")
+}
+
+// DeclineGuarantee records the guarantor's refusal
+func (uc *guaranteeUsecase) DeclineGuarantee(ctx context.Context, id, guarantorID primitive.ObjectID) error {
+	return uc.respond(ctx, id, guarantorID, "declined")
+}
+
+func (uc *guaranteeUsecase) respond(ctx context.Context, id, guarantorID primitive.ObjectID, status string) error {
+	guarantee, err := uc.guaranteeRepo.GetGuaranteeByID(ctx, id)
+	if err != nil {
+		return errors.New("guarantee not found")
+	}
+	if guarantee.GuarantorID != guarantorID {
+		return errors.New("this guarantee invitation is not addressed to you")
+	}
+	if guarantee.Status != "pending" {
+		return errors.New("this guarantee invitation has already been answered")
+	}
+
+	loan, err := uc.loanRepo.GetLoanByID(ctx, guarantee.LoanID)
+	if err != nil {
+		return errors.New("loan not found")
+	}
+	if loan.Status != "pending" {
+		return errors.New("the loan is no longer awaiting guarantees")
+	}
+
+	return uc.transactor.WithTransaction(ctx, func(ctx context.Context) error {
+		if err := uc.guaranteeRepo.UpdateGuaranteeStatus(ctx, id, status); err != nil {
+			return err
+		}
+		err := appendLoanEvent(ctx, uc.historyRepo, domain.LoanEvent{
+			LoanID:  loan.ID,
+			Type:    "guarantee_" + status,
+			ActorID: guarantorID,
+			Changes: []domain.FieldChange{{Field: "guarantee_status", OldValue: "pending", NewValue: status}},
+			Note:    guarantee.GuarantorEmail,
+		})
+		if err != nil {
+			return err
+		}
+		return publishEvent(ctx, uc.outboxRepo, auditEvent(domain.EventGuaranteeAnswered, "guarantee", id, guarantorID,
+			map[string]interface{}{"loan_id": loan.ID.Hex(), "status": status}))
+	})
+}
+
+// resolveGuarantors checks that every invited guarantor is a registered user other than the borrower
+func resolveGuarantors(userRepo domain.UserRepository, borrower domain.User, invites []domain.GuarantorInvite) ([]domain.User, error) {
+	seen := make(map[string]bool)
+	guarantors := make([]domain.User, 0, len(invites))
+	for _, invite := range invites {
+		email := strings.ToLower(strings.TrimSpace(invite.Email))
+		if email == "" {
+			return nil, errors.New("guarantor email is required")
+		}
+		if invite.LiabilityShare <= 0 || invite.LiabilityShare > 100 {
+			return nil, errors.New("liability share must be between 0 and 100 percent")
+		}
+		if seen[email] {
+			return nil, fmt.Errorf("guarantor %s is listed more than once", invite.Email)
+		}
+		seen[email] = true
+
+		// Registered addresses keep the case they were entered in, so try that too
+		guarantor, err := userRepo.FindByEmail(email)
+		if err != nil {
+			guarantor, err = userRepo.FindByEmail(strings.TrimSpace(invite.Email))
+		}
+		if err != nil {
+			return nil, fmt.Errorf("guarantor %s is not a registered user", invite.Email)
+		}
+		if guarantor.ID == borrower.ID {
+			return nil, errors.New("you cannot guarantee your own loan")
+		}
+		guarantors = append(guarantors, guarantor)
+	}
+	return guarantors, nil
+}
+
+// createGuarantee stores a pending guarantee and emails the invitation to the guarantor; it runs
+// inside the caller's transaction
+func createGuarantee(ctx context.Context, repo domain.GuaranteeRepository, historyRepo domain.LoanHistoryRepository,
+	emailRepo domain.EmailRepository, loan domain.Loan, borrower, guarantor domain.User, share float64) (primitive.ObjectID, error) {
+	guarantee := domain.Guarantee{
+		ID:              primitive.NewObjectID(),
+		LoanID:          loan.ID,
+		BorrowerID:      borrower.ID,
+		GuarantorID:     guarantor.ID,
+		GuarantorEmail:  guarantor.Email,
+		LiabilityShare:  share,
+		LiabilityAmount: loan.Amount * share / 100,
+		Status:          "pending",
+		InvitedAt:       time.Now(),
+	}
+	id, err := repo.CreateGuarantee(ctx, guarantee)
+	if err != nil {
+		return primitive.NilObjectID, err
+	}
+
+	if err := appendLoanEvent(ctx, historyRepo, domain.LoanEvent{
+		LoanID:  loan.ID,
+		Type:    "guarantor_invited",
+		ActorID: borrower.ID,
+		Note:    fmt.Sprintf("%s for %.2f%%", guarantor.Email, share),
+	}); err != nil {
+		return primitive.NilObjectID, err
+	}
+
+	// The invitation is visible in the guarantor's account even if the email cannot be queued
+	email, err := infrastructure.GuarantorInvitationEmail(guarantor.Email, guarantor.Locale, borrower.UserName, loan.Amount, share)
+	if err == nil {
+		err = queueEmail(ctx, emailRepo, email)
+	}
+	if err != nil {
+		log.Println("Error queueing guarantor invitation:", err)
+	}
+	return id, nil
+}
@@ -0,0 +1,140 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"loan-tracker/domain"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type kycUsecase struct {
+	userRepo   domain.UserRepository
+	outboxRepo domain.OutboxRepository
+}
+
+// NewKYCUsecase creates a new instance of KYCUsecase
+func NewKYCUsecase(userRepo domain.UserRepository, outboxRepo domain.OutboxRepository) domain.KYCUsecase {
+	return &kycUsecase{
+		userRepo:   userRepo,
+		outboxRepo: outboxRepo,
+	}
+}
+
+// GetKYC returns the borrower's KYC profile, reporting "not_started" if nothing was submitted yet
+func (uc *kycUsecase) GetKYC(ctx context.Context, userID primitive.ObjectID) (domain.KYCProfile, error) {
+	user, err := uc.userRepo.FindByID(domain.User{ID: userID})
+	if err != nil {
+		return domain.KYCProfile{}, errors.New("user not found")
+	}
+	return kycOf(user), nil
+}
+
+// SubmitKYC stores the borrower's identity data and puts it in the review queue
+func (uc *kycUsecase) SubmitKYC(ctx context.Context, userID primitive.ObjectID, profile domain.KYCProfile) error {
+	user, err := uc.userRepo.FindByID(domain.User{ID: userID})
+	if err != nil {
+		return errors.New("user not found")
+	}
+	if kycOf(user).Status == "verified" {
+		return errors.New("your KYC profile is already verified and can no longer be changed")
+	}
+	if err := validateKYC(profile); err != nil {
+		return err
+	}
+
+	profile.Status = "submitted"
+	profile.RejectionReason = ""
+	profile.SubmittedAt = time.Now()
+	profile.ReviewedAt = time.Time{}
+	profile.ReviewedBy = primitive.NilObjectID
+	if err := uc.userRepo.UpdateKYC(user, profile); err != nil {
+		return err
+	}
+	recordEvent(ctx, uc.outboxRepo, auditEvent(domain.EventKYCSubmitted, "user", userID, userID, nil))
+	return nil
+}
+
+// GetReviewQueue lists users whose KYC is in the given state, oldest submission first
+func (uc *kycUsecase) GetReviewQueue(ctx context.Context, status string) ([]domain.ResponseUser, error) {
+	if status == "" {
+		status = "submitted"
+	}
+	switch status {
+	case "submitted", "verified", "rejected":
+	default:
+		return nil, errors.New("invalid KYC status, you can only filter by submitted, verified or rejected")
+	}
+	return uc.userRepo.GetUsersByKYCStatus(status)
+}
+
+// ReviewKYC lets an admin verify or reject a submitted KYC profile
+func (uc *kycUsecase) ReviewKYC(ctx context.Context, userID, reviewerID primitive.ObjectID, review domain.KYCReview) error {
+	if review.Status != "verified" && review.Status != "rejected" {
+		return errors.New("invalid status value, you can only enter verified or rejected")
+	}
+	if review.Status == "rejected" && strings.TrimSpace(review.Reason) == "" {
+		return errors.New("a reason is required when rejecting a KYC profile")
+	}
+
+	user, err := uc.userRepo.FindByID(domain.User{ID: userID})
+	if err != nil {
+		return errors.New("user not found")
+	}
+	profile := kycOf(user)
+	if profile.Status != "submitted" {
+		return errors.New("only submitted KYC profiles can be reviewed")
+	}
+
+	profile.Status = review.Status
+	profile.RejectionReason = review.Reason
+	profile.ReviewedAt = time.Now()
+	profile.ReviewedBy = reviewerID
+	if err := uc.userRepo.UpdateKYC(user, profile); err != nil {
+		return err
+	}
+	recordEvent(ctx, uc.outboxRepo, auditEvent(domain.EventKYCReviewed, "user", userID, reviewerID,
+		map[string]interface{}{"status": review.Status, "reason": review.Reason}))
+	return nil
+}
+
+func kycOf(user domain.User) domain.KYCProfile {
+	if user.KYC == nil || user.KYC.Status == "" {
+		return domain.KYCProfile{Status: "not_started"}
+	}
+	return *user.KYC
+}
+
+func validateKYC(profile domain.KYCProfile) error {
+	if strings.TrimSpace(profile.LegalName) == "" {
+		return errors.New("legal name is required")
+	}
+	dob, err := time.Parse("2006-01-02", profile.DateOfBirth)
+	if err != nil {
+		return errors.New("date of birth must be in YYYY-MM-DD format")
+	}
+	if dob.AddDate(18, 0, 0).After(time.Now()) {
+		return errors.New("borrowers must be at least 18 years old")
+	}
+	if strings.TrimSpace(profile.NationalID) == "" {
+		return errors.New("national ID is required")
+	}
+	if profile.Address.Street == "" || profile.Address.City == "" || profile.Address.Country == "" {
+		return errors.New("address must include street, city and country")
+	}
+	switch profile.Employment.Status {
+	case "employed", "self_employed":
+		if profile.Employment.Employer == "" {
+			return errors.New("employer is required for employed borrowers")
+		}
+	case "unemployed", "student", "retired":
+	default:
+		return errors.New("employment status must be employed, self_employed, unemployed, student or retired")
+	}
+	if profile.MonthlyIncome < 0 {
+		return errors.New("monthly income cannot be negative")
+	}
+	return nil
+}
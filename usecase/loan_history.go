@@ -0,0 +1,46 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"loan-tracker/domain"
+	"log"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// GetLoanHistory returns the loan's timeline to an admin or to the borrower who owns it
+func (uc *loanUsecase) GetLoanHistory(ctx context.Context, id, userID primitive.ObjectID, isAdmin bool) ([]domain.LoanEvent, error) {
+	loan, err := uc.loanRepo.GetLoanByID(ctx, id)
+	if err != nil {
+		return nil, errors.New("loan not found")
+	}
+	if !isAdmin && loan.UserID != userID {
+		return nil, errors.New("loan not found")
+	}
+	return uc.historyRepo.GetEventsByLoan(ctx, id)
+}
+
+// appendLoanEvent adds an entry to the loan's history. Called inside a transaction, a failure
+// must be returned so the change it describes is rolled back with it.
+func appendLoanEvent(ctx context.Context, repo domain.LoanHistoryRepository, event domain.LoanEvent) error {
+	event.ID = primitive.NewObjectID()
+	if event.Timestamp.IsZero() {
+		event.Timestamp = time.Now()
+	}
+	return repo.AppendEvent(ctx, event)
+}
+
+// recordLoanEvent adds an entry to the loan's history for a change that was saved on its own. A
+// failure is only logged because the change stands either way.
+func recordLoanEvent(ctx context.Context, repo domain.LoanHistoryRepository, event domain.LoanEvent) {
+	if err := appendLoanEvent(ctx, repo, event); err != nil {
+		log.Println("Error recording loan history:", err)
+	}
+}
+
+// statusChange describes a move from one loan status to another
+func statusChange(from, to string) []domain.FieldChange {
+	return []domain.FieldChange{{Field: "status", OldValue: from, NewValue: to}}
+}
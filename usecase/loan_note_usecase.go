@@ -0,0 +1,192 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"loan-tracker/domain"
+	"loan-tracker/infrastructure"
+	"log"
+	"regexp"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+var mentionPattern = regexp.MustCompile(`@([A-Za-z0-9_.\-]+)`)
+
+type loanNoteUsecase struct {
+	noteRepo  domain.LoanNoteRepository
+	loanRepo  domain.LoanRepository
+	userRepo  domain.UserRepository
+	emailRepo domain.EmailRepository
+}
+
+// NewLoanNoteUsecase creates a new instance of LoanNoteUsecase
+func NewLoanNoteUsecase(noteRepo domain.LoanNoteRepository, loanRepo domain.LoanRepository, userRepo domain.UserRepository,
+	emailRepo domain.EmailRepository) domain.LoanNoteUsecase {
+	return &loanNoteUsecase{
+		noteRepo:  noteRepo,
+		loanRepo:  loanRepo,
+		userRepo:  userRepo,
+		emailRepo: emailRepo,
+	}
+}
+
+// AddNote posts an internal note; any @username mentioned must belong to an admin, who is notified by email
+func (uc *loanNoteUsecase) AddNote(ctx context.Context, loanID, authorID primitive.ObjectID, req domain.NoteRequest) (primitive.ObjectID, error) {
+	if _, err := uc.loanRepo.GetLoanByID(ctx, loanID); err != nil {
+		return primitive.NilObjectID, errors.New("loan not found")
+	}
+	note, err := uc.newNote(ctx, loanID, authorID, "internal", req)
+	if err != nil {
+		return primitive.NilObjectID, err
+	}
+
+	mentioned, err := uc.resolveMentions(note.Body)
+	if err != nil {
+		return primitive.NilObjectID, err
+	}
+	for _, admin := range mentioned {
+		note.Mentions = append(note.Mentions, admin.ID)
+	}
+
+	id, err := uc.noteRepo.CreateNote(ctx, note)
+	if err != nil {
+		return primitive.NilObjectID, err
+	}
+
+	author, _ := uc.userRepo.FindByID(domain.User{ID: authorID})
+	for _, admin := range mentioned {
+		if admin.ID == authorID {
+			continue
+		}
+		email, err := infrastructure.NoteMentionEmail(admin.Email, admin.Locale, author.UserName, loanID.Hex(), note.Body)
+		if err == nil {
+			err = queueEmail(ctx, uc.emailRepo, email)
+		}
+		if err != nil {
+			log.Println("Error queueing note mention:", err)
+		}
+	}
+	return id, nil
+}
+
+// GetNotes lists the internal notes on a loan
+func (uc *loanNoteUsecase) GetNotes(ctx context.Context, loanID primitive.ObjectID) ([]domain.LoanNote, error) {
+	return uc.noteRepo.GetNotesByLoan(ctx, loanID, "internal")
+}
+
+// AddComment posts a comment the borrower can see; borrowers may only comment on their own loans
+func (uc *loanNoteUsecase) AddComment(ctx context.Context, loanID, authorID primitive.ObjectID, isAdmin bool, req domain.NoteRequest) (primitive.ObjectID, error) {
+	if err := uc.checkCommentAccess(ctx, loanID, authorID, isAdmin); err != nil {
+		return primitive.NilObjectID, err
+	}
+	note, err := uc.newNote(ctx, loanID, authorID, "borrower", req)
+	if err != nil {
+		return primitive.NilObjectID, err
+	}
+	return uc.noteRepo.CreateNote(ctx, note)
+}
+
+// GetComments lists the borrower-visible comments on a loan
+func (uc *loanNoteUsecase) GetComments(ctx context.Context, loanID, userID primitive.ObjectID, isAdmin bool) ([]domain.LoanNote, error) {
+	if err := uc.checkCommentAccess(ctx, loanID, userID, isAdmin); err != nil {
+		return nil, err
+	}
+	return uc.noteRepo.GetNotesByLoan(ctx, loanID, "borrower")
+}
+
+// EditNote changes the text of the author's own note or comment, keeping the previous text
+func (uc *loanNoteUsecase) EditNote(ctx context.Context, loanID, noteID, authorID primitive.ObjectID, visibility, body string) (domain.LoanNote, error) {
+	body = strings.TrimSpace(body)
+	if body == "" {
+		return domain.LoanNote{}, errors.New("note body is required")
+	}
+	note, err := uc.noteRepo.GetNoteByID(ctx, noteID)
+	if err != nil || note.LoanID != loanID || note.Visibility != visibility {
+		return domain.LoanNote{}, errors.New("note not found")
+	}
+	if note.AuthorID != authorID {
+		return domain.LoanNote{}, errors.New("you can only edit your own notes")
+	}
+	if body == note.Body {
+		return note, nil
+	}
+	if visibility == "internal" {
+		if _, err := uc.resolveMentions(body); err != nil {
+			return domain.LoanNote{}, err
+		}
+	}
+
+	revision := domain.NoteRevision{Body: note.Body, EditedAt: time.Now()}
+	if err := uc.noteRepo.UpdateNoteBody(ctx, noteID, body, revision); err != nil {
+		return domain.LoanNote{}, err
+	}
+	note.Edits = append(note.Edits, revision)
+	note.Body = body
+	note.UpdatedAt = revision.EditedAt
+	return note, nil
+}
+
+func (uc *loanNoteUsecase) newNote(ctx context.Context, loanID, authorID primitive.ObjectID, visibility string, req domain.NoteRequest) (domain.LoanNote, error) {
+	body := strings.TrimSpace(req.Body)
+	if body == "" {
+		return domain.LoanNote{}, errors.New("note body is required")
+	}
+
+	note := domain.LoanNote{
+		ID:         primitive.NewObjectID(),
+		LoanID:     loanID,
+		AuthorID:   authorID,
+		Visibility: visibility,
+		Body:       body,
+		CreatedAt:  time.Now(),
+		UpdatedAt:  time.Now(),
+	}
+	if req.ParentID != "" {
+		parentID, err := primitive.ObjectIDFromHex(req.ParentID)
+		if err != nil {
+			return domain.LoanNote{}, errors.New("invalid parent id")
+		}
+		// Replies stay within the same loan and the same audience
+		parent, err := uc.noteRepo.GetNoteByID(ctx, parentID)
+		if err != nil || parent.LoanID != loanID || parent.Visibility != visibility {
+			return domain.LoanNote{}, errors.New("parent note not found")
+		}
+		note.ParentID = parentID
+	}
+	return note, nil
+}
+
+func (uc *loanNoteUsecase) checkCommentAccess(ctx context.Context, loanID, userID primitive.ObjectID, isAdmin bool) error {
+	loan, err := uc.loanRepo.GetLoanByID(ctx, loanID)
+	if err != nil {
+		return errors.New("loan not found")
+	}
+	if !isAdmin && loan.UserID != userID {
+		return errors.New("loan not found")
+	}
+	return nil
+}
+
+// resolveMentions looks up every @username in the body and requires each to be an admin
+func (uc *loanNoteUsecase) resolveMentions(body string) ([]domain.User, error) {
+	seen := make(map[string]bool)
+	var admins []domain.User
+	for _, match := range mentionPattern.FindAllStringSubmatch(body, -1) {
+		username := match[1]
+		if seen[username] {
+			continue
+		}
+		seen[username] = true
+
+		user, err := uc.userRepo.FindByUserName(username)
+		if err != nil || !user.IsAdmin {
+			return nil, fmt.Errorf("@%s is not an admin and cannot be mentioned", username)
+		}
+		admins = append(admins, user)
+	}
+	return admins, nil
+}
@@ -3,74 +3,882 @@ package usecase
 import (
 	"context"
 	"errors"
+	"fmt"
 	"loan-tracker/domain"
+	"loan-tracker/infrastructure"
+	"log"
+	"strings"
 	"time"
 
 	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
 type loanUsecase struct {
-	loanRepo domain.LoanRepository
+	loanRepo       domain.LoanRepository
+	productRepo    domain.ProductRepository
+	guaranteeRepo  domain.GuaranteeRepository
+	userRepo       domain.UserRepository
+	creditLineRepo domain.CreditLineRepository
+	repaymentRepo  domain.RepaymentRepository
+	historyRepo    domain.LoanHistoryRepository
+	documentRepo   domain.LoanDocumentRepository
+	acceptanceRepo domain.AcceptanceRepository
+	outboxRepo     domain.OutboxRepository
+	emailRepo      domain.EmailRepository
+	transactor     domain.Transactor
 }
 
 // NewLoanUsecase creates a new instance of LoanUsecase
-func NewLoanUsecase(loanRepo domain.LoanRepository) domain.LoanUsecase {
+func NewLoanUsecase(loanRepo domain.LoanRepository, productRepo domain.ProductRepository, guaranteeRepo domain.GuaranteeRepository,
+	userRepo domain.UserRepository, creditLineRepo domain.CreditLineRepository, repaymentRepo domain.RepaymentRepository,
+	historyRepo domain.LoanHistoryRepository, documentRepo domain.LoanDocumentRepository,
+	acceptanceRepo domain.AcceptanceRepository, outboxRepo domain.OutboxRepository, emailRepo domain.EmailRepository,
+	transactor domain.Transactor) domain.LoanUsecase {
 	return &loanUsecase{
-		loanRepo: loanRepo,
+		loanRepo:       loanRepo,
+		productRepo:    productRepo,
+		guaranteeRepo:  guaranteeRepo,
+		userRepo:       userRepo,
+		creditLineRepo: creditLineRepo,
+		repaymentRepo:  repaymentRepo,
+		historyRepo:    historyRepo,
+		documentRepo:   documentRepo,
+		acceptanceRepo: acceptanceRepo,
+		outboxRepo:     outboxRepo,
+		emailRepo:      emailRepo,
+		transactor:     transactor,
 	}
 }
 
 // ApplyForLoan handles the business logic for applying for a loan
 func (uc *loanUsecase) ApplyForLoan(ctx context.Context, loan domain.Loan) (primitive.ObjectID, error) {
+	// Refinance links are only set through RefinanceLoan
+	loan.RefinancesLoanID = primitive.NilObjectID
+	return uc.createApplication(ctx, loan)
+}
+
+// createApplication validates a new application against its product and stores it as pending
+func (uc *loanUsecase) createApplication(ctx context.Context, loan domain.Loan) (primitive.ObjectID, error) {
+	if loan.Amount <= 0 {
+		return primitive.NilObjectID, errors.New("loan amount must be greater than zero")
+	}
+
+	switch loan.Type {
+	case "":
+		loan.Type = "term"
+	case "term":
+	case "credit_line":
+		if loan.ProductCode == "" {
+			return primitive.NilObjectID, errors.New("credit line applications must name a product")
+		}
+	default:
+		return primitive.NilObjectID, errors.New("invalid loan type, you can only enter term or credit_line")
+	}
+
+	var product domain.LoanProduct
+	if loan.ProductCode != "" {
+		var err error
+		product, err = uc.productRepo.GetProductByCode(ctx, loan.ProductCode)
+		if err != nil {
+			return primitive.NilObjectID, errors.New("loan product not found")
+		}
+	}
+
+	// Borrowers cannot pick their own rate; it always comes from the product
+	loan.InterestRate = product.InterestRate
+	loan.Schedule = nil
+	if loan.Type == "term" && loan.Tenor > 0 {
+		if loan.Frequency == "" {
+			loan.Frequency = "monthly"
+		}
+		if err := validateTerms(loan.Tenor, loan.Frequency); err != nil {
+			return primitive.NilObjectID, err
+		}
+	}
+
+	if product.RequiresKYC {
+		borrower, err := uc.userRepo.FindByID(domain.User{ID: loan.UserID})
+		if err != nil {
+			return primitive.NilObjectID, errors.New("borrower not found")
+		}
+		if kycOf(borrower).Status != "verified" {
+			return primitive.NilObjectID, errors.New("this product requires a verified KYC profile")
+		}
+	}
+
+	if len(loan.Guarantors) < product.RequiredGuarantors {
+		return primitive.NilObjectID, fmt.Errorf("this product requires at least %d guarantor(s)", product.RequiredGuarantors)
+	}
+	var borrower domain.User
+	var guarantors []domain.User
+	if len(loan.Guarantors) > 0 {
+		var total float64
+		for _, invite := range loan.Guarantors {
+			total += invite.LiabilityShare
+		}
+		// Shares such as 33.33/33.33/33.34 only add up to 100 once rounded to cents
+		if roundCents(total) != 100 {
+			return primitive.NilObjectID, errors.New("guarantor liability shares must add up to 100 percent")
+		}
+
+		var err error
+		borrower, err = uc.userRepo.FindByID(domain.User{ID: loan.UserID})
+		if err != nil {
+			return primitive.NilObjectID, errors.New("borrower not found")
+		}
+		guarantors, err = resolveGuarantors(uc.userRepo, borrower, loan.Guarantors)
+		if err != nil {
+			return primitive.NilObjectID, err
+		}
+	}
+
 	loan.ID = primitive.NewObjectID()
 	loan.Status = "pending"
 	loan.CreatedAt = time.Now()
 	loan.UpdatedAt = time.Now()
+	loan.AmountRepaid = 0
+	loan.OutstandingBalance = 0
+	loan.OutstandingPrincipal = 0
+	loan.NetDisbursement = 0
+	loan.ProcessingFee = 0
+	loan.RefinancePayoff = 0
+	loan.RefinancedByLoanID = primitive.NilObjectID
+	loan.ClosedReason = ""
+	loan.ClosedAt = time.Time{}
+	loan.ApprovedAt = time.Time{}
+	loan.DisbursedAt = time.Time{}
+	loan.AssignedOfficerID = primitive.NilObjectID
+	loan.AssignedAt = time.Time{}
+	loan.Stages = nil
+	enterStage(&loan, "unassigned", primitive.NilObjectID, loan.CreatedAt)
 
-	loanID, err := uc.loanRepo.CreateLoan(ctx, loan)
+	// The loan is only stored together with all of its guarantees
+	var loanID primitive.ObjectID
+	err := uc.transactor.WithTransaction(ctx, func(ctx context.Context) error {
+		var err error
+		loanID, err = uc.loanRepo.CreateLoan(ctx, loan)
+		if err != nil {
+			return err
+		}
+		if err := uc.recordApplication(ctx, loan); err != nil {
+			return err
+		}
+		for i, guarantor := range guarantors {
+			if _, err := createGuarantee(ctx, uc.guaranteeRepo, uc.historyRepo, uc.emailRepo, loan, borrower, guarantor, loan.Guarantors[i].LiabilityShare); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
 	if err != nil {
 		return primitive.NilObjectID, err
 	}
+	autoAssign(ctx, uc.loanRepo, uc.userRepo, uc.historyRepo, &loan)
 	return loanID, nil
 }
 
+// recordApplication adds a new application to the loan's history and the outbox
+func (uc *loanUsecase) recordApplication(ctx context.Context, loan domain.Loan) error {
+	applied := domain.LoanEvent{
+		LoanID:  loan.ID,
+		Type:    "applied",
+		ActorID: loan.UserID,
+		Changes: []domain.FieldChange{
+			{Field: "status", NewValue: loan.Status},
+			{Field: "type", NewValue: loan.Type},
+			{Field: "product", NewValue: loan.ProductCode},
+			{Field: "amount", NewValue: loan.Amount},
+			{Field: "tenor", NewValue: loan.Tenor},
+			{Field: "frequency", NewValue: loan.Frequency},
+		},
+		Timestamp: loan.CreatedAt,
+	}
+	event := loanEvent(domain.EventLoanApplied, loan, loan.UserID, map[string]interface{}{
+		"tenor":     loan.Tenor,
+		"frequency": loan.Frequency,
+	})
+	event.OccurredAt = loan.CreatedAt
+	if !loan.RefinancesLoanID.IsZero() {
+		applied.Note = "refinance of loan " + loan.RefinancesLoanID.Hex()
+		event.Data["refinances_loan_id"] = loan.RefinancesLoanID.Hex()
+	}
+	if err := appendLoanEvent(ctx, uc.historyRepo, applied); err != nil {
+		return err
+	}
+	return publishEvent(ctx, uc.outboxRepo, event)
+}
+
 // ViewLoanStatus retrieves the status of a specific loan
-func (uc *loanUsecase) ViewLoanStatus(ctx context.Context, id primitive.ObjectID) (domain.Loan, error) {
+func (uc *loanUsecase) ViewLoanStatus(ctx context.Context, id, viewerID primitive.ObjectID) (domain.Loan, error) {
 	loan, err := uc.loanRepo.GetLoanByID(ctx, id)
 	if err != nil {
 		return domain.Loan{}, err
 	}
+	recordEvent(ctx, uc.outboxRepo, loanEvent(domain.EventLoanViewed, loan, viewerID, nil))
 	return loan, nil
 }
 
 // ViewAllLoans retrieves all loan applications based on the provided filter
-func (uc *loanUsecase) ViewAllLoans(ctx context.Context) ([]domain.Loan, error) {
+func (uc *loanUsecase) ViewAllLoans(ctx context.Context, adminID primitive.ObjectID, filter domain.LoanFilter) ([]domain.Loan, error) {
 
-	loans, err := uc.loanRepo.GetAllLoans(ctx)
+	loans, err := uc.loanRepo.FindLoans(ctx, filter)
 	if err != nil {
 		return nil, err
 	}
+	recordEvent(ctx, uc.outboxRepo, auditEvent(domain.EventLoansListed, "user", adminID, adminID,
+		map[string]interface{}{"count": len(loans)}))
 	return loans, nil
 }
 
 // ApproveOrRejectLoan handles the business logic for approving or rejecting a loan application
-func (uc *loanUsecase) ApproveOrRejectLoan(ctx context.Context, id primitive.ObjectID, status string) error {
+func (uc *loanUsecase) ApproveOrRejectLoan(ctx context.Context, id, adminID primitive.ObjectID, status string) error {
 	if status != "approved" && status != "rejected" {
 		return errors.New("invalid status value, you can only enter approved or rejected")
 	}
 
-	err := uc.loanRepo.UpdateLoanStatus(ctx, id, status)
+	loan, err := uc.loanRepo.GetLoanByID(ctx, id)
+	if err != nil {
+		return err
+	}
+	if loan.Status != "pending" {
+		return errors.New("only pending loans can be approved or rejected")
+	}
+	var product domain.LoanProduct
+	if loan.ProductCode != "" {
+		product, err = uc.productRepo.GetProductByCode(ctx, loan.ProductCode)
+		if err != nil {
+			return err
+		}
+	}
+
+	if status == "rejected" {
+		err := uc.transactor.WithTransaction(ctx, func(ctx context.Context) error {
+			if err := uc.loanRepo.TransitionLoanStatus(ctx, id, "pending", status); err != nil {
+				return err
+			}
+			if err := appendLoanEvent(ctx, uc.historyRepo, domain.LoanEvent{
+				LoanID:  id,
+				Type:    "rejected",
+				ActorID: adminID,
+				Changes: statusChange("pending", status),
+			}); err != nil {
+				return err
+			}
+			loan.Status = status
+			if err := publishEvent(ctx, uc.outboxRepo, loanEvent(domain.EventLoanRejected, loan, adminID, nil)); err != nil {
+				return err
+			}
+			return uc.guaranteeRepo.ReleaseLoanGuarantees(ctx, id)
+		})
+		if err != nil {
+			return err
+		}
+		finishReview(ctx, uc.loanRepo, loan)
+		return nil
+	}
+
+	if err := uc.checkGuarantees(ctx, loan, product); err != nil {
+		return err
+	}
+	var refinanced domain.Loan
+	if !loan.RefinancesLoanID.IsZero() {
+		refinanced, err = uc.loanRepo.GetLoanByID(ctx, loan.RefinancesLoanID)
+		if err != nil {
+			return errors.New("the loan being refinanced was not found")
+		}
+		if refinanced.Status != "approved" {
+			return errors.New("the loan being refinanced is no longer active")
+		}
+		if payoffAmount(refinanced, time.Now())+processingFee(loan.Amount, product) >= loan.Amount {
+			return errors.New("the new loan no longer covers the outstanding balance of the loan being refinanced and its processing fee")
+		}
+	}
+
+	loan.Status = status
+	loan.UpdatedAt = time.Now()
+	loan.ApprovedAt = loan.UpdatedAt
+	closeStage(&loan, loan.UpdatedAt)
+	loan.Stage = "decided"
+	if loan.Type == "term" || loan.Type == "" {
+		loan.OutstandingBalance = loan.Amount
+		loan.OutstandingPrincipal = loan.Amount
+		loan.ProcessingFee = processingFee(loan.Amount, product)
+		loan.RefinancePayoff = payoffAmount(refinanced, loan.UpdatedAt)
+		loan.NetDisbursement = roundCents(loan.Amount - loan.RefinancePayoff - loan.ProcessingFee)
+		if loan.Tenor > 0 {
+			loan.Schedule, err = GenerateSchedule(loan.Amount, loan.InterestRate, loan.Tenor, loan.Frequency, loan.UpdatedAt)
+			if err != nil {
+				return err
+			}
+			loan.OutstandingBalance = scheduleTotal(loan.Schedule)
+		}
+	}
+	err = uc.transactor.WithTransaction(ctx, func(ctx context.Context) error {
+		if err := uc.loanRepo.UpdateLoan(ctx, loan, "pending"); err != nil {
+			return err
+		}
+
+		changes := statusChange("pending", status)
+		changes = append(changes,
+			domain.FieldChange{Field: "outstanding_balance", OldValue: 0.0, NewValue: loan.OutstandingBalance},
+			domain.FieldChange{Field: "outstanding_principal", OldValue: 0.0, NewValue: loan.OutstandingPrincipal},
+			domain.FieldChange{Field: "net_disbursement", OldValue: 0.0, NewValue: loan.NetDisbursement},
+		)
+		if loan.ProcessingFee > 0 {
+			changes = append(changes, domain.FieldChange{Field: "processing_fee", OldValue: 0.0, NewValue: loan.ProcessingFee})
+		}
+		if loan.RefinancePayoff > 0 {
+			changes = append(changes, domain.FieldChange{Field: "refinance_payoff", OldValue: 0.0, NewValue: loan.RefinancePayoff})
+		}
+		if err := appendLoanEvent(ctx, uc.historyRepo, domain.LoanEvent{
+			LoanID:    id,
+			Type:      "approved",
+			ActorID:   adminID,
+			Changes:   changes,
+			Timestamp: loan.UpdatedAt,
+		}); err != nil {
+			return err
+		}
+		event := loanEvent(domain.EventLoanApproved, loan, adminID, map[string]interface{}{
+			"interest_rate":       loan.InterestRate,
+			"outstanding_balance": loan.OutstandingBalance,
+			"net_disbursement":    loan.NetDisbursement,
+		})
+		event.OccurredAt = loan.UpdatedAt
+		return publishEvent(ctx, uc.outboxRepo, event)
+	})
+	if err != nil {
+		return err
+	}
+	// The approval stands without it; an admin can regenerate the agreement later
+	if _, err := issueAgreement(ctx, uc.documentRepo, uc.historyRepo, uc.userRepo, uc.guaranteeRepo, loan, product, adminID); err != nil {
+		log.Println("Error generating loan agreement:", err)
+	}
+	return nil
+}
+
+// DisburseLoan releases the funds of an approved loan. The borrower must first have accepted the
+// latest agreement; disbursing opens the credit line or settles the loan being refinanced.
+func (uc *loanUsecase) DisburseLoan(ctx context.Context, id, adminID primitive.ObjectID) error {
+	loan, err := uc.loanRepo.GetLoanByID(ctx, id)
+	if err != nil {
+		return errors.New("loan not found")
+	}
+	if loan.Status != "approved" {
+		return errors.New("only approved loans can be disbursed")
+	}
+	if !loan.DisbursedAt.IsZero() {
+		return errors.New("loan has already been disbursed")
+	}
+	agreement, err := uc.documentRepo.GetLatestDocument(ctx, id, "agreement")
+	if err != nil {
+		return errors.New("no agreement has been issued for this loan")
+	}
+	acceptance, err := uc.acceptanceRepo.GetAcceptedAgreement(ctx, id, agreement.Checksum)
+	if err != nil {
+		return errors.New("the borrower has not accepted the current agreement")
+	}
+	// Agreements before v2 listed calendar due dates counted from approval, which disbursing would move
+	if agreement.TemplateVersion == "v1" && len(loan.Schedule) > 0 {
+		return errors.New("the agreement dates the schedule from approval; regenerate it and have the borrower accept it again")
+	}
+	var product domain.LoanProduct
+	if loan.ProductCode != "" {
+		product, err = uc.productRepo.GetProductByCode(ctx, loan.ProductCode)
+		if err != nil {
+			return err
+		}
+	}
+
+	// The old loan may have been paid down since approval, which leaves more for the borrower. It is
+	// paid off at its principal and the interest accrued until now.
+	var refinanced domain.Loan
+	netDisbursement, refinancePayoff := loan.NetDisbursement, loan.RefinancePayoff
+	if !loan.RefinancesLoanID.IsZero() {
+		refinanced, err = uc.loanRepo.GetLoanByID(ctx, loan.RefinancesLoanID)
+		if err != nil {
+			return errors.New("the loan being refinanced was not found")
+		}
+		if refinanced.Status == "approved" {
+			refinancePayoff = payoffAmount(refinanced, time.Now())
+			netDisbursement = roundCents(loan.Amount - refinancePayoff - loan.ProcessingFee)
+		} else if refinanced.RefinancedByLoanID != loan.ID {
+			return errors.New("the loan being refinanced is no longer active")
+		}
+	}
+
+	// The disbursement, the credit line or payoff it triggers and its event commit together
+	return uc.transactor.WithTransaction(ctx, func(ctx context.Context) error {
+		return uc.disburse(ctx, loan, refinanced, product, acceptance, netDisbursement, refinancePayoff, adminID)
+	})
+}
+
+// disburse marks the loan disbursed and dates its schedule from now, then opens its credit line or
+// pays off the loan it refinances
+func (uc *loanUsecase) disburse(ctx context.Context, loan, refinanced domain.Loan, product domain.LoanProduct,
+	acceptance domain.AgreementAcceptance, netDisbursement, refinancePayoff float64, adminID primitive.ObjectID) error {
+	id := loan.ID
+	now := time.Now()
+	if err := uc.loanRepo.MarkDisbursed(ctx, id, now, netDisbursement, refinancePayoff); err != nil {
+		return err
+	}
+	changes := []domain.FieldChange{{Field: "disbursed_at", OldValue: nil, NewValue: now}}
+	if netDisbursement != loan.NetDisbursement {
+		changes = append(changes, domain.FieldChange{Field: "net_disbursement", OldValue: loan.NetDisbursement, NewValue: netDisbursement})
+	}
+	if refinancePayoff != loan.RefinancePayoff {
+		changes = append(changes, domain.FieldChange{Field: "refinance_payoff", OldValue: loan.RefinancePayoff, NewValue: refinancePayoff})
+	}
+	// The agreement states each due date as a number of periods after disbursement, so the dates
+	// the borrower accepted are fixed here, counting from today
+	if len(loan.Schedule) > 0 {
+		schedule, err := GenerateSchedule(loan.Amount, loan.InterestRate, loan.Tenor, loan.Frequency, now)
+		if err != nil {
+			return err
+		}
+		changes = append(changes, domain.FieldChange{Field: "first_due_date", OldValue: loan.Schedule[0].DueDate, NewValue: schedule[0].DueDate})
+		loan.Schedule = allocateRepayments(schedule, loan.AmountRepaid)
+		if err := uc.loanRepo.UpdateSchedule(ctx, id, loan.Schedule, outstandingPrincipal(loan)); err != nil {
+			return err
+		}
+	}
+	if err := appendLoanEvent(ctx, uc.historyRepo, domain.LoanEvent{
+		LoanID:    id,
+		Type:      "disbursed",
+		ActorID:   adminID,
+		Changes:   changes,
+		Note:      "agreement sha256 " + acceptance.AgreementChecksum + " accepted " + acceptance.AcceptedAt.Format(time.RFC3339),
+		Timestamp: now,
+	}); err != nil {
+		return err
+	}
+	event := loanEvent(domain.EventLoanDisbursed, loan, adminID, map[string]interface{}{
+		"net_disbursement": netDisbursement,
+		"disbursed_at":     now,
+	})
+	event.OccurredAt = now
+	if err := publishEvent(ctx, uc.outboxRepo, event); err != nil {
+		return err
+	}
+
+	if loan.Type == "credit_line" {
+		// Lines approved before disbursement was a separate step were opened at approval
+		lines, err := uc.creditLineRepo.GetCreditLinesByUser(ctx, loan.UserID)
+		if err != nil {
+			return err
+		}
+		for _, line := range lines {
+			if line.LoanID == loan.ID {
+				return nil
+			}
+		}
+		lineID, err := openCreditLine(ctx, uc.creditLineRepo, loan, product)
+		if err != nil {
+			return err
+		}
+		if err := appendLoanEvent(ctx, uc.historyRepo, domain.LoanEvent{
+			LoanID:  id,
+			Type:    "credit_line_opened",
+			ActorID: adminID,
+			Note:    "credit line " + lineID.Hex(),
+		}); err != nil {
+			return err
+		}
+		return nil
+	}
+	if refinanced.Status == "approved" {
+		return uc.payOffRefinancedLoan(ctx, refinanced, loan, adminID)
+	}
+	return nil
+}
+
+// payOffRefinancedLoan settles the old loan out of the new principal and closes it as refinanced.
+// The payoff covers the principal and the interest accrued so far; the interest of later
+// installments is dropped when the loan closes.
+func (uc *loanUsecase) payOffRefinancedLoan(ctx context.Context, old, replacement domain.Loan, adminID primitive.ObjectID) error {
+	now := time.Now()
+	payoff := domain.Repayment{
+		LoanID:     old.ID,
+		UserID:     old.UserID,
+		Amount:     payoffAmount(old, now),
+		Method:     "refinance",
+		Reference:  replacement.ID.Hex(),
+		RecordedBy: adminID,
+		PaidAt:     now,
+	}
+	paidOff, _, err := postRepayment(ctx, uc.loanRepo, uc.repaymentRepo, uc.historyRepo, uc.outboxRepo, payoff)
+	if err != nil {
+		return err
+	}
+	return closeLoan(ctx, uc.loanRepo, uc.guaranteeRepo, uc.historyRepo, uc.outboxRepo, paidOff, "refinanced", replacement.ID, adminID)
+}
+
+// checkGuarantees blocks approval until enough guarantors have accepted to cover the whole loan
+func (uc *loanUsecase) checkGuarantees(ctx context.Context, loan domain.Loan, product domain.LoanProduct) error {
+	if product.RequiredGuarantors == 0 {
+		return nil
+	}
+
+	guarantees, err := uc.guaranteeRepo.GetGuaranteesByLoan(ctx, loan.ID)
 	if err != nil {
 		return err
 	}
+	accepted := 0
+	var share float64
+	for _, g := range guarantees {
+		if g.Status == "accepted" {
+			accepted++
+			share += g.LiabilityShare
+		}
+	}
+	if accepted < product.RequiredGuarantors || roundCents(share) < 100 {
+		return fmt.Errorf("loan cannot be approved until %d guarantor(s) covering 100 percent have accepted (%d accepted, %.2f%% covered)",
+			product.RequiredGuarantors, accepted, share)
+	}
 	return nil
 }
 
 // DeleteLoan handles the business logic for deleting a loan application
-func (uc *loanUsecase) DeleteLoan(ctx context.Context, id primitive.ObjectID) error {
-	err := uc.loanRepo.DeleteLoan(ctx, id)
+func (uc *loanUsecase) DeleteLoan(ctx context.Context, id, adminID primitive.ObjectID) error {
+	loan, err := uc.loanRepo.GetLoanByID(ctx, id)
+	if err != nil {
+		return errors.New("loan not found")
+	}
+	return uc.transactor.WithTransaction(ctx, func(ctx context.Context) error {
+		if err := uc.loanRepo.DeleteLoan(ctx, id); err != nil {
+			return err
+		}
+		return publishEvent(ctx, uc.outboxRepo, loanEvent(domain.EventLoanDeleted, loan, adminID, nil))
+	})
+}
+
+// RefinanceLoan applies for a new loan that will pay off the given active loan once approved.
+// The borrower receives only the difference between the new principal and the old loan's payoff amount.
+func (uc *loanUsecase) RefinanceLoan(ctx context.Context, id primitive.ObjectID, loan domain.Loan) (primitive.ObjectID, error) {
+	old, err := uc.loanRepo.GetLoanByID(ctx, id)
+	if err != nil {
+		return primitive.NilObjectID, errors.New("loan not found")
+	}
+	if old.UserID != loan.UserID {
+		return primitive.NilObjectID, errors.New("you can only refinance your own loan")
+	}
+	if old.Status != "approved" || old.Type == "credit_line" {
+		return primitive.NilObjectID, errors.New("only active term loans can be refinanced")
+	}
+	if payoff := payoffAmount(old, time.Now()); loan.Amount <= payoff {
+		return primitive.NilObjectID, fmt.Errorf("the new amount must exceed the payoff amount of %.2f", payoff)
+	}
+
+	repayments, err := uc.repaymentRepo.GetRepaymentsByLoan(ctx, id)
+	if err != nil {
+		return primitive.NilObjectID, err
+	}
+	if len(repayments) == 0 {
+		return primitive.NilObjectID, errors.New("a top-up requires at least one repayment on the current loan")
+	}
+
+	pending, err := uc.loanRepo.FindLoans(ctx, domain.LoanFilter{RefinancesLoanID: id, Status: "pending"})
+	if err != nil {
+		return primitive.NilObjectID, err
+	}
+	if len(pending) > 0 {
+		return primitive.NilObjectID, errors.New("a refinance application for this loan is already pending")
+	}
+
+	loan.Type = "term"
+	if loan.ProductCode == "" {
+		loan.ProductCode = old.ProductCode
+	}
+	if loan.Description == "" {
+		loan.Description = "Refinance of loan " + old.ID.Hex()
+	}
+	loan.RefinancesLoanID = old.ID
+	return uc.createApplication(ctx, loan)
+}
+
+// EditLoan lets the borrower change a pending application and records every changed field
+func (uc *loanUsecase) EditLoan(ctx context.Context, id, userID primitive.ObjectID, edit domain.LoanEdit) (domain.Loan, error) {
+	loan, err := uc.getOwnedPendingLoan(ctx, id, userID)
+	if err != nil {
+		return domain.Loan{}, err
+	}
+
+	var changes []domain.FieldChange
+	amountChanged := edit.Amount != nil && *edit.Amount != loan.Amount
+	if amountChanged {
+		if *edit.Amount <= 0 {
+			return domain.Loan{}, errors.New("loan amount must be greater than zero")
+		}
+		if !loan.RefinancesLoanID.IsZero() {
+			old, err := uc.loanRepo.GetLoanByID(ctx, loan.RefinancesLoanID)
+			if payoff := payoffAmount(old, time.Now()); err == nil && *edit.Amount <= payoff {
+				return domain.Loan{}, fmt.Errorf("the new amount must exceed the payoff amount of %.2f", payoff)
+			}
+		}
+		changes = append(changes, domain.FieldChange{Field: "amount", OldValue: loan.Amount, NewValue: *edit.Amount})
+		loan.Amount = *edit.Amount
+	}
+	if edit.Description != nil && *edit.Description != loan.Description {
+		changes = append(changes, domain.FieldChange{Field: "description", OldValue: loan.Description, NewValue: *edit.Description})
+		loan.Description = *edit.Description
+	}
+	if edit.Tenor != nil || edit.Frequency != nil {
+		if loan.Type == "credit_line" {
+			return domain.Loan{}, errors.New("credit line applications have no repayment terms")
+		}
+		tenor, frequency := loan.Tenor, loan.Frequency
+		if edit.Tenor != nil {
+			tenor = *edit.Tenor
+		}
+		if edit.Frequency != nil {
+			frequency = *edit.Frequency
+		}
+		if frequency == "" {
+			frequency = "monthly"
+		}
+		if err := validateTerms(tenor, frequency); err != nil {
+			return domain.Loan{}, err
+		}
+		if tenor != loan.Tenor {
+			changes = append(changes, domain.FieldChange{Field: "tenor", OldValue: loan.Tenor, NewValue: tenor})
+			loan.Tenor = tenor
+		}
+		if frequency != loan.Frequency {
+			changes = append(changes, domain.FieldChange{Field: "frequency", OldValue: loan.Frequency, NewValue: frequency})
+			loan.Frequency = frequency
+		}
+	}
+	if len(changes) == 0 {
+		return loan, nil
+	}
+
+	loan.UpdatedAt = time.Now()
+	err = uc.transactor.WithTransaction(ctx, func(ctx context.Context) error {
+		if err := uc.loanRepo.EditPendingLoan(ctx, loan); err != nil {
+			return err
+		}
+		if err := appendLoanEvent(ctx, uc.historyRepo, domain.LoanEvent{
+			LoanID:    loan.ID,
+			Type:      "edited",
+			ActorID:   userID,
+			Changes:   changes,
+			Timestamp: loan.UpdatedAt,
+		}); err != nil {
+			return err
+		}
+		fields := make([]string, len(changes))
+		for i, change := range changes {
+			fields[i] = change.Field
+		}
+		event := loanEvent(domain.EventLoanEdited, loan, userID, map[string]interface{}{
+			"changed_fields": fields,
+			"tenor":          loan.Tenor,
+			"frequency":      loan.Frequency,
+		})
+		event.OccurredAt = loan.UpdatedAt
+		if err := publishEvent(ctx, uc.outboxRepo, event); err != nil {
+			return err
+		}
+
+		// Guarantors agreed to a share of the old amount, so they have to confirm again
+		if amountChanged {
+			return uc.reconfirmGuarantees(ctx, loan)
+		}
+		return nil
+	})
+	if err != nil {
+		return domain.Loan{}, err
+	}
+	return loan, nil
+}
+
+// CancelLoan lets the borrower withdraw a pending application
+func (uc *loanUsecase) CancelLoan(ctx context.Context, id, userID primitive.ObjectID, reason string) error {
+	loan, err := uc.getOwnedPendingLoan(ctx, id, userID)
+	if err != nil {
+		return err
+	}
+	err = uc.transactor.WithTransaction(ctx, func(ctx context.Context) error {
+		if err := uc.loanRepo.TransitionLoanStatus(ctx, loan.ID, "pending", "cancelled"); err != nil {
+			return err
+		}
+		if err := uc.guaranteeRepo.ReleaseLoanGuarantees(ctx, loan.ID); err != nil {
+			return err
+		}
+		if err := appendLoanEvent(ctx, uc.historyRepo, domain.LoanEvent{
+			LoanID:  loan.ID,
+			Type:    "cancelled",
+			ActorID: userID,
+			Changes: statusChange("pending", "cancelled"),
+			Note:    reason,
+		}); err != nil {
+			return err
+		}
+		loan.Status = "cancelled"
+		return publishEvent(ctx, uc.outboxRepo, loanEvent(domain.EventLoanCancelled, loan, userID, map[string]interface{}{"reason": reason}))
+	})
+	if err != nil {
+		return err
+	}
+	finishReview(ctx, uc.loanRepo, loan)
+	return nil
+}
+
+func (uc *loanUsecase) getOwnedPendingLoan(ctx context.Context, id, userID primitive.ObjectID) (domain.Loan, error) {
+	loan, err := uc.loanRepo.GetLoanByID(ctx, id)
+	if err != nil || loan.UserID != userID {
+		return domain.Loan{}, errors.New("loan not found")
+	}
+	if loan.Status != "pending" {
+		return domain.Loan{}, errors.New("only pending applications can be changed")
+	}
+	return loan, nil
+}
+
+// reconfirmGuarantees resets the loan's guarantees to the new amount and invites the guarantors again
+func (uc *loanUsecase) reconfirmGuarantees(ctx context.Context, loan domain.Loan) error {
+	if err := uc.guaranteeRepo.ResetLoanGuarantees(ctx, loan.ID, loan.Amount); err != nil {
+		return err
+	}
+	guarantees, err := uc.guaranteeRepo.GetGuaranteesByLoan(ctx, loan.ID)
 	if err != nil {
 		return err
 	}
+	borrower, err := uc.userRepo.FindByID(domain.User{ID: loan.UserID})
+	if err != nil {
+		return err
+	}
+	for _, g := range guarantees {
+		if g.Status != "pending" {
+			continue
+		}
+		// Without the guarantor's record the invitation goes out in the default language
+		guarantor, _ := uc.userRepo.FindByID(domain.User{ID: g.GuarantorID})
+		email, err := infrastructure.GuarantorInvitationEmail(g.GuarantorEmail, guarantor.Locale, borrower.UserName, loan.Amount, g.LiabilityShare)
+		if err == nil {
+			err = queueEmail(ctx, uc.emailRepo, email)
+		}
+		if err != nil {
+			log.Println("Error queueing guarantor invitation:", err)
+		}
+	}
 	return nil
 }
+
+// ExpireStaleApplications expires pending applications older than their product's SLA. Borrowers hear of it
+// from the notification center, which follows the loan.expired events.
+func (uc *loanUsecase) ExpireStaleApplications(ctx context.Context) error {
+	pending, err := uc.loanRepo.FindLoans(ctx, domain.LoanFilter{Status: "pending", Order: "asc"})
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	slas := make(map[string]int)
+	failed := 0
+	for _, loan := range pending {
+		slaDays := uc.pendingSLADays(ctx, loan.ProductCode, slas)
+		if now.Before(loan.CreatedAt.AddDate(0, 0, slaDays)) {
+			continue
+		}
+
+		err := uc.transactor.WithTransaction(ctx, func(ctx context.Context) error {
+			if err := uc.loanRepo.TransitionLoanStatus(ctx, loan.ID, "pending", "expired"); err != nil {
+				return err
+			}
+			if err := appendLoanEvent(ctx, uc.historyRepo, domain.LoanEvent{
+				LoanID:  loan.ID,
+				Type:    "expired",
+				Changes: statusChange("pending", "expired"),
+				Note:    fmt.Sprintf("not reviewed within %d days", slaDays),
+			}); err != nil {
+				return err
+			}
+			expired := loan
+			expired.Status = "expired"
+			return publishEvent(ctx, uc.outboxRepo, loanEvent(domain.EventLoanExpired, expired, primitive.NilObjectID, map[string]interface{}{"sla_days": slaDays}))
+		})
+		if err != nil {
+			// An admin or the borrower acted on it in the meantime
+			if errors.As(err, &domain.LoanStatusError{}) {
+				continue
+			}
+			log.Println("Error expiring loan", loan.ID.Hex()+":", err)
+			failed++
+			continue
+		}
+		finishReview(ctx, uc.loanRepo, loan)
+		if err := uc.guaranteeRepo.ReleaseLoanGuarantees(ctx, loan.ID); err != nil {
+			log.Println("Error releasing guarantees of expired loan:", err)
+		}
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d stale application(s) could not be expired", failed)
+	}
+	return nil
+}
+
+// EscalateApplicationsNearSLA emails admins a summary of pending applications about to expire
+func (uc *loanUsecase) EscalateApplicationsNearSLA(ctx context.Context) error {
+	pending, err := uc.loanRepo.FindLoans(ctx, domain.LoanFilter{Status: "pending", Order: "asc"})
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	warningWindow := time.Duration(infrastructure.IntSetting("SLA_WARNING_DAYS", 2)) * 24 * time.Hour
+	slas := make(map[string]int)
+	var lines []string
+	for _, loan := range pending {
+		deadline := loan.CreatedAt.AddDate(0, 0, uc.pendingSLADays(ctx, loan.ProductCode, slas))
+		if now.Before(deadline) && deadline.Sub(now) <= warningWindow {
+			lines = append(lines, fmt.Sprintf("- %s: %.2f (%s), applied %s, expires %s",
+				loan.ID.Hex(), loan.Amount, productLabel(loan.ProductCode),
+				loan.CreatedAt.Format("2006-01-02"), deadline.Format("2006-01-02 15:04")))
+		}
+	}
+	if len(lines) == 0 {
+		return nil
+	}
+
+	users, err := uc.userRepo.GetAllUsers()
+	if err != nil {
+		return err
+	}
+	summary := strings.Join(lines, "\n")
+	for _, user := range users {
+		if !user.IsAdmin {
+			continue
+		}
+		email, err := infrastructure.SLAEscalationEmail(user.Email, user.Locale, summary)
+		if err != nil {
+			return err
+		}
+		if err := queueEmail(ctx, uc.emailRepo, email); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// pendingSLADays returns how long an application for the product may stay pending, caching lookups in slas
+func (uc *loanUsecase) pendingSLADays(ctx context.Context, code string, slas map[string]int) int {
+	if days, ok := slas[code]; ok {
+		return days
+	}
+	days := infrastructure.IntSetting("PENDING_SLA_DAYS", 14)
+	if code != "" {
+		if product, err := uc.productRepo.GetProductByCode(ctx, code); err == nil && product.PendingSLADays > 0 {
+			days = product.PendingSLADays
+		}
+	}
+	slas[code] = days
+	return days
+}
+
+func productLabel(code string) string {
+	if code == "" {
+		return "no product"
+	}
+	return code
+}
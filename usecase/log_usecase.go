@@ -2,6 +2,7 @@ package usecase
 
 import (
 	"context"
+	"fmt"
 	"loan-tracker/domain"
 )
 
@@ -19,6 +20,149 @@ func (uc *logUsecase) LogEvent(ctx context.Context, log domain.Log) error {
 	return uc.logRepo.CreateLog(ctx, log)
 }
 
-func (uc *logUsecase) GetSystemLogs(ctx context.Context) ([]domain.Log, error) {
-	return uc.logRepo.GetAllLogs(ctx)
+func (uc *logUsecase) GetSystemLogs(ctx context.Context, filter domain.LogFilter) ([]domain.Log, error) {
+	return uc.logRepo.FindLogs(ctx, filter)
+}
+
+// RecordEvent is the event bus subscriber that keeps the system log of domain and audit events,
+// under the log types those actions have always been logged with
+func (uc *logUsecase) RecordEvent(ctx context.Context, event domain.Event) error {
+	entry := domain.Log{
+		Timestamp: event.OccurredAt,
+		Type:      event.Type,
+		Details:   fmt.Sprintf("%s %s %s", event.Type, event.AggregateType, event.AggregateID.Hex()),
+	}
+	loanID := event.AggregateID.Hex()
+	id := event.AggregateID.Hex()
+	actorID := event.ActorID.Hex()
+	switch event.Type {
+	case domain.EventUserRegistered:
+		entry.Type = "user_registration"
+		entry.Details = fmt.Sprint("User registered with email: ", event.Data["email"])
+	case domain.EventLoanApplied:
+		entry.Type = "loan_application"
+		entry.Details = "Loan applied with ID: " + loanID
+		if refinances, ok := event.Data["refinances_loan_id"]; ok {
+			entry.Type = "loan_refinance_application"
+			entry.Details = fmt.Sprint("Refinance of loan ", refinances, " applied with ID: ", loanID)
+		}
+	case domain.EventLoanEdited:
+		entry.Type = "loan_edit"
+		entry.Details = "Loan application edited with ID: " + loanID
+	case domain.EventLoanApproved, domain.EventLoanRejected:
+		entry.Type = "loan_approval_rejection"
+		entry.Details = fmt.Sprint("Loan status updated for ID: ", loanID, " to ", event.Data["status"])
+	case domain.EventLoanCancelled:
+		entry.Type = "loan_cancellation"
+		entry.Details = "Loan application cancelled with ID: " + loanID
+	case domain.EventLoanExpired:
+		entry.Type = "loan_expiry"
+		entry.Details = "Loan application expired with ID: " + loanID
+	case domain.EventLoanDisbursed:
+		entry.Type = "loan_disbursement"
+		entry.Details = "Loan disbursed for ID: " + loanID
+	case domain.EventLoanClosed:
+		entry.Type = "loan_closure"
+		entry.Details = fmt.Sprint("Loan closed with ID: ", loanID, " (", event.Data["reason"], ")")
+	case domain.EventRepaymentReceived:
+		entry.Type = "loan_repayment"
+		entry.Details = fmt.Sprint("Repayment of ", event.Data["repayment_amount"], " by ", event.Data["method"], " recorded for loan ID: ", loanID)
+	case domain.EventLoanViewed:
+		entry.Type = "view_loan_status"
+		entry.Details = "Loan status retrieved for ID: " + loanID + " by user ID: " + actorID
+	case domain.EventLoansListed:
+		entry.Type = "view_all_loans"
+		entry.Details = "All loans retrieved by admin ID: " + actorID
+	case domain.EventLoanDeleted:
+		entry.Type = "loan_deletion"
+		entry.Details = "Loan deleted with ID: " + loanID
+	case domain.EventLoanAssigned:
+		entry.Type = "loan_assignment"
+		entry.Details = fmt.Sprint("Loan ID: ", loanID, " assigned to officer ID: ", event.Data["officer_id"])
+	case domain.EventUserEmailVerified:
+		entry.Type = "email_verification"
+		entry.Details = "Email verified for user ID: " + id
+	case domain.EventUserLoggedIn:
+		entry.Type = "login_attempt"
+		entry.Details = fmt.Sprint("User logged in with email: ", event.Data["email"])
+	case domain.EventUserTokenRefreshed:
+		entry.Type = "token_refresh"
+		entry.Details = "Token refreshed for user ID: " + id
+	case domain.EventUserProfileViewed:
+		entry.Type = "user_profile_retrieval"
+		entry.Details = "User profile retrieved for user ID: " + id
+	case domain.EventUserPasswordResetRequested:
+		entry.Type = "password_reset_request"
+		entry.Details = fmt.Sprint("Password reset requested for email: ", event.Data["email"])
+	case domain.EventUserPasswordReset:
+		entry.Type = "password_reset_completion"
+		entry.Details = "Password reset completed for user ID: " + id
+	case domain.EventUsersListed:
+		entry.Type = "get_all_users"
+		entry.Details = "All users retrieved by admin ID: " + actorID
+	case domain.EventUserDeleted:
+		entry.Type = "user_deletion"
+		entry.Details = "User deleted with ID: " + id
+	case domain.EventUserRoleChanged:
+		entry.Type = "user_role_change"
+		entry.Details = fmt.Sprint("User ID: ", id, " given role: ", event.Data["role"])
+	case domain.EventKYCSubmitted:
+		entry.Type = "kyc_submission"
+		entry.Details = "KYC profile submitted for user ID: " + id
+	case domain.EventKYCReviewed:
+		entry.Type = "kyc_review"
+		entry.Details = fmt.Sprint("KYC profile ", event.Data["status"], " for user ID: ", id)
+	case domain.EventProductCreated:
+		entry.Type = "product_creation"
+		entry.Details = fmt.Sprint("Loan product created with code: ", event.Data["code"])
+	case domain.EventProductUpdated:
+		entry.Type = "product_update"
+		entry.Details = fmt.Sprint("Loan product updated with code: ", event.Data["code"])
+	case domain.EventGuarantorInvited:
+		entry.Type = "guarantor_invitation"
+		entry.Details = fmt.Sprint("Guarantor invited for loan ID: ", event.Data["loan_id"])
+	case domain.EventGuaranteeAnswered:
+		entry.Type = "guarantee_response"
+		entry.Details = fmt.Sprint("Guarantee ", event.Data["status"], " with ID: ", id)
+	case domain.EventCreditLineDrawn:
+		entry.Type = "credit_line_draw"
+		entry.Details = fmt.Sprintf("Drew %.2f on credit line ID: %s", event.Data["amount"], id)
+	case domain.EventCreditLineRepaid:
+		entry.Type = "credit_line_repayment"
+		entry.Details = fmt.Sprintf("Repaid %.2f on credit line ID: %s", event.Data["amount"], id)
+	case domain.EventCreditLineLimitChanged:
+		entry.Type = "credit_line_limit_change"
+		entry.Details = fmt.Sprintf("Limit set to %.2f on credit line ID: %s", event.Data["limit"], id)
+	case domain.EventCreditLineStatusChanged:
+		entry.Type = "credit_line_status_change"
+		entry.Details = fmt.Sprint("Credit line ", event.Data["status"], " with ID: ", id)
+	case domain.EventBankStatementImported:
+		entry.Type = "bank_statement_import"
+		entry.Details = fmt.Sprint("Bank statement imported: ", event.Data["file_name"], " (", id, ")")
+	case domain.EventBankTransactionResolved:
+		entry.Type = "bank_transaction_match"
+		if event.Data["status"] == "ignored" {
+			entry.Type = "bank_transaction_ignore"
+		}
+		entry.Details = fmt.Sprint("Bank transaction ", id, " ", event.Data["status"], " by admin ", actorID)
+	case domain.EventDataExported:
+		entry.Type = "data_export"
+		entry.Details = fmt.Sprint("Exported ", event.Data["dataset"], " by admin ID: ", actorID)
+	case domain.EventWebhookSubscriptionCreated:
+		entry.Type = "webhook_subscription_creation"
+		entry.Details = fmt.Sprint("Webhook subscription created for ", event.Data["url"], " (", id, ")")
+	case domain.EventWebhookSubscriptionUpdated:
+		entry.Type = "webhook_subscription_update"
+		entry.Details = "Webhook subscription updated: " + id
+	case domain.EventWebhookSubscriptionDeleted:
+		entry.Type = "webhook_subscription_deletion"
+		entry.Details = "Webhook subscription deleted: " + id
+	case domain.EventWebhookDeliveryReplayed:
+		entry.Type = "webhook_delivery_replay"
+		entry.Details = fmt.Sprint("Webhook delivery ", id, " replayed as ", event.Data["replay_id"])
+	case domain.EventEmailResent:
+		entry.Type = "email_resend"
+		entry.Details = fmt.Sprint("Email ", id, " (", event.Data["kind"], ") queued again for ", event.Data["to"])
+	}
+	return uc.logRepo.CreateLog(ctx, entry)
 }
@@ -0,0 +1,501 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"loan-tracker/domain"
+	"loan-tracker/infrastructure"
+	"log"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type mandateUsecase struct {
+	mandateRepo   domain.MandateRepository
+	loanRepo      domain.LoanRepository
+	repaymentRepo domain.RepaymentRepository
+	guaranteeRepo domain.GuaranteeRepository
+	historyRepo   domain.LoanHistoryRepository
+	outboxRepo    domain.OutboxRepository
+	transactor    domain.Transactor
+	provider      infrastructure.DebitProvider
+}
+
+// NewMandateUsecase creates a new instance of MandateUsecase collecting through the given debit provider
+func NewMandateUsecase(mandateRepo domain.MandateRepository, loanRepo domain.LoanRepository, repaymentRepo domain.RepaymentRepository,
+	guaranteeRepo domain.GuaranteeRepository, historyRepo domain.LoanHistoryRepository, outboxRepo domain.OutboxRepository,
+	transactor domain.Transactor, provider infrastructure.DebitProvider) domain.MandateUsecase {
+	return &mandateUsecase{
+		mandateRepo:   mandateRepo,
+		loanRepo:      loanRepo,
+		repaymentRepo: repaymentRepo,
+		guaranteeRepo: guaranteeRepo,
+		historyRepo:   historyRepo,
+		outboxRepo:    outboxRepo,
+		transactor:    transactor,
+		provider:      provider,
+	}
+}
+
+// CreateMandate registers the borrower's authorization with the debit provider. It stays pending
+// until the bank confirms it; a loan can only have one pending or active mandate.
+func (uc *mandateUsecase) CreateMandate(ctx context.Context, loanID, userID primitive.ObjectID, req domain.MandateRequest) (domain.Mandate, error) {
+	loan, err := uc.loanRepo.GetLoanByID(ctx, loanID)
+	if err != nil || loan.UserID != userID {
+		return domain.Mandate{}, errors.New("loan not found")
+	}
+	if loan.Type == "credit_line" {
+		return domain.Mandate{}, errors.New("credit lines are repaid from the credit line itself")
+	}
+	if loan.Status != "pending" && loan.Status != "approved" {
+		return domain.Mandate{}, errors.New("a mandate can only be set up for a pending or active loan")
+	}
+	account := strings.ReplaceAll(strings.TrimSpace(req.AccountNumber), " ", "")
+	if len(account) < 6 {
+		return domain.Mandate{}, errors.New("please provide a valid account number")
+	}
+
+	mandates, err := uc.mandateRepo.GetMandatesByLoan(ctx, loanID)
+	if err != nil {
+		return domain.Mandate{}, err
+	}
+	for _, m := range mandates {
+		if m.Status != "revoked" {
+			return domain.Mandate{}, errors.New("this loan already has a " + m.Status + " mandate")
+		}
+	}
+
+	mandate := domain.Mandate{
+		ID:            primitive.NewObjectID(),
+		LoanID:        loan.ID,
+		UserID:        loan.UserID,
+		AccountHolder: strings.TrimSpace(req.AccountHolder),
+		AccountMask:   "****" + account[len(account)-4:],
+		BankCode:      strings.TrimSpace(req.BankCode),
+		Provider:      uc.provider.Name(),
+		Status:        "pending",
+		CreatedAt:     time.Now(),
+	}
+	mandate.ProviderReference, err = uc.provider.RegisterMandate(ctx, infrastructure.MandateInstruction{
+		Reference:     mandate.ID.Hex(),
+		AccountHolder: mandate.AccountHolder,
+		AccountNumber: account,
+		BankCode:      mandate.BankCode,
+	})
+	if err != nil {
+		return domain.Mandate{}, err
+	}
+	if _, err := uc.mandateRepo.CreateMandate(ctx, mandate); err != nil {
+		return domain.Mandate{}, err
+	}
+
+	recordLoanEvent(ctx, uc.historyRepo, domain.LoanEvent{
+		LoanID:  loan.ID,
+		Type:    "mandate_created",
+		ActorID: userID,
+		Note:    "direct debit from account " + mandate.AccountMask,
+	})
+	return mandate, nil
+}
+
+func (uc *mandateUsecase) GetMandates(ctx context.Context, loanID, userID primitive.ObjectID, isAdmin bool) ([]domain.Mandate, error) {
+	loan, err := uc.loanRepo.GetLoanByID(ctx, loanID)
+	if err != nil || (!isAdmin && loan.UserID != userID) {
+		return nil, errors.New("loan not found")
+	}
+	return uc.mandateRepo.GetMandatesByLoan(ctx, loanID)
+}
+
+// RevokeMandate stops collection under the mandate, by the borrower or an admin. Debits already
+// submitted to the bank are left to complete; collections still waiting are cancelled.
+func (uc *mandateUsecase) RevokeMandate(ctx context.Context, loanID, mandateID, userID primitive.ObjectID, isAdmin bool, reason string) (domain.Mandate, error) {
+	mandate, err := uc.mandateRepo.GetMandateByID(ctx, mandateID)
+	if err != nil || mandate.LoanID != loanID || (!isAdmin && mandate.UserID != userID) {
+		return domain.Mandate{}, errors.New("mandate not found")
+	}
+	if mandate.Status == "revoked" {
+		return domain.Mandate{}, errors.New("mandate has already been revoked")
+	}
+	if strings.TrimSpace(reason) == "" {
+		reason = "revoked by the borrower"
+		if isAdmin {
+			reason = "revoked by an admin"
+		}
+	}
+	if err := uc.provider.CancelMandate(ctx, mandate.ProviderReference); err != nil {
+		return domain.Mandate{}, err
+	}
+	if err := uc.revoke(ctx, &mandate, userID, reason); err != nil {
+		return domain.Mandate{}, err
+	}
+	return mandate, nil
+}
+
+func (uc *mandateUsecase) GetCollections(ctx context.Context, loanID, userID primitive.ObjectID, isAdmin bool) ([]domain.DebitCollection, error) {
+	loan, err := uc.loanRepo.GetLoanByID(ctx, loanID)
+	if err != nil || (!isAdmin && loan.UserID != userID) {
+		return nil, errors.New("loan not found")
+	}
+	return uc.mandateRepo.GetCollectionsByLoan(ctx, loanID)
+}
+
+func (uc *mandateUsecase) GetCollectionsByStatus(ctx context.Context, status string) ([]domain.DebitCollection, error) {
+	return uc.mandateRepo.GetCollectionsByStatus(ctx, status)
+}
+
+// RunCollections is the scheduled collection job. Failures for a single mandate or collection are
+// logged and left for the next run.
+func (uc *mandateUsecase) RunCollections(ctx context.Context) error {
+	if err := uc.applyResults(ctx); err != nil {
+		return err
+	}
+	if err := uc.raiseCollections(ctx); err != nil {
+		return err
+	}
+	return uc.submitDueCollections(ctx)
+}
+
+// applyResults records the bank's answers to mandates and debits
+func (uc *mandateUsecase) applyResults(ctx context.Context) error {
+	results, err := uc.provider.FetchResults(ctx)
+	if err != nil {
+		return err
+	}
+	for _, result := range results {
+		var err error
+		switch result.Kind {
+		case "mandate":
+			err = uc.applyMandateResult(ctx, result)
+		case "debit":
+			err = uc.applyDebitResult(ctx, result)
+		default:
+			err = fmt.Errorf("unsupported result kind %q", result.Kind)
+		}
+		if err != nil {
+			log.Println("Error applying direct debit result", result.ID+":", err)
+			continue
+		}
+		if err := uc.provider.AcknowledgeResult(ctx, result); err != nil {
+			log.Println("Error acknowledging direct debit result", result.ID+":", err)
+		}
+	}
+	return nil
+}
+
+func (uc *mandateUsecase) applyMandateResult(ctx context.Context, result infrastructure.DebitResult) error {
+	mandate, err := uc.mandateRepo.GetMandateByProviderReference(ctx, uc.provider.Name(), result.ProviderReference)
+	if err != nil {
+		return errors.New("mandate not found")
+	}
+	switch result.Status {
+	case "active":
+		if mandate.Status != "pending" {
+			return nil
+		}
+		mandate.Status = "active"
+		mandate.ActivatedAt = result.OccurredAt
+		if mandate.ActivatedAt.IsZero() {
+			mandate.ActivatedAt = time.Now()
+		}
+		if err := uc.mandateRepo.UpdateMandateStatus(ctx, mandate, "pending"); err != nil {
+			return err
+		}
+		recordLoanEvent(ctx, uc.historyRepo, domain.LoanEvent{
+			LoanID:  mandate.LoanID,
+			Type:    "mandate_activated",
+			Changes: []domain.FieldChange{{Field: "mandate_status", OldValue: "pending", NewValue: "active"}},
+		})
+		return nil
+	case "rejected", "cancelled":
+		if mandate.Status == "revoked" {
+			return nil
+		}
+		reason := result.Status + " by the bank"
+		if result.FailureCode != "" {
+			reason += ": " + result.FailureCode
+		}
+		return uc.revoke(ctx, &mandate, primitive.NilObjectID, reason)
+	default:
+		return fmt.Errorf("unsupported mandate status %q", result.Status)
+	}
+}
+
+// applyDebitResult posts a collected debit as a repayment, or schedules a retry of a failed one.
+// Results that arrive twice find the collection no longer submitted and are ignored.
+func (uc *mandateUsecase) applyDebitResult(ctx context.Context, result infrastructure.DebitResult) error {
+	collection, err := uc.mandateRepo.GetCollectionByProviderReference(ctx, uc.provider.Name(), result.ProviderReference)
+	if err != nil {
+		return errors.New("collection not found")
+	}
+	if collection.Status != "submitted" {
+		return nil
+	}
+	attempt := &collection.Attempts[len(collection.Attempts)-1]
+	attempt.Status = result.Status
+	attempt.FailureCode = result.FailureCode
+	attempt.CompletedAt = result.OccurredAt
+	collection.UpdatedAt = time.Now()
+
+	switch result.Status {
+	case "failed":
+		return uc.failAttempt(ctx, collection, result.FailureCode, "submitted")
+	case "succeeded":
+	default:
+		return fmt.Errorf("unsupported debit status %q", result.Status)
+	}
+
+	// Post what was actually collected, even if it differs from what was asked for
+	received := attempt.Amount
+	if result.Amount > 0 {
+		received = float64(result.Amount) / 100
+	}
+	if attempt.CompletedAt.IsZero() {
+		attempt.CompletedAt = collection.UpdatedAt
+	}
+	collection.Status = "succeeded"
+	collection.NextAttemptAt = time.Time{}
+	if err := uc.mandateRepo.UpdateCollection(ctx, collection, "submitted"); err != nil {
+		return err
+	}
+
+	repaymentID, err := settleRepayment(ctx, uc.loanRepo, uc.repaymentRepo, uc.guaranteeRepo, uc.historyRepo, uc.outboxRepo, uc.transactor, domain.Repayment{
+		LoanID:      collection.LoanID,
+		UserID:      collection.UserID,
+		Amount:      received,
+		Method:      "direct_debit",
+		Reference:   collection.Provider + ":" + collection.ProviderReference,
+		Installment: collection.Installment,
+		PaidAt:      attempt.CompletedAt,
+	})
+	if err != nil && repaymentID.IsZero() {
+		// The money has arrived, so the collection is kept aside for an admin to resolve
+		log.Println("Error posting direct debit", collection.ID.Hex()+":", err)
+		collection.Status = "unapplied"
+		collection.FailureCode = err.Error()
+		return uc.mandateRepo.UpdateCollection(ctx, collection, "succeeded")
+	}
+	if err != nil {
+		log.Println("Error closing loan after direct debit", collection.ID.Hex()+":", err)
+	}
+	collection.RepaymentID = repaymentID
+	return uc.mandateRepo.UpdateCollection(ctx, collection, "succeeded")
+}
+
+// raiseCollections schedules a collection for each active mandate whose next installment falls due
+// within DEBIT_LEAD_DAYS. Mandates of loans that have been closed are revoked.
+func (uc *mandateUsecase) raiseCollections(ctx context.Context) error {
+	mandates, err := uc.mandateRepo.GetActiveMandates(ctx)
+	if err != nil {
+		return err
+	}
+	horizon := time.Now().AddDate(0, 0, infrastructure.IntSetting("DEBIT_LEAD_DAYS", 0))
+	for _, mandate := range mandates {
+		loan, err := uc.loanRepo.GetLoanByID(ctx, mandate.LoanID)
+		if err != nil {
+			log.Println("Error loading loan for mandate", mandate.ID.Hex()+":", err)
+			continue
+		}
+		if loan.Status == "closed" {
+			if err := uc.provider.CancelMandate(ctx, mandate.ProviderReference); err != nil {
+				log.Println("Error cancelling mandate", mandate.ID.Hex()+":", err)
+				continue
+			}
+			if err := uc.revoke(ctx, &mandate, primitive.NilObjectID, "loan closed"); err != nil {
+				log.Println("Error revoking mandate", mandate.ID.Hex()+":", err)
+			}
+			continue
+		}
+		// Nothing is owed until the borrower has accepted the agreement and received the funds
+		if loan.Status != "approved" || loan.DisbursedAt.IsZero() {
+			continue
+		}
+		next := nextUnpaidInstallment(loan.Schedule)
+		if next == nil || next.DueDate.After(horizon) {
+			continue
+		}
+		exists, err := uc.mandateRepo.HasCollection(ctx, loan.ID, next.Number)
+		if err != nil {
+			return err
+		}
+		if exists {
+			continue
+		}
+
+		now := time.Now()
+		collection := domain.DebitCollection{
+			ID:            primitive.NewObjectID(),
+			MandateID:     mandate.ID,
+			LoanID:        loan.ID,
+			UserID:        loan.UserID,
+			Installment:   next.Number,
+			Currency:      infrastructure.DotEnvLoaderDefault("PAYMENT_CURRENCY", "USD"),
+			Provider:      uc.provider.Name(),
+			Status:        "scheduled",
+			Attempts:      []domain.DebitAttempt{},
+			NextAttemptAt: now,
+			CreatedAt:     now,
+			UpdatedAt:     now,
+		}
+		if _, err := uc.mandateRepo.CreateCollection(ctx, collection); err != nil {
+			log.Println("Error scheduling collection for loan", loan.ID.Hex()+":", err)
+		}
+	}
+	return nil
+}
+
+// submitDueCollections sends new and retried collections to the provider. The amount is worked out
+// at each attempt, so money paid in the meantime by other means is not collected again.
+func (uc *mandateUsecase) submitDueCollections(ctx context.Context) error {
+	collections, err := uc.mandateRepo.GetDueCollections(ctx, time.Now())
+	if err != nil {
+		return err
+	}
+	for _, collection := range collections {
+		if err := uc.submit(ctx, collection); err != nil {
+			log.Println("Error submitting collection", collection.ID.Hex()+":", err)
+		}
+	}
+	return nil
+}
+
+func (uc *mandateUsecase) submit(ctx context.Context, collection domain.DebitCollection) error {
+	from := collection.Status
+	collection.UpdatedAt = time.Now()
+	collection.NextAttemptAt = time.Time{}
+
+	mandate, err := uc.mandateRepo.GetMandateByID(ctx, collection.MandateID)
+	if err != nil {
+		return err
+	}
+	loan, err := uc.loanRepo.GetLoanByID(ctx, collection.LoanID)
+	if err != nil {
+		return err
+	}
+	amount := 0.0
+	if loan.Status == "approved" && !loan.DisbursedAt.IsZero() {
+		for _, installment := range loan.Schedule {
+			if installment.Number == collection.Installment && installment.Status != "paid" {
+				amount = roundCents(installment.Amount - installment.PaidAmount)
+			}
+		}
+		if amount > loan.OutstandingBalance {
+			amount = roundCents(loan.OutstandingBalance)
+		}
+	}
+	switch {
+	case mandate.Status != "active":
+		collection.Status = "cancelled"
+		collection.FailureCode = "mandate " + mandate.Status
+		return uc.mandateRepo.UpdateCollection(ctx, collection, from)
+	case amount <= 0:
+		collection.Status = "cancelled"
+		collection.FailureCode = "nothing left to collect"
+		return uc.mandateRepo.UpdateCollection(ctx, collection, from)
+	}
+
+	attempt := domain.DebitAttempt{
+		Number:      len(collection.Attempts) + 1,
+		Amount:      amount,
+		SubmittedAt: collection.UpdatedAt,
+		Status:      "submitted",
+	}
+	collection.Amount = amount
+	reference, err := uc.provider.SubmitDebit(ctx, infrastructure.DebitInstruction{
+		Reference:        fmt.Sprintf("%s-%d", collection.ID.Hex(), attempt.Number),
+		MandateReference: mandate.ProviderReference,
+		Amount:           toMinorUnits(amount),
+		Currency:         collection.Currency,
+		Description:      fmt.Sprintf("Installment %d of loan %s", collection.Installment, loan.ID.Hex()),
+		CollectOn:        collection.UpdatedAt,
+	})
+	if err != nil {
+		log.Println("Error submitting debit for collection", collection.ID.Hex()+":", err)
+		attempt.Status = "failed"
+		attempt.FailureCode = "submission_error"
+		attempt.CompletedAt = collection.UpdatedAt
+		collection.Attempts = append(collection.Attempts, attempt)
+		return uc.failAttempt(ctx, collection, attempt.FailureCode, from)
+	}
+	attempt.ProviderReference = reference
+	collection.Attempts = append(collection.Attempts, attempt)
+	collection.ProviderReference = reference
+	collection.Status = "submitted"
+	return uc.mandateRepo.UpdateCollection(ctx, collection, from)
+}
+
+// failAttempt schedules the next retry after a failed attempt. Once DEBIT_RETRY_DELAYS is exhausted,
+// or the failure is one listed in DEBIT_FINAL_FAILURES, the collection fails; a final failure also
+// revokes the mandate since it can no longer be used.
+func (uc *mandateUsecase) failAttempt(ctx context.Context, collection domain.DebitCollection, code, from string) error {
+	delays := infrastructure.IntervalListSetting("DEBIT_RETRY_DELAYS", []time.Duration{72 * time.Hour, 120 * time.Hour})
+	final := isFinalDebitFailure(code)
+	collection.FailureCode = code
+	if !final && len(collection.Attempts) <= len(delays) {
+		collection.Status = "retrying"
+		collection.NextAttemptAt = time.Now().Add(delays[len(collection.Attempts)-1])
+	} else {
+		collection.Status = "failed"
+		collection.NextAttemptAt = time.Time{}
+	}
+	if err := uc.mandateRepo.UpdateCollection(ctx, collection, from); err != nil {
+		return err
+	}
+
+	note := fmt.Sprintf("direct debit of installment %d failed (%s)", collection.Installment, code)
+	if collection.Status == "retrying" {
+		note += ", retrying on " + collection.NextAttemptAt.Format("2006-01-02")
+	}
+	recordLoanEvent(ctx, uc.historyRepo, domain.LoanEvent{
+		LoanID: collection.LoanID,
+		Type:   "direct_debit_failed",
+		Note:   note,
+	})
+
+	if final {
+		mandate, err := uc.mandateRepo.GetMandateByID(ctx, collection.MandateID)
+		if err == nil && mandate.Status != "revoked" {
+			return uc.revoke(ctx, &mandate, primitive.NilObjectID, "debit failed: "+code)
+		}
+	}
+	return nil
+}
+
+// revoke marks the mandate revoked, cancels its waiting collections and records it in the loan's history
+func (uc *mandateUsecase) revoke(ctx context.Context, mandate *domain.Mandate, actorID primitive.ObjectID, reason string) error {
+	from := mandate.Status
+	mandate.Status = "revoked"
+	mandate.RevokedAt = time.Now()
+	mandate.RevokedBy = actorID
+	mandate.RevocationReason = reason
+	if err := uc.mandateRepo.UpdateMandateStatus(ctx, *mandate, from); err != nil {
+		return err
+	}
+	if err := uc.mandateRepo.CancelCollections(ctx, mandate.ID, "mandate revoked"); err != nil {
+		log.Println("Error cancelling collections of mandate", mandate.ID.Hex()+":", err)
+	}
+	recordLoanEvent(ctx, uc.historyRepo, domain.LoanEvent{
+		LoanID:  mandate.LoanID,
+		Type:    "mandate_revoked",
+		ActorID: actorID,
+		Changes: []domain.FieldChange{{Field: "mandate_status", OldValue: from, NewValue: "revoked"}},
+		Note:    reason,
+	})
+	return nil
+}
+
+// isFinalDebitFailure reports whether retrying the debit is pointless, e.g. because the account was closed
+func isFinalDebitFailure(code string) bool {
+	if code == "" {
+		return false
+	}
+	finals := infrastructure.DotEnvLoaderDefault("DEBIT_FINAL_FAILURES", "account_closed,mandate_cancelled,mandate_invalid,no_account")
+	for _, final := range strings.Split(finals, ",") {
+		if strings.TrimSpace(final) == code {
+			return true
+		}
+	}
+	return false
+}
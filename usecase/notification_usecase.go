@@ -0,0 +1,422 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"loan-tracker/domain"
+	"loan-tracker/infrastructure"
+	"log"
+	"sort"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// statusTemplates names the email template of each loan status alert
+var statusTemplates = map[string]string{
+	domain.EventLoanApproved:  "loan_approved",
+	domain.EventLoanRejected:  "loan_rejected",
+	domain.EventLoanExpired:   "application_expired",
+	domain.EventLoanDisbursed: "loan_disbursed",
+	domain.EventLoanClosed:    "loan_closed",
+}
+
+// fallbackChannels is where a notification goes when it cannot be sent on a channel. Emails are
+// retried by the outbox, so email ends the chain.
+var fallbackChannels = map[string]string{
+	domain.ChannelPush: domain.ChannelSMS,
+	domain.ChannelSMS:  domain.ChannelEmail,
+}
+
+type notificationUsecase struct {
+	notificationRepo domain.NotificationRepository
+	loanRepo         domain.LoanRepository
+	userRepo         domain.UserRepository
+	emailRepo        domain.EmailRepository
+	transactor       domain.Transactor
+	sms              infrastructure.NotificationProvider
+	push             infrastructure.NotificationProvider
+}
+
+// NewNotificationUsecase creates a new instance of NotificationUsecase sending SMS and push
+// notifications through the given providers
+func NewNotificationUsecase(notificationRepo domain.NotificationRepository, loanRepo domain.LoanRepository, userRepo domain.UserRepository,
+	emailRepo domain.EmailRepository, transactor domain.Transactor, sms, push infrastructure.NotificationProvider) domain.NotificationUsecase {
+	return &notificationUsecase{
+		notificationRepo: notificationRepo,
+		loanRepo:         loanRepo,
+		userRepo:         userRepo,
+		emailRepo:        emailRepo,
+		transactor:       transactor,
+		sms:              sms,
+		push:             push,
+	}
+}
+
+func (uc *notificationUsecase) HandleEvent(ctx context.Context, event domain.Event) error {
+	userID, err := primitive.ObjectIDFromHex(eventString(event.Data, "user_id"))
+	if err != nil || event.AggregateType != "loan" {
+		return nil
+	}
+	key := "event:" + event.ID.Hex()
+	data := map[string]interface{}{
+		"LoanID": event.AggregateID.Hex(),
+		"Amount": eventFloat(event.Data, "amount"),
+	}
+
+	if event.Type == domain.EventRepaymentReceived {
+		// A refinance payoff is reported by the closure of the loan instead
+		if eventString(event.Data, "method") == "refinance" {
+			return nil
+		}
+		data["Amount"] = eventFloat(event.Data, "repayment_amount")
+		data["PaidAt"] = eventTime(event.Data, "paid_at", event.OccurredAt)
+		data["Reference"] = eventString(event.Data, "reference")
+		data["OutstandingBalance"] = eventFloat(event.Data, "outstanding_balance")
+		return uc.notify(ctx, userID, domain.NotificationPaymentReceipt, key, "payment_receipt", event.AggregateID, data)
+	}
+
+	template, ok := statusTemplates[event.Type]
+	if !ok {
+		return nil
+	}
+	switch event.Type {
+	case domain.EventLoanExpired:
+		data["SLADays"] = int(eventFloat(event.Data, "sla_days"))
+	case domain.EventLoanDisbursed:
+		if net := eventFloat(event.Data, "net_disbursement"); net > 0 {
+			data["Amount"] = net
+		}
+		data["FirstAmount"] = 0.0
+		if loan, err := uc.loanRepo.GetLoanByID(ctx, event.AggregateID); err == nil && len(loan.Schedule) > 0 {
+			data["FirstAmount"] = loan.Schedule[0].Amount
+			data["FirstDueDate"] = loan.Schedule[0].DueDate
+		}
+	case domain.EventLoanClosed:
+		data["Refinanced"] = eventString(event.Data, "reason") == "refinanced"
+	}
+	return uc.notify(ctx, userID, domain.NotificationLoanStatus, key, template, event.AggregateID, data)
+}
+
+// SendPaymentReminders looks at the unpaid installments of disbursed loans. The next one to fall
+// due gets a reminder once it is within one of PAYMENT_REMINDER_BEFORE (72h,24h) of its due date,
+// one per window. The oldest one past due gets an overdue notice OVERDUE_NOTICE_AFTER (24h) later.
+func (uc *notificationUsecase) SendPaymentReminders(ctx context.Context) error {
+	windows := infrastructure.IntervalListSetting("PAYMENT_REMINDER_BEFORE", []time.Duration{72 * time.Hour, 24 * time.Hour})
+	sort.Slice(windows, func(i, j int) bool { return windows[i] < windows[j] })
+	overdueAfter := infrastructure.IntervalSetting("OVERDUE_NOTICE_AFTER", 24*time.Hour)
+	now := time.Now()
+
+	return uc.loanRepo.StreamLoans(ctx, domain.LoanFilter{Status: "approved"}, func(loan domain.Loan) error {
+		// Installments only fall due once the funds have been released
+		if loan.DisbursedAt.IsZero() {
+			return nil
+		}
+		var overdue, upcoming *domain.Installment
+		for i := range loan.Schedule {
+			installment := &loan.Schedule[i]
+			if installment.Status == "paid" {
+				continue
+			}
+			if installment.DueDate.After(now) {
+				upcoming = installment
+				break
+			}
+			if overdue == nil {
+				overdue = installment
+			}
+		}
+
+		if overdue != nil && now.Sub(overdue.DueDate) >= overdueAfter {
+			key := fmt.Sprintf("overdue:%s:%d", loan.ID.Hex(), overdue.Number)
+			if err := uc.notify(ctx, loan.UserID, domain.NotificationPaymentOverdue, key, "payment_overdue", loan.ID, installmentData(loan, *overdue)); err != nil {
+				log.Println("Error sending overdue notice for loan", loan.ID.Hex()+":", err)
+			}
+		}
+		if upcoming != nil {
+			// Only the closest window counts, so a reminder missed while the job was down is not sent late
+			untilDue := upcoming.DueDate.Sub(now)
+			for _, window := range windows {
+				if untilDue > window {
+					continue
+				}
+				key := fmt.Sprintf("reminder:%s:%d:%s", loan.ID.Hex(), upcoming.Number, window)
+				if err := uc.notify(ctx, loan.UserID, domain.NotificationPaymentReminder, key, "payment_reminder", loan.ID, installmentData(loan, *upcoming)); err != nil {
+					log.Println("Error sending payment reminder for loan", loan.ID.Hex()+":", err)
+				}
+				break
+			}
+		}
+		return nil
+	})
+}
+
+func (uc *notificationUsecase) GetInbox(ctx context.Context, userID primitive.ObjectID, status string) (domain.NotificationInbox, error) {
+	if status != "" && status != "read" && status != "unread" {
+		return domain.NotificationInbox{}, errors.New("invalid status, use read or unread")
+	}
+	notifications, err := uc.notificationRepo.FindNotifications(ctx, domain.NotificationFilter{UserID: userID, Channel: domain.ChannelInApp, Status: status})
+	if err != nil {
+		return domain.NotificationInbox{}, err
+	}
+	unread, err := uc.notificationRepo.CountNotifications(ctx, domain.NotificationFilter{UserID: userID, Channel: domain.ChannelInApp, Status: "unread"})
+	if err != nil {
+		return domain.NotificationInbox{}, err
+	}
+	if notifications == nil {
+		notifications = []domain.Notification{}
+	}
+	return domain.NotificationInbox{Unread: unread, Notifications: notifications}, nil
+}
+
+func (uc *notificationUsecase) MarkRead(ctx context.Context, id, userID primitive.ObjectID) error {
+	return uc.notificationRepo.MarkRead(ctx, id, userID, time.Now())
+}
+
+func (uc *notificationUsecase) MarkAllRead(ctx context.Context, userID primitive.ObjectID) (int64, error) {
+	return uc.notificationRepo.MarkAllRead(ctx, userID, time.Now())
+}
+
+func (uc *notificationUsecase) GetPreferences(ctx context.Context, userID primitive.ObjectID) (domain.NotificationPreferences, error) {
+	preferences, err := uc.notificationRepo.GetPreferences(ctx, userID)
+	if err != nil {
+		return domain.NotificationPreferences{}, err
+	}
+	preferences.Channels = withDefaultChannels(preferences.Channels)
+	return preferences, nil
+}
+
+// UpdatePreferences stores only the kinds the user set, so the others keep following the defaults.
+// An empty list of channels turns a kind off.
+func (uc *notificationUsecase) UpdatePreferences(ctx context.Context, userID primitive.ObjectID, channels map[string][]string) (domain.NotificationPreferences, error) {
+	preferences, err := uc.notificationRepo.GetPreferences(ctx, userID)
+	if err != nil {
+		return domain.NotificationPreferences{}, err
+	}
+	if preferences.Channels == nil {
+		preferences.Channels = make(map[string][]string)
+	}
+	for kind, chosen := range channels {
+		if !containsString(domain.NotificationKinds, kind) {
+			return domain.NotificationPreferences{}, fmt.Errorf("unknown notification kind %q", kind)
+		}
+		unique := []string{}
+		for _, channel := range chosen {
+			if !containsString(domain.NotificationChannels, channel) {
+				return domain.NotificationPreferences{}, fmt.Errorf("unknown notification channel %q", channel)
+			}
+			if !containsString(unique, channel) {
+				unique = append(unique, channel)
+			}
+		}
+		preferences.Channels[kind] = unique
+	}
+	preferences.UpdatedAt = time.Now()
+	if err := uc.notificationRepo.SavePreferences(ctx, preferences); err != nil {
+		return domain.NotificationPreferences{}, err
+	}
+	preferences.Channels = withDefaultChannels(preferences.Channels)
+	return preferences, nil
+}
+
+// notify sends a notification on the channels the user chose for its kind, rendering it from the
+// email template in the user's language. A key that was already used is not notified again, so
+// retried events and repeated reminder runs are harmless.
+func (uc *notificationUsecase) notify(ctx context.Context, userID primitive.ObjectID, kind, key, template string, loanID primitive.ObjectID, data map[string]interface{}) error {
+	user, err := uc.userRepo.FindByID(domain.User{ID: userID})
+	if err != nil {
+		return fmt.Errorf("loading user %s: %w", userID.Hex(), err)
+	}
+	preferences, err := uc.GetPreferences(ctx, userID)
+	if err != nil {
+		return err
+	}
+	channels := preferences.Channels[kind]
+	email, err := infrastructure.NotificationEmail(template, user.Email, user.Locale, data)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	notification := domain.Notification{
+		ID:        primitive.NewObjectID(),
+		UserID:    userID,
+		Kind:      kind,
+		LoanID:    loanID,
+		Title:     email.Subject,
+		Body:      email.Body,
+		Channels:  channels,
+		Key:       key,
+		CreatedAt: now,
+	}
+	if containsString(channels, domain.ChannelEmail) {
+		notification.Deliveries = []domain.NotificationDelivery{{Channel: domain.ChannelEmail, Status: "queued", At: now}}
+	}
+	var added bool
+	err = uc.transactor.WithTransaction(ctx, func(ctx context.Context) error {
+		if added, err = uc.notificationRepo.AddNotification(ctx, notification); err != nil || !added {
+			return err
+		}
+		if containsString(channels, domain.ChannelEmail) {
+			return queueEmail(ctx, uc.emailRepo, email)
+		}
+		return nil
+	})
+	if err != nil || !added {
+		return err
+	}
+	uc.deliver(ctx, user, notification, email)
+	return nil
+}
+
+// deliver sends a stored notification by SMS and push, which have no outbox. A channel the user
+// cannot be reached on, or whose provider fails, hands over to its fallback unless the
+// notification already went there.
+func (uc *notificationUsecase) deliver(ctx context.Context, user domain.User, notification domain.Notification, email infrastructure.Email) {
+	var pending []string
+	for _, channel := range notification.Channels {
+		if channel == domain.ChannelSMS || channel == domain.ChannelPush {
+			pending = append(pending, channel)
+		}
+	}
+	if len(pending) == 0 {
+		return
+	}
+
+	channels := notification.Channels
+	deliveries := notification.Deliveries
+	for i := 0; i < len(pending); i++ {
+		delivery := domain.NotificationDelivery{Channel: pending[i], Status: "sent", At: time.Now()}
+		var fallback *domain.NotificationDelivery
+		if err := uc.send(ctx, user, pending[i], notification); err != nil {
+			delivery.Status = "failed"
+			delivery.Error = err.Error()
+			if next, ok := fallbackChannels[pending[i]]; ok && !containsString(channels, next) {
+				delivery.FallbackTo = next
+				channels = append(channels, next)
+				if next == domain.ChannelEmail {
+					fallback = &domain.NotificationDelivery{Channel: next, Status: "queued", At: time.Now()}
+					if err := queueEmail(ctx, uc.emailRepo, email); err != nil {
+						fallback.Status = "failed"
+						fallback.Error = err.Error()
+					}
+				} else {
+					pending = append(pending, next)
+				}
+			}
+		}
+		deliveries = append(deliveries, delivery)
+		if fallback != nil {
+			deliveries = append(deliveries, *fallback)
+		}
+	}
+	if err := uc.notificationRepo.SetDeliveries(ctx, notification.ID, channels, deliveries); err != nil {
+		log.Println("Error recording deliveries of notification", notification.ID.Hex()+":", err)
+	}
+}
+
+// send delivers a notification by SMS to the user's verified phone or by push to each of their
+// devices, succeeding if any device accepted it
+func (uc *notificationUsecase) send(ctx context.Context, user domain.User, channel string, notification domain.Notification) error {
+	message := infrastructure.Message{Channel: channel, Kind: notification.Kind, Body: notification.Body}
+	if channel == domain.ChannelSMS {
+		if uc.sms == nil {
+			return errors.New("text messages are disabled")
+		}
+		if user.Phone == "" {
+			return errors.New("no verified phone number")
+		}
+		message.To = user.Phone
+		return uc.sms.Send(ctx, message)
+	}
+
+	if uc.push == nil {
+		return errors.New("push notifications are disabled")
+	}
+	if len(user.PushDevices) == 0 {
+		return errors.New("no registered device")
+	}
+	message.Title = notification.Title
+	message.Data = map[string]string{"notification_id": notification.ID.Hex(), "kind": notification.Kind}
+	if !notification.LoanID.IsZero() {
+		message.Data["loan_id"] = notification.LoanID.Hex()
+	}
+	var err error
+	delivered := false
+	for _, device := range user.PushDevices {
+		message.To = device.Token
+		if sendErr := uc.push.Send(ctx, message); sendErr != nil {
+			err = sendErr
+		} else {
+			delivered = true
+		}
+	}
+	if delivered {
+		return nil
+	}
+	return err
+}
+
+// withDefaultChannels fills in the default channels for the kinds the user has not set
+func withDefaultChannels(channels map[string][]string) map[string][]string {
+	merged := make(map[string][]string)
+	for _, kind := range domain.NotificationKinds {
+		if chosen, ok := channels[kind]; ok {
+			merged[kind] = chosen
+		} else {
+			merged[kind] = append([]string{}, domain.DefaultNotificationChannels...)
+		}
+	}
+	return merged
+}
+
+func installmentData(loan domain.Loan, installment domain.Installment) map[string]interface{} {
+	return map[string]interface{}{
+		"LoanID":      loan.ID.Hex(),
+		"Installment": installment.Number,
+		"DueDate":     installment.DueDate,
+		"AmountDue":   roundCents(installment.Amount - installment.PaidAmount),
+	}
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+// Event data comes back from the outbox with BSON types, so these read it whatever numeric or
+// date type it was stored as
+
+func eventString(data map[string]interface{}, key string) string {
+	value, _ := data[key].(string)
+	return value
+}
+
+func eventFloat(data map[string]interface{}, key string) float64 {
+	switch value := data[key].(type) {
+	case float64:
+		return value
+	case int32:
+		return float64(value)
+	case int64:
+		return float64(value)
+	case int:
+		return float64(value)
+	}
+	return 0
+}
+
+func eventTime(data map[string]interface{}, key string, fallback time.Time) time.Time {
+	switch value := data[key].(type) {
+	case time.Time:
+		return value
+	case primitive.DateTime:
+		return value.Time()
+	}
+	return fallback
+}
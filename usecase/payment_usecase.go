@@ -0,0 +1,256 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"loan-tracker/domain"
+	"loan-tracker/infrastructure"
+	"log"
+	"math"
+	"net/http"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type paymentUsecase struct {
+	paymentRepo   domain.PaymentRepository
+	loanRepo      domain.LoanRepository
+	repaymentRepo domain.RepaymentRepository
+	guaranteeRepo domain.GuaranteeRepository
+	userRepo      domain.UserRepository
+	historyRepo   domain.LoanHistoryRepository
+	outboxRepo    domain.OutboxRepository
+	transactor    domain.Transactor
+	provider      infrastructure.PaymentProvider
+}
+
+// NewPaymentUsecase creates a new instance of PaymentUsecase collecting through the given provider
+func NewPaymentUsecase(paymentRepo domain.PaymentRepository, loanRepo domain.LoanRepository, repaymentRepo domain.RepaymentRepository,
+	guaranteeRepo domain.GuaranteeRepository, userRepo domain.UserRepository, historyRepo domain.LoanHistoryRepository,
+	outboxRepo domain.OutboxRepository, transactor domain.Transactor, provider infrastructure.PaymentProvider) domain.PaymentUsecase {
+	return &paymentUsecase{
+		paymentRepo:   paymentRepo,
+		loanRepo:      loanRepo,
+		repaymentRepo: repaymentRepo,
+		guaranteeRepo: guaranteeRepo,
+		userRepo:      userRepo,
+		historyRepo:   historyRepo,
+		outboxRepo:    outboxRepo,
+		transactor:    transactor,
+		provider:      provider,
+	}
+}
+
+// CreateCheckout starts an online payment of the borrower's next installment, or of the given amount
+func (uc *paymentUsecase) CreateCheckout(ctx context.Context, loanID, userID primitive.ObjectID, req domain.PaymentRequest) (domain.Payment, error) {
+	if uc.provider == nil {
+		return domain.Payment{}, infrastructure.ErrPaymentsDisabled
+	}
+	loan, err := uc.loanRepo.GetLoanByID(ctx, loanID)
+	if err != nil || loan.UserID != userID {
+		return domain.Payment{}, errors.New("loan not found")
+	}
+	if loan.Type == "credit_line" {
+		return domain.Payment{}, errors.New("credit lines are repaid from the credit line itself")
+	}
+	if loan.Status != "approved" {
+		return domain.Payment{}, errors.New("only active loans can be repaid")
+	}
+	if loan.DisbursedAt.IsZero() {
+		return domain.Payment{}, errors.New("this loan has not been disbursed yet")
+	}
+
+	payment := domain.Payment{
+		ID:       primitive.NewObjectID(),
+		LoanID:   loan.ID,
+		UserID:   loan.UserID,
+		Amount:   roundCents(req.Amount),
+		Currency: infrastructure.DotEnvLoaderDefault("PAYMENT_CURRENCY", "USD"),
+		Provider: uc.provider.Name(),
+		Status:   "pending",
+	}
+	// Repayments settle the oldest installment first, so that is the only one that can be paid
+	if next := nextUnpaidInstallment(loan.Schedule); next != nil {
+		if req.Installment != 0 && req.Installment != next.Number {
+			return domain.Payment{}, fmt.Errorf("installment %d is the next one due and must be paid first", next.Number)
+		}
+		payment.Installment = next.Number
+		if payment.Amount == 0 {
+			payment.Amount = roundCents(next.Amount - next.PaidAmount)
+		}
+	} else if req.Installment != 0 {
+		return domain.Payment{}, errors.New("this loan has no unpaid installments")
+	}
+	if payment.Amount == 0 {
+		payment.Amount = loan.OutstandingBalance
+	}
+	if payment.Amount <= 0 {
+		return domain.Payment{}, errors.New("payment amount must be greater than zero")
+	}
+	if payment.Amount > loan.OutstandingBalance+0.005 {
+		return domain.Payment{}, fmt.Errorf("payment amount exceeds the outstanding balance of %.2f", loan.OutstandingBalance)
+	}
+
+	borrower, err := uc.userRepo.FindByID(domain.User{ID: loan.UserID})
+	if err != nil {
+		return domain.Payment{}, errors.New("borrower not found")
+	}
+	checkout, err := uc.provider.CreateCheckout(ctx, infrastructure.CheckoutRequest{
+		Reference:     payment.ID.Hex(),
+		Amount:        toMinorUnits(payment.Amount),
+		Currency:      payment.Currency,
+		Description:   "Repayment of loan " + loan.ID.Hex(),
+		CustomerEmail: borrower.Email,
+	})
+	if err != nil {
+		return domain.Payment{}, err
+	}
+	payment.ProviderReference = checkout.ProviderReference
+	payment.CheckoutURL = checkout.CheckoutURL
+	payment.ExpiresAt = checkout.ExpiresAt
+	payment.CreatedAt = time.Now()
+	payment.UpdatedAt = payment.CreatedAt
+	if _, err := uc.paymentRepo.CreatePayment(ctx, payment); err != nil {
+		return domain.Payment{}, err
+	}
+	return payment, nil
+}
+
+// GetPayments lists a loan's online payments for its borrower or an admin
+func (uc *paymentUsecase) GetPayments(ctx context.Context, loanID, userID primitive.ObjectID, isAdmin bool) ([]domain.Payment, error) {
+	loan, err := uc.loanRepo.GetLoanByID(ctx, loanID)
+	if err != nil || (!isAdmin && loan.UserID != userID) {
+		return nil, errors.New("loan not found")
+	}
+	return uc.paymentRepo.GetPaymentsByLoan(ctx, loanID)
+}
+
+// HandleWebhook records the outcome reported by the provider and posts the repayment for a successful
+// payment. Providers retry deliveries until one succeeds: a settled payment is left alone, except that
+// closing its loan is tried again if that failed the first time.
+func (uc *paymentUsecase) HandleWebhook(ctx context.Context, provider string, header http.Header, body []byte) error {
+	if uc.provider == nil {
+		return infrastructure.ErrPaymentsDisabled
+	}
+	if provider != uc.provider.Name() {
+		return errors.New("unknown payment provider")
+	}
+	event, err := uc.provider.ParseWebhook(header, body)
+	if err != nil {
+		return err
+	}
+	payment, err := uc.paymentRepo.GetPaymentByProviderReference(ctx, provider, event.ProviderReference)
+	if err != nil {
+		return errors.New("payment not found")
+	}
+	if payment.Status == "succeeded" {
+		loan, err := uc.loanRepo.GetLoanByID(ctx, payment.LoanID)
+		if err != nil {
+			return err
+		}
+		return closeIfRepaid(ctx, uc.loanRepo, uc.guaranteeRepo, uc.historyRepo, uc.outboxRepo, uc.transactor, loan, primitive.NilObjectID)
+	}
+	if payment.Status != "pending" {
+		return nil
+	}
+
+	payment.UpdatedAt = time.Now()
+	switch event.Status {
+	case "failed":
+		payment.Status = "failed"
+		payment.FailureReason = event.FailureReason
+		return uc.paymentRepo.UpdatePaymentStatus(ctx, payment, "pending")
+	case "succeeded":
+	default:
+		return fmt.Errorf("unsupported payment status %q", event.Status)
+	}
+
+	// Post what was actually collected, even if it differs from what was asked for
+	received := float64(event.Amount) / 100
+	if received != payment.Amount {
+		log.Printf("Payment %s asked for %.2f but the provider collected %.2f", payment.ID.Hex(), payment.Amount, received)
+	}
+	payment.Status = "succeeded"
+	payment.PaidAt = event.OccurredAt
+	if payment.PaidAt.IsZero() {
+		payment.PaidAt = payment.UpdatedAt
+	}
+
+	// The payment only becomes succeeded together with its repayment, so a failure part way leaves
+	// it pending for the provider's next delivery
+	var loan domain.Loan
+	var postErr error
+	err = uc.transactor.WithTransaction(ctx, func(ctx context.Context) error {
+		loan, payment.RepaymentID, postErr = postRepayment(ctx, uc.loanRepo, uc.repaymentRepo, uc.historyRepo, uc.outboxRepo, domain.Repayment{
+			LoanID:      payment.LoanID,
+			UserID:      payment.UserID,
+			Amount:      received,
+			Method:      "online",
+			Reference:   payment.Provider + ":" + payment.ProviderReference,
+			Installment: payment.Installment,
+			PaidAt:      payment.PaidAt,
+		})
+		if postErr != nil {
+			return postErr
+		}
+		return uc.paymentRepo.UpdatePaymentStatus(ctx, payment, "pending")
+	})
+	if postErr != nil {
+		// The money has arrived, so the payment is kept aside for an admin to resolve
+		log.Println("Error posting online payment", payment.ID.Hex()+":", postErr)
+		payment.Status = "unapplied"
+		payment.FailureReason = postErr.Error()
+		return uc.paymentRepo.UpdatePaymentStatus(ctx, payment, "pending")
+	}
+	if err != nil {
+		return err
+	}
+
+	// A failed closure is returned so the provider delivers again and the closure is retried
+	return closeIfRepaid(ctx, uc.loanRepo, uc.guaranteeRepo, uc.historyRepo, uc.outboxRepo, uc.transactor, loan, primitive.NilObjectID)
+}
+
+func (uc *paymentUsecase) SimulatorEnabled() bool {
+	_, ok := uc.provider.(*infrastructure.SimulatorProvider)
+	return ok
+}
+
+// SimulatePayment has the simulator provider settle a pending checkout and deliver its signed webhook
+func (uc *paymentUsecase) SimulatePayment(ctx context.Context, providerReference, outcome string) (domain.Payment, error) {
+	simulator, ok := uc.provider.(*infrastructure.SimulatorProvider)
+	if !ok {
+		return domain.Payment{}, errors.New("the payment simulator is not enabled")
+	}
+	payment, err := uc.paymentRepo.GetPaymentByProviderReference(ctx, simulator.Name(), providerReference)
+	if err != nil {
+		return domain.Payment{}, errors.New("payment not found")
+	}
+	if payment.Status != "pending" {
+		return domain.Payment{}, errors.New("payment has already been " + payment.Status)
+	}
+
+	header, body, err := simulator.SimulatePayment(providerReference, payment.ID.Hex(), toMinorUnits(payment.Amount), payment.Currency, outcome)
+	if err != nil {
+		return domain.Payment{}, err
+	}
+	if err := uc.HandleWebhook(ctx, simulator.Name(), header, body); err != nil {
+		return domain.Payment{}, err
+	}
+	return uc.paymentRepo.GetPaymentByID(ctx, payment.ID)
+}
+
+// nextUnpaidInstallment returns the earliest installment that is not fully paid
+func nextUnpaidInstallment(schedule []domain.Installment) *domain.Installment {
+	for i := range schedule {
+		if schedule[i].Status != "paid" {
+			return &schedule[i]
+		}
+	}
+	return nil
+}
+
+func toMinorUnits(amount float64) int64 {
+	return int64(math.Round(amount * 100))
+}
@@ -0,0 +1,109 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"loan-tracker/domain"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type productUsecase struct {
+	productRepo domain.ProductRepository
+	outboxRepo  domain.OutboxRepository
+	transactor  domain.Transactor
+}
+
+// NewProductUsecase creates a new instance of ProductUsecase
+func NewProductUsecase(productRepo domain.ProductRepository, outboxRepo domain.OutboxRepository, transactor domain.Transactor) domain.ProductUsecase {
+	return &productUsecase{
+		productRepo: productRepo,
+		outboxRepo:  outboxRepo,
+		transactor:  transactor,
+	}
+}
+
+// CreateProduct validates and stores a new loan product
+func (uc *productUsecase) CreateProduct(ctx context.Context, adminID primitive.ObjectID, product domain.LoanProduct) (primitive.ObjectID, error) {
+	if err := validateProduct(product); err != nil {
+		return primitive.NilObjectID, err
+	}
+	if _, err := uc.productRepo.GetProductByCode(ctx, product.Code); err == nil {
+		return primitive.NilObjectID, errors.New("a product with this code already exists")
+	}
+
+	product.ID = primitive.NewObjectID()
+	product.CreatedAt = time.Now()
+	product.UpdatedAt = time.Now()
+	var id primitive.ObjectID
+	err := uc.transactor.WithTransaction(ctx, func(ctx context.Context) error {
+		var err error
+		if id, err = uc.productRepo.CreateProduct(ctx, product); err != nil {
+			return err
+		}
+		return publishEvent(ctx, uc.outboxRepo, productEvent(domain.EventProductCreated, product, adminID))
+	})
+	if err != nil {
+		return primitive.NilObjectID, err
+	}
+	return id, nil
+}
+
+// GetProduct retrieves a single product by its code
+func (uc *productUsecase) GetProduct(ctx context.Context, code string) (domain.LoanProduct, error) {
+	return uc.productRepo.GetProductByCode(ctx, code)
+}
+
+// GetAllProducts lists every configured loan product
+func (uc *productUsecase) GetAllProducts(ctx context.Context) ([]domain.LoanProduct, error) {
+	return uc.productRepo.GetAllProducts(ctx)
+}
+
+// UpdateProduct replaces the settings of an existing product, keeping its code
+func (uc *productUsecase) UpdateProduct(ctx context.Context, adminID primitive.ObjectID, code string, product domain.LoanProduct) error {
+	existing, err := uc.productRepo.GetProductByCode(ctx, code)
+	if err != nil {
+		return errors.New("product not found")
+	}
+
+	product.ID = existing.ID
+	product.Code = existing.Code
+	product.CreatedAt = existing.CreatedAt
+	product.UpdatedAt = time.Now()
+	if err := validateProduct(product); err != nil {
+		return err
+	}
+	return uc.transactor.WithTransaction(ctx, func(ctx context.Context) error {
+		if err := uc.productRepo.UpdateProduct(ctx, code, product); err != nil {
+			return err
+		}
+		return publishEvent(ctx, uc.outboxRepo, productEvent(domain.EventProductUpdated, product, adminID))
+	})
+}
+
+func productEvent(eventType string, product domain.LoanProduct, adminID primitive.ObjectID) domain.Event {
+	return auditEvent(eventType, "product", product.ID, adminID, map[string]interface{}{"code": product.Code, "name": product.Name})
+}
+
+func validateProduct(product domain.LoanProduct) error {
+	if product.Code == "" {
+		return errors.New("product code is required")
+	}
+	if product.Name == "" {
+		return errors.New("product name is required")
+	}
+	if product.InterestRate < 0 {
+		return errors.New("interest rate cannot be negative")
+	}
+	if product.ProcessingFee < 0 || product.ProcessingFee >= 100 {
+		return errors.New("processing fee must be between 0 and 100 percent")
+	}
+	if product.PendingSLADays < 0 {
+		return errors.New("pending SLA days cannot be negative")
+	}
+	if product.RequiredGuarantors < 0 {
+		return errors.New("required guarantors cannot be negative")
+	}
+	return nil
+}
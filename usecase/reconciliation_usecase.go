@@ -0,0 +1,404 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"loan-tracker/domain"
+	"loan-tracker/infrastructure"
+	"log"
+	"math"
+	"sort"
+	"strings"
+	"time"
+	"unicode"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type reconciliationUsecase struct {
+	reconciliationRepo domain.ReconciliationRepository
+	loanRepo           domain.LoanRepository
+	repaymentRepo      domain.RepaymentRepository
+	guaranteeRepo      domain.GuaranteeRepository
+	userRepo           domain.UserRepository
+	historyRepo        domain.LoanHistoryRepository
+	outboxRepo         domain.OutboxRepository
+	transactor         domain.Transactor
+}
+
+// NewReconciliationUsecase creates a new instance of ReconciliationUsecase
+func NewReconciliationUsecase(reconciliationRepo domain.ReconciliationRepository, loanRepo domain.LoanRepository,
+	repaymentRepo domain.RepaymentRepository, guaranteeRepo domain.GuaranteeRepository, userRepo domain.UserRepository,
+	historyRepo domain.LoanHistoryRepository, outboxRepo domain.OutboxRepository, transactor domain.Transactor) domain.ReconciliationUsecase {
+	return &reconciliationUsecase{
+		reconciliationRepo: reconciliationRepo,
+		loanRepo:           loanRepo,
+		repaymentRepo:      repaymentRepo,
+		guaranteeRepo:      guaranteeRepo,
+		userRepo:           userRepo,
+		historyRepo:        historyRepo,
+		outboxRepo:         outboxRepo,
+		transactor:         transactor,
+	}
+}
+
+// ImportStatement stores every line of the statement, then posts the incoming payments that match a
+// single loan with confidence. Everything else incoming stays "unmatched" for an admin to resolve.
+func (uc *reconciliationUsecase) ImportStatement(ctx context.Context, fileName, format string, data []byte, adminID primitive.ObjectID) (domain.BankStatement, error) {
+	if format == "" {
+		format = infrastructure.DetectStatementFormat(fileName, data)
+	}
+	lines, err := infrastructure.ParseBankStatement(format, data)
+	if err != nil {
+		return domain.BankStatement{}, err
+	}
+	statement := domain.BankStatement{
+		ID:         primitive.NewObjectID(),
+		FileName:   fileName,
+		Format:     format,
+		Checksum:   checksum(data),
+		ImportedBy: adminID,
+		ImportedAt: time.Now(),
+		Lines:      len(lines),
+	}
+	exists, err := uc.reconciliationRepo.StatementExists(ctx, statement.Checksum)
+	if err != nil {
+		return domain.BankStatement{}, err
+	}
+	if exists {
+		return domain.BankStatement{}, errors.New("this statement has already been imported")
+	}
+	if _, err := uc.reconciliationRepo.CreateStatement(ctx, statement); err != nil {
+		return domain.BankStatement{}, err
+	}
+
+	matcher, err := uc.newMatcher(ctx)
+	if err != nil {
+		return domain.BankStatement{}, err
+	}
+	var transactions []domain.BankTransaction
+	seen := map[string]int{}
+	for i, line := range lines {
+		t := domain.BankTransaction{
+			ID:                  primitive.NewObjectID(),
+			StatementID:         statement.ID,
+			LineNumber:          i + 1,
+			BookingDate:         line.BookingDate,
+			Amount:              line.Amount,
+			Currency:            line.Currency,
+			Reference:           line.Reference,
+			CounterpartyName:    line.CounterpartyName,
+			CounterpartyAccount: line.CounterpartyAccount,
+			BankReference:       line.BankReference,
+			Status:              "unmatched",
+		}
+		// Identical lines within one file are separate payments, so the occurrence is part of the fingerprint
+		key := lineFingerprint(line)
+		seen[key]++
+		t.Fingerprint = checksum([]byte(fmt.Sprintf("%s#%d", key, seen[key])))
+		duplicate, err := uc.reconciliationRepo.TransactionExists(ctx, t.Fingerprint)
+		if err != nil {
+			return domain.BankStatement{}, err
+		}
+		if duplicate {
+			statement.Duplicates++
+			continue
+		}
+		if t.Amount <= 0 {
+			t.Status = "ignored"
+			t.Note = "not an incoming payment"
+		} else {
+			t.Candidates = matcher.candidates(t)
+		}
+		transactions = append(transactions, t)
+	}
+	// Lines are stored before anything is posted, so an interrupted import leaves them in the queue
+	if err := uc.reconciliationRepo.CreateTransactions(ctx, transactions); err != nil {
+		return domain.BankStatement{}, err
+	}
+
+	threshold := infrastructure.IntSetting("RECONCILIATION_MATCH_SCORE", 80)
+	for _, t := range transactions {
+		if t.Status == "ignored" {
+			statement.Ignored++
+			continue
+		}
+		loanID, ok := confidentMatch(t.Candidates, threshold)
+		if ok {
+			err := uc.postMatch(ctx, t, loanID, adminID, primitive.NilObjectID, "matched automatically")
+			if err == nil {
+				statement.Matched++
+				continue
+			}
+			log.Println("Error posting matched bank transfer", t.ID.Hex()+":", err)
+		}
+		statement.Unmatched++
+	}
+
+	err = uc.transactor.WithTransaction(ctx, func(ctx context.Context) error {
+		if err := uc.reconciliationRepo.UpdateStatement(ctx, statement); err != nil {
+			return err
+		}
+		return publishEvent(ctx, uc.outboxRepo, auditEvent(domain.EventBankStatementImported, "bank_statement", statement.ID, adminID,
+			map[string]interface{}{
+				"file_name":  statement.FileName,
+				"lines":      statement.Lines,
+				"matched":    statement.Matched,
+				"unmatched":  statement.Unmatched,
+				"ignored":    statement.Ignored,
+				"duplicates": statement.Duplicates,
+			}))
+	})
+	if err != nil {
+		return domain.BankStatement{}, err
+	}
+	return statement, nil
+}
+
+func (uc *reconciliationUsecase) GetStatements(ctx context.Context) ([]domain.BankStatement, error) {
+	return uc.reconciliationRepo.GetStatements(ctx)
+}
+
+// GetTransactions lists statement lines; with no filter it returns the reconciliation queue
+func (uc *reconciliationUsecase) GetTransactions(ctx context.Context, filter domain.BankTransactionFilter) ([]domain.BankTransaction, error) {
+	if filter.StatementID.IsZero() && filter.Status == "" {
+		filter.Status = "unmatched"
+	}
+	return uc.reconciliationRepo.FindTransactions(ctx, filter)
+}
+
+// MatchTransaction posts a queued line as a repayment of the chosen loan
+func (uc *reconciliationUsecase) MatchTransaction(ctx context.Context, id, adminID primitive.ObjectID, req domain.ReconcileRequest) (domain.BankTransaction, error) {
+	if req.LoanID.IsZero() {
+		return domain.BankTransaction{}, errors.New("please provide the loan_id to match")
+	}
+	t, err := uc.queuedTransaction(ctx, id)
+	if err != nil {
+		return domain.BankTransaction{}, err
+	}
+	if err := uc.postMatch(ctx, t, req.LoanID, adminID, adminID, req.Note); err != nil {
+		return domain.BankTransaction{}, err
+	}
+	t, err = uc.reconciliationRepo.GetTransactionByID(ctx, id)
+	if err != nil {
+		return domain.BankTransaction{}, err
+	}
+	// The repayment was posted in its own transaction, so the event can only follow it
+	recordEvent(ctx, uc.outboxRepo, bankTransactionEvent(t, adminID))
+	return t, nil
+}
+
+// IgnoreTransaction takes a queued line that is not a loan repayment out of the queue
+func (uc *reconciliationUsecase) IgnoreTransaction(ctx context.Context, id, adminID primitive.ObjectID, req domain.ReconcileRequest) (domain.BankTransaction, error) {
+	if strings.TrimSpace(req.Note) == "" {
+		return domain.BankTransaction{}, errors.New("please give a note explaining why the line is ignored")
+	}
+	t, err := uc.queuedTransaction(ctx, id)
+	if err != nil {
+		return domain.BankTransaction{}, err
+	}
+	t.Status = "ignored"
+	t.Note = req.Note
+	t.ResolvedBy = adminID
+	t.ResolvedAt = time.Now()
+	err = uc.transactor.WithTransaction(ctx, func(ctx context.Context) error {
+		if err := uc.reconciliationRepo.UpdateTransactionStatus(ctx, t, "unmatched"); err != nil {
+			return err
+		}
+		return publishEvent(ctx, uc.outboxRepo, bankTransactionEvent(t, adminID))
+	})
+	if err != nil {
+		return domain.BankTransaction{}, err
+	}
+	return t, nil
+}
+
+func bankTransactionEvent(t domain.BankTransaction, adminID primitive.ObjectID) domain.Event {
+	return auditEvent(domain.EventBankTransactionResolved, "bank_transaction", t.ID, adminID, map[string]interface{}{
+		"statement_id": t.StatementID.Hex(),
+		"status":       t.Status,
+		"loan_id":      hexOrEmpty(t.LoanID),
+		"amount":       t.Amount,
+	})
+}
+
+func (uc *reconciliationUsecase) queuedTransaction(ctx context.Context, id primitive.ObjectID) (domain.BankTransaction, error) {
+	t, err := uc.reconciliationRepo.GetTransactionByID(ctx, id)
+	if err != nil {
+		return domain.BankTransaction{}, errors.New("bank transaction not found")
+	}
+	if t.Status != "unmatched" {
+		return domain.BankTransaction{}, errors.New("this line has already been reconciled")
+	}
+	return t, nil
+}
+
+// postMatch records the line as a bank transfer repayment of the loan and marks it matched
+func (uc *reconciliationUsecase) postMatch(ctx context.Context, t domain.BankTransaction, loanID, recordedBy, resolvedBy primitive.ObjectID, note string) error {
+	loan, err := uc.loanRepo.GetLoanByID(ctx, loanID)
+	if err != nil {
+		return errors.New("loan not found")
+	}
+	if loan.Type == "credit_line" {
+		return errors.New("bank transfers can only be matched to term loans")
+	}
+	if loan.DisbursedAt.IsZero() {
+		return errors.New("this loan has not been disbursed yet")
+	}
+	// Claim the line first so it cannot be posted twice
+	if err := uc.reconciliationRepo.UpdateTransactionStatus(ctx, domain.BankTransaction{ID: t.ID, Status: "matching"}, "unmatched"); err != nil {
+		return err
+	}
+	reference := t.BankReference
+	if reference == "" {
+		reference = "statement " + t.StatementID.Hex() + " line " + fmt.Sprint(t.LineNumber)
+	}
+	repaymentID, err := settleRepayment(ctx, uc.loanRepo, uc.repaymentRepo, uc.guaranteeRepo, uc.historyRepo, uc.outboxRepo, uc.transactor, domain.Repayment{
+		LoanID:     loan.ID,
+		UserID:     loan.UserID,
+		Amount:     t.Amount,
+		Method:     "bank_transfer",
+		Reference:  reference,
+		RecordedBy: recordedBy,
+		PaidAt:     t.BookingDate,
+	})
+	if repaymentID.IsZero() {
+		if releaseErr := uc.reconciliationRepo.UpdateTransactionStatus(ctx, domain.BankTransaction{ID: t.ID, Status: "unmatched"}, "matching"); releaseErr != nil {
+			log.Println("Error returning bank transaction", t.ID.Hex(), "to the queue:", releaseErr)
+		}
+		return err
+	}
+	if err != nil {
+		log.Println("Error closing loan after bank transfer", t.ID.Hex()+":", err)
+	}
+
+	t.Status = "matched"
+	t.Note = note
+	t.LoanID = loan.ID
+	t.RepaymentID = repaymentID
+	t.ResolvedBy = resolvedBy
+	t.ResolvedAt = time.Now()
+	return uc.reconciliationRepo.UpdateTransactionStatus(ctx, t, "matching")
+}
+
+// loanMatcher scores statement lines against the disbursed term loans and their borrowers
+type loanMatcher struct {
+	loans []domain.Loan
+	names map[primitive.ObjectID][]string
+}
+
+func (uc *reconciliationUsecase) newMatcher(ctx context.Context) (loanMatcher, error) {
+	loans, err := uc.loanRepo.FindLoans(ctx, domain.LoanFilter{Status: "approved"})
+	if err != nil {
+		return loanMatcher{}, err
+	}
+	m := loanMatcher{names: map[primitive.ObjectID][]string{}}
+	for _, loan := range loans {
+		// Nothing is owed on a loan until it has been paid out
+		if loan.Type == "credit_line" || loan.DisbursedAt.IsZero() {
+			continue
+		}
+		m.loans = append(m.loans, loan)
+		if _, ok := m.names[loan.UserID]; ok {
+			continue
+		}
+		if borrower, err := uc.userRepo.FindByID(domain.User{ID: loan.UserID}); err == nil {
+			m.names[loan.UserID] = nameTokens(borrowerName(borrower))
+		}
+	}
+	return m, nil
+}
+
+// candidates returns up to five loans named by the line's reference or payer, best first. The
+// reference naming the loan id scores 60, the payer's name 25 (10 for a partial match) and an
+// amount equal to the next installment or the balance 25.
+func (m loanMatcher) candidates(t domain.BankTransaction) []domain.MatchCandidate {
+	reference := strings.ToLower(t.Reference)
+	payer := nameTokens(t.CounterpartyName)
+
+	var candidates []domain.MatchCandidate
+	for _, loan := range m.loans {
+		c := domain.MatchCandidate{LoanID: loan.ID}
+		if strings.Contains(reference, loan.ID.Hex()) {
+			c.Score += 60
+			c.Reasons = append(c.Reasons, "reference names the loan")
+		}
+		switch shared := sharedTokens(payer, m.names[loan.UserID]); {
+		case shared > 0 && shared == len(payer) && shared == len(m.names[loan.UserID]):
+			c.Score += 25
+			c.Reasons = append(c.Reasons, "payer name matches the borrower")
+		case shared > 0:
+			c.Score += 10
+			c.Reasons = append(c.Reasons, "payer name partly matches the borrower")
+		}
+		// An amount alone is too common to make a loan a candidate
+		if c.Score == 0 {
+			continue
+		}
+		next := nextUnpaidInstallment(loan.Schedule)
+		switch {
+		case t.Amount > loan.OutstandingBalance+0.005:
+			c.Reasons = append(c.Reasons, "amount exceeds the outstanding balance")
+		case next != nil && math.Abs(t.Amount-(next.Amount-next.PaidAmount)) < 0.005:
+			c.Score += 25
+			c.Reasons = append(c.Reasons, "amount matches the next installment")
+		case math.Abs(t.Amount-loan.OutstandingBalance) < 0.005:
+			c.Score += 25
+			c.Reasons = append(c.Reasons, "amount settles the outstanding balance")
+		}
+		candidates = append(candidates, c)
+	}
+	sort.SliceStable(candidates, func(i, j int) bool { return candidates[i].Score > candidates[j].Score })
+	if len(candidates) > 5 {
+		candidates = candidates[:5]
+	}
+	return candidates
+}
+
+// confidentMatch accepts the best candidate when it reaches the threshold and clearly beats the next
+func confidentMatch(candidates []domain.MatchCandidate, threshold int) (primitive.ObjectID, bool) {
+	if len(candidates) == 0 || candidates[0].Score < threshold {
+		return primitive.NilObjectID, false
+	}
+	if len(candidates) > 1 && candidates[0].Score-candidates[1].Score < 20 {
+		return primitive.NilObjectID, false
+	}
+	for _, reason := range candidates[0].Reasons {
+		if reason == "amount exceeds the outstanding balance" {
+			return primitive.NilObjectID, false
+		}
+	}
+	return candidates[0].LoanID, true
+}
+
+// nameTokens lowercases a name and splits it into words of letters, ignoring one-letter initials
+func nameTokens(name string) []string {
+	var tokens []string
+	for _, word := range strings.FieldsFunc(strings.ToLower(name), func(r rune) bool { return !unicode.IsLetter(r) }) {
+		if len([]rune(word)) > 1 {
+			tokens = append(tokens, word)
+		}
+	}
+	return tokens
+}
+
+func sharedTokens(a, b []string) int {
+	shared := 0
+	for _, x := range a {
+		for _, y := range b {
+			if x == y {
+				shared++
+				break
+			}
+		}
+	}
+	return shared
+}
+
+func lineFingerprint(line infrastructure.StatementLine) string {
+	return strings.Join([]string{
+		line.BookingDate.Format("2006-01-02"), fmt.Sprintf("%.2f", line.Amount), line.Currency,
+		line.Reference, line.CounterpartyName, line.CounterpartyAccount, line.BankReference,
+	}, "|")
+}
@@ -0,0 +1,202 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"loan-tracker/domain"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type repaymentUsecase struct {
+	repaymentRepo domain.RepaymentRepository
+	loanRepo      domain.LoanRepository
+	guaranteeRepo domain.GuaranteeRepository
+	historyRepo   domain.LoanHistoryRepository
+	outboxRepo    domain.OutboxRepository
+	transactor    domain.Transactor
+}
+
+// NewRepaymentUsecase creates a new instance of RepaymentUsecase
+func NewRepaymentUsecase(repaymentRepo domain.RepaymentRepository, loanRepo domain.LoanRepository, guaranteeRepo domain.GuaranteeRepository,
+	historyRepo domain.LoanHistoryRepository, outboxRepo domain.OutboxRepository, transactor domain.Transactor) domain.RepaymentUsecase {
+	return &repaymentUsecase{
+		repaymentRepo: repaymentRepo,
+		loanRepo:      loanRepo,
+		guaranteeRepo: guaranteeRepo,
+		historyRepo:   historyRepo,
+		outboxRepo:    outboxRepo,
+		transactor:    transactor,
+	}
+}
+
+// RecordRepayment posts a payment against an active loan and closes the loan once it is fully repaid
+func (uc *repaymentUsecase) RecordRepayment(ctx context.Context, loanID, recordedBy primitive.ObjectID, repayment domain.Repayment) (primitive.ObjectID, error) {
+	if repayment.Amount <= 0 {
+		return primitive.NilObjectID, errors.New("repayment amount must be greater than zero")
+	}
+	loan, err := uc.loanRepo.GetLoanByID(ctx, loanID)
+	if err != nil {
+		return primitive.NilObjectID, errors.New("loan not found")
+	}
+	if loan.DisbursedAt.IsZero() {
+		return primitive.NilObjectID, errors.New("loan has not been disbursed")
+	}
+
+	repayment.LoanID = loan.ID
+	repayment.UserID = loan.UserID
+	repayment.RecordedBy = recordedBy
+	if repayment.Method == "" {
+		repayment.Method = "manual"
+	}
+	if repayment.PaidAt.IsZero() {
+		repayment.PaidAt = time.Now()
+	}
+
+	return settleRepayment(ctx, uc.loanRepo, uc.repaymentRepo, uc.guaranteeRepo, uc.historyRepo, uc.outboxRepo, uc.transactor, repayment)
+}
+
+// GetLoanRepayments lists the repayments of a loan for its borrower or an admin
+func (uc *repaymentUsecase) GetLoanRepayments(ctx context.Context, loanID, userID primitive.ObjectID, isAdmin bool) ([]domain.Repayment, error) {
+	loan, err := uc.loanRepo.GetLoanByID(ctx, loanID)
+	if err != nil {
+		return nil, errors.New("loan not found")
+	}
+	if !isAdmin && loan.UserID != userID {
+		return nil, errors.New("loan not found")
+	}
+	return uc.repaymentRepo.GetRepaymentsByLoan(ctx, loanID)
+}
+
+// GetRepayments lists repayments across all loans for admins, newest first
+func (uc *repaymentUsecase) GetRepayments(ctx context.Context, filter domain.RepaymentFilter) ([]domain.Repayment, error) {
+	return uc.repaymentRepo.FindRepayments(ctx, filter)
+}
+
+// postRepayment reduces the loan's outstanding balance and stores the repayment record
+func postRepayment(ctx context.Context, loanRepo domain.LoanRepository, repaymentRepo domain.RepaymentRepository, historyRepo domain.LoanHistoryRepository,
+	outboxRepo domain.OutboxRepository, repayment domain.Repayment) (domain.Loan, primitive.ObjectID, error) {
+	loan, err := loanRepo.ApplyRepayment(ctx, repayment.LoanID, repayment.Amount)
+	if err != nil {
+		return domain.Loan{}, primitive.NilObjectID, err
+	}
+
+	if len(loan.Schedule) > 0 {
+		loan.Schedule = allocateRepayments(loan.Schedule, loan.AmountRepaid)
+	}
+	principalBefore := loan.OutstandingPrincipal
+	loan.OutstandingPrincipal = outstandingPrincipal(loan)
+	if err := loanRepo.UpdateSchedule(ctx, loan.ID, loan.Schedule, loan.OutstandingPrincipal); err != nil {
+		return domain.Loan{}, primitive.NilObjectID, err
+	}
+
+	repayment.ID = primitive.NewObjectID()
+	repayment.CreatedAt = time.Now()
+	id, err := repaymentRepo.CreateRepayment(ctx, repayment)
+	if err != nil {
+		return domain.Loan{}, primitive.NilObjectID, err
+	}
+
+	if err := appendLoanEvent(ctx, historyRepo, domain.LoanEvent{
+		LoanID:  loan.ID,
+		Type:    "payment",
+		ActorID: repayment.RecordedBy,
+		Changes: []domain.FieldChange{
+			{Field: "amount_repaid", OldValue: roundCents(loan.AmountRepaid - repayment.Amount), NewValue: loan.AmountRepaid},
+			{Field: "outstanding_balance", OldValue: roundCents(loan.OutstandingBalance + repayment.Amount), NewValue: loan.OutstandingBalance},
+			{Field: "outstanding_principal", OldValue: principalBefore, NewValue: loan.OutstandingPrincipal},
+		},
+		Note:      repayment.Method + " repayment " + id.Hex(),
+		Timestamp: repayment.CreatedAt,
+	}); err != nil {
+		return domain.Loan{}, primitive.NilObjectID, err
+	}
+
+	event := loanEvent(domain.EventRepaymentReceived, loan, repayment.RecordedBy, map[string]interface{}{
+		"repayment_id":          id.Hex(),
+		"repayment_amount":      repayment.Amount,
+		"method":                repayment.Method,
+		"reference":             repayment.Reference,
+		"paid_at":               repayment.PaidAt,
+		"outstanding_balance":   loan.OutstandingBalance,
+		"outstanding_principal": loan.OutstandingPrincipal,
+	})
+	if repayment.Installment > 0 {
+		event.Data["installment"] = repayment.Installment
+	}
+	event.OccurredAt = repayment.CreatedAt
+	if err := publishEvent(ctx, outboxRepo, event); err != nil {
+		return domain.Loan{}, primitive.NilObjectID, err
+	}
+	return loan, id, nil
+}
+
+// settleRepayment posts a repayment and closes the loan once it is fully repaid. The repayment and the
+// closure are separate transactions, so a repayment that was posted stands even if closing fails.
+func settleRepayment(ctx context.Context, loanRepo domain.LoanRepository, repaymentRepo domain.RepaymentRepository, guaranteeRepo domain.GuaranteeRepository,
+	historyRepo domain.LoanHistoryRepository, outboxRepo domain.OutboxRepository, transactor domain.Transactor,
+	repayment domain.Repayment) (primitive.ObjectID, error) {
+	var updated domain.Loan
+	var repaymentID primitive.ObjectID
+	err := transactor.WithTransaction(ctx, func(ctx context.Context) error {
+		var err error
+		updated, repaymentID, err = postRepayment(ctx, loanRepo, repaymentRepo, historyRepo, outboxRepo, repayment)
+		return err
+	})
+	if err != nil {
+		return primitive.NilObjectID, err
+	}
+	if err := closeIfRepaid(ctx, loanRepo, guaranteeRepo, historyRepo, outboxRepo, transactor, updated, repayment.RecordedBy); err != nil {
+		return repaymentID, err
+	}
+	return repaymentID, nil
+}
+
+// closeIfRepaid closes an active loan whose balance has been paid off. A loan that is already
+// closed is left alone, so a closure that failed can be retried.
+func closeIfRepaid(ctx context.Context, loanRepo domain.LoanRepository, guaranteeRepo domain.GuaranteeRepository, historyRepo domain.LoanHistoryRepository,
+	outboxRepo domain.OutboxRepository, transactor domain.Transactor, loan domain.Loan, actorID primitive.ObjectID) error {
+	if loan.Status != "approved" || loan.OutstandingBalance >= 0.005 {
+		return nil
+	}
+	return transactor.WithTransaction(ctx, func(ctx context.Context) error {
+		return closeLoan(ctx, loanRepo, guaranteeRepo, historyRepo, outboxRepo, loan, "repaid", primitive.NilObjectID, actorID)
+	})
+}
+
+// closeLoan marks a loan closed, frees its guarantors and records the closure in its history
+func closeLoan(ctx context.Context, loanRepo domain.LoanRepository, guaranteeRepo domain.GuaranteeRepository, historyRepo domain.LoanHistoryRepository,
+	outboxRepo domain.OutboxRepository, loan domain.Loan, reason string, refinancedBy, actorID primitive.ObjectID) error {
+	id := loan.ID
+	if err := loanRepo.CloseLoan(ctx, id, reason, refinancedBy); err != nil {
+		return err
+	}
+
+	event := domain.LoanEvent{
+		LoanID:  id,
+		Type:    "closed",
+		ActorID: actorID,
+		Changes: statusChange("approved", "closed"),
+		Note:    reason,
+	}
+	if !refinancedBy.IsZero() {
+		event.Note = "refinanced by loan " + refinancedBy.Hex()
+	}
+	if loan.OutstandingBalance >= 0.005 {
+		event.Changes = append(event.Changes, domain.FieldChange{Field: "outstanding_balance", OldValue: loan.OutstandingBalance, NewValue: 0.0})
+	}
+	if err := appendLoanEvent(ctx, historyRepo, event); err != nil {
+		return err
+	}
+
+	loan.Status = "closed"
+	closed := loanEvent(domain.EventLoanClosed, loan, actorID, map[string]interface{}{"reason": reason})
+	if !refinancedBy.IsZero() {
+		closed.Data["refinanced_by_loan_id"] = refinancedBy.Hex()
+	}
+	if err := publishEvent(ctx, outboxRepo, closed); err != nil {
+		return err
+	}
+	return guaranteeRepo.ReleaseLoanGuarantees(ctx, id)
+}
@@ -0,0 +1,167 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"loan-tracker/domain"
+	"math"
+	"time"
+)
+
+type reportUsecase struct {
+	reportRepo domain.ReportRepository
+}
+
+// NewReportUsecase creates a new instance of ReportUsecase
+func NewReportUsecase(reportRepo domain.ReportRepository) domain.ReportUsecase {
+	return &reportUsecase{
+		reportRepo: reportRepo,
+	}
+}
+
+// Portfolio reports disbursements and what is still owed on them
+func (uc *reportUsecase) Portfolio(ctx context.Context, filter domain.ReportFilter) ([]domain.PortfolioRow, error) {
+	if err := checkReportFilter(&filter); err != nil {
+		return nil, err
+	}
+	rows, err := uc.reportRepo.Portfolio(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+	for i := range rows {
+		rows[i].TotalDisbursed = roundCents(rows[i].TotalDisbursed)
+		rows[i].AverageTicketSize = roundCents(rows[i].AverageTicketSize)
+		rows[i].OutstandingPrincipal = roundCents(rows[i].OutstandingPrincipal)
+		rows[i].OutstandingBalance = roundCents(rows[i].OutstandingBalance)
+	}
+	return rows, nil
+}
+
+// Approvals reports how applications were decided
+func (uc *reportUsecase) Approvals(ctx context.Context, filter domain.ReportFilter) ([]domain.ApprovalRow, error) {
+	if err := checkReportFilter(&filter); err != nil {
+		return nil, err
+	}
+	rows, err := uc.reportRepo.Approvals(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+	for i := range rows {
+		decided := rows[i].Approved + rows[i].Rejected
+		rows[i].ApprovalRate = ratio(float64(rows[i].Approved), float64(decided))
+		rows[i].RejectionRate = ratio(float64(rows[i].Rejected), float64(decided))
+	}
+	return rows, nil
+}
+
+// PortfolioAtRisk reports the share of outstanding principal on loans more than 30 and 90 days late
+func (uc *reportUsecase) PortfolioAtRisk(ctx context.Context, filter domain.ReportFilter) ([]domain.PARRow, error) {
+	if err := checkReportFilter(&filter); err != nil {
+		return nil, err
+	}
+	rows, err := uc.reportRepo.PortfolioAtRisk(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+	for i := range rows {
+		rows[i].PAR30 = ratio(rows[i].PAR30Principal, rows[i].OutstandingPrincipal)
+		rows[i].PAR90 = ratio(rows[i].PAR90Principal, rows[i].OutstandingPrincipal)
+		rows[i].OutstandingPrincipal = roundCents(rows[i].OutstandingPrincipal)
+		rows[i].PAR30Principal = roundCents(rows[i].PAR30Principal)
+		rows[i].PAR90Principal = roundCents(rows[i].PAR90Principal)
+	}
+	return rows, nil
+}
+
+// Collections reports how much of what fell due has been collected
+func (uc *reportUsecase) Collections(ctx context.Context, filter domain.ReportFilter) ([]domain.CollectionsRow, error) {
+	if err := checkReportFilter(&filter); err != nil {
+		return nil, err
+	}
+	rows, err := uc.reportRepo.Collections(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+	for i := range rows {
+		rows[i].CollectionsRate = ratio(rows[i].AmountCollected, rows[i].AmountDue)
+		rows[i].AmountDue = roundCents(rows[i].AmountDue)
+		rows[i].AmountCollected = roundCents(rows[i].AmountCollected)
+	}
+	return rows, nil
+}
+
+// Vintage builds a repayment curve for every monthly cohort of disbursed loans. Months on book
+// without repayments still get a point so the curves line up.
+func (uc *reportUsecase) Vintage(ctx context.Context, filter domain.ReportFilter) ([]domain.VintageCurve, error) {
+	if err := checkReportFilter(&filter); err != nil {
+		return nil, err
+	}
+	cohorts, err := uc.reportRepo.VintageCohorts(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+	repayments, err := uc.reportRepo.VintageRepayments(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	repaid := make(map[string]map[int]float64)
+	for _, r := range repayments {
+		key := r.Cohort + "|" + r.Product
+		if repaid[key] == nil {
+			repaid[key] = make(map[int]float64)
+		}
+		repaid[key][r.MonthsOnBook] += r.Repaid
+	}
+
+	for i, cohort := range cohorts {
+		start, err := time.Parse("2006-01", cohort.Cohort)
+		if err != nil {
+			return nil, err
+		}
+		monthsOnBook := monthsBetween(start, filter.AsOf)
+
+		var cumulative float64
+		points := make([]domain.VintagePoint, 0, monthsOnBook+1)
+		for mob := 0; mob <= monthsOnBook; mob++ {
+			amount := repaid[cohort.Cohort+"|"+cohort.Product][mob]
+			cumulative += amount
+			points = append(points, domain.VintagePoint{
+				MonthsOnBook:     mob,
+				Repaid:           roundCents(amount),
+				CumulativeRepaid: roundCents(cumulative),
+				RepaidRate:       ratio(cumulative, cohort.TotalDisbursed),
+			})
+		}
+		cohorts[i].TotalDisbursed = roundCents(cohort.TotalDisbursed)
+		cohorts[i].Points = points
+	}
+	return cohorts, nil
+}
+
+// checkReportFilter validates the range and defaults the as-of date to now
+func checkReportFilter(filter *domain.ReportFilter) error {
+	if !filter.From.IsZero() && !filter.To.IsZero() && !filter.From.Before(filter.To) {
+		return errors.New("from must be before to")
+	}
+	if filter.AsOf.IsZero() {
+		filter.AsOf = time.Now()
+	}
+	return nil
+}
+
+// ratio returns part/whole rounded to four decimals, or 0 when there is nothing to divide by
+func ratio(part, whole float64) float64 {
+	if whole == 0 {
+		return 0
+	}
+	return math.Round(part/whole*10000) / 10000
+}
+
+func monthsBetween(from, to time.Time) int {
+	months := (to.Year()-from.Year())*12 + int(to.Month()) - int(from.Month())
+	if months < 0 {
+		return 0
+	}
+	return months
+}
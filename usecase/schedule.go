@@ -0,0 +1,181 @@
+package usecase
+
+import (
+	"errors"
+	"loan-tracker/domain"
+	"math"
+	"time"
+)
+
+const maxTenor = 360
+
+// periodsPerYear maps a repayment frequency to the number of installments in a year
+var periodsPerYear = map[string]int{
+	"weekly":   52,
+	"biweekly": 26,
+	"monthly":  12,
+}
+
+// validateTerms checks the tenor and frequency of a loan or quote
+func validateTerms(tenor int, frequency string) error {
+	if tenor < 1 || tenor > maxTenor {
+		return errors.New("tenor must be between 1 and 360 installments")
+	}
+	if _, ok := periodsPerYear[frequency]; !ok {
+		return errors.New("invalid frequency, you can only enter weekly, biweekly or monthly")
+	}
+	return nil
+}
+
+// GenerateSchedule builds an equal-installment amortization schedule starting one period after start.
+// The last installment absorbs any rounding difference so the principal is repaid exactly.
+func GenerateSchedule(principal, annualRate float64, tenor int, frequency string, start time.Time) ([]domain.Installment, error) {
+	if principal <= 0 {
+		return nil, errors.New("amount must be greater than zero")
+	}
+	if annualRate < 0 {
+		return nil, errors.New("interest rate cannot be negative")
+	}
+	if err := validateTerms(tenor, frequency); err != nil {
+		return nil, err
+	}
+
+	rate := annualRate / 100 / float64(periodsPerYear[frequency])
+	payment := principal / float64(tenor)
+	if rate > 0 {
+		payment = principal * rate / (1 - math.Pow(1+rate, -float64(tenor)))
+	}
+	payment = roundCents(payment)
+
+	schedule := make([]domain.Installment, 0, tenor)
+	balance := principal
+	for n := 1; n <= tenor; n++ {
+		interest := roundCents(balance * rate)
+		principalPart := roundCents(payment - interest)
+		if n == tenor || principalPart > balance {
+			principalPart = roundCents(balance)
+		}
+		balance = roundCents(balance - principalPart)
+
+		schedule = append(schedule, domain.Installment{
+			Number:    n,
+			DueDate:   dueDate(start, frequency, n),
+			Amount:    roundCents(principalPart + interest),
+			Principal: principalPart,
+			Interest:  interest,
+			Balance:   balance,
+			Status:    "due",
+		})
+	}
+	return schedule, nil
+}
+
+// scheduleTotal is what the borrower owes over the life of the schedule
+func scheduleTotal(schedule []domain.Installment) float64 {
+	var total float64
+	for _, inst := range schedule {
+		total += inst.Amount
+	}
+	return roundCents(total)
+}
+
+// outstandingPrincipal is the part of the amount lent that the repayments allocated to the schedule
+// have not covered yet; a payment goes to the interest of its installment first
+func outstandingPrincipal(loan domain.Loan) float64 {
+	if len(loan.Schedule) == 0 {
+		return roundCents(loan.OutstandingBalance)
+	}
+	var principal float64
+	for _, inst := range loan.Schedule {
+		principal += inst.Principal - math.Min(math.Max(inst.PaidAmount-inst.Interest, 0), inst.Principal)
+	}
+	return roundCents(principal)
+}
+
+// payoffAmount is what settles the loan early at the given time: the outstanding principal, the
+// unpaid interest of the installments already due and the interest accrued so far in the current
+// period. Interest of the periods after that has not been earned and is not charged.
+func payoffAmount(loan domain.Loan, at time.Time) float64 {
+	payoff := outstandingPrincipal(loan)
+	for i, inst := range loan.Schedule {
+		if !inst.DueDate.After(at) {
+			payoff += math.Max(inst.Interest-inst.PaidAmount, 0)
+			continue
+		}
+		periodStart := dueDate(inst.DueDate, loan.Frequency, -1)
+		if i > 0 {
+			periodStart = loan.Schedule[i-1].DueDate
+		}
+		if at.After(periodStart) {
+			accrued := inst.Interest * at.Sub(periodStart).Seconds() / inst.DueDate.Sub(periodStart).Seconds()
+			payoff += math.Max(accrued-inst.PaidAmount, 0)
+		}
+		break
+	}
+	return math.Min(roundCents(payoff), roundCents(loan.OutstandingBalance))
+}
+
+// processingFee is the product's fee on a loan amount, deducted from what is paid out
+func processingFee(amount float64, product domain.LoanProduct) float64 {
+	return roundCents(amount * product.ProcessingFee / 100)
+}
+
+// allocateRepayments spreads the cumulative amount repaid over the schedule, oldest installment first
+func allocateRepayments(schedule []domain.Installment, repaid float64) []domain.Installment {
+	remaining := repaid
+	for i := range schedule {
+		paid := math.Min(remaining, schedule[i].Amount)
+		remaining = roundCents(remaining - paid)
+		schedule[i].PaidAmount = roundCents(paid)
+		switch {
+		case schedule[i].PaidAmount >= schedule[i].Amount:
+			schedule[i].Status = "paid"
+		case schedule[i].PaidAmount > 0:
+			schedule[i].Status = "partial"
+		default:
+			schedule[i].Status = "due"
+		}
+	}
+	return schedule
+}
+
+// computeAPR returns the annual percentage rate implied by the schedule when fee is deducted upfront
+func computeAPR(principal, fee float64, schedule []domain.Installment, frequency string) float64 {
+	received := principal - fee
+	if received <= 0 || len(schedule) == 0 {
+		return 0
+	}
+	presentValue := func(rate float64) float64 {
+		var pv float64
+		for _, inst := range schedule {
+			pv += inst.Amount / math.Pow(1+rate, float64(inst.Number))
+		}
+		return pv
+	}
+
+	// Present value falls as the rate rises, so bisect for the rate at which it equals what was received
+	low, high := 0.0, 1.0
+	if presentValue(low) <= received {
+		return 0
+	}
+	for i := 0; i < 200; i++ {
+		mid := (low + high) / 2
+		if presentValue(mid) > received {
+			low = mid
+		} else {
+			high = mid
+		}
+	}
+	return roundCents((low + high) / 2 * float64(periodsPerYear[frequency]) * 100)
+}
+
+func dueDate(start time.Time, frequency string, n int) time.Time {
+	switch frequency {
+	case "weekly":
+		return start.AddDate(0, 0, 7*n)
+	case "biweekly":
+		return start.AddDate(0, 0, 14*n)
+	default:
+		return start.AddDate(0, n, 0)
+	}
+}
@@ -11,25 +11,69 @@ import (
 )
 
 type UserUsecases struct {
-	UserRepo domain.UserRepository
+	UserRepo      domain.UserRepository
+	GuaranteeRepo domain.GuaranteeRepository
+	OutboxRepo    domain.OutboxRepository
+	EmailRepo     domain.EmailRepository
+	Transactor    domain.Transactor
 }
 
-func NewUserUsecase(Userrepo domain.UserRepository) domain.UserUsecase {
+func NewUserUsecase(Userrepo domain.UserRepository, guaranteeRepo domain.GuaranteeRepository, outboxRepo domain.OutboxRepository,
+	emailRepo domain.EmailRepository, transactor domain.Transactor) domain.UserUsecase {
 	return &UserUsecases{
-		UserRepo: Userrepo,
+		UserRepo:      Userrepo,
+		GuaranteeRepo: guaranteeRepo,
+		OutboxRepo:    outboxRepo,
+		EmailRepo:     emailRepo,
+		Transactor:    transactor,
 	}
 }
 
-func (uc *UserUsecases) RegisterUser(c context.Context, user *domain.User) error {
-	return uc.UserRepo.RegisterUser(user)
+func (uc *UserUsecases) RegisterUser(c context.Context, user *domain.User, platform string) error {
+	user.Locale = infrastructure.MatchLocale(user.Locale)
+	return uc.Transactor.WithTransaction(c, func(ctx context.Context) error {
+		if err := uc.UserRepo.RegisterUser(ctx, user); err != nil {
+			return err
+		}
+		email, err := infrastructure.VerificationEmail(user.Email, user.Locale, platform, infrastructure.EmailToken(user.Email))
+		if err != nil {
+			return err
+		}
+		if err := queueEmail(ctx, uc.EmailRepo, email); err != nil {
+			return err
+		}
+		return publishEvent(ctx, uc.OutboxRepo, domain.Event{
+			Type:          domain.EventUserRegistered,
+			AggregateType: "user",
+			AggregateID:   user.ID,
+			ActorID:       user.ID,
+			Data: map[string]interface{}{
+				"user_id":  user.ID.Hex(),
+				"email":    user.Email,
+				"username": user.UserName,
+			},
+		})
+	})
 }
 
 func (uc *UserUsecases) VerifyUserEmail(c context.Context, token string) error {
-	return uc.UserRepo.VerifyUserEmail(token)
+	if err := uc.UserRepo.VerifyUserEmail(token); err != nil {
+		return err
+	}
+	uc.recordTokenUse(c, domain.EventUserEmailVerified, token)
+	return nil
 }
 
 func (uc *UserUsecases) LoginUser(c context.Context, user domain.User) (string, error) {
-	return uc.UserRepo.LoginUser(user)
+	token, err := uc.UserRepo.LoginUser(user)
+	if err != nil {
+		return "", err
+	}
+	if found, err := uc.UserRepo.FindByEmail(user.Email); err == nil {
+		recordEvent(c, uc.OutboxRepo, auditEvent(domain.EventUserLoggedIn, "user", found.ID, found.ID,
+			map[string]interface{}{"email": found.Email}))
+	}
+	return token, nil
 }
 
 func (uc *UserUsecases) TokenRefresh(c context.Context, refreshToken string) (string, error) {
@@ -62,25 +106,106 @@ func (uc *UserUsecases) TokenRefresh(c context.Context, refreshToken string) (st
 		return "", errors.New("failed to generate access token")
 	}
 
+	recordEvent(c, uc.OutboxRepo, auditEvent(domain.EventUserTokenRefreshed, "user", ruser.ID, ruser.ID, nil))
 	return newAccessToken, nil
 }
 
 func (uc *UserUsecases) UserProfile(c context.Context, user domain.User) (domain.ResponseUser, error) {
-	return uc.UserRepo.UserProfile(user)
+	profile, err := uc.UserRepo.UserProfile(user)
+	if err != nil {
+		return domain.ResponseUser{}, err
+	}
+
+	guarantees, err := uc.GuaranteeRepo.GetGuaranteesByGuarantor(c, user.ID)
+	if err != nil {
+		return domain.ResponseUser{}, err
+	}
+	exposure := domain.GuarantorExposure{}
+	for _, g := range guarantees {
+		if g.Status == "accepted" {
+			exposure.ActiveGuarantees++
+			exposure.TotalLiability += g.LiabilityAmount
+		}
+	}
+	profile.GuarantorExposure = &exposure
+
+	recordEvent(c, uc.OutboxRepo, auditEvent(domain.EventUserProfileViewed, "user", user.ID, user.ID, nil))
+	return profile, nil
 }
 
-func (uc *UserUsecases) PasswordResetRequest(c context.Context, email string) error {
-	return uc.UserRepo.PasswordResetRequest(email)
+func (uc *UserUsecases) PasswordResetRequest(c context.Context, email, platform string) error {
+	user, err := uc.UserRepo.FindByEmail(email)
+	if err != nil {
+		return errors.New("user not found")
+	}
+	reset, err := infrastructure.ResetEmail(email, user.Locale, platform, infrastructure.EmailToken(email))
+	if err != nil {
+		return err
+	}
+	return uc.Transactor.WithTransaction(c, func(ctx context.Context) error {
+		if err := queueEmail(ctx, uc.EmailRepo, reset); err != nil {
+			return err
+		}
+		return publishEvent(ctx, uc.OutboxRepo, auditEvent(domain.EventUserPasswordResetRequested, "user", user.ID, user.ID,
+			map[string]interface{}{"email": user.Email}))
+	})
 }
 
 func (uc *UserUsecases) PasswordReset(c context.Context, token string, newPassword string) error {
-	return uc.UserRepo.PasswordReset(token, newPassword)
+	if err := uc.UserRepo.PasswordReset(token, newPassword); err != nil {
+		return err
+	}
+	uc.recordTokenUse(c, domain.EventUserPasswordReset, token)
+	return nil
 }
 
-func (uc *UserUsecases) GetAllUsers(c context.Context) ([]domain.ResponseUser, error) {
-	return uc.UserRepo.GetAllUsers()
+func (uc *UserUsecases) GetAllUsers(c context.Context, adminID primitive.ObjectID, filter domain.UserFilter) ([]domain.ResponseUser, error) {
+	users, err := uc.UserRepo.FindUsers(filter)
+	if err != nil {
+		return nil, err
+	}
+	recordEvent(c, uc.OutboxRepo, auditEvent(domain.EventUsersListed, "user", adminID, adminID,
+		map[string]interface{}{"count": len(users)}))
+	return users, nil
+}
+
+func (uc *UserUsecases) DeleteUser(c context.Context, adminID primitive.ObjectID, user domain.User) error {
+	if err := uc.UserRepo.DeleteUser(user); err != nil {
+		return err
+	}
+	recordEvent(c, uc.OutboxRepo, auditEvent(domain.EventUserDeleted, "user", user.ID, adminID, nil))
+	return nil
+}
+
+// SetRole makes an admin a loan officer or a supervisor
+func (uc *UserUsecases) SetRole(c context.Context, adminID primitive.ObjectID, user domain.User, role string) error {
+	if role != "officer" && role != "supervisor" {
+		return errors.New("invalid role, you can only enter officer or supervisor")
+	}
+	found, err := uc.UserRepo.FindByID(user)
+	if err != nil {
+		return errors.New("user not found")
+	}
+	if !found.IsAdmin {
+		return errors.New("only admins can be given a role")
+	}
+	if err := uc.UserRepo.UpdateRole(found, role); err != nil {
+		return err
+	}
+	recordEvent(c, uc.OutboxRepo, auditEvent(domain.EventUserRoleChanged, "user", found.ID, adminID,
+		map[string]interface{}{"role": role, "previous_role": found.Role}))
+	return nil
 }
 
-func (uc *UserUsecases) DeleteUser(c context.Context, user domain.User) error {
-	return uc.UserRepo.DeleteUser(user)
+// recordTokenUse records an action taken with an emailed token against the user it was issued to
+func (uc *UserUsecases) recordTokenUse(c context.Context, eventType, token string) {
+	email, err := infrastructure.VerifyToken(token)
+	if err != nil {
+		return
+	}
+	user, err := uc.UserRepo.FindByEmail(email)
+	if err != nil {
+		return
+	}
+	recordEvent(c, uc.OutboxRepo, auditEvent(eventType, "user", user.ID, user.ID, map[string]interface{}{"email": email}))
 }
@@ -0,0 +1,223 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"loan-tracker/domain"
+	"loan-tracker/infrastructure"
+	"net/url"
+	"os"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+var tokenPattern = regexp.MustCompile(`token=([^\s"<&]+)`)
+
+// useTestEnv runs the test from a directory whose .env holds the token secret, since
+// EmailToken requires a .env file to be present.
+func useTestEnv(t *testing.T) {
+	t.Helper()
+	dir := t.TempDir()
+	if err := os.WriteFile(dir+"/.env", []byte("Reset_Password=test-secret\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chdir(dir); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.Chdir(wd) })
+	t.Setenv("PUBLIC_BASE_URL", "https://app.example.com")
+}
+
+type mailFixture struct {
+	users  *UserUsecases
+	emails domain.EmailUsecase
+	repo   *fakeUserRepo
+	outbox *fakeOutbox
+	mailer *infrastructure.MemoryMailer
+}
+
+func newMailFixture() *mailFixture {
+	userRepo := newFakeUserRepo()
+	emailRepo := newFakeEmailRepo()
+	outbox := &fakeOutbox{}
+	mailer := infrastructure.NewMemoryMailer()
+	return &mailFixture{
+		users:  NewUserUsecase(userRepo, nil, outbox, emailRepo, fakeTransactor{}).(*UserUsecases),
+		emails: NewEmailUsecase(emailRepo, outbox, fakeTransactor{}, mailer),
+		repo:   userRepo,
+		outbox: outbox,
+		mailer: mailer,
+	}
+}
+
+// emailedToken returns the token in the link of an email, checking it was issued for the recipient
+func emailedToken(t *testing.T, email infrastructure.Email) string {
+	t.Helper()
+	match := tokenPattern.FindStringSubmatch(email.Body)
+	if match == nil {
+		t.Fatalf("%s email has no token link:\n%s", email.Kind, email.Body)
+	}
+	token, err := url.QueryUnescape(match[1])
+	if err != nil {
+		t.Fatalf("bad token %q: %v", match[1], err)
+	}
+	owner, err := infrastructure.VerifyToken(token)
+	if err != nil || owner != email.To {
+		t.Fatalf("token in %s email belongs to %q (%v), want %s", email.Kind, owner, err, email.To)
+	}
+	return token
+}
+
+func TestRegisterUserSendsVerificationEmail(t *testing.T) {
+	useTestEnv(t)
+	f := newMailFixture()
+	ctx := context.Background()
+
+	user := &domain.User{Email: "abebe@example.com", UserName: "abebe", Locale: "fr-FR,fr;q=0.9"}
+	if err := f.users.RegisterUser(ctx, user, infrastructure.PlatformWeb); err != nil {
+		t.Fatalf("RegisterUser: %v", err)
+	}
+	if sent := f.mailer.Sent(); len(sent) != 0 {
+		t.Fatalf("%d emails sent before the worker ran, want them queued", len(sent))
+	}
+	if err := f.emails.SendQueuedEmails(ctx); err != nil {
+		t.Fatalf("SendQueuedEmails: %v", err)
+	}
+
+	sent := f.mailer.SentTo(user.Email)
+	if len(sent) != 1 || len(f.mailer.Sent()) != 1 {
+		t.Fatalf("sent %d emails to %s and %d in all, want one verification email", len(sent), user.Email, len(f.mailer.Sent()))
+	}
+	email := sent[0]
+	if email.Kind != "email_verification" || email.Locale != "fr" {
+		t.Errorf("sent a %q email in %q, want email_verification in fr", email.Kind, email.Locale)
+	}
+	if email.Subject == "" || email.HTML == "" {
+		t.Error("verification email has no subject or HTML part")
+	}
+	if !strings.Contains(email.Body, "https://app.example.com/users/verify-email?token=") {
+		t.Errorf("verification email does not link to the web app:\n%s", email.Body)
+	}
+	emailedToken(t, email)
+
+	if got := f.outbox.types(); len(got) != 1 || got[0] != domain.EventUserRegistered {
+		t.Errorf("published %v, want one %s event", got, domain.EventUserRegistered)
+	}
+
+	// The worker does not send the same message twice
+	if err := f.emails.SendQueuedEmails(ctx); err != nil {
+		t.Fatalf("SendQueuedEmails: %v", err)
+	}
+	if len(f.mailer.Sent()) != 1 {
+		t.Errorf("sent %d emails after a second run, want 1", len(f.mailer.Sent()))
+	}
+}
+
+func TestRegisterUserSendsNothingWhenRegistrationFails(t *testing.T) {
+	useTestEnv(t)
+	f := newMailFixture()
+	f.repo.registerErr = errors.New("email already registered")
+	ctx := context.Background()
+
+	user := &domain.User{Email: "abebe@example.com", UserName: "abebe"}
+	if err := f.users.RegisterUser(ctx, user, infrastructure.PlatformWeb); err == nil {
+		t.Fatal("RegisterUser succeeded although the repository failed")
+	}
+	if err := f.emails.SendQueuedEmails(ctx); err != nil {
+		t.Fatalf("SendQueuedEmails: %v", err)
+	}
+	if sent := f.mailer.Sent(); len(sent) != 0 {
+		t.Errorf("sent %d emails for a failed registration", len(sent))
+	}
+}
+
+func TestPasswordResetRequestSendsResetEmail(t *testing.T) {
+	useTestEnv(t)
+	t.Setenv("APP_MOBILE_URL", "loantracker://")
+	f := newMailFixture()
+	ctx := context.Background()
+
+	user := &domain.User{Email: "abebe@example.com", UserName: "abebe", Locale: "en"}
+	if err := f.users.RegisterUser(ctx, user, infrastructure.PlatformMobile); err != nil {
+		t.Fatalf("RegisterUser: %v", err)
+	}
+	if err := f.emails.SendQueuedEmails(ctx); err != nil {
+		t.Fatalf("SendQueuedEmails: %v", err)
+	}
+	f.mailer.Reset()
+
+	if err := f.users.PasswordResetRequest(ctx, user.Email, infrastructure.PlatformMobile); err != nil {
+		t.Fatalf("PasswordResetRequest: %v", err)
+	}
+	if err := f.emails.SendQueuedEmails(ctx); err != nil {
+		t.Fatalf("SendQueuedEmails: %v", err)
+	}
+
+	sent := f.mailer.Sent()
+	if len(sent) != 1 {
+		t.Fatalf("sent %d emails, want one reset email", len(sent))
+	}
+	email := sent[0]
+	if email.To != user.Email || email.Kind != "password_reset" || email.Locale != "en" {
+		t.Errorf("sent a %q email in %q to %s, want password_reset in en to %s", email.Kind, email.Locale, email.To, user.Email)
+	}
+	if !strings.Contains(email.Body, "loantracker://users/password-reset?token=") {
+		t.Errorf("reset email does not link to the mobile app:\n%s", email.Body)
+	}
+	token := emailedToken(t, email)
+
+	types := f.outbox.types()
+	if len(types) != 2 || types[1] != domain.EventUserPasswordResetRequested {
+		t.Errorf("published %v, want %s after the registration", types, domain.EventUserPasswordResetRequested)
+	}
+	for _, event := range f.outbox.events {
+		for _, value := range event.Data {
+			if s, ok := value.(string); ok && strings.Contains(s, token) {
+				t.Errorf("%s event carries the reset token", event.Type)
+			}
+		}
+	}
+}
+
+func TestPasswordResetRequestForUnknownEmailSendsNothing(t *testing.T) {
+	useTestEnv(t)
+	f := newMailFixture()
+	ctx := context.Background()
+
+	if err := f.users.PasswordResetRequest(ctx, "nobody@example.com", infrastructure.PlatformWeb); err == nil {
+		t.Error("PasswordResetRequest succeeded for an unknown email")
+	}
+	if err := f.emails.SendQueuedEmails(ctx); err != nil {
+		t.Fatalf("SendQueuedEmails: %v", err)
+	}
+	if sent := f.mailer.Sent(); len(sent) != 0 {
+		t.Errorf("sent %d emails for an unknown address", len(sent))
+	}
+}
+
+func TestQueuedEmailIsRetriedWhenTheMailerFails(t *testing.T) {
+	useTestEnv(t)
+	f := newMailFixture()
+	ctx := context.Background()
+
+	user := &domain.User{Email: "abebe@example.com", UserName: "abebe"}
+	if err := f.users.RegisterUser(ctx, user, infrastructure.PlatformWeb); err != nil {
+		t.Fatalf("RegisterUser: %v", err)
+	}
+	f.mailer.FailWith(errors.New("connection refused"))
+	if err := f.emails.SendQueuedEmails(ctx); err != nil {
+		t.Fatalf("SendQueuedEmails: %v", err)
+	}
+	if len(f.mailer.Sent()) != 0 {
+		t.Fatal("a failing mailer captured an email")
+	}
+	messages, _ := f.emails.GetEmails(ctx, domain.EmailFilter{})
+	if len(messages) != 1 || messages[0].Status != "retrying" || messages[0].LastError == "" {
+		t.Fatalf("queued emails after a failure: %+v, want one retrying", messages)
+	}
+}
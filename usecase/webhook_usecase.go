@@ -0,0 +1,319 @@
+package usecase
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"loan-tracker/domain"
+	"loan-tracker/infrastructure"
+	"log"
+	"net/url"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const (
+	// webhookLease is how long a worker holds a delivery; it must outlast the send timeout
+	webhookLease     = 2 * time.Minute
+	webhookBatchSize = 50
+)
+
+type webhookUsecase struct {
+	webhookRepo domain.WebhookRepository
+	outboxRepo  domain.OutboxRepository
+	transactor  domain.Transactor
+	sender      *infrastructure.WebhookSender
+}
+
+// NewWebhookUsecase creates a new instance of WebhookUsecase posting through the given sender
+func NewWebhookUsecase(webhookRepo domain.WebhookRepository, outboxRepo domain.OutboxRepository, transactor domain.Transactor,
+	sender *infrastructure.WebhookSender) domain.WebhookUsecase {
+	return &webhookUsecase{
+		webhookRepo: webhookRepo,
+		outboxRepo:  outboxRepo,
+		transactor:  transactor,
+		sender:      sender,
+	}
+}
+
+func (uc *webhookUsecase) CreateSubscription(ctx context.Context, adminID primitive.ObjectID, req domain.WebhookSubscriptionRequest) (domain.WebhookSubscription, string, error) {
+	if err := validateWebhookRequest(req); err != nil {
+		return domain.WebhookSubscription{}, "", err
+	}
+	secret := req.Secret
+	if secret == "" {
+		var err error
+		if secret, err = infrastructure.NewWebhookSecret(); err != nil {
+			return domain.WebhookSubscription{}, "", err
+		}
+	}
+
+	now := time.Now()
+	subscription := domain.WebhookSubscription{
+		ID:          primitive.NewObjectID(),
+		URL:         req.URL,
+		Secret:      secret,
+		EventTypes:  req.EventTypes,
+		Description: req.Description,
+		Active:      req.Active == nil || *req.Active,
+		CreatedBy:   adminID,
+		CreatedAt:   now,
+		UpdatedAt:   now,
+	}
+	if subscription.EventTypes == nil {
+		subscription.EventTypes = []string{}
+	}
+	err := uc.transactor.WithTransaction(ctx, func(ctx context.Context) error {
+		if _, err := uc.webhookRepo.CreateSubscription(ctx, subscription); err != nil {
+			return err
+		}
+		return publishEvent(ctx, uc.outboxRepo, subscriptionEvent(domain.EventWebhookSubscriptionCreated, subscription, adminID))
+	})
+	if err != nil {
+		return domain.WebhookSubscription{}, "", err
+	}
+	return subscription, secret, nil
+}
+
+func (uc *webhookUsecase) GetSubscriptions(ctx context.Context) ([]domain.WebhookSubscription, error) {
+	return uc.webhookRepo.GetSubscriptions(ctx)
+}
+
+// UpdateSubscription replaces a subscription's settings. Deactivating it cancels the deliveries
+// still waiting to be sent.
+func (uc *webhookUsecase) UpdateSubscription(ctx context.Context, id, adminID primitive.ObjectID, req domain.WebhookSubscriptionRequest) (domain.WebhookSubscription, error) {
+	subscription, err := uc.webhookRepo.GetSubscriptionByID(ctx, id)
+	if err != nil {
+		return domain.WebhookSubscription{}, errors.New("webhook subscription not found")
+	}
+	if err := validateWebhookRequest(req); err != nil {
+		return domain.WebhookSubscription{}, err
+	}
+
+	subscription.URL = req.URL
+	subscription.EventTypes = req.EventTypes
+	if subscription.EventTypes == nil {
+		subscription.EventTypes = []string{}
+	}
+	subscription.Description = req.Description
+	if req.Secret != "" {
+		subscription.Secret = req.Secret
+	}
+	if req.Active != nil {
+		subscription.Active = *req.Active
+	}
+	subscription.UpdatedAt = time.Now()
+	err = uc.transactor.WithTransaction(ctx, func(ctx context.Context) error {
+		if err := uc.webhookRepo.UpdateSubscription(ctx, subscription); err != nil {
+			return err
+		}
+		return publishEvent(ctx, uc.outboxRepo, subscriptionEvent(domain.EventWebhookSubscriptionUpdated, subscription, adminID))
+	})
+	if err != nil {
+		return domain.WebhookSubscription{}, err
+	}
+	if !subscription.Active {
+		if err := uc.webhookRepo.CancelDeliveries(ctx, subscription.ID, "subscription deactivated"); err != nil {
+			log.Println("Error cancelling deliveries of webhook subscription", subscription.ID.Hex()+":", err)
+		}
+	}
+	return subscription, nil
+}
+
+func (uc *webhookUsecase) DeleteSubscription(ctx context.Context, id, adminID primitive.ObjectID) error {
+	return uc.transactor.WithTransaction(ctx, func(ctx context.Context) error {
+		if err := uc.webhookRepo.DeleteSubscription(ctx, id); err != nil {
+			return err
+		}
+		if err := uc.webhookRepo.CancelDeliveries(ctx, id, "subscription deleted"); err != nil {
+			return err
+		}
+		return publishEvent(ctx, uc.outboxRepo, auditEvent(domain.EventWebhookSubscriptionDeleted, "webhook_subscription", id, adminID, nil))
+	})
+}
+
+func subscriptionEvent(eventType string, subscription domain.WebhookSubscription, adminID primitive.ObjectID) domain.Event {
+	return auditEvent(eventType, "webhook_subscription", subscription.ID, adminID, map[string]interface{}{
+		"url":         subscription.URL,
+		"event_types": subscription.EventTypes,
+		"active":      subscription.Active,
+	})
+}
+
+func (uc *webhookUsecase) HandleEvent(ctx context.Context, event domain.Event) error {
+	subscriptions, err := uc.webhookRepo.GetActiveSubscriptions(ctx, event.Type)
+	if err != nil {
+		return err
+	}
+	if len(subscriptions) == 0 {
+		return nil
+	}
+	payload, err := json.Marshal(map[string]interface{}{
+		"id":             event.ID.Hex(),
+		"type":           event.Type,
+		"occurred_at":    event.OccurredAt.UTC(),
+		"aggregate_type": event.AggregateType,
+		"aggregate_id":   event.AggregateID.Hex(),
+		"data":           event.Data,
+	})
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	for _, subscription := range subscriptions {
+		delivery := domain.WebhookDelivery{
+			ID:             primitive.NewObjectID(),
+			SubscriptionID: subscription.ID,
+			EventID:        event.ID,
+			EventType:      event.Type,
+			Payload:        string(payload),
+			Status:         "pending",
+			Attempts:       []domain.WebhookAttempt{},
+			NextAttemptAt:  now,
+			CreatedAt:      now,
+			UpdatedAt:      now,
+		}
+		// Queueing is idempotent, so an event redelivered by the bus is not sent twice
+		if err := uc.webhookRepo.QueueDelivery(ctx, delivery); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// DeliverWebhooks sends each due delivery once. A failure is retried after a delay that doubles
+// from WEBHOOK_RETRY_DELAY (30s) up to 6 hours, and after WEBHOOK_MAX_ATTEMPTS (10) the delivery
+// is dead-lettered until an admin replays it.
+func (uc *webhookUsecase) DeliverWebhooks(ctx context.Context) error {
+	now := time.Now()
+	deliveries, err := uc.webhookRepo.GetDueDeliveries(ctx, now, webhookBatchSize)
+	if err != nil {
+		return err
+	}
+	maxAttempts := infrastructure.IntSetting("WEBHOOK_MAX_ATTEMPTS", 10)
+	retryDelay := infrastructure.IntervalSetting("WEBHOOK_RETRY_DELAY", 30*time.Second)
+	for _, delivery := range deliveries {
+		if err := uc.webhookRepo.ClaimDelivery(ctx, delivery, now.Add(webhookLease)); err != nil {
+			continue
+		}
+		uc.deliver(ctx, delivery, maxAttempts, retryDelay)
+	}
+	return nil
+}
+
+func (uc *webhookUsecase) deliver(ctx context.Context, delivery domain.WebhookDelivery, maxAttempts int, retryDelay time.Duration) {
+	subscription, err := uc.webhookRepo.GetSubscriptionByID(ctx, delivery.SubscriptionID)
+	if err != nil || !subscription.Active {
+		delivery.Status = "cancelled"
+		delivery.LastError = "subscription deleted or deactivated"
+		delivery.NextAttemptAt = time.Time{}
+		delivery.UpdatedAt = time.Now()
+		if err := uc.webhookRepo.UpdateDelivery(ctx, delivery); err != nil {
+			log.Println("Error updating webhook delivery", delivery.ID.Hex()+":", err)
+		}
+		return
+	}
+
+	started := time.Now()
+	statusCode, sendErr := uc.sender.Send(ctx, subscription.URL, subscription.Secret, delivery.EventType, delivery.ID.Hex(), []byte(delivery.Payload))
+	attempt := domain.WebhookAttempt{
+		Number:      len(delivery.Attempts) + 1,
+		AttemptedAt: started,
+		StatusCode:  statusCode,
+		DurationMS:  time.Since(started).Milliseconds(),
+	}
+	if sendErr != nil {
+		attempt.Error = sendErr.Error()
+	}
+	delivery.Attempts = append(delivery.Attempts, attempt)
+	delivery.UpdatedAt = time.Now()
+
+	switch {
+	case sendErr == nil:
+		delivery.Status = "delivered"
+		delivery.DeliveredAt = delivery.UpdatedAt
+		delivery.NextAttemptAt = time.Time{}
+		delivery.LastError = ""
+	case len(delivery.Attempts) >= maxAttempts:
+		delivery.Status = "dead"
+		delivery.NextAttemptAt = time.Time{}
+		delivery.LastError = attempt.Error
+		log.Printf("Giving up on webhook delivery %s to %s after %d attempts: %s", delivery.ID.Hex(), subscription.URL, len(delivery.Attempts), attempt.Error)
+	default:
+		delivery.Status = "retrying"
+		delivery.NextAttemptAt = delivery.UpdatedAt.Add(backoff(len(delivery.Attempts), retryDelay, 6*time.Hour))
+		delivery.LastError = attempt.Error
+	}
+	if err := uc.webhookRepo.UpdateDelivery(ctx, delivery); err != nil {
+		log.Println("Error updating webhook delivery", delivery.ID.Hex()+":", err)
+	}
+}
+
+func (uc *webhookUsecase) GetDeliveries(ctx context.Context, filter domain.WebhookDeliveryFilter) ([]domain.WebhookDelivery, error) {
+	return uc.webhookRepo.FindDeliveries(ctx, filter)
+}
+
+// ReplayDelivery queues the original payload again. The event id in the payload is unchanged, so
+// receivers that de-duplicate on it will recognise the replay.
+func (uc *webhookUsecase) ReplayDelivery(ctx context.Context, id, adminID primitive.ObjectID) (domain.WebhookDelivery, error) {
+	original, err := uc.webhookRepo.GetDeliveryByID(ctx, id)
+	if err != nil {
+		return domain.WebhookDelivery{}, errors.New("webhook delivery not found")
+	}
+	if original.Status == "pending" || original.Status == "retrying" {
+		return domain.WebhookDelivery{}, errors.New("delivery is still being attempted")
+	}
+	subscription, err := uc.webhookRepo.GetSubscriptionByID(ctx, original.SubscriptionID)
+	if err != nil {
+		return domain.WebhookDelivery{}, errors.New("the delivery's subscription no longer exists")
+	}
+	if !subscription.Active {
+		return domain.WebhookDelivery{}, errors.New("the delivery's subscription is not active")
+	}
+
+	now := time.Now()
+	replay := domain.WebhookDelivery{
+		ID:             primitive.NewObjectID(),
+		SubscriptionID: original.SubscriptionID,
+		EventID:        original.EventID,
+		EventType:      original.EventType,
+		Payload:        original.Payload,
+		Status:         "pending",
+		Attempts:       []domain.WebhookAttempt{},
+		NextAttemptAt:  now,
+		ReplayOf:       original.ID,
+		ReplayedBy:     adminID,
+		CreatedAt:      now,
+		UpdatedAt:      now,
+	}
+	err = uc.transactor.WithTransaction(ctx, func(ctx context.Context) error {
+		if _, err := uc.webhookRepo.CreateDelivery(ctx, replay); err != nil {
+			return err
+		}
+		return publishEvent(ctx, uc.outboxRepo, auditEvent(domain.EventWebhookDeliveryReplayed, "webhook_delivery", original.ID, adminID,
+			map[string]interface{}{"replay_id": replay.ID.Hex(), "subscription_id": replay.SubscriptionID.Hex(), "event_type": replay.EventType}))
+	})
+	if err != nil {
+		return domain.WebhookDelivery{}, err
+	}
+	return replay, nil
+}
+
+func validateWebhookRequest(req domain.WebhookSubscriptionRequest) error {
+	target, err := url.Parse(req.URL)
+	if err != nil || (target.Scheme != "http" && target.Scheme != "https") || target.Host == "" {
+		return errors.New("url must be an absolute http or https URL")
+	}
+	known := make(map[string]bool)
+	for _, t := range domain.EventTypes {
+		known[t] = true
+	}
+	for _, t := range req.EventTypes {
+		if !known[t] {
+			return errors.New("unknown event type: " + t)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,396 @@
+package usecase
+
+import (
+	"context"
+	"io"
+	"loan-tracker/domain"
+	"loan-tracker/infrastructure"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"testing"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type fakeWebhookRepo struct {
+	subscriptions map[primitive.ObjectID]domain.WebhookSubscription
+	deliveries    map[primitive.ObjectID]domain.WebhookDelivery
+}
+
+func newFakeWebhookRepo() *fakeWebhookRepo {
+	return &fakeWebhookRepo{
+		subscriptions: make(map[primitive.ObjectID]domain.WebhookSubscription),
+		deliveries:    make(map[primitive.ObjectID]domain.WebhookDelivery),
+	}
+}
+
+func (r *fakeWebhookRepo) CreateSubscription(ctx context.Context, subscription domain.WebhookSubscription) (primitive.ObjectID, error) {
+	r.subscriptions[subscription.ID] = subscription
+	return subscription.ID, nil
+}
+
+func (r *fakeWebhookRepo) GetSubscriptionByID(ctx context.Context, id primitive.ObjectID) (domain.WebhookSubscription, error) {
+	subscription, ok := r.subscriptions[id]
+	if !ok {
+		return domain.WebhookSubscription{}, errNotFound
+	}
+	return subscription, nil
+}
+
+func (r *fakeWebhookRepo) GetSubscriptions(ctx context.Context) ([]domain.WebhookSubscription, error) {
+	var subscriptions []domain.WebhookSubscription
+	for _, s := range r.subscriptions {
+		subscriptions = append(subscriptions, s)
+	}
+	return subscriptions, nil
+}
+
+func (r *fakeWebhookRepo) GetActiveSubscriptions(ctx context.Context, eventType string) ([]domain.WebhookSubscription, error) {
+	var subscriptions []domain.WebhookSubscription
+	for _, s := range r.subscriptions {
+		if !s.Active {
+			continue
+		}
+		for _, t := range s.EventTypes {
+			if t == eventType {
+				subscriptions = append(subscriptions, s)
+				break
+			}
+		}
+		if len(s.EventTypes) == 0 {
+			subscriptions = append(subscriptions, s)
+		}
+	}
+	return subscriptions, nil
+}
+
+func (r *fakeWebhookRepo) UpdateSubscription(ctx context.Context, subscription domain.WebhookSubscription) error {
+	r.subscriptions[subscription.ID] = subscription
+	return nil
+}
+
+func (r *fakeWebhookRepo) DeleteSubscription(ctx context.Context, id primitive.ObjectID) error {
+	delete(r.subscriptions, id)
+	return nil
+}
+
+func (r *fakeWebhookRepo) QueueDelivery(ctx context.Context, delivery domain.WebhookDelivery) error {
+	for _, d := range r.deliveries {
+		if d.SubscriptionID == delivery.SubscriptionID && d.EventID == delivery.EventID && d.ReplayOf.IsZero() {
+			return nil
+		}
+	}
+	r.deliveries[delivery.ID] = delivery
+	return nil
+}
+
+func (r *fakeWebhookRepo) CreateDelivery(ctx context.Context, delivery domain.WebhookDelivery) (primitive.ObjectID, error) {
+	r.deliveries[delivery.ID] = delivery
+	return delivery.ID, nil
+}
+
+func (r *fakeWebhookRepo) GetDeliveryByID(ctx context.Context, id primitive.ObjectID) (domain.WebhookDelivery, error) {
+	delivery, ok := r.deliveries[id]
+	if !ok {
+		return domain.WebhookDelivery{}, errNotFound
+	}
+	return delivery, nil
+}
+
+func (r *fakeWebhookRepo) FindDeliveries(ctx context.Context, filter domain.WebhookDeliveryFilter) ([]domain.WebhookDelivery, error) {
+	var deliveries []domain.WebhookDelivery
+	for _, d := range r.deliveries {
+		if filter.Status != "" && d.Status != filter.Status {
+			continue
+		}
+		deliveries = append(deliveries, d)
+	}
+	return deliveries, nil
+}
+
+func (r *fakeWebhookRepo) GetDueDeliveries(ctx context.Context, now time.Time, limit int) ([]domain.WebhookDelivery, error) {
+	var due []domain.WebhookDelivery
+	for _, d := range r.deliveries {
+		if (d.Status == "pending" || d.Status == "retrying") && !d.NextAttemptAt.After(now) {
+			due = append(due, d)
+		}
+	}
+	sort.Slice(due, func(i, j int) bool { return due[i].CreatedAt.Before(due[j].CreatedAt) })
+	if len(due) > limit {
+		due = due[:limit]
+	}
+	return due, nil
+}
+
+func (r *fakeWebhookRepo) ClaimDelivery(ctx context.Context, delivery domain.WebhookDelivery, until time.Time) error {
+	stored := r.deliveries[delivery.ID]
+	stored.NextAttemptAt = until
+	r.deliveries[delivery.ID] = stored
+	return nil
+}
+
+func (r *fakeWebhookRepo) UpdateDelivery(ctx context.Context, delivery domain.WebhookDelivery) error {
+	r.deliveries[delivery.ID] = delivery
+	return nil
+}
+
+func (r *fakeWebhookRepo) CancelDeliveries(ctx context.Context, subscriptionID primitive.ObjectID, reason string) error {
+	for id, d := range r.deliveries {
+		if d.SubscriptionID == subscriptionID && (d.Status == "pending" || d.Status == "retrying") {
+			d.Status = "cancelled"
+			d.LastError = reason
+			r.deliveries[id] = d
+		}
+	}
+	return nil
+}
+
+// makeDue moves every waiting delivery's next attempt into the past, as if the backoff had elapsed
+func (r *fakeWebhookRepo) makeDue() {
+	for id, d := range r.deliveries {
+		if d.Status == "pending" || d.Status == "retrying" {
+			d.NextAttemptAt = time.Now().Add(-time.Second)
+			r.deliveries[id] = d
+		}
+	}
+}
+
+// receiver is a webhook endpoint answering with the queued status codes, then 200
+type receiver struct {
+	server   *httptest.Server
+	statuses []int
+	requests []*http.Request
+	bodies   []string
+}
+
+func newReceiver(t *testing.T, statuses ...int) *receiver {
+	r := &receiver{statuses: statuses}
+	r.server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		body, _ := io.ReadAll(req.Body)
+		r.requests = append(r.requests, req)
+		r.bodies = append(r.bodies, string(body))
+		status := http.StatusOK
+		if len(r.statuses) > 0 {
+			status, r.statuses = r.statuses[0], r.statuses[1:]
+		}
+		w.WriteHeader(status)
+	}))
+	t.Cleanup(r.server.Close)
+	return r
+}
+
+// setupWebhooks queues one loan.approved event for a subscription pointing at the receiver
+func setupWebhooks(t *testing.T, rcv *receiver) (*webhookUsecase, *fakeWebhookRepo, *fakeOutbox, domain.WebhookSubscription) {
+	t.Helper()
+	repo := newFakeWebhookRepo()
+	outbox := &fakeOutbox{}
+	uc := NewWebhookUsecase(repo, outbox, fakeTransactor{}, infrastructure.NewWebhookSender(rcv.server.Client())).(*webhookUsecase)
+
+	subscription := domain.WebhookSubscription{
+		ID:         primitive.NewObjectID(),
+		URL:        rcv.server.URL,
+		Secret:     "whsec_test",
+		EventTypes: []string{domain.EventLoanApproved},
+		Active:     true,
+	}
+	repo.subscriptions[subscription.ID] = subscription
+
+	loan := domain.Loan{ID: primitive.NewObjectID(), UserID: primitive.NewObjectID(), Amount: 1000, Status: "approved"}
+	event := loanEvent(domain.EventLoanApproved, loan, primitive.NewObjectID(), nil)
+	event.ID = primitive.NewObjectID()
+	event.OccurredAt = time.Now()
+	if err := uc.HandleEvent(context.Background(), event); err != nil {
+		t.Fatalf("HandleEvent: %v", err)
+	}
+	if len(repo.deliveries) != 1 {
+		t.Fatalf("HandleEvent queued %d deliveries, want 1", len(repo.deliveries))
+	}
+	return uc, repo, outbox, subscription
+}
+
+func onlyDelivery(t *testing.T, repo *fakeWebhookRepo) domain.WebhookDelivery {
+	t.Helper()
+	if len(repo.deliveries) != 1 {
+		t.Fatalf("got %d deliveries, want 1", len(repo.deliveries))
+	}
+	for _, d := range repo.deliveries {
+		return d
+	}
+	return domain.WebhookDelivery{}
+}
+
+func TestDeliverWebhooksSignsRequests(t *testing.T) {
+	rcv := newReceiver(t)
+	uc, repo, _, subscription := setupWebhooks(t, rcv)
+
+	if err := uc.DeliverWebhooks(context.Background()); err != nil {
+		t.Fatalf("DeliverWebhooks: %v", err)
+	}
+	if len(rcv.requests) != 1 {
+		t.Fatalf("receiver got %d requests, want 1", len(rcv.requests))
+	}
+	delivery := onlyDelivery(t, repo)
+	req, body := rcv.requests[0], rcv.bodies[0]
+	if body != delivery.Payload {
+		t.Errorf("body = %s, want the queued payload %s", body, delivery.Payload)
+	}
+	if got := req.Header.Get(infrastructure.WebhookEventHeader); got != domain.EventLoanApproved {
+		t.Errorf("event header = %q, want %q", got, domain.EventLoanApproved)
+	}
+	if got := req.Header.Get(infrastructure.WebhookDeliveryHeader); got != delivery.ID.Hex() {
+		t.Errorf("delivery header = %q, want %q", got, delivery.ID.Hex())
+	}
+	signature := req.Header.Get(infrastructure.WebhookSignatureHeader)
+	if err := infrastructure.VerifySignature([]byte(subscription.Secret), signature, []byte(body), time.Minute); err != nil {
+		t.Errorf("signature %q does not verify with the subscription secret: %v", signature, err)
+	}
+	if delivery.Status != "delivered" || delivery.DeliveredAt.IsZero() {
+		t.Errorf("delivery is %q (delivered at %v), want delivered", delivery.Status, delivery.DeliveredAt)
+	}
+}
+
+func TestDeliverWebhooksRetriesWithBackoff(t *testing.T) {
+	t.Setenv("WEBHOOK_RETRY_DELAY", "1m")
+	t.Setenv("WEBHOOK_MAX_ATTEMPTS", "5")
+	rcv := newReceiver(t, http.StatusInternalServerError, http.StatusBadGateway)
+	uc, repo, _, _ := setupWebhooks(t, rcv)
+	ctx := context.Background()
+
+	// Each failure doubles the wait before the next attempt
+	for i, wait := range []time.Duration{time.Minute, 2 * time.Minute} {
+		if err := uc.DeliverWebhooks(ctx); err != nil {
+			t.Fatalf("DeliverWebhooks: %v", err)
+		}
+		delivery := onlyDelivery(t, repo)
+		if delivery.Status != "retrying" {
+			t.Fatalf("after failure %d the delivery is %q, want retrying", i+1, delivery.Status)
+		}
+		if len(delivery.Attempts) != i+1 {
+			t.Fatalf("after failure %d there are %d attempts recorded", i+1, len(delivery.Attempts))
+		}
+		attempt := delivery.Attempts[i]
+		if attempt.StatusCode < 500 || attempt.Error == "" {
+			t.Errorf("attempt %d recorded status %d and error %q", i+1, attempt.StatusCode, attempt.Error)
+		}
+		if got := delivery.NextAttemptAt.Sub(delivery.UpdatedAt); got != wait {
+			t.Errorf("after failure %d the next attempt is in %s, want %s", i+1, got, wait)
+		}
+
+		// Not due yet, so running again sends nothing
+		if err := uc.DeliverWebhooks(ctx); err != nil {
+			t.Fatalf("DeliverWebhooks: %v", err)
+		}
+		if len(rcv.requests) != i+1 {
+			t.Fatalf("delivery was retried before its backoff elapsed")
+		}
+		repo.makeDue()
+	}
+
+	if err := uc.DeliverWebhooks(ctx); err != nil {
+		t.Fatalf("DeliverWebhooks: %v", err)
+	}
+	delivery := onlyDelivery(t, repo)
+	if delivery.Status != "delivered" || len(delivery.Attempts) != 3 || delivery.LastError != "" {
+		t.Errorf("got %q after %d attempts (last error %q), want delivered on the third", delivery.Status, len(delivery.Attempts), delivery.LastError)
+	}
+	if len(rcv.requests) != 3 {
+		t.Errorf("receiver got %d requests, want 3", len(rcv.requests))
+	}
+}
+
+func TestDeliverWebhooksDeadLettersAfterMaxAttempts(t *testing.T) {
+	t.Setenv("WEBHOOK_MAX_ATTEMPTS", "3")
+	rcv := newReceiver(t, 500, 500, 500, 500, 500)
+	uc, repo, _, _ := setupWebhooks(t, rcv)
+	ctx := context.Background()
+
+	for i := 0; i < 5; i++ {
+		if err := uc.DeliverWebhooks(ctx); err != nil {
+			t.Fatalf("DeliverWebhooks: %v", err)
+		}
+		repo.makeDue()
+	}
+
+	delivery := onlyDelivery(t, repo)
+	if delivery.Status != "dead" {
+		t.Fatalf("delivery is %q, want dead", delivery.Status)
+	}
+	if len(delivery.Attempts) != 3 || len(rcv.requests) != 3 {
+		t.Errorf("made %d attempts and %d requests, want 3 of each", len(delivery.Attempts), len(rcv.requests))
+	}
+	if !delivery.NextAttemptAt.IsZero() {
+		t.Errorf("dead delivery is still scheduled for %v", delivery.NextAttemptAt)
+	}
+	if delivery.LastError == "" {
+		t.Error("dead delivery does not say why it failed")
+	}
+}
+
+func TestReplayDeliverySendsTheLoggedPayloadAgain(t *testing.T) {
+	t.Setenv("WEBHOOK_MAX_ATTEMPTS", "1")
+	rcv := newReceiver(t, http.StatusServiceUnavailable)
+	uc, repo, outbox, subscription := setupWebhooks(t, rcv)
+	ctx := context.Background()
+
+	if err := uc.DeliverWebhooks(ctx); err != nil {
+		t.Fatalf("DeliverWebhooks: %v", err)
+	}
+	original := onlyDelivery(t, repo)
+	if original.Status != "dead" {
+		t.Fatalf("delivery is %q, want dead", original.Status)
+	}
+
+	adminID := primitive.NewObjectID()
+	replay, err := uc.ReplayDelivery(ctx, original.ID, adminID)
+	if err != nil {
+		t.Fatalf("ReplayDelivery: %v", err)
+	}
+	if replay.ID == original.ID || replay.ReplayOf != original.ID || replay.ReplayedBy != adminID {
+		t.Errorf("replay %+v is not recorded as a replay of %s by %s", replay, original.ID.Hex(), adminID.Hex())
+	}
+	if replay.Status != "pending" || replay.EventID != original.EventID || replay.Payload != original.Payload {
+		t.Errorf("replay %+v does not queue the original event again", replay)
+	}
+	if got := outbox.types(); len(got) != 1 || got[0] != domain.EventWebhookDeliveryReplayed {
+		t.Errorf("published %v, want one %s event", got, domain.EventWebhookDeliveryReplayed)
+	}
+
+	if err := uc.DeliverWebhooks(ctx); err != nil {
+		t.Fatalf("DeliverWebhooks: %v", err)
+	}
+	if len(rcv.requests) != 2 {
+		t.Fatalf("receiver got %d requests, want 2", len(rcv.requests))
+	}
+	if rcv.bodies[1] != original.Payload {
+		t.Errorf("replayed body = %s, want the original %s", rcv.bodies[1], original.Payload)
+	}
+	signature := rcv.requests[1].Header.Get(infrastructure.WebhookSignatureHeader)
+	if err := infrastructure.VerifySignature([]byte(subscription.Secret), signature, []byte(rcv.bodies[1]), time.Minute); err != nil {
+		t.Errorf("replay signature does not verify: %v", err)
+	}
+	if got := repo.deliveries[replay.ID]; got.Status != "delivered" {
+		t.Errorf("replay is %q, want delivered", got.Status)
+	}
+	if got := repo.deliveries[original.ID]; got.Status != "dead" {
+		t.Errorf("original delivery became %q, want it left dead", got.Status)
+	}
+
+	if _, err := uc.ReplayDelivery(ctx, primitive.NewObjectID(), adminID); err == nil {
+		t.Error("replaying an unknown delivery succeeded")
+	}
+}
+
+func TestReplayDeliveryRefusesDeliveriesInProgress(t *testing.T) {
+	rcv := newReceiver(t)
+	uc, repo, _, _ := setupWebhooks(t, rcv)
+
+	pending := onlyDelivery(t, repo)
+	if _, err := uc.ReplayDelivery(context.Background(), pending.ID, primitive.NewObjectID()); err == nil {
+		t.Error("replaying a pending delivery succeeded")
+	}
+	if len(repo.deliveries) != 1 {
+		t.Errorf("got %d deliveries, want the pending one only", len(repo.deliveries))
+	}
+}